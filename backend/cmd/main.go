@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/rendley/vegshare/backend/internal/api"
@@ -13,30 +14,61 @@ import (
 	catalogHandler "github.com/rendley/vegshare/backend/internal/catalog/handler"
 	catalogRepository "github.com/rendley/vegshare/backend/internal/catalog/repository"
 	catalogService "github.com/rendley/vegshare/backend/internal/catalog/service"
+	coopHandler "github.com/rendley/vegshare/backend/internal/coop/handler"
+	coopRepository "github.com/rendley/vegshare/backend/internal/coop/repository"
+	coopService "github.com/rendley/vegshare/backend/internal/coop/service"
+	deadletterHandler "github.com/rendley/vegshare/backend/internal/deadletter/handler"
+	deadletterRepository "github.com/rendley/vegshare/backend/internal/deadletter/repository"
+	deadletterService "github.com/rendley/vegshare/backend/internal/deadletter/service"
+	deliveryHandler "github.com/rendley/vegshare/backend/internal/delivery/handler"
+	deliveryRepository "github.com/rendley/vegshare/backend/internal/delivery/repository"
+	deliveryService "github.com/rendley/vegshare/backend/internal/delivery/service"
+	deviceAdapter "github.com/rendley/vegshare/backend/internal/device/adapter"
+	deviceHandler "github.com/rendley/vegshare/backend/internal/device/handler"
+	deviceRepository "github.com/rendley/vegshare/backend/internal/device/repository"
+	deviceService "github.com/rendley/vegshare/backend/internal/device/service"
 	farmHandler "github.com/rendley/vegshare/backend/internal/farm/handler"
 	farmRepository "github.com/rendley/vegshare/backend/internal/farm/repository"
 	farmService "github.com/rendley/vegshare/backend/internal/farm/service"
+	harvestHandler "github.com/rendley/vegshare/backend/internal/harvest/handler"
+	harvestRepository "github.com/rendley/vegshare/backend/internal/harvest/repository"
+	harvestService "github.com/rendley/vegshare/backend/internal/harvest/service"
 	"github.com/rendley/vegshare/backend/internal/leasing/domain"
 	leasingHandler "github.com/rendley/vegshare/backend/internal/leasing/handler"
 	leasingModels "github.com/rendley/vegshare/backend/internal/leasing/models"
 	leasingRepository "github.com/rendley/vegshare/backend/internal/leasing/repository"
 	leasingService "github.com/rendley/vegshare/backend/internal/leasing/service"
+	"github.com/rendley/vegshare/backend/internal/notification/bot"
+	notificationHandler "github.com/rendley/vegshare/backend/internal/notification/handler"
+	notificationRepository "github.com/rendley/vegshare/backend/internal/notification/repository"
+	notificationService "github.com/rendley/vegshare/backend/internal/notification/service"
+	"github.com/rendley/vegshare/backend/internal/operations/actionhandlers"
+	"github.com/rendley/vegshare/backend/internal/operations/actions"
 	operationsHandler "github.com/rendley/vegshare/backend/internal/operations/handler"
+	"github.com/rendley/vegshare/backend/internal/operations/quota"
 	operationsRepository "github.com/rendley/vegshare/backend/internal/operations/repository"
 	operationsService "github.com/rendley/vegshare/backend/internal/operations/service"
+	"github.com/rendley/vegshare/backend/internal/outbox/relay"
 	plotHandler "github.com/rendley/vegshare/backend/internal/plot/handler"
 	plotRepository "github.com/rendley/vegshare/backend/internal/plot/repository"
 	plotService "github.com/rendley/vegshare/backend/internal/plot/service"
+	scheduleHandler "github.com/rendley/vegshare/backend/internal/schedule/handler"
+	scheduleRepository "github.com/rendley/vegshare/backend/internal/schedule/repository"
+	"github.com/rendley/vegshare/backend/internal/schedule/scheduler"
+	scheduleService "github.com/rendley/vegshare/backend/internal/schedule/service"
 	streamingHandler "github.com/rendley/vegshare/backend/internal/streaming/handler"
 	streamingService "github.com/rendley/vegshare/backend/internal/streaming/service"
 	taskHandler "github.com/rendley/vegshare/backend/internal/task/handler"
+	"github.com/rendley/vegshare/backend/internal/task/releaser"
 	taskRepository "github.com/rendley/vegshare/backend/internal/task/repository"
 	taskService "github.com/rendley/vegshare/backend/internal/task/service"
+	"github.com/rendley/vegshare/backend/internal/task/sla"
 	unitcontentRepository "github.com/rendley/vegshare/backend/internal/unitcontent/repository"
 	unitcontentService "github.com/rendley/vegshare/backend/internal/unitcontent/service"
 	userHandler "github.com/rendley/vegshare/backend/internal/user/handler"
 	userRepository "github.com/rendley/vegshare/backend/internal/user/repository"
 	userService "github.com/rendley/vegshare/backend/internal/user/service"
+	"github.com/rendley/vegshare/backend/internal/worker"
 	"github.com/rendley/vegshare/backend/pkg/config"
 	"github.com/rendley/vegshare/backend/pkg/database"
 	"github.com/rendley/vegshare/backend/pkg/jwt"
@@ -44,6 +76,8 @@ import (
 	"github.com/rendley/vegshare/backend/pkg/middleware"
 	"github.com/rendley/vegshare/backend/pkg/rabbitmq"
 	"github.com/rendley/vegshare/backend/pkg/security"
+	"github.com/rendley/vegshare/backend/pkg/storage"
+	"github.com/rendley/vegshare/backend/pkg/telegram"
 )
 
 func main() {
@@ -56,12 +90,6 @@ func main() {
 	hasher := security.NewBcryptHasher(10)
 	jwtGen := jwt.NewGenerator(cfg.JWT.Secret, cfg.JWT.AccessTokenTTL, cfg.JWT.RefreshTokenTTL)
 
-	rabbitMQClient, err := rabbitmq.New(cfg.RabbitMQ.URL)
-	if err != nil {
-		log.Fatalf("Failed to connect to RabbitMQ: %v", err)
-	}
-	defer rabbitMQClient.Close()
-
 	db, err := database.New(cfg)
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
@@ -80,18 +108,44 @@ func main() {
 	catalogRepo := catalogRepository.NewRepository(db)
 	cameraRepo := cameraRepository.NewRepository(db)
 	plotRepo := plotRepository.NewRepository(db)
+	coopRepo := coopRepository.NewRepository(db)
 	taskRepo := taskRepository.NewRepository(db)
 	unitContentRepo := unitcontentRepository.NewRepository(db)
+	harvestRepo := harvestRepository.NewRepository(db)
+	deliveryRepo := deliveryRepository.NewRepository(db)
+	deadletterRepo := deadletterRepository.NewRepository(db)
+	deviceRepo := deviceRepository.NewRepository(db)
+	scheduleRepo := scheduleRepository.NewRepository(db)
+	notificationRepo := notificationRepository.NewRepository(db)
 
 	// Services
 	authSvc := authService.NewAuthService(authRepo, hasher, jwtGen)
 	userSvc := userService.NewUserService(userRepo)
 	farmSvc := farmService.NewFarmService(farmRepo)
 	plotSvc := plotService.NewService(plotRepo, farmSvc)
+	coopSvc := coopService.NewService(coopRepo, farmSvc)
 	leasingSvc := leasingService.NewLeasingService(db, leasingRepo)
 	unitContentSvc := unitcontentService.NewService(unitContentRepo)
 	catalogSvc := catalogService.NewService(catalogRepo)
-	taskSvc := taskService.NewService(db, taskRepo, operationsRepo, unitContentSvc, plotSvc, catalogSvc)
+	harvestSvc := harvestService.NewService(harvestRepo, unitContentSvc)
+	actionHandlers := actionhandlers.NewDefaultRegistry(actionhandlers.Deps{
+		Plots:       plotSvc,
+		Coops:       coopSvc,
+		Catalog:     catalogSvc,
+		UnitContent: unitContentSvc,
+		Harvest:     harvestSvc,
+	})
+	fileStorage, err := storage.New(cfg.Storage)
+	if err != nil {
+		log.Fatalf("Failed to create file storage: %v", err)
+	}
+	taskSvc := taskService.NewService(db, taskRepo, operationsRepo, userRepo, actionHandlers, fileStorage, cfg)
+
+	actionRegistry := actions.NewDefaultRegistry()
+	quotaPolicy, err := quota.NewPolicy(cfg.Quotas)
+	if err != nil {
+		log.Fatalf("Invalid quotas config: %v", err)
+	}
 
 	// --- Регистрация UnitManager ---
 	unitManager, ok := plotSvc.(domain.UnitManager)
@@ -100,9 +154,22 @@ func main() {
 	}
 	leasingSvc.RegisterUnitManager(leasingModels.UnitTypePlot, unitManager)
 
-	operationsSvc := operationsService.NewOperationsService(operationsRepo, leasingRepo, rabbitMQClient, cfg)
+	coopUnitManager, ok := coopSvc.(domain.UnitManager)
+	if !ok {
+		log.Fatalf("coop.Service не реализует интерфейс domain.UnitManager")
+	}
+	leasingSvc.RegisterUnitManager(leasingModels.UnitTypeCoop, coopUnitManager)
+
+	operationsSvc := operationsService.NewOperationsService(db, operationsRepo, leasingRepo, actionRegistry, quotaPolicy, fileStorage, cfg)
+	scheduleSvc := scheduleService.NewService(db, scheduleRepo, operationsSvc, log)
+	deliverySvc := deliveryService.NewService(db, deliveryRepo, farmSvc, harvestSvc, operationsSvc)
+	deadletterSvc := deadletterService.NewService(db, deadletterRepo, operationsRepo)
+	deviceAdapters := deviceAdapter.NewDefaultRegistry(cfg.Devices.HTTPTimeout, cfg.Devices.SimulatorDelay)
+	deviceSvc := deviceService.NewService(db, deviceRepo, deviceAdapters, actionRegistry, actionHandlers, log)
 	cameraSvc := cameraService.NewService(cameraRepo)
 	streamingSvc := streamingService.NewService(cfg, log, cameraSvc)
+	telegramClient := telegram.NewClient(cfg.Telegram.APIURL, cfg.Telegram.BotToken, nil)
+	notificationSvc := notificationService.NewService(db, notificationRepo, taskSvc, telegramClient, cfg.Telegram, log)
 
 	// Middleware
 	mw := middleware.NewMiddleware(cfg, log)
@@ -112,15 +179,54 @@ func main() {
 	userHandler := userHandler.NewUserHandler(userSvc, log)
 	cameraHandler := cameraHandler.NewCameraHandler(cameraSvc, log)
 	plotHandler := plotHandler.NewPlotHandler(plotSvc, log)
+	coopHandler := coopHandler.NewCoopHandler(coopSvc, log)
 	farmHandler := farmHandler.NewFarmHandler(farmSvc, log)
 	leasingHandler := leasingHandler.NewLeasingHandler(leasingSvc, log)
 	operationsHandler := operationsHandler.NewOperationsHandler(operationsSvc, log)
 	catalogHandler := catalogHandler.NewCatalogHandler(catalogSvc, log)
 	streamingHandler := streamingHandler.NewStreamingHandler(streamingSvc, log)
 	taskHandler := taskHandler.NewTaskHandler(taskSvc, log)
+	harvestHandler := harvestHandler.NewHarvestHandler(harvestSvc, log)
+	deliveryHandler := deliveryHandler.NewDeliveryHandler(deliverySvc, log)
+	deadletterHandler := deadletterHandler.NewDeadLetterHandler(deadletterSvc, log)
+	deviceHandler := deviceHandler.NewDeviceHandler(deviceSvc, log)
+	scheduleHandler := scheduleHandler.NewScheduleHandler(scheduleSvc, log)
+	notificationHandler := notificationHandler.NewNotificationHandler(notificationSvc, log)
+
+	// Планировщик расписаний работает в каждом экземпляре API; дублей нет благодаря блокировке строк
+	go scheduler.New(scheduleSvc, log, cfg.Scheduler).Run(context.Background())
+
+	// Возврат в пул задач, которые слишком долго в работе
+	go releaser.New(taskSvc, log, cfg.Tasks).Run(context.Background())
+
+	// Отметка задач с истекшим сроком и события task.overdue
+	go sla.New(taskSvc, log, cfg.Tasks).Run(context.Background())
+
+	// Нажатия кнопок в Telegram; обновления бота получает только один экземпляр API
+	if cfg.Telegram.Enabled && cfg.Telegram.PollUpdates {
+		go bot.New(telegramClient, notificationSvc, log, cfg.Telegram).Run(context.Background())
+	}
+
+	// В режиме разработки с шиной в памяти relay и воркер работают в процессе API
+	if cfg.RabbitMQ.Driver == rabbitmq.DriverMemory {
+		bus, err := rabbitmq.Connect(cfg.RabbitMQ, log)
+		if err != nil {
+			log.Fatalf("Failed to create message bus: %v", err)
+		}
+		defer bus.Close()
+
+		ctx := context.Background()
+		go relay.New(db, bus, log, cfg.Outbox).Run(ctx)
+		go func() {
+			if err := worker.New(db, bus, log, cfg).Run(ctx); err != nil {
+				log.Fatalf("Worker failed: %v", err)
+			}
+		}()
+		log.Info("In-memory message bus started: relay and worker run inside the API process")
+	}
 
 	// Создаем и запускаем сервер
-	srv := api.New(cfg, mw, authHandler, userHandler, farmHandler, leasingHandler, operationsHandler, catalogHandler, cameraHandler, plotHandler, streamingHandler, taskHandler)
+	srv := api.New(cfg, mw, authHandler, userHandler, farmHandler, leasingHandler, operationsHandler, catalogHandler, cameraHandler, plotHandler, coopHandler, streamingHandler, taskHandler, harvestHandler, deliveryHandler, deadletterHandler, deviceHandler, scheduleHandler, notificationHandler)
 
 	if err := srv.Start(); err != nil {
 		log.Fatalf("Server failed: %v", err)
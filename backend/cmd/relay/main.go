@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/rendley/vegshare/backend/internal/outbox/relay"
+	"github.com/rendley/vegshare/backend/pkg/config"
+	"github.com/rendley/vegshare/backend/pkg/database"
+	"github.com/rendley/vegshare/backend/pkg/logger"
+	"github.com/rendley/vegshare/backend/pkg/rabbitmq"
+)
+
+// Relay - отдельный процесс, который переносит сообщения из таблицы outbox в RabbitMQ.
+func main() {
+	cfg := config.Load()
+	log := logger.New()
+
+	if cfg.RabbitMQ.Driver == rabbitmq.DriverMemory {
+		log.Fatalf("Driver '%s' works only inside the API process; run cmd/main.go instead", rabbitmq.DriverMemory)
+	}
+
+	db, err := database.New(cfg)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	client, err := rabbitmq.New(cfg.RabbitMQ, log)
+	if err != nil {
+		log.Fatalf("Failed to connect to RabbitMQ: %v", err)
+	}
+	defer client.Close()
+
+	// Очереди объявляются с DLX, поэтому relay должен объявить их так же, как воркер.
+	for _, name := range []string{"actions", "cancellations", "task_notifications"} {
+		t := rabbitmq.NewTopology(cfg.RabbitMQ.Queues[name], cfg.RabbitMQ.Retry)
+		if err := client.DeclareTopology(t); err != nil {
+			log.Fatalf("Failed to declare topology for queue '%s': %v", t.Queue, err)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	log.Printf(" [*] Outbox relay started. To exit press CTRL+C")
+	relay.New(db, client, log, cfg.Outbox).Run(ctx)
+	log.Printf("Outbox relay stopped")
+}
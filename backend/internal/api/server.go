@@ -10,10 +10,17 @@ import (
 	authhandler "github.com/rendley/vegshare/backend/internal/auth/handler"
 	camerahandler "github.com/rendley/vegshare/backend/internal/camera/handler"
 	cataloghandler "github.com/rendley/vegshare/backend/internal/catalog/handler"
+	coophandler "github.com/rendley/vegshare/backend/internal/coop/handler"
+	deadletterhandler "github.com/rendley/vegshare/backend/internal/deadletter/handler"
 	farmhandler "github.com/rendley/vegshare/backend/internal/farm/handler"
+	deliveryhandler "github.com/rendley/vegshare/backend/internal/delivery/handler"
+	devicehandler "github.com/rendley/vegshare/backend/internal/device/handler"
+	harvesthandler "github.com/rendley/vegshare/backend/internal/harvest/handler"
 	leasinghandler "github.com/rendley/vegshare/backend/internal/leasing/handler"
+	notificationhandler "github.com/rendley/vegshare/backend/internal/notification/handler"
 	operationshandler "github.com/rendley/vegshare/backend/internal/operations/handler"
 	plothandler "github.com/rendley/vegshare/backend/internal/plot/handler"
+	schedulehandler "github.com/rendley/vegshare/backend/internal/schedule/handler"
 	streaminghandler "github.com/rendley/vegshare/backend/internal/streaming/handler"
 	taskhandler "github.com/rendley/vegshare/backend/internal/task/handler"
 	userhandler "github.com/rendley/vegshare/backend/internal/user/handler"
@@ -23,35 +30,49 @@ import (
 
 // Server - это наша основная структура сервера, которая объединяет все зависимости.
 type Server struct {
-	cfg               *config.Config
-	mw                *middleware.Middleware
-	AuthHandler       *authhandler.AuthHandler
-	UserHandler       *userhandler.UserHandler
-	FarmHandler       *farmhandler.FarmHandler
-	LeasingHandler    *leasinghandler.LeasingHandler
-	OperationsHandler *operationshandler.OperationsHandler
-	CatalogHandler    *cataloghandler.CatalogHandler
-	CameraHandler     *camerahandler.CameraHandler
-	PlotHandler       *plothandler.PlotHandler
-	StreamingHandler  *streaminghandler.StreamingHandler
-	TaskHandler       *taskhandler.TaskHandler
+	cfg                 *config.Config
+	mw                  *middleware.Middleware
+	AuthHandler         *authhandler.AuthHandler
+	UserHandler         *userhandler.UserHandler
+	FarmHandler         *farmhandler.FarmHandler
+	LeasingHandler      *leasinghandler.LeasingHandler
+	OperationsHandler   *operationshandler.OperationsHandler
+	CatalogHandler      *cataloghandler.CatalogHandler
+	CameraHandler       *camerahandler.CameraHandler
+	PlotHandler         *plothandler.PlotHandler
+	CoopHandler         *coophandler.CoopHandler
+	StreamingHandler    *streaminghandler.StreamingHandler
+	TaskHandler         *taskhandler.TaskHandler
+	HarvestHandler      *harvesthandler.HarvestHandler
+	DeliveryHandler     *deliveryhandler.DeliveryHandler
+	DeadLetterHandler   *deadletterhandler.DeadLetterHandler
+	DeviceHandler       *devicehandler.DeviceHandler
+	ScheduleHandler     *schedulehandler.ScheduleHandler
+	NotificationHandler *notificationhandler.NotificationHandler
 }
 
 // New - это конструктор для `Server`.
-func New(cfg *config.Config, mw *middleware.Middleware, auth *authhandler.AuthHandler, user *userhandler.UserHandler, farm *farmhandler.FarmHandler, leasing *leasinghandler.LeasingHandler, ops *operationshandler.OperationsHandler, catalog *cataloghandler.CatalogHandler, camera *camerahandler.CameraHandler, plot *plothandler.PlotHandler, stream *streaminghandler.StreamingHandler, task *taskhandler.TaskHandler) *Server {
+func New(cfg *config.Config, mw *middleware.Middleware, auth *authhandler.AuthHandler, user *userhandler.UserHandler, farm *farmhandler.FarmHandler, leasing *leasinghandler.LeasingHandler, ops *operationshandler.OperationsHandler, catalog *cataloghandler.CatalogHandler, camera *camerahandler.CameraHandler, plot *plothandler.PlotHandler, coop *coophandler.CoopHandler, stream *streaminghandler.StreamingHandler, task *taskhandler.TaskHandler, harvest *harvesthandler.HarvestHandler, delivery *deliveryhandler.DeliveryHandler, deadLetters *deadletterhandler.DeadLetterHandler, devices *devicehandler.DeviceHandler, schedules *schedulehandler.ScheduleHandler, notifications *notificationhandler.NotificationHandler) *Server {
 	return &Server{
-		cfg:               cfg,
-		mw:                mw,
-		AuthHandler:       auth,
-		UserHandler:       user,
-		FarmHandler:       farm,
-		LeasingHandler:    leasing,
-		OperationsHandler: ops,
-		CatalogHandler:    catalog,
-		CameraHandler:     camera,
-		PlotHandler:       plot,
-		StreamingHandler:  stream,
-		TaskHandler:       task,
+		cfg:                 cfg,
+		mw:                  mw,
+		AuthHandler:         auth,
+		UserHandler:         user,
+		FarmHandler:         farm,
+		LeasingHandler:      leasing,
+		OperationsHandler:   ops,
+		CatalogHandler:      catalog,
+		CameraHandler:       camera,
+		PlotHandler:         plot,
+		CoopHandler:         coop,
+		StreamingHandler:    stream,
+		TaskHandler:         task,
+		HarvestHandler:      harvest,
+		DeliveryHandler:     delivery,
+		DeadLetterHandler:   deadLetters,
+		DeviceHandler:       devices,
+		ScheduleHandler:     schedules,
+		NotificationHandler: notifications,
 	}
 }
 
@@ -96,6 +117,13 @@ func (s *Server) Start() error {
 			r.Mount("/users", s.UserHandler.Routes())
 			r.Mount("/leasing", s.LeasingHandler.Routes())
 			r.Mount("/operations", s.OperationsHandler.Routes())
+			r.Mount("/schedules", s.ScheduleHandler.Routes())
+			r.Mount("/harvests", s.HarvestHandler.Routes())
+			r.Mount("/delivery", s.DeliveryHandler.Routes())
+			// Очередь задач исполнителя; персонал - пользователи с ролью admin
+			r.With(s.mw.AdminMiddleware).Mount("/tasks", s.TaskHandler.StaffRoutes())
+			// Привязка Telegram-бота: сотрудникам он присылает новые задачи, арендаторам - невыполненные действия
+			r.Mount("/notifications", s.NotificationHandler.Routes())
 
 			// --- Иерархия фермы: РЕГИОНЫ ---
 			// r.Route() группирует роуты по общему префиксу, делая код чище.
@@ -152,6 +180,21 @@ func (s *Server) Start() error {
 				})
 			})
 
+			// --- ЗАГОНЫ (Coops) ---
+			r.Route("/coops", func(r chi.Router) {
+				// GET /coops?structure_id=... - получить загоны в строении (для всех)
+				r.Get("/", s.CoopHandler.GetCoops)
+				// POST /coops - создать загон (только админ)
+				r.With(s.mw.AdminMiddleware).Post("/", s.CoopHandler.CreateCoop)
+
+				// Группа для конкретного загона: /coops/{coopID}
+				r.Route("/{coopID}", func(r chi.Router) {
+					r.Get("/", s.CoopHandler.GetCoopByID)
+					r.With(s.mw.AdminMiddleware).Put("/", s.CoopHandler.UpdateCoop)
+					r.With(s.mw.AdminMiddleware).Delete("/", s.CoopHandler.DeleteCoop)
+				})
+			})
+
 			// --- КАМЕРЫ (НОВЫЕ ПОЛИМОРФНЫЕ РОУТЫ) ---
 			r.Route("/cameras", func(r chi.Router) {
 				// GET /cameras?unit_id=...&unit_type=... - получить камеры для любого юнита
@@ -183,8 +226,20 @@ func (s *Server) Start() error {
 					r.Put("/{userID}/role", s.UserHandler.UpdateUserRole)
 				})
 
+				// Управление арендами
+				r.Mount("/leases", s.LeasingHandler.AdminRoutes())
+
 				// Управление задачами
 				r.Mount("/tasks", s.TaskHandler.Routes())
+
+				// Слоты выдачи и доставки урожая
+				r.Mount("/delivery", s.DeliveryHandler.AdminRoutes())
+
+				// Разбор сообщений, которые воркер не смог обработать
+				r.Mount("/dead-letters", s.DeadLetterHandler.AdminRoutes())
+
+				// IoT-устройства, выполняющие действия без персонала
+				r.Mount("/devices", s.DeviceHandler.AdminRoutes())
 			})
 		})
 
@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/rendley/vegshare/backend/internal/coop/service"
+	"github.com/rendley/vegshare/backend/pkg/api"
+	"github.com/sirupsen/logrus"
+)
+
+// --- DTOs ---
+type CoopRequest struct {
+	Name        string    `json:"name" validate:"required,min=2,max=100"`
+	Capacity    int       `json:"capacity" validate:"required,gte=1"`
+	AnimalType  string    `json:"animal_type" validate:"required,min=2,max=50"`
+	StructureID uuid.UUID `json:"structure_id" validate:"required"`
+}
+
+type UpdateCoopRequest struct {
+	Name       string `json:"name" validate:"required,min=2,max=100"`
+	Capacity   int    `json:"capacity" validate:"required,gte=1"`
+	AnimalType string `json:"animal_type" validate:"required,min=2,max=50"`
+	Status     string `json:"status" validate:"required,oneof=available rented maintenance"`
+}
+
+// --- Handler ---
+
+type CoopHandler struct {
+	service  service.Service
+	logger   *logrus.Logger
+	validate *validator.Validate
+}
+
+func NewCoopHandler(s service.Service, l *logrus.Logger) *CoopHandler {
+	return &CoopHandler{
+		service:  s,
+		logger:   l,
+		validate: validator.New(),
+	}
+}
+
+func (h *CoopHandler) CreateCoop(w http.ResponseWriter, r *http.Request) {
+	var req CoopRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.RespondWithError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		api.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	coop, err := h.service.CreateCoop(r.Context(), req.Name, req.Capacity, req.AnimalType, req.StructureID)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidStructureType) {
+			api.RespondWithError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		h.logger.Errorf("ошибка при создании загона: %v", err)
+		api.RespondWithError(w, "could not create coop", http.StatusInternalServerError)
+		return
+	}
+
+	api.RespondWithJSON(h.logger, w, coop, http.StatusCreated)
+}
+
+func (h *CoopHandler) GetCoops(w http.ResponseWriter, r *http.Request) {
+	structureIDStr := r.URL.Query().Get("structure_id")
+	if structureIDStr == "" {
+		api.RespondWithError(w, "structure_id query parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	structureID, err := uuid.Parse(structureIDStr)
+	if err != nil {
+		api.RespondWithError(w, "invalid structure_id query parameter", http.StatusBadRequest)
+		return
+	}
+
+	coops, err := h.service.GetCoopsByStructure(r.Context(), structureID)
+	if err != nil {
+		h.logger.Errorf("ошибка при получении списка загонов: %v", err)
+		api.RespondWithError(w, "could not retrieve coops", http.StatusInternalServerError)
+		return
+	}
+
+	api.RespondWithJSON(h.logger, w, coops, http.StatusOK)
+}
+
+func (h *CoopHandler) GetCoopByID(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "coopID")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		api.RespondWithError(w, "invalid coop ID in URL", http.StatusBadRequest)
+		return
+	}
+
+	coop, err := h.service.GetCoopByID(r.Context(), id)
+	if err != nil {
+		h.logger.Errorf("ошибка при получении загона: %v", err)
+		api.RespondWithError(w, "coop not found", http.StatusNotFound)
+		return
+	}
+
+	api.RespondWithJSON(h.logger, w, coop, http.StatusOK)
+}
+
+func (h *CoopHandler) UpdateCoop(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "coopID")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		api.RespondWithError(w, "invalid coop ID in URL", http.StatusBadRequest)
+		return
+	}
+
+	var req UpdateCoopRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.RespondWithError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		api.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	coop, err := h.service.UpdateCoop(r.Context(), id, req.Name, req.Capacity, req.AnimalType, req.Status)
+	if err != nil {
+		h.logger.Errorf("ошибка при обновлении загона: %v", err)
+		api.RespondWithError(w, "could not update coop", http.StatusInternalServerError)
+		return
+	}
+
+	api.RespondWithJSON(h.logger, w, coop, http.StatusOK)
+}
+
+func (h *CoopHandler) DeleteCoop(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "coopID")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		api.RespondWithError(w, "invalid coop ID in URL", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.DeleteCoop(r.Context(), id); err != nil {
+		h.logger.Errorf("ошибка при удалении загона: %v", err)
+		api.RespondWithError(w, "could not delete coop", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Coop представляет арендуемый пользователем загон/курятник внутри строения (например, птичника).
+type Coop struct {
+	ID          uuid.UUID `db:"id" json:"id"`
+	StructureID uuid.UUID `db:"structure_id" json:"structure_id"`
+	Name        string    `db:"name" json:"name"`
+	// Capacity - максимальное количество животных, которое можно разместить в загоне.
+	Capacity int `db:"capacity" json:"capacity"`
+	// AnimalType определяет, для каких животных предназначен загон, например, "chicken" или "quail".
+	AnimalType string `db:"animal_type" json:"animal_type"`
+	// Status показывает текущее состояние загона: 'available', 'rented', 'maintenance'.
+	Status    string    `db:"status" json:"status"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// GetID возвращает ID загона, удовлетворяя интерфейсу LeasableUnit.
+func (c Coop) GetID() uuid.UUID {
+	return c.ID
+}
+
+// GetStatus возвращает текущий статус загона, удовлетворяя интерфейсу LeasableUnit.
+func (c Coop) GetStatus() string {
+	return c.Status
+}
+
+// GetUnitType возвращает тип юнита "coop", удовлетворяя интерфейсу LeasableUnit.
+func (c Coop) GetUnitType() string {
+	return "coop"
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/rendley/vegshare/backend/internal/coop/models"
+	"github.com/rendley/vegshare/backend/pkg/database"
+)
+
+// Repository определяет контракт для хранилища загонов.
+type Repository interface {
+	CreateCoop(ctx context.Context, coop *models.Coop) error
+	GetCoopByID(ctx context.Context, id uuid.UUID) (*models.Coop, error)
+	GetCoopsByStructure(ctx context.Context, structureID uuid.UUID) ([]models.Coop, error)
+	UpdateCoop(ctx context.Context, coop *models.Coop) error
+	DeleteCoop(ctx context.Context, id uuid.UUID) error
+}
+
+// repository реализует интерфейс Repository.
+type repository struct {
+	db database.DBTX
+}
+
+// NewRepository - конструктор для репозитория загонов.
+func NewRepository(db database.DBTX) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) CreateCoop(ctx context.Context, coop *models.Coop) error {
+	query := `INSERT INTO coops (id, structure_id, name, capacity, animal_type, status, created_at, updated_at)
+	          VALUES (:id, :structure_id, :name, :capacity, :animal_type, :status, :created_at, :updated_at)`
+	_, err := r.db.NamedExecContext(ctx, query, coop)
+	if err != nil {
+		return fmt.Errorf("не удалось создать загон: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetCoopByID(ctx context.Context, id uuid.UUID) (*models.Coop, error) {
+	var coop models.Coop
+	query := `SELECT * FROM coops WHERE id = $1`
+	err := r.db.GetContext(ctx, &coop, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить загон по ID: %w", err)
+	}
+	return &coop, nil
+}
+
+func (r *repository) GetCoopsByStructure(ctx context.Context, structureID uuid.UUID) ([]models.Coop, error) {
+	var coops []models.Coop
+	query := `SELECT * FROM coops WHERE structure_id = $1`
+	err := r.db.SelectContext(ctx, &coops, query, structureID)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить список загонов для строения: %w", err)
+	}
+	return coops, nil
+}
+
+func (r *repository) UpdateCoop(ctx context.Context, coop *models.Coop) error {
+	query := `UPDATE coops SET name = :name, capacity = :capacity, animal_type = :animal_type, status = :status, updated_at = :updated_at WHERE id = :id`
+	_, err := r.db.NamedExecContext(ctx, query, coop)
+	if err != nil {
+		return fmt.Errorf("не удалось обновить загон: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) DeleteCoop(ctx context.Context, id uuid.UUID) error {
+	query := `DELETE FROM coops WHERE id = $1`
+	_, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("не удалось удалить загон: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/rendley/vegshare/backend/internal/coop/models"
+	"github.com/rendley/vegshare/backend/internal/coop/repository"
+	farmService "github.com/rendley/vegshare/backend/internal/farm/service"
+	"github.com/rendley/vegshare/backend/internal/leasing/domain"
+)
+
+// CoopStructureType - тип строения, в котором допускается размещать загоны.
+const CoopStructureType = "poultry_coop"
+
+// ErrInvalidStructureType возвращается, если загон создают в строении не типа CoopStructureType.
+var ErrInvalidStructureType = errors.New("загон можно создать только в строении типа '" + CoopStructureType + "'")
+
+// Service определяет контракт для сервиса загонов.
+type Service interface {
+	CreateCoop(ctx context.Context, name string, capacity int, animalType string, structureID uuid.UUID) (*models.Coop, error)
+	GetCoopByID(ctx context.Context, id uuid.UUID) (*models.Coop, error)
+	GetCoopsByStructure(ctx context.Context, structureID uuid.UUID) ([]models.Coop, error)
+	UpdateCoop(ctx context.Context, id uuid.UUID, name string, capacity int, animalType, status string) (*models.Coop, error)
+	DeleteCoop(ctx context.Context, id uuid.UUID) error
+
+	// Методы из domain.UnitManager
+	GetLeasableUnit(ctx context.Context, unitID uuid.UUID) (domain.LeasableUnit, error)
+	UpdateUnitStatus(ctx context.Context, unitID uuid.UUID, status string) error
+	WithTx(tx *sqlx.Tx) domain.UnitManager
+}
+
+// service реализует интерфейс Service.
+type service struct {
+	repo    repository.Repository
+	farmSvc farmService.Service
+}
+
+// NewService - конструктор для сервиса загонов.
+func NewService(repo repository.Repository, farmSvc farmService.Service) Service {
+	return &service{repo: repo, farmSvc: farmSvc}
+}
+
+// WithTx создает новый сервис в рамках транзакции.
+func (s *service) WithTx(tx *sqlx.Tx) domain.UnitManager {
+	return &service{
+		repo:    repository.NewRepository(tx),
+		farmSvc: s.farmSvc,
+	}
+}
+
+func (s *service) CreateCoop(ctx context.Context, name string, capacity int, animalType string, structureID uuid.UUID) (*models.Coop, error) {
+	structure, err := s.farmSvc.GetStructureByID(ctx, structureID)
+	if err != nil {
+		return nil, fmt.Errorf("строение с ID %s не найдено: %w", structureID, err)
+	}
+	if structure.Type != CoopStructureType {
+		return nil, fmt.Errorf("%w, текущий тип: '%s'", ErrInvalidStructureType, structure.Type)
+	}
+
+	now := time.Now()
+	coop := &models.Coop{
+		ID:          uuid.New(),
+		StructureID: structureID,
+		Name:        name,
+		Capacity:    capacity,
+		AnimalType:  animalType,
+		Status:      "available",
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	if err := s.repo.CreateCoop(ctx, coop); err != nil {
+		return nil, err
+	}
+
+	return coop, nil
+}
+
+func (s *service) GetCoopByID(ctx context.Context, id uuid.UUID) (*models.Coop, error) {
+	return s.repo.GetCoopByID(ctx, id)
+}
+
+func (s *service) GetCoopsByStructure(ctx context.Context, structureID uuid.UUID) ([]models.Coop, error) {
+	return s.repo.GetCoopsByStructure(ctx, structureID)
+}
+
+func (s *service) UpdateCoop(ctx context.Context, id uuid.UUID, name string, capacity int, animalType, status string) (*models.Coop, error) {
+	coop, err := s.repo.GetCoopByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("загон для обновления не найден: %w", err)
+	}
+
+	coop.Name = name
+	coop.Capacity = capacity
+	coop.AnimalType = animalType
+	coop.Status = status
+	coop.UpdatedAt = time.Now()
+
+	if err := s.repo.UpdateCoop(ctx, coop); err != nil {
+		return nil, err
+	}
+
+	return coop, nil
+}
+
+func (s *service) DeleteCoop(ctx context.Context, id uuid.UUID) error {
+	return s.repo.DeleteCoop(ctx, id)
+}
+
+func (s *service) GetLeasableUnit(ctx context.Context, unitID uuid.UUID) (domain.LeasableUnit, error) {
+	return s.GetCoopByID(ctx, unitID)
+}
+
+func (s *service) UpdateUnitStatus(ctx context.Context, unitID uuid.UUID, status string) error {
+	coop, err := s.GetCoopByID(ctx, unitID)
+	if err != nil {
+		return err
+	}
+	_, err = s.UpdateCoop(ctx, unitID, coop.Name, coop.Capacity, coop.AnimalType, status)
+	return err
+}
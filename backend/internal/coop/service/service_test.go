@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rendley/vegshare/backend/internal/coop/models"
+	"github.com/rendley/vegshare/backend/internal/coop/repository"
+	farmModels "github.com/rendley/vegshare/backend/internal/farm/models"
+	farmMocks "github.com/rendley/vegshare/backend/internal/farm/service/mocks"
+	"github.com/rendley/vegshare/backend/internal/leasing/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// --- Mocks ---
+
+type MockCoopRepository struct {
+	mock.Mock
+}
+
+var _ repository.Repository = &MockCoopRepository{}
+
+func (m *MockCoopRepository) CreateCoop(ctx context.Context, coop *models.Coop) error {
+	args := m.Called(ctx, coop)
+	return args.Error(0)
+}
+
+func (m *MockCoopRepository) GetCoopByID(ctx context.Context, id uuid.UUID) (*models.Coop, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Coop), args.Error(1)
+}
+
+func (m *MockCoopRepository) GetCoopsByStructure(ctx context.Context, structureID uuid.UUID) ([]models.Coop, error) {
+	args := m.Called(ctx, structureID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Coop), args.Error(1)
+}
+
+func (m *MockCoopRepository) UpdateCoop(ctx context.Context, coop *models.Coop) error {
+	args := m.Called(ctx, coop)
+	return args.Error(0)
+}
+
+func (m *MockCoopRepository) DeleteCoop(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func TestCoopService(t *testing.T) {
+	ctx := context.Background()
+	mockCoopRepo := new(MockCoopRepository)
+	mockFarmSvc := new(farmMocks.FarmService)
+	coopSvc := NewService(mockCoopRepo, mockFarmSvc)
+
+	t.Run("CreateCoop - Success", func(t *testing.T) {
+		structureID := uuid.New()
+		mockFarmSvc.On("GetStructureByID", ctx, structureID).Return(&farmModels.Structure{ID: structureID, Type: CoopStructureType}, nil).Once()
+		mockCoopRepo.On("CreateCoop", ctx, mock.AnythingOfType("*models.Coop")).Return(nil).Once()
+
+		coop, err := coopSvc.CreateCoop(ctx, "Курятник #1", 10, "chicken", structureID)
+
+		assert.NoError(t, err)
+		assert.NotNil(t, coop)
+		assert.Equal(t, "Курятник #1", coop.Name)
+		assert.Equal(t, 10, coop.Capacity)
+		assert.Equal(t, "available", coop.Status)
+		assert.Equal(t, structureID, coop.StructureID)
+		mockCoopRepo.AssertExpectations(t)
+		mockFarmSvc.AssertExpectations(t)
+	})
+
+	t.Run("CreateCoop - Wrong structure type", func(t *testing.T) {
+		structureID := uuid.New()
+		mockFarmSvc.On("GetStructureByID", ctx, structureID).Return(&farmModels.Structure{ID: structureID, Type: "greenhouse"}, nil).Once()
+
+		coop, err := coopSvc.CreateCoop(ctx, "Курятник #2", 10, "chicken", structureID)
+
+		assert.ErrorIs(t, err, ErrInvalidStructureType)
+		assert.Nil(t, coop)
+		mockFarmSvc.AssertExpectations(t)
+	})
+
+	t.Run("UpdateCoop - Success", func(t *testing.T) {
+		coopID := uuid.New()
+		original := &models.Coop{ID: coopID, Name: "Old", Capacity: 5, AnimalType: "chicken", Status: "available"}
+		mockCoopRepo.On("GetCoopByID", ctx, coopID).Return(original, nil).Once()
+		mockCoopRepo.On("UpdateCoop", ctx, mock.AnythingOfType("*models.Coop")).Return(nil).Once()
+
+		updated, err := coopSvc.UpdateCoop(ctx, coopID, "New", 8, "quail", "maintenance")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "New", updated.Name)
+		assert.Equal(t, 8, updated.Capacity)
+		assert.Equal(t, "quail", updated.AnimalType)
+		assert.Equal(t, "maintenance", updated.Status)
+		mockCoopRepo.AssertExpectations(t)
+	})
+
+	t.Run("UnitManager - GetLeasableUnit and UpdateUnitStatus", func(t *testing.T) {
+		coopID := uuid.New()
+		coop := &models.Coop{ID: coopID, Name: "Coop", Capacity: 3, AnimalType: "chicken", Status: "available"}
+		mockCoopRepo.On("GetCoopByID", ctx, coopID).Return(coop, nil)
+		mockCoopRepo.On("UpdateCoop", ctx, mock.AnythingOfType("*models.Coop")).Return(nil).Once()
+
+		var manager domain.UnitManager = coopSvc
+		unit, err := manager.GetLeasableUnit(ctx, coopID)
+		assert.NoError(t, err)
+		assert.Equal(t, "coop", unit.GetUnitType())
+		assert.Equal(t, "available", unit.GetStatus())
+
+		err = manager.UpdateUnitStatus(ctx, coopID, "rented")
+		assert.NoError(t, err)
+		assert.Equal(t, "rented", coop.Status)
+		mockCoopRepo.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/rendley/vegshare/backend/internal/deadletter/models"
+	"github.com/rendley/vegshare/backend/internal/deadletter/service"
+	"github.com/rendley/vegshare/backend/pkg/api"
+	"github.com/sirupsen/logrus"
+)
+
+// DeadLetterHandler обрабатывает HTTP-запросы администратора к сообщениям из DLQ.
+type DeadLetterHandler struct {
+	service service.Service
+	logger  *logrus.Logger
+}
+
+// NewDeadLetterHandler - конструктор для DeadLetterHandler.
+func NewDeadLetterHandler(s service.Service, l *logrus.Logger) *DeadLetterHandler {
+	return &DeadLetterHandler{
+		service: s,
+		logger:  l,
+	}
+}
+
+// List возвращает сообщения из DLQ (опционально ?queue=...&redriven=true|false).
+func (h *DeadLetterHandler) List(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := models.Filter{Queue: q.Get("queue")}
+	if v := q.Get("redriven"); v != "" {
+		redriven, err := strconv.ParseBool(v)
+		if err != nil {
+			api.RespondWithError(w, "invalid redriven query parameter", http.StatusBadRequest)
+			return
+		}
+		filter.Redriven = &redriven
+	}
+
+	deadLetters, err := h.service.List(r.Context(), filter)
+	if err != nil {
+		h.logger.Errorf("ошибка при получении сообщений из DLQ: %v", err)
+		api.RespondWithError(w, "could not retrieve dead letters", http.StatusInternalServerError)
+		return
+	}
+
+	api.RespondWithJSON(h.logger, w, deadLetters, http.StatusOK)
+}
+
+// Get возвращает сообщение из DLQ вместе с текущим состоянием операции.
+func (h *DeadLetterHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "deadLetterID"))
+	if err != nil {
+		api.RespondWithError(w, "invalid dead letter ID in URL", http.StatusBadRequest)
+		return
+	}
+
+	details, err := h.service.Get(r.Context(), id)
+	if err != nil {
+		h.respondWithServiceError(w, "ошибка при получении сообщения из DLQ", err)
+		return
+	}
+
+	api.RespondWithJSON(h.logger, w, details, http.StatusOK)
+}
+
+// Redrive возвращает сообщение в исходную очередь.
+func (h *DeadLetterHandler) Redrive(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "deadLetterID"))
+	if err != nil {
+		api.RespondWithError(w, "invalid dead letter ID in URL", http.StatusBadRequest)
+		return
+	}
+
+	dl, err := h.service.Redrive(r.Context(), id)
+	if err != nil {
+		h.respondWithServiceError(w, "ошибка при повторной отправке сообщения из DLQ", err)
+		return
+	}
+
+	api.RespondWithJSON(h.logger, w, dl, http.StatusOK)
+}
+
+func (h *DeadLetterHandler) respondWithServiceError(w http.ResponseWriter, msg string, err error) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		api.RespondWithError(w, "dead letter not found", http.StatusNotFound)
+	case errors.Is(err, service.ErrAlreadyRedriven):
+		api.RespondWithError(w, err.Error(), http.StatusConflict)
+	default:
+		h.logger.Errorf("%s: %v", msg, err)
+		api.RespondWithError(w, err.Error(), http.StatusInternalServerError)
+	}
+}
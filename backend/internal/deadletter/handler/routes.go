@@ -0,0 +1,22 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// AdminRoutes возвращает роутер для разбора сообщений из DLQ.
+// Монтируется внутри группы /admin, защищенной AdminMiddleware.
+func (h *DeadLetterHandler) AdminRoutes() http.Handler {
+	r := chi.NewRouter()
+
+	// GET /admin/dead-letters?queue=&redriven= - список сообщений
+	r.Get("/", h.List)
+	// GET /admin/dead-letters/{deadLetterID} - сообщение и состояние операции
+	r.Get("/{deadLetterID}", h.Get)
+	// POST /admin/dead-letters/{deadLetterID}/redrive - вернуть сообщение в очередь
+	r.Post("/{deadLetterID}/redrive", h.Redrive)
+
+	return r
+}
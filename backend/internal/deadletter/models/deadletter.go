@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	operationsModels "github.com/rendley/vegshare/backend/internal/operations/models"
+)
+
+// DeadLetter - сообщение из DLQ, сохраненное для разбора администратором.
+type DeadLetter struct {
+	ID    uuid.UUID `db:"id" json:"id"`
+	Queue string    `db:"queue" json:"queue"`
+	// OperationID - операция из тела сообщения; nil, если тело не удалось разобрать.
+	OperationID *uuid.UUID `db:"operation_id" json:"operation_id,omitempty"`
+	Payload     string     `db:"payload" json:"payload"`
+	LastError   string     `db:"last_error" json:"last_error"`
+	Attempts    int        `db:"attempts" json:"attempts"`
+	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
+	// RedrivenAt - когда сообщение было отправлено на повторную обработку.
+	RedrivenAt *time.Time `db:"redriven_at" json:"redriven_at,omitempty"`
+}
+
+// DeadLetterDetails - сообщение из DLQ вместе с текущим состоянием операции.
+type DeadLetterDetails struct {
+	DeadLetter
+	Operation *operationsModels.OperationLog `json:"operation,omitempty"`
+}
+
+// Filter - параметры выборки сообщений из DLQ.
+type Filter struct {
+	Queue string
+	// Redriven: nil - все, false - только ожидающие разбора, true - только отправленные повторно.
+	Redriven *bool
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/rendley/vegshare/backend/internal/deadletter/models"
+	"github.com/rendley/vegshare/backend/pkg/database"
+)
+
+// ErrAlreadyRedriven возвращается при повторной отправке уже отправленного сообщения.
+var ErrAlreadyRedriven = errors.New("сообщение уже отправлено на повторную обработку")
+
+// Repository определяет контракт для хранилища "мертвых" сообщений.
+type Repository interface {
+	Create(ctx context.Context, dl *models.DeadLetter) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.DeadLetter, error)
+	List(ctx context.Context, filter models.Filter) ([]models.DeadLetter, error)
+	// MarkRedriven отмечает сообщение отправленным повторно; ErrAlreadyRedriven, если оно
+	// уже отправлено (в том числе параллельным запросом).
+	MarkRedriven(ctx context.Context, id uuid.UUID) error
+}
+
+type repository struct {
+	db database.DBTX
+}
+
+// NewRepository - конструктор для репозитория DLQ.
+func NewRepository(db database.DBTX) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) Create(ctx context.Context, dl *models.DeadLetter) error {
+	query := `INSERT INTO dead_letters (id, queue, operation_id, payload, last_error, attempts, created_at)
+	          VALUES (:id, :queue, :operation_id, :payload, :last_error, :attempts, :created_at)`
+	if _, err := r.db.NamedExecContext(ctx, query, dl); err != nil {
+		return fmt.Errorf("не удалось сохранить сообщение из DLQ: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetByID(ctx context.Context, id uuid.UUID) (*models.DeadLetter, error) {
+	var dl models.DeadLetter
+	query := `SELECT * FROM dead_letters WHERE id = $1`
+	if err := r.db.GetContext(ctx, &dl, query, id); err != nil {
+		return nil, fmt.Errorf("не удалось получить сообщение из DLQ по ID: %w", err)
+	}
+	return &dl, nil
+}
+
+func (r *repository) List(ctx context.Context, filter models.Filter) ([]models.DeadLetter, error) {
+	query := `
+        SELECT * FROM dead_letters
+        WHERE ($1 = '' OR queue = $1)
+          AND ($2::boolean IS NULL OR (redriven_at IS NOT NULL) = $2)
+        ORDER BY created_at DESC`
+
+	deadLetters := []models.DeadLetter{}
+	if err := r.db.SelectContext(ctx, &deadLetters, query, filter.Queue, filter.Redriven); err != nil {
+		return nil, fmt.Errorf("не удалось получить сообщения из DLQ: %w", err)
+	}
+	return deadLetters, nil
+}
+
+func (r *repository) MarkRedriven(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE dead_letters SET redriven_at = NOW() WHERE id = $1 AND redriven_at IS NULL`
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("не удалось отметить сообщение как отправленное повторно: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("не удалось проверить результат обновления: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrAlreadyRedriven
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/rendley/vegshare/backend/internal/deadletter/models"
+	"github.com/rendley/vegshare/backend/internal/deadletter/repository"
+	operationsRepository "github.com/rendley/vegshare/backend/internal/operations/repository"
+	outboxModels "github.com/rendley/vegshare/backend/internal/outbox/models"
+	outboxRepository "github.com/rendley/vegshare/backend/internal/outbox/repository"
+	"github.com/rendley/vegshare/backend/pkg/database"
+)
+
+// ErrAlreadyRedriven возвращается при повторной отправке уже отправленного сообщения.
+var ErrAlreadyRedriven = repository.ErrAlreadyRedriven
+
+// ErrUnrecordable возвращается, если сообщение нельзя сохранить из-за него самого: тело
+// не является текстом UTF-8 или запись нарушает ограничения БД. Повтор записи не поможет.
+var ErrUnrecordable = errors.New("сообщение нельзя сохранить в журнал DLQ")
+
+// Service определяет контракт для разбора "мертвых" сообщений.
+type Service interface {
+	// Record сохраняет сообщение, попавшее в DLQ очереди queue.
+	Record(ctx context.Context, queue, payload, lastError string, attempts int) (*models.DeadLetter, error)
+	List(ctx context.Context, filter models.Filter) ([]models.DeadLetter, error)
+	// Get возвращает сообщение вместе с текущим состоянием связанной операции.
+	Get(ctx context.Context, id uuid.UUID) (*models.DeadLetterDetails, error)
+	// Redrive возвращает сообщение в исходную очередь через outbox.
+	Redrive(ctx context.Context, id uuid.UUID) (*models.DeadLetter, error)
+}
+
+type service struct {
+	db      *sqlx.DB
+	repo    repository.Repository
+	opsRepo operationsRepository.Repository
+
+	// Фабрики репозиториев поверх транзакции; в тестах подменяются моками.
+	newRepo   func(db database.DBTX) repository.Repository
+	newOutbox func(db database.DBTX) outboxRepository.Repository
+}
+
+// NewService - конструктор для сервиса DLQ.
+func NewService(db *sqlx.DB, repo repository.Repository, opsRepo operationsRepository.Repository) Service {
+	return &service{
+		db:        db,
+		repo:      repo,
+		opsRepo:   opsRepo,
+		newRepo:   repository.NewRepository,
+		newOutbox: outboxRepository.NewRepository,
+	}
+}
+
+func (s *service) Record(ctx context.Context, queue, payload, lastError string, attempts int) (*models.DeadLetter, error) {
+	// PostgreSQL не принимает в text некорректный UTF-8 и нулевой байт.
+	for _, text := range []string{payload, lastError} {
+		if !utf8.ValidString(text) || strings.ContainsRune(text, 0) {
+			return nil, fmt.Errorf("%w: текст не является корректной строкой UTF-8", ErrUnrecordable)
+		}
+	}
+
+	dl := &models.DeadLetter{
+		ID:          uuid.New(),
+		Queue:       queue,
+		OperationID: operationIDFromPayload(payload),
+		Payload:     payload,
+		LastError:   lastError,
+		Attempts:    attempts,
+		CreatedAt:   time.Now(),
+	}
+	// Операции может уже не быть - из-за этого сообщение часто и попадает в DLQ. Ссылку на нее
+	// тогда не сохраняем, иначе внешний ключ не даст записать сообщение.
+	if dl.OperationID != nil {
+		if _, err := s.opsRepo.GetOperationLogByID(ctx, *dl.OperationID); err != nil {
+			if !errors.Is(err, sql.ErrNoRows) {
+				return nil, err
+			}
+			dl.OperationID = nil
+		}
+	}
+	if err := s.repo.Create(ctx, dl); err != nil {
+		var pqErr *pq.Error
+		// Классы 22 (некорректные данные) и 23 (нарушение ограничений) повторяются при каждой попытке.
+		if errors.As(err, &pqErr) && (pqErr.Code.Class() == "22" || pqErr.Code.Class() == "23") {
+			return nil, fmt.Errorf("%w: %w", ErrUnrecordable, err)
+		}
+		return nil, err
+	}
+	return dl, nil
+}
+
+// operationIDFromPayload достает ID операции из тела сообщения: операции (id) или события
+// задачи (operation_id). Тело может быть некорректным - именно поэтому сообщение и попало в DLQ, -
+// тогда возвращается nil.
+func operationIDFromPayload(payload string) *uuid.UUID {
+	var msg struct {
+		ID          uuid.UUID `json:"id"`
+		OperationID uuid.UUID `json:"operation_id"`
+	}
+	if err := json.Unmarshal([]byte(payload), &msg); err != nil {
+		return nil
+	}
+	if msg.ID == uuid.Nil {
+		msg.ID = msg.OperationID
+	}
+	if msg.ID == uuid.Nil {
+		return nil
+	}
+	return &msg.ID
+}
+
+func (s *service) List(ctx context.Context, filter models.Filter) ([]models.DeadLetter, error) {
+	return s.repo.List(ctx, filter)
+}
+
+func (s *service) Get(ctx context.Context, id uuid.UUID) (*models.DeadLetterDetails, error) {
+	dl, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	details := &models.DeadLetterDetails{DeadLetter: *dl}
+	if dl.OperationID != nil {
+		op, err := s.opsRepo.GetOperationLogByID(ctx, *dl.OperationID)
+		if err != nil {
+			return nil, err
+		}
+		details.Operation = op
+	}
+	return details, nil
+}
+
+func (s *service) Redrive(ctx context.Context, id uuid.UUID) (*models.DeadLetter, error) {
+	dl, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if dl.RedrivenAt != nil {
+		return nil, ErrAlreadyRedriven
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось начать транзакцию: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Условное обновление не дает двум параллельным запросам отправить сообщение дважды.
+	if err := s.newRepo(tx).MarkRedriven(ctx, id); err != nil {
+		return nil, err
+	}
+	// Сообщение уходит в outbox без заголовков попыток, поэтому воркер начнет отсчет повторов заново.
+	if err := s.newOutbox(tx).Create(ctx, outboxModels.NewMessage(dl.Queue, dl.Payload)); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("не удалось закоммитить транзакцию: %w", err)
+	}
+
+	now := time.Now()
+	dl.RedrivenAt = &now
+	return dl, nil
+}
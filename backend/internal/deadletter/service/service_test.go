@@ -0,0 +1,253 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/rendley/vegshare/backend/internal/deadletter/models"
+	"github.com/rendley/vegshare/backend/internal/deadletter/repository"
+	operationsModels "github.com/rendley/vegshare/backend/internal/operations/models"
+	operationsRepository "github.com/rendley/vegshare/backend/internal/operations/repository"
+	outboxModels "github.com/rendley/vegshare/backend/internal/outbox/models"
+	outboxRepository "github.com/rendley/vegshare/backend/internal/outbox/repository"
+	outboxMocks "github.com/rendley/vegshare/backend/internal/outbox/repository/mocks"
+	taskModels "github.com/rendley/vegshare/backend/internal/task/models"
+	"github.com/rendley/vegshare/backend/pkg/database"
+	"github.com/rendley/vegshare/backend/pkg/database/dbtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// --- Mocks ---
+
+type MockDeadLetterRepository struct {
+	mock.Mock
+}
+
+var _ repository.Repository = &MockDeadLetterRepository{}
+
+func (m *MockDeadLetterRepository) Create(ctx context.Context, dl *models.DeadLetter) error {
+	args := m.Called(ctx, dl)
+	return args.Error(0)
+}
+
+func (m *MockDeadLetterRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.DeadLetter, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.DeadLetter), args.Error(1)
+}
+
+func (m *MockDeadLetterRepository) List(ctx context.Context, filter models.Filter) ([]models.DeadLetter, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.DeadLetter), args.Error(1)
+}
+
+func (m *MockDeadLetterRepository) MarkRedriven(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+type MockOperationsRepository struct {
+	mock.Mock
+}
+
+var _ operationsRepository.Repository = &MockOperationsRepository{}
+
+func (m *MockOperationsRepository) GetOperationLogByID(ctx context.Context, logID uuid.UUID) (*operationsModels.OperationLog, error) {
+	args := m.Called(ctx, logID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*operationsModels.OperationLog), args.Error(1)
+}
+
+// Dummy implementations for other repository methods to satisfy the interface
+func (m *MockOperationsRepository) CreateOperationLog(ctx context.Context, log *operationsModels.OperationLog) error {
+	return nil
+}
+func (m *MockOperationsRepository) ListOperationLogs(ctx context.Context, filter operationsModels.ActionFilter) ([]operationsModels.ActionHistoryItem, error) {
+	return nil, nil
+}
+func (m *MockOperationsRepository) DeleteOperationLog(ctx context.Context, logID uuid.UUID) error {
+	return nil
+}
+func (m *MockOperationsRepository) UpdateOperationLogStatus(ctx context.Context, logID uuid.UUID, status string) error {
+	return nil
+}
+func (m *MockOperationsRepository) CancelOperationLog(ctx context.Context, logID uuid.UUID) error {
+	return nil
+}
+func (m *MockOperationsRepository) TransitionOperationLogStatus(ctx context.Context, logID uuid.UUID, from, to string) (bool, error) {
+	return false, nil
+}
+func (m *MockOperationsRepository) CountOperations(ctx context.Context, userID, unitID uuid.UUID, actionType string, from, to time.Time) (int, error) {
+	return 0, nil
+}
+func (m *MockOperationsRepository) LockQuota(ctx context.Context, userID, unitID uuid.UUID) error {
+	return nil
+}
+func (m *MockOperationsRepository) ListAttachments(ctx context.Context, taskIDs []uuid.UUID) ([]taskModels.TaskAttachment, error) {
+	return nil, nil
+}
+func (m *MockOperationsRepository) GetAttachment(ctx context.Context, operationID, attachmentID uuid.UUID) (*taskModels.TaskAttachment, error) {
+	return nil, nil
+}
+
+func TestDeadLetterService(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(MockDeadLetterRepository)
+	mockOpsRepo := new(MockOperationsRepository)
+	mockOutbox := new(outboxMocks.Repository)
+	db, stats := dbtest.New()
+	svc := NewService(db, mockRepo, mockOpsRepo)
+	svc.(*service).newRepo = func(database.DBTX) repository.Repository { return mockRepo }
+	svc.(*service).newOutbox = func(database.DBTX) outboxRepository.Repository { return mockOutbox }
+
+	t.Run("Record - Extracts operation ID from payload", func(t *testing.T) {
+		opID := uuid.New()
+		payload := `{"id":"` + opID.String() + `","action_type":"watering"}`
+		mockOpsRepo.On("GetOperationLogByID", ctx, opID).Return(&operationsModels.OperationLog{ID: opID}, nil).Once()
+		mockRepo.On("Create", ctx, mock.AnythingOfType("*models.DeadLetter")).Return(nil).Once()
+
+		dl, err := svc.Record(ctx, "actions_queue", payload, "boom", 5)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "actions_queue", dl.Queue)
+		assert.Equal(t, 5, dl.Attempts)
+		assert.Equal(t, "boom", dl.LastError)
+		if assert.NotNil(t, dl.OperationID) {
+			assert.Equal(t, opID, *dl.OperationID)
+		}
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Record - Missing operation is stored without the link", func(t *testing.T) {
+		opID := uuid.New()
+		payload := `{"id":"` + opID.String() + `","action_type":"watering"}`
+		mockOpsRepo.On("GetOperationLogByID", ctx, opID).Return(nil, fmt.Errorf("не удалось получить операцию по ID: %w", sql.ErrNoRows)).Once()
+		mockRepo.On("Create", ctx, mock.MatchedBy(func(dl *models.DeadLetter) bool {
+			return dl.OperationID == nil && dl.Payload == payload
+		})).Return(nil).Once()
+
+		dl, err := svc.Record(ctx, "actions_queue", payload, "invalid message", 1)
+
+		assert.NoError(t, err)
+		assert.Nil(t, dl.OperationID)
+		mockRepo.AssertExpectations(t)
+		mockOpsRepo.AssertExpectations(t)
+	})
+
+	t.Run("Record - Malformed payload is stored without operation", func(t *testing.T) {
+		mockRepo.On("Create", ctx, mock.AnythingOfType("*models.DeadLetter")).Return(nil).Once()
+
+		dl, err := svc.Record(ctx, "actions_queue", "not json", "invalid message", 1)
+
+		assert.NoError(t, err)
+		assert.Nil(t, dl.OperationID)
+		assert.Equal(t, "not json", dl.Payload)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Record - Invalid UTF-8 is unrecordable", func(t *testing.T) {
+		dl, err := svc.Record(ctx, "actions_queue", "\xff\xfe", "invalid message", 1)
+
+		assert.ErrorIs(t, err, ErrUnrecordable)
+		assert.Nil(t, dl)
+		mockRepo.AssertNotCalled(t, "Create", ctx, mock.MatchedBy(func(dl *models.DeadLetter) bool { return dl.Payload == "\xff\xfe" }))
+	})
+
+	t.Run("Record - Constraint violation is unrecordable", func(t *testing.T) {
+		mockRepo.On("Create", ctx, mock.MatchedBy(func(dl *models.DeadLetter) bool { return dl.Payload == "constraint" })).
+			Return(fmt.Errorf("не удалось сохранить сообщение: %w", &pq.Error{Code: "23514"})).Once()
+
+		dl, err := svc.Record(ctx, "actions_queue", "constraint", "invalid message", 1)
+
+		assert.ErrorIs(t, err, ErrUnrecordable)
+		assert.Nil(t, dl)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Record - Database outage is not permanent", func(t *testing.T) {
+		mockRepo.On("Create", ctx, mock.MatchedBy(func(dl *models.DeadLetter) bool { return dl.Payload == "outage" })).
+			Return(fmt.Errorf("не удалось сохранить сообщение: %w", &pq.Error{Code: "57P03"})).Once()
+
+		dl, err := svc.Record(ctx, "actions_queue", "outage", "invalid message", 1)
+
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, ErrUnrecordable)
+		assert.Nil(t, dl)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Get - Includes current operation state", func(t *testing.T) {
+		id, opID := uuid.New(), uuid.New()
+		mockRepo.On("GetByID", ctx, id).Return(&models.DeadLetter{ID: id, OperationID: &opID}, nil).Once()
+		mockOpsRepo.On("GetOperationLogByID", ctx, opID).Return(&operationsModels.OperationLog{ID: opID, Status: operationsModels.StatusProcessing}, nil).Once()
+
+		details, err := svc.Get(ctx, id)
+
+		assert.NoError(t, err)
+		if assert.NotNil(t, details.Operation) {
+			assert.Equal(t, operationsModels.StatusProcessing, details.Operation.Status)
+		}
+		mockRepo.AssertExpectations(t)
+		mockOpsRepo.AssertExpectations(t)
+	})
+
+	t.Run("Redrive - Already redriven", func(t *testing.T) {
+		id := uuid.New()
+		redrivenAt := time.Now()
+		mockRepo.On("GetByID", ctx, id).Return(&models.DeadLetter{ID: id, RedrivenAt: &redrivenAt}, nil).Once()
+
+		dl, err := svc.Redrive(ctx, id)
+
+		assert.ErrorIs(t, err, ErrAlreadyRedriven)
+		assert.Nil(t, dl)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Redrive - Returns the message to its queue through outbox", func(t *testing.T) {
+		id := uuid.New()
+		committed := stats.Committed()
+		mockRepo.On("GetByID", ctx, id).Return(&models.DeadLetter{ID: id, Queue: "actions_queue", Payload: "payload"}, nil).Once()
+		mockRepo.On("MarkRedriven", ctx, id).Return(nil).Once()
+		mockOutbox.On("Create", ctx, mock.MatchedBy(func(msg *outboxModels.Message) bool {
+			return msg.Queue == "actions_queue" && msg.Payload == "payload"
+		})).Return(nil).Once()
+
+		dl, err := svc.Redrive(ctx, id)
+
+		assert.NoError(t, err)
+		assert.NotNil(t, dl.RedrivenAt)
+		assert.Equal(t, committed+1, stats.Committed())
+		mockRepo.AssertExpectations(t)
+		mockOutbox.AssertExpectations(t)
+	})
+
+	t.Run("Redrive - Concurrent redrive wins the conditional update", func(t *testing.T) {
+		id := uuid.New()
+		committed := stats.Committed()
+		mockRepo.On("GetByID", ctx, id).Return(&models.DeadLetter{ID: id, Queue: "actions_queue", Payload: "raced payload"}, nil).Once()
+		mockRepo.On("MarkRedriven", ctx, id).Return(repository.ErrAlreadyRedriven).Once()
+
+		dl, err := svc.Redrive(ctx, id)
+
+		assert.ErrorIs(t, err, ErrAlreadyRedriven)
+		assert.Nil(t, dl)
+		assert.Equal(t, committed, stats.Committed())
+		mockOutbox.AssertNotCalled(t, "Create", ctx, mock.MatchedBy(func(msg *outboxModels.Message) bool {
+			return msg.Payload == "raced payload"
+		}))
+		mockRepo.AssertExpectations(t)
+	})
+}
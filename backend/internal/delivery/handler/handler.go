@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/rendley/vegshare/backend/internal/delivery/models"
+	"github.com/rendley/vegshare/backend/internal/delivery/repository"
+	"github.com/rendley/vegshare/backend/internal/delivery/service"
+	"github.com/rendley/vegshare/backend/pkg/api"
+	"github.com/rendley/vegshare/backend/pkg/middleware"
+	"github.com/sirupsen/logrus"
+)
+
+// DeliveryHandler обрабатывает HTTP-запросы, связанные с выдачей и доставкой урожая.
+type DeliveryHandler struct {
+	service  service.Service
+	logger   *logrus.Logger
+	validate *validator.Validate
+}
+
+// NewDeliveryHandler - конструктор для DeliveryHandler.
+func NewDeliveryHandler(s service.Service, l *logrus.Logger) *DeliveryHandler {
+	return &DeliveryHandler{
+		service:  s,
+		logger:   l,
+		validate: validator.New(),
+	}
+}
+
+// GetAvailableSlots возвращает свободные слоты (опционально ?land_parcel_id=...&method=pickup|delivery).
+func (h *DeliveryHandler) GetAvailableSlots(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := models.SlotFilter{Method: q.Get("method")}
+	if v := q.Get("land_parcel_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			api.RespondWithError(w, "invalid land_parcel_id query parameter", http.StatusBadRequest)
+			return
+		}
+		filter.LandParcelID = &id
+	}
+
+	slots, err := h.service.GetAvailableSlots(r.Context(), filter)
+	if err != nil {
+		h.logger.Errorf("ошибка при получении свободных слотов: %v", err)
+		api.RespondWithError(w, "could not retrieve slots", http.StatusInternalServerError)
+		return
+	}
+
+	api.RespondWithJSON(h.logger, w, slots, http.StatusOK)
+}
+
+// BookSlot бронирует слот для выдачи записи урожая текущего пользователя.
+func (h *DeliveryHandler) BookSlot(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		api.RespondWithError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req service.BookingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.RespondWithError(w, "некорректное тело запроса", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		api.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	booking, err := h.service.BookSlot(r.Context(), userID, req)
+	if err != nil {
+		if errors.Is(err, repository.ErrSlotUnavailable) {
+			api.RespondWithError(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if errors.Is(err, service.ErrInvalidBooking) {
+			api.RespondWithError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		h.logger.Errorf("ошибка при бронировании слота: %v", err)
+		api.RespondWithError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	api.RespondWithJSON(h.logger, w, booking, http.StatusCreated)
+}
+
+// GetMyBookings возвращает брони текущего пользователя.
+func (h *DeliveryHandler) GetMyBookings(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		api.RespondWithError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	bookings, err := h.service.GetMyBookings(r.Context(), userID)
+	if err != nil {
+		h.logger.Errorf("ошибка при получении броней: %v", err)
+		api.RespondWithError(w, "could not retrieve bookings", http.StatusInternalServerError)
+		return
+	}
+
+	api.RespondWithJSON(h.logger, w, bookings, http.StatusOK)
+}
+
+// CancelBooking отменяет бронь текущего пользователя.
+func (h *DeliveryHandler) CancelBooking(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		api.RespondWithError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	bookingID, err := uuid.Parse(chi.URLParam(r, "bookingID"))
+	if err != nil {
+		api.RespondWithError(w, "invalid booking ID in URL", http.StatusBadRequest)
+		return
+	}
+
+	booking, err := h.service.CancelBooking(r.Context(), userID, bookingID)
+	if err != nil {
+		if errors.Is(err, repository.ErrBookingNotActive) {
+			api.RespondWithError(w, err.Error(), http.StatusConflict)
+			return
+		}
+		h.logger.Errorf("ошибка при отмене брони: %v", err)
+		api.RespondWithError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	api.RespondWithJSON(h.logger, w, booking, http.StatusOK)
+}
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rendley/vegshare/backend/pkg/api"
+)
+
+// createSlotRequest - тело запроса на создание слота выдачи урожая.
+type createSlotRequest struct {
+	LandParcelID uuid.UUID `json:"land_parcel_id" validate:"required"`
+	Method       string    `json:"method" validate:"required,oneof=pickup delivery"`
+	StartsAt     time.Time `json:"starts_at" validate:"required"`
+	EndsAt       time.Time `json:"ends_at" validate:"required"`
+	Capacity     int       `json:"capacity" validate:"required,gt=0"`
+}
+
+// AdminCreateSlot создает слот самовывоза или курьерской доставки для земельного участка.
+func (h *DeliveryHandler) AdminCreateSlot(w http.ResponseWriter, r *http.Request) {
+	var req createSlotRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.RespondWithError(w, "некорректное тело запроса", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		api.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	slot, err := h.service.CreateSlot(r.Context(), req.LandParcelID, req.Method, req.StartsAt, req.EndsAt, req.Capacity)
+	if err != nil {
+		h.logger.Errorf("ошибка при создании слота доставки: %v", err)
+		api.RespondWithError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	api.RespondWithJSON(h.logger, w, slot, http.StatusCreated)
+}
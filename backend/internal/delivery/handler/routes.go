@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Routes возвращает роутер для выдачи и доставки урожая.
+func (h *DeliveryHandler) Routes() http.Handler {
+	r := chi.NewRouter()
+
+	// GET /delivery/slots?land_parcel_id=&method= - свободные слоты
+	r.Get("/slots", h.GetAvailableSlots)
+	// POST /delivery/bookings - забронировать слот для записи урожая
+	r.Post("/bookings", h.BookSlot)
+	// GET /delivery/bookings - брони текущего пользователя
+	r.Get("/bookings", h.GetMyBookings)
+	// POST /delivery/bookings/{bookingID}/cancel - отменить бронь
+	r.Post("/bookings/{bookingID}/cancel", h.CancelBooking)
+
+	return r
+}
+
+// AdminRoutes возвращает роутер для управления слотами.
+// Монтируется внутри группы /admin, защищенной AdminMiddleware.
+func (h *DeliveryHandler) AdminRoutes() http.Handler {
+	r := chi.NewRouter()
+
+	// POST /admin/delivery/slots - создать слот
+	r.Post("/slots", h.AdminCreateSlot)
+
+	return r
+}
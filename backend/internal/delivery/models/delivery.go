@@ -0,0 +1,73 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Способы получения урожая. Значения совпадают с типами системных действий в operations/actions.
+const (
+	MethodPickup   = "pickup"
+	MethodDelivery = "delivery"
+)
+
+// Статусы бронирования слота.
+const (
+	BookingStatusBooked    = "booked"
+	BookingStatusCancelled = "cancelled"
+)
+
+// Slot - временное окно выдачи урожая на земельном участке (пункт самовывоза)
+// или отправки курьера с него, с ограниченным числом мест.
+type Slot struct {
+	ID           uuid.UUID `db:"id" json:"id"`
+	LandParcelID uuid.UUID `db:"land_parcel_id" json:"land_parcel_id"`
+	Method       string    `db:"method" json:"method"`
+	StartsAt     time.Time `db:"starts_at" json:"starts_at"`
+	EndsAt       time.Time `db:"ends_at" json:"ends_at"`
+	Capacity     int       `db:"capacity" json:"capacity"`
+	Booked       int       `db:"booked" json:"booked"`
+	CreatedAt    time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt    time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// SlotFilter - параметры выборки свободных слотов.
+type SlotFilter struct {
+	LandParcelID *uuid.UUID
+	Method       string
+}
+
+// Booking - бронь слота пользователем для выдачи одной записи урожая.
+type Booking struct {
+	ID        uuid.UUID `db:"id" json:"id"`
+	SlotID    uuid.UUID `db:"slot_id" json:"slot_id"`
+	UserID    uuid.UUID `db:"user_id" json:"user_id"`
+	HarvestID uuid.UUID `db:"harvest_id" json:"harvest_id"`
+	// Address - адрес доставки; заполняется только для курьерской доставки.
+	Address *string `db:"address" json:"address,omitempty"`
+	Status  string  `db:"status" json:"status"`
+	// OperationID - операция, через которую персоналу создана задача на выдачу.
+	OperationID *uuid.UUID `db:"operation_id" json:"operation_id,omitempty"`
+	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// EnrichedBooking - бронь вместе с данными слота.
+type EnrichedBooking struct {
+	Booking
+	LandParcelID uuid.UUID `db:"land_parcel_id" json:"land_parcel_id"`
+	Method       string    `db:"method" json:"method"`
+	StartsAt     time.Time `db:"starts_at" json:"starts_at"`
+	EndsAt       time.Time `db:"ends_at" json:"ends_at"`
+}
+
+// ActionParams - параметры операции pickup/delivery, по которой воркер создает задачу персоналу.
+type ActionParams struct {
+	BookingID    uuid.UUID `json:"booking_id"`
+	HarvestID    uuid.UUID `json:"harvest_id"`
+	SlotID       uuid.UUID `json:"slot_id"`
+	LandParcelID uuid.UUID `json:"land_parcel_id"`
+	StartsAt     time.Time `json:"starts_at"`
+	Address      *string   `json:"address,omitempty"`
+}
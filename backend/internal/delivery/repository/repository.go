@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/rendley/vegshare/backend/internal/delivery/models"
+	"github.com/rendley/vegshare/backend/pkg/database"
+)
+
+// ErrSlotUnavailable возвращается, если в слоте не осталось мест или он уже начался.
+var ErrSlotUnavailable = errors.New("слот недоступен для бронирования")
+
+// ErrBookingNotActive возвращается при попытке изменить бронь, которая уже не активна.
+var ErrBookingNotActive = errors.New("бронь уже не активна")
+
+// Repository определяет контракт для хранилища слотов и бронирований выдачи урожая.
+type Repository interface {
+	CreateSlot(ctx context.Context, slot *models.Slot) error
+	GetSlotByID(ctx context.Context, id uuid.UUID) (*models.Slot, error)
+	GetAvailableSlots(ctx context.Context, filter models.SlotFilter) ([]models.Slot, error)
+	// ReserveSlot атомарно занимает одно место в слоте; ErrSlotUnavailable, если мест нет.
+	ReserveSlot(ctx context.Context, id uuid.UUID) error
+	ReleaseSlot(ctx context.Context, id uuid.UUID) error
+	// GetUnitLandParcelID возвращает земельный участок, на котором стоит грядка или загон.
+	GetUnitLandParcelID(ctx context.Context, unitID uuid.UUID, unitType string) (uuid.UUID, error)
+
+	CreateBooking(ctx context.Context, booking *models.Booking) error
+	GetBookingByID(ctx context.Context, id uuid.UUID) (*models.Booking, error)
+	GetBookingsByUserID(ctx context.Context, userID uuid.UUID) ([]models.EnrichedBooking, error)
+	// UpdateBooking обновляет активную бронь; ErrBookingNotActive, если ее уже отменили
+	// или выполнили в параллельном запросе.
+	UpdateBooking(ctx context.Context, booking *models.Booking) error
+}
+
+type repository struct {
+	db database.DBTX
+}
+
+// NewRepository - конструктор для репозитория доставки.
+func NewRepository(db database.DBTX) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) CreateSlot(ctx context.Context, slot *models.Slot) error {
+	query := `INSERT INTO delivery_slots (id, land_parcel_id, method, starts_at, ends_at, capacity, booked, created_at, updated_at)
+	          VALUES (:id, :land_parcel_id, :method, :starts_at, :ends_at, :capacity, :booked, :created_at, :updated_at)`
+	if _, err := r.db.NamedExecContext(ctx, query, slot); err != nil {
+		return fmt.Errorf("не удалось создать слот доставки: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetSlotByID(ctx context.Context, id uuid.UUID) (*models.Slot, error) {
+	var slot models.Slot
+	query := `SELECT * FROM delivery_slots WHERE id = $1`
+	if err := r.db.GetContext(ctx, &slot, query, id); err != nil {
+		return nil, fmt.Errorf("не удалось получить слот доставки по ID: %w", err)
+	}
+	return &slot, nil
+}
+
+// GetAvailableSlots возвращает будущие слоты, в которых еще есть свободные места.
+func (r *repository) GetAvailableSlots(ctx context.Context, filter models.SlotFilter) ([]models.Slot, error) {
+	query := `
+        SELECT * FROM delivery_slots
+        WHERE starts_at > NOW() AND booked < capacity
+          AND ($1::uuid IS NULL OR land_parcel_id = $1)
+          AND ($2 = '' OR method = $2)
+        ORDER BY starts_at`
+
+	slots := []models.Slot{}
+	if err := r.db.SelectContext(ctx, &slots, query, filter.LandParcelID, filter.Method); err != nil {
+		return nil, fmt.Errorf("не удалось получить свободные слоты: %w", err)
+	}
+	return slots, nil
+}
+
+func (r *repository) ReserveSlot(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE delivery_slots SET booked = booked + 1, updated_at = NOW()
+	          WHERE id = $1 AND booked < capacity AND starts_at > NOW()`
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("не удалось забронировать место в слоте: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("не удалось проверить результат бронирования: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrSlotUnavailable
+	}
+	return nil
+}
+
+func (r *repository) ReleaseSlot(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE delivery_slots SET booked = booked - 1, updated_at = NOW() WHERE id = $1 AND booked > 0`
+	if _, err := r.db.ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("не удалось освободить место в слоте: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetUnitLandParcelID(ctx context.Context, unitID uuid.UUID, unitType string) (uuid.UUID, error) {
+	var landParcelID uuid.UUID
+	query := `
+        SELECT
+            s.land_parcel_id
+        FROM
+            structures s
+        WHERE
+            s.id = (
+                SELECT structure_id FROM plots WHERE $2 = 'plot' AND id = $1
+                UNION ALL
+                SELECT structure_id FROM coops WHERE $2 = 'coop' AND id = $1
+            )`
+	if err := r.db.GetContext(ctx, &landParcelID, query, unitID, unitType); err != nil {
+		return uuid.Nil, fmt.Errorf("не удалось получить земельный участок юнита: %w", err)
+	}
+	return landParcelID, nil
+}
+
+func (r *repository) CreateBooking(ctx context.Context, booking *models.Booking) error {
+	query := `INSERT INTO delivery_bookings (id, slot_id, user_id, harvest_id, address, status, operation_id, created_at, updated_at)
+	          VALUES (:id, :slot_id, :user_id, :harvest_id, :address, :status, :operation_id, :created_at, :updated_at)`
+	if _, err := r.db.NamedExecContext(ctx, query, booking); err != nil {
+		return fmt.Errorf("не удалось создать бронь: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetBookingByID(ctx context.Context, id uuid.UUID) (*models.Booking, error) {
+	var booking models.Booking
+	query := `SELECT * FROM delivery_bookings WHERE id = $1`
+	if err := r.db.GetContext(ctx, &booking, query, id); err != nil {
+		return nil, fmt.Errorf("не удалось получить бронь по ID: %w", err)
+	}
+	return &booking, nil
+}
+
+func (r *repository) GetBookingsByUserID(ctx context.Context, userID uuid.UUID) ([]models.EnrichedBooking, error) {
+	query := `
+        SELECT b.*, s.land_parcel_id, s.method, s.starts_at, s.ends_at
+        FROM delivery_bookings b
+        JOIN delivery_slots s ON s.id = b.slot_id
+        WHERE b.user_id = $1
+        ORDER BY s.starts_at DESC`
+
+	bookings := []models.EnrichedBooking{}
+	if err := r.db.SelectContext(ctx, &bookings, query, userID); err != nil {
+		return nil, fmt.Errorf("не удалось получить брони пользователя: %w", err)
+	}
+	return bookings, nil
+}
+
+func (r *repository) UpdateBooking(ctx context.Context, booking *models.Booking) error {
+	query := `UPDATE delivery_bookings SET status = :status, operation_id = :operation_id, updated_at = :updated_at
+	          WHERE id = :id AND status = 'booked'`
+	result, err := r.db.NamedExecContext(ctx, query, booking)
+	if err != nil {
+		return fmt.Errorf("не удалось обновить бронь: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("не удалось проверить результат обновления брони: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrBookingNotActive
+	}
+	return nil
+}
@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/rendley/vegshare/backend/internal/delivery/models"
+	"github.com/rendley/vegshare/backend/internal/delivery/repository"
+	farmService "github.com/rendley/vegshare/backend/internal/farm/service"
+	harvestService "github.com/rendley/vegshare/backend/internal/harvest/service"
+	operationsService "github.com/rendley/vegshare/backend/internal/operations/service"
+	"github.com/rendley/vegshare/backend/pkg/database"
+)
+
+// ErrInvalidBooking возвращается, если запись урожая нельзя забронировать на выбранный слот.
+var ErrInvalidBooking = errors.New("некорректная бронь")
+
+// BookingRequest описывает выбор пользователем слота для выдачи записи урожая.
+type BookingRequest struct {
+	SlotID    uuid.UUID `json:"slot_id" validate:"required"`
+	HarvestID uuid.UUID `json:"harvest_id" validate:"required"`
+	// Address обязателен для курьерской доставки и игнорируется при самовывозе.
+	Address string `json:"address"`
+}
+
+// Service определяет контракт для сервиса выдачи и доставки урожая.
+type Service interface {
+	CreateSlot(ctx context.Context, landParcelID uuid.UUID, method string, startsAt, endsAt time.Time, capacity int) (*models.Slot, error)
+	GetAvailableSlots(ctx context.Context, filter models.SlotFilter) ([]models.Slot, error)
+	BookSlot(ctx context.Context, userID uuid.UUID, req BookingRequest) (*models.Booking, error)
+	GetMyBookings(ctx context.Context, userID uuid.UUID) ([]models.EnrichedBooking, error)
+	CancelBooking(ctx context.Context, userID, bookingID uuid.UUID) (*models.Booking, error)
+}
+
+type service struct {
+	db                *sqlx.DB
+	repo              repository.Repository
+	farmService       farmService.Service
+	harvestService    harvestService.Service
+	operationsService operationsService.Service
+	// newRepo создает репозиторий поверх транзакции; в тестах подменяется моком.
+	newRepo func(db database.DBTX) repository.Repository
+}
+
+// NewService - конструктор для сервиса доставки.
+func NewService(db *sqlx.DB, repo repository.Repository, fs farmService.Service, hs harvestService.Service, ops operationsService.Service) Service {
+	return &service{
+		db:                db,
+		repo:              repo,
+		farmService:       fs,
+		harvestService:    hs,
+		operationsService: ops,
+		newRepo:           repository.NewRepository,
+	}
+}
+
+func (s *service) CreateSlot(ctx context.Context, landParcelID uuid.UUID, method string, startsAt, endsAt time.Time, capacity int) (*models.Slot, error) {
+	if method != models.MethodPickup && method != models.MethodDelivery {
+		return nil, fmt.Errorf("неизвестный способ получения урожая: '%s'", method)
+	}
+	if !endsAt.After(startsAt) {
+		return nil, fmt.Errorf("время окончания слота должно быть позже времени начала")
+	}
+	if capacity <= 0 {
+		return nil, fmt.Errorf("вместимость слота должна быть положительной")
+	}
+	if _, err := s.farmService.GetLandParcelByID(ctx, landParcelID); err != nil {
+		return nil, fmt.Errorf("земельный участок с ID %s не найден: %w", landParcelID, err)
+	}
+
+	now := time.Now()
+	slot := &models.Slot{
+		ID:           uuid.New(),
+		LandParcelID: landParcelID,
+		Method:       method,
+		StartsAt:     startsAt,
+		EndsAt:       endsAt,
+		Capacity:     capacity,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+
+	if err := s.repo.CreateSlot(ctx, slot); err != nil {
+		return nil, err
+	}
+	return slot, nil
+}
+
+func (s *service) GetAvailableSlots(ctx context.Context, filter models.SlotFilter) ([]models.Slot, error) {
+	return s.repo.GetAvailableSlots(ctx, filter)
+}
+
+// BookSlot в одной транзакции занимает место в слоте, ставит персоналу задачу на выдачу урожая
+// через журнал операций и создает бронь: ни место, ни операция не остаются без брони.
+func (s *service) BookSlot(ctx context.Context, userID uuid.UUID, req BookingRequest) (*models.Booking, error) {
+	slot, err := s.repo.GetSlotByID(ctx, req.SlotID)
+	if err != nil {
+		return nil, err
+	}
+
+	harvest, err := s.harvestService.GetRecordByID(ctx, req.HarvestID)
+	if err != nil {
+		return nil, err
+	}
+	if harvest.UserID != userID {
+		return nil, fmt.Errorf("запись урожая %s не принадлежит пользователю", req.HarvestID)
+	}
+
+	// Урожай выдается на том участке, где собран: слот другого участка не подходит.
+	landParcelID, err := s.repo.GetUnitLandParcelID(ctx, harvest.UnitID, harvest.UnitType)
+	if err != nil {
+		return nil, err
+	}
+	if landParcelID != slot.LandParcelID {
+		return nil, fmt.Errorf("%w: урожай собран не на участке слота", ErrInvalidBooking)
+	}
+
+	var address *string
+	if slot.Method == models.MethodDelivery {
+		addr := strings.TrimSpace(req.Address)
+		if addr == "" {
+			return nil, fmt.Errorf("%w: для курьерской доставки необходимо указать адрес", ErrInvalidBooking)
+		}
+		address = &addr
+	}
+
+	now := time.Now()
+	booking := &models.Booking{
+		ID:        uuid.New(),
+		SlotID:    slot.ID,
+		UserID:    userID,
+		HarvestID: harvest.ID,
+		Address:   address,
+		Status:    models.BookingStatusBooked,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	params, err := json.Marshal(models.ActionParams{
+		BookingID:    booking.ID,
+		HarvestID:    harvest.ID,
+		SlotID:       slot.ID,
+		LandParcelID: slot.LandParcelID,
+		StartsAt:     slot.StartsAt,
+		Address:      address,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("не удалось сериализовать параметры доставки: %w", err)
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось начать транзакцию: %w", err)
+	}
+	defer tx.Rollback()
+
+	repoTx := s.newRepo(tx)
+	if err := repoTx.ReserveSlot(ctx, slot.ID); err != nil {
+		return nil, err
+	}
+
+	operation, err := s.operationsService.CreateInternalAction(ctx, tx, userID, operationsService.ActionRequest{
+		UnitID:     harvest.UnitID,
+		UnitType:   harvest.UnitType,
+		ActionType: slot.Method,
+		Parameters: params,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("не удалось создать операцию доставки: %w", err)
+	}
+
+	booking.OperationID = &operation.ID
+	if err := repoTx.CreateBooking(ctx, booking); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("не удалось закоммитить транзакцию: %w", err)
+	}
+
+	return booking, nil
+}
+
+func (s *service) GetMyBookings(ctx context.Context, userID uuid.UUID) ([]models.EnrichedBooking, error) {
+	return s.repo.GetBookingsByUserID(ctx, userID)
+}
+
+// CancelBooking отменяет бронь пользователя до начала слота и освобождает место.
+func (s *service) CancelBooking(ctx context.Context, userID, bookingID uuid.UUID) (*models.Booking, error) {
+	booking, err := s.repo.GetBookingByID(ctx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if booking.UserID != userID {
+		return nil, fmt.Errorf("бронь %s не принадлежит пользователю", bookingID)
+	}
+	if booking.Status != models.BookingStatusBooked {
+		return nil, fmt.Errorf("%w, текущий статус: '%s'", repository.ErrBookingNotActive, booking.Status)
+	}
+
+	slot, err := s.repo.GetSlotByID(ctx, booking.SlotID)
+	if err != nil {
+		return nil, err
+	}
+	if !slot.StartsAt.After(time.Now()) {
+		return nil, fmt.Errorf("слот уже начался, бронь нельзя отменить")
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось начать транзакцию: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Условное обновление брони блокирует ее строку, поэтому параллельная отмена
+	// не освободит место в слоте второй раз.
+	booking.Status = models.BookingStatusCancelled
+	booking.UpdatedAt = time.Now()
+
+	repoTx := s.newRepo(tx)
+	if err := repoTx.UpdateBooking(ctx, booking); err != nil {
+		return nil, err
+	}
+
+	// Операция с задачей персоналу отменяется в той же транзакции, что и бронь:
+	// если задачу уже взяли в работу, бронь остается, а место в слоте не освобождается.
+	if booking.OperationID != nil {
+		if _, err := s.operationsService.CancelInternalAction(ctx, tx, userID, *booking.OperationID); err != nil {
+			return nil, fmt.Errorf("не удалось отменить задачу на выдачу урожая: %w", err)
+		}
+	}
+
+	if err := repoTx.ReleaseSlot(ctx, booking.SlotID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("не удалось закоммитить транзакцию: %w", err)
+	}
+	return booking, nil
+}
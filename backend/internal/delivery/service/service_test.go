@@ -0,0 +1,344 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/rendley/vegshare/backend/internal/delivery/models"
+	"github.com/rendley/vegshare/backend/internal/delivery/repository"
+	farmModels "github.com/rendley/vegshare/backend/internal/farm/models"
+	farmMocks "github.com/rendley/vegshare/backend/internal/farm/service/mocks"
+	harvestModels "github.com/rendley/vegshare/backend/internal/harvest/models"
+	harvestService "github.com/rendley/vegshare/backend/internal/harvest/service"
+	operationsModels "github.com/rendley/vegshare/backend/internal/operations/models"
+	operationsRepository "github.com/rendley/vegshare/backend/internal/operations/repository"
+	operationsService "github.com/rendley/vegshare/backend/internal/operations/service"
+	operationsMocks "github.com/rendley/vegshare/backend/internal/operations/service/mocks"
+	"github.com/rendley/vegshare/backend/pkg/database"
+	"github.com/rendley/vegshare/backend/pkg/database/dbtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// --- Mocks ---
+
+type MockDeliveryRepository struct {
+	mock.Mock
+}
+
+var _ repository.Repository = &MockDeliveryRepository{}
+
+func (m *MockDeliveryRepository) CreateSlot(ctx context.Context, slot *models.Slot) error {
+	args := m.Called(ctx, slot)
+	return args.Error(0)
+}
+
+func (m *MockDeliveryRepository) GetSlotByID(ctx context.Context, id uuid.UUID) (*models.Slot, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Slot), args.Error(1)
+}
+
+func (m *MockDeliveryRepository) GetAvailableSlots(ctx context.Context, filter models.SlotFilter) ([]models.Slot, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Slot), args.Error(1)
+}
+
+func (m *MockDeliveryRepository) ReserveSlot(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockDeliveryRepository) ReleaseSlot(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockDeliveryRepository) GetUnitLandParcelID(ctx context.Context, unitID uuid.UUID, unitType string) (uuid.UUID, error) {
+	args := m.Called(ctx, unitID, unitType)
+	return args.Get(0).(uuid.UUID), args.Error(1)
+}
+
+func (m *MockDeliveryRepository) CreateBooking(ctx context.Context, booking *models.Booking) error {
+	return m.Called(ctx, booking).Error(0)
+}
+
+func (m *MockDeliveryRepository) GetBookingByID(ctx context.Context, id uuid.UUID) (*models.Booking, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Booking), args.Error(1)
+}
+
+func (m *MockDeliveryRepository) GetBookingsByUserID(ctx context.Context, userID uuid.UUID) ([]models.EnrichedBooking, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.EnrichedBooking), args.Error(1)
+}
+
+func (m *MockDeliveryRepository) UpdateBooking(ctx context.Context, booking *models.Booking) error {
+	return m.Called(ctx, booking).Error(0)
+}
+
+type MockHarvestService struct {
+	mock.Mock
+}
+
+var _ harvestService.Service = &MockHarvestService{}
+
+func (m *MockHarvestService) GetRecordByID(ctx context.Context, id uuid.UUID) (*harvestModels.HarvestRecord, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*harvestModels.HarvestRecord), args.Error(1)
+}
+
+func (m *MockHarvestService) Harvest(ctx context.Context, req harvestModels.HarvestRequest) (*harvestModels.HarvestRecord, error) {
+	return nil, nil
+}
+func (m *MockHarvestService) GetHarvestHistory(ctx context.Context, userID uuid.UUID, unitID *uuid.UUID) ([]harvestModels.EnrichedHarvestRecord, error) {
+	return nil, nil
+}
+func (m *MockHarvestService) WithTx(tx *sqlx.Tx) harvestService.Service { return m }
+
+// --- Tests ---
+
+func TestDeliveryService(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(MockDeliveryRepository)
+	mockFarmSvc := new(farmMocks.FarmService)
+	mockHarvestSvc := new(MockHarvestService)
+	mockOpsSvc := new(operationsMocks.OperationsService)
+	db, stats := dbtest.New()
+	deliverySvc := NewService(db, mockRepo, mockFarmSvc, mockHarvestSvc, mockOpsSvc)
+	deliverySvc.(*service).newRepo = func(database.DBTX) repository.Repository { return mockRepo }
+
+	startsAt := time.Now().Add(24 * time.Hour)
+	endsAt := startsAt.Add(2 * time.Hour)
+
+	t.Run("CreateSlot - Success", func(t *testing.T) {
+		parcelID := uuid.New()
+		mockFarmSvc.On("GetLandParcelByID", ctx, parcelID).Return(&farmModels.LandParcel{ID: parcelID}, nil).Once()
+		mockRepo.On("CreateSlot", ctx, mock.AnythingOfType("*models.Slot")).Return(nil).Once()
+
+		slot, err := deliverySvc.CreateSlot(ctx, parcelID, models.MethodPickup, startsAt, endsAt, 5)
+
+		assert.NoError(t, err)
+		assert.Equal(t, parcelID, slot.LandParcelID)
+		assert.Equal(t, 5, slot.Capacity)
+		assert.Equal(t, 0, slot.Booked)
+		mockFarmSvc.AssertExpectations(t)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("CreateSlot - Ends before start", func(t *testing.T) {
+		slot, err := deliverySvc.CreateSlot(ctx, uuid.New(), models.MethodPickup, endsAt, startsAt, 5)
+
+		assert.Error(t, err)
+		assert.Nil(t, slot)
+	})
+
+	t.Run("CreateSlot - Unknown method", func(t *testing.T) {
+		slot, err := deliverySvc.CreateSlot(ctx, uuid.New(), "drone", startsAt, endsAt, 5)
+
+		assert.Error(t, err)
+		assert.Nil(t, slot)
+	})
+
+	t.Run("BookSlot - Harvest of another user", func(t *testing.T) {
+		slotID := uuid.New()
+		harvestID := uuid.New()
+		mockRepo.On("GetSlotByID", ctx, slotID).Return(&models.Slot{ID: slotID, Method: models.MethodPickup, StartsAt: startsAt}, nil).Once()
+		mockHarvestSvc.On("GetRecordByID", ctx, harvestID).Return(&harvestModels.HarvestRecord{ID: harvestID, UserID: uuid.New()}, nil).Once()
+
+		booking, err := deliverySvc.BookSlot(ctx, uuid.New(), BookingRequest{SlotID: slotID, HarvestID: harvestID})
+
+		assert.Error(t, err)
+		assert.Nil(t, booking)
+		mockRepo.AssertNotCalled(t, "ReserveSlot", ctx, slotID)
+		mockHarvestSvc.AssertExpectations(t)
+	})
+
+	t.Run("BookSlot - Courier delivery without address", func(t *testing.T) {
+		userID := uuid.New()
+		slotID := uuid.New()
+		harvestID := uuid.New()
+		unitID := uuid.New()
+		parcelID := uuid.New()
+		mockRepo.On("GetSlotByID", ctx, slotID).Return(&models.Slot{ID: slotID, LandParcelID: parcelID, Method: models.MethodDelivery, StartsAt: startsAt}, nil).Once()
+		mockHarvestSvc.On("GetRecordByID", ctx, harvestID).Return(&harvestModels.HarvestRecord{ID: harvestID, UserID: userID, UnitID: unitID, UnitType: "plot"}, nil).Once()
+		mockRepo.On("GetUnitLandParcelID", ctx, unitID, "plot").Return(parcelID, nil).Once()
+
+		booking, err := deliverySvc.BookSlot(ctx, userID, BookingRequest{SlotID: slotID, HarvestID: harvestID, Address: "  "})
+
+		assert.ErrorIs(t, err, ErrInvalidBooking)
+		assert.Nil(t, booking)
+		mockOpsSvc.AssertNotCalled(t, "CreateInternalAction", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("BookSlot - Harvest from another land parcel", func(t *testing.T) {
+		userID := uuid.New()
+		slotID := uuid.New()
+		harvestID := uuid.New()
+		unitID := uuid.New()
+		mockRepo.On("GetSlotByID", ctx, slotID).Return(&models.Slot{ID: slotID, LandParcelID: uuid.New(), Method: models.MethodPickup, StartsAt: startsAt}, nil).Once()
+		mockHarvestSvc.On("GetRecordByID", ctx, harvestID).Return(&harvestModels.HarvestRecord{ID: harvestID, UserID: userID, UnitID: unitID, UnitType: "plot"}, nil).Once()
+		mockRepo.On("GetUnitLandParcelID", ctx, unitID, "plot").Return(uuid.New(), nil).Once()
+
+		booking, err := deliverySvc.BookSlot(ctx, userID, BookingRequest{SlotID: slotID, HarvestID: harvestID})
+
+		assert.ErrorIs(t, err, ErrInvalidBooking)
+		assert.Nil(t, booking)
+		mockRepo.AssertNotCalled(t, "ReserveSlot", ctx, slotID)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("BookSlot - Success", func(t *testing.T) {
+		userID := uuid.New()
+		slotID := uuid.New()
+		harvestID := uuid.New()
+		unitID := uuid.New()
+		parcelID := uuid.New()
+		operationID := uuid.New()
+		committed := stats.Committed()
+		mockRepo.On("GetSlotByID", ctx, slotID).Return(&models.Slot{ID: slotID, LandParcelID: parcelID, Method: models.MethodPickup, StartsAt: startsAt}, nil).Once()
+		mockHarvestSvc.On("GetRecordByID", ctx, harvestID).Return(&harvestModels.HarvestRecord{ID: harvestID, UserID: userID, UnitID: unitID, UnitType: "plot"}, nil).Once()
+		mockRepo.On("GetUnitLandParcelID", ctx, unitID, "plot").Return(parcelID, nil).Once()
+		mockRepo.On("ReserveSlot", ctx, slotID).Return(nil).Once()
+		mockOpsSvc.On("CreateInternalAction", ctx, mock.AnythingOfType("*sqlx.Tx"), userID, mock.MatchedBy(func(req operationsService.ActionRequest) bool {
+			return req.UnitID == unitID && req.ActionType == models.MethodPickup
+		})).Return(&operationsModels.OperationLog{ID: operationID}, nil).Once()
+		mockRepo.On("CreateBooking", ctx, mock.MatchedBy(func(b *models.Booking) bool {
+			return b.OperationID != nil && *b.OperationID == operationID
+		})).Return(nil).Once()
+
+		booking, err := deliverySvc.BookSlot(ctx, userID, BookingRequest{SlotID: slotID, HarvestID: harvestID})
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.BookingStatusBooked, booking.Status)
+		assert.Equal(t, operationID, *booking.OperationID)
+		assert.Equal(t, committed+1, stats.Committed())
+		mockRepo.AssertExpectations(t)
+		mockOpsSvc.AssertExpectations(t)
+	})
+
+	t.Run("BookSlot - Operation failure rolls the reservation back", func(t *testing.T) {
+		userID := uuid.New()
+		slotID := uuid.New()
+		harvestID := uuid.New()
+		unitID := uuid.New()
+		parcelID := uuid.New()
+		committed := stats.Committed()
+		mockRepo.On("GetSlotByID", ctx, slotID).Return(&models.Slot{ID: slotID, LandParcelID: parcelID, Method: models.MethodPickup, StartsAt: startsAt}, nil).Once()
+		mockHarvestSvc.On("GetRecordByID", ctx, harvestID).Return(&harvestModels.HarvestRecord{ID: harvestID, UserID: userID, UnitID: unitID, UnitType: "plot"}, nil).Once()
+		mockRepo.On("GetUnitLandParcelID", ctx, unitID, "plot").Return(parcelID, nil).Once()
+		mockRepo.On("ReserveSlot", ctx, slotID).Return(nil).Once()
+		mockOpsSvc.On("CreateInternalAction", ctx, mock.AnythingOfType("*sqlx.Tx"), userID, mock.Anything).Return(nil, errors.New("outbox unavailable")).Once()
+
+		booking, err := deliverySvc.BookSlot(ctx, userID, BookingRequest{SlotID: slotID, HarvestID: harvestID})
+
+		assert.Error(t, err)
+		assert.Nil(t, booking)
+		assert.Equal(t, committed, stats.Committed())
+		mockRepo.AssertNotCalled(t, "ReleaseSlot", ctx, slotID)
+		mockOpsSvc.AssertExpectations(t)
+	})
+
+	t.Run("CancelBooking - Not owner", func(t *testing.T) {
+		bookingID := uuid.New()
+		mockRepo.On("GetBookingByID", ctx, bookingID).Return(&models.Booking{ID: bookingID, UserID: uuid.New(), Status: models.BookingStatusBooked}, nil).Once()
+
+		booking, err := deliverySvc.CancelBooking(ctx, uuid.New(), bookingID)
+
+		assert.Error(t, err)
+		assert.Nil(t, booking)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("CancelBooking - Slot already started", func(t *testing.T) {
+		userID := uuid.New()
+		bookingID := uuid.New()
+		slotID := uuid.New()
+		mockRepo.On("GetBookingByID", ctx, bookingID).Return(&models.Booking{ID: bookingID, SlotID: slotID, UserID: userID, Status: models.BookingStatusBooked}, nil).Once()
+		mockRepo.On("GetSlotByID", ctx, slotID).Return(&models.Slot{ID: slotID, StartsAt: time.Now().Add(-time.Hour)}, nil).Once()
+
+		booking, err := deliverySvc.CancelBooking(ctx, userID, bookingID)
+
+		assert.Error(t, err)
+		assert.Nil(t, booking)
+		mockRepo.AssertNotCalled(t, "ReleaseSlot", ctx, slotID)
+	})
+
+	t.Run("CancelBooking - Task already in progress", func(t *testing.T) {
+		userID := uuid.New()
+		bookingID := uuid.New()
+		slotID := uuid.New()
+		operationID := uuid.New()
+		mockRepo.On("GetBookingByID", ctx, bookingID).Return(&models.Booking{ID: bookingID, SlotID: slotID, UserID: userID, Status: models.BookingStatusBooked, OperationID: &operationID}, nil).Once()
+		mockRepo.On("GetSlotByID", ctx, slotID).Return(&models.Slot{ID: slotID, StartsAt: startsAt}, nil).Once()
+		committed := stats.Committed()
+		mockRepo.On("UpdateBooking", ctx, mock.MatchedBy(func(b *models.Booking) bool { return b.ID == bookingID })).Return(nil).Once()
+		mockOpsSvc.On("CancelInternalAction", ctx, mock.AnythingOfType("*sqlx.Tx"), userID, operationID).Return(nil, operationsRepository.ErrNotCancellable).Once()
+
+		booking, err := deliverySvc.CancelBooking(ctx, userID, bookingID)
+
+		assert.ErrorIs(t, err, operationsRepository.ErrNotCancellable)
+		assert.Nil(t, booking)
+		assert.Equal(t, committed, stats.Committed())
+		mockOpsSvc.AssertExpectations(t)
+		mockRepo.AssertNotCalled(t, "ReleaseSlot", ctx, slotID)
+	})
+
+	t.Run("CancelBooking - Success cancels the task and releases the slot in one transaction", func(t *testing.T) {
+		userID := uuid.New()
+		bookingID := uuid.New()
+		slotID := uuid.New()
+		operationID := uuid.New()
+		committed := stats.Committed()
+		mockRepo.On("GetBookingByID", ctx, bookingID).Return(&models.Booking{ID: bookingID, SlotID: slotID, UserID: userID, Status: models.BookingStatusBooked, OperationID: &operationID}, nil).Once()
+		mockRepo.On("GetSlotByID", ctx, slotID).Return(&models.Slot{ID: slotID, StartsAt: startsAt}, nil).Once()
+		mockRepo.On("UpdateBooking", ctx, mock.MatchedBy(func(b *models.Booking) bool {
+			return b.ID == bookingID && b.Status == models.BookingStatusCancelled
+		})).Return(nil).Once()
+		mockOpsSvc.On("CancelInternalAction", ctx, mock.AnythingOfType("*sqlx.Tx"), userID, operationID).Return(&operationsModels.OperationLog{ID: operationID, Status: operationsModels.StatusCancelled}, nil).Once()
+		mockRepo.On("ReleaseSlot", ctx, slotID).Return(nil).Once()
+
+		booking, err := deliverySvc.CancelBooking(ctx, userID, bookingID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.BookingStatusCancelled, booking.Status)
+		assert.Equal(t, committed+1, stats.Committed())
+		mockRepo.AssertExpectations(t)
+		mockOpsSvc.AssertExpectations(t)
+	})
+
+	t.Run("CancelBooking - Concurrent cancellation does not release the slot twice", func(t *testing.T) {
+		userID := uuid.New()
+		bookingID := uuid.New()
+		slotID := uuid.New()
+		operationID := uuid.New()
+		committed := stats.Committed()
+		mockRepo.On("GetBookingByID", ctx, bookingID).Return(&models.Booking{ID: bookingID, SlotID: slotID, UserID: userID, Status: models.BookingStatusBooked, OperationID: &operationID}, nil).Once()
+		mockRepo.On("GetSlotByID", ctx, slotID).Return(&models.Slot{ID: slotID, StartsAt: startsAt}, nil).Once()
+		mockRepo.On("UpdateBooking", ctx, mock.MatchedBy(func(b *models.Booking) bool { return b.ID == bookingID })).Return(repository.ErrBookingNotActive).Once()
+
+		booking, err := deliverySvc.CancelBooking(ctx, userID, bookingID)
+
+		assert.ErrorIs(t, err, repository.ErrBookingNotActive)
+		assert.Nil(t, booking)
+		assert.Equal(t, committed, stats.Committed())
+		mockOpsSvc.AssertNotCalled(t, "CancelInternalAction", ctx, mock.Anything, userID, operationID)
+		mockRepo.AssertNotCalled(t, "ReleaseSlot", ctx, slotID)
+	})
+}
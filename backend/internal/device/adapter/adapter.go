@@ -0,0 +1,72 @@
+// Пакет adapter содержит способы передачи команд устройствам. Новый протокол (например, MQTT)
+// добавляется реализацией Adapter и регистрацией в Registry под своим именем.
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rendley/vegshare/backend/internal/device/models"
+)
+
+// Command - команда устройству выполнить действие.
+type Command struct {
+	OperationID uuid.UUID       `json:"operation_id"`
+	ActionType  string          `json:"action_type"`
+	Parameters  json.RawMessage `json:"parameters"`
+}
+
+// Result - итог выполнения команды, сообщенный устройством.
+type Result struct {
+	// Success - действие выполнено; иначе Message объясняет причину отказа устройства.
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+// Adapter передает команду устройству и ждет результата.
+// Ошибка означает, что результат неизвестен (устройство недоступно, таймаут);
+// отказ устройства возвращается как Result с Success == false.
+type Adapter interface {
+	Execute(ctx context.Context, device *models.Device, cmd Command) (Result, error)
+}
+
+// Registry хранит адаптеры по имени (models.Device.Adapter).
+type Registry struct {
+	adapters map[string]Adapter
+}
+
+// NewRegistry создает пустой реестр адаптеров.
+func NewRegistry() *Registry {
+	return &Registry{adapters: make(map[string]Adapter)}
+}
+
+// Register добавляет или заменяет адаптер.
+func (r *Registry) Register(name string, a Adapter) {
+	r.adapters[name] = a
+}
+
+// Get возвращает адаптер по имени.
+func (r *Registry) Get(name string) (Adapter, error) {
+	a, ok := r.adapters[name]
+	if !ok {
+		return nil, fmt.Errorf("неизвестный адаптер устройства '%s'", name)
+	}
+	return a, nil
+}
+
+// Has сообщает, зарегистрирован ли адаптер.
+func (r *Registry) Has(name string) bool {
+	_, ok := r.adapters[name]
+	return ok
+}
+
+// NewDefaultRegistry регистрирует встроенные адаптеры: http и simulator.
+func NewDefaultRegistry(httpTimeout, simulatorDelay time.Duration) *Registry {
+	r := NewRegistry()
+	r.Register(models.AdapterHTTP, NewHTTPAdapter(httpTimeout))
+	r.Register(models.AdapterSimulator, NewSimulator(simulatorDelay))
+	return r
+}
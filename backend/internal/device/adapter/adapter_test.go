@@ -0,0 +1,105 @@
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rendley/vegshare/backend/internal/device/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPAdapter(t *testing.T) {
+	ctx := context.Background()
+	cmd := Command{OperationID: uuid.New(), ActionType: "water", Parameters: json.RawMessage(`{"volume_liters":2}`)}
+
+	t.Run("Sends command and returns device result", func(t *testing.T) {
+		var received Command
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, http.MethodPost, r.Method)
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+			json.NewEncoder(w).Encode(Result{Success: true, Message: "полито 2 л"})
+		}))
+		defer srv.Close()
+
+		result, err := NewHTTPAdapter(time.Second).Execute(ctx, &models.Device{Endpoint: srv.URL}, cmd)
+
+		require.NoError(t, err)
+		assert.Equal(t, Result{Success: true, Message: "полито 2 л"}, result)
+		assert.Equal(t, cmd.OperationID, received.OperationID)
+		assert.JSONEq(t, `{"volume_liters":2}`, string(received.Parameters))
+	})
+
+	t.Run("Non-2xx response is a device failure", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "valve stuck", http.StatusServiceUnavailable)
+		}))
+		defer srv.Close()
+
+		result, err := NewHTTPAdapter(time.Second).Execute(ctx, &models.Device{Endpoint: srv.URL}, cmd)
+
+		require.NoError(t, err)
+		assert.False(t, result.Success)
+		assert.Contains(t, result.Message, "valve stuck")
+	})
+
+	t.Run("Timeout is an error", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(200 * time.Millisecond)
+		}))
+		defer srv.Close()
+
+		_, err := NewHTTPAdapter(50*time.Millisecond).Execute(ctx, &models.Device{Endpoint: srv.URL}, cmd)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestSimulator(t *testing.T) {
+	ctx := context.Background()
+	cmd := Command{OperationID: uuid.New(), ActionType: "water"}
+
+	t.Run("Succeeds and records commands", func(t *testing.T) {
+		sim := NewSimulator(0)
+
+		result, err := sim.Execute(ctx, &models.Device{}, cmd)
+
+		require.NoError(t, err)
+		assert.True(t, result.Success)
+		assert.Equal(t, []Command{cmd}, sim.Commands())
+	})
+
+	t.Run("Returns configured failure", func(t *testing.T) {
+		sim := NewSimulator(0)
+		sim.Err = errors.New("offline")
+
+		_, err := sim.Execute(ctx, &models.Device{}, cmd)
+
+		assert.EqualError(t, err, "offline")
+	})
+
+	t.Run("Stops on context cancellation", func(t *testing.T) {
+		sim := NewSimulator(time.Hour)
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+
+		_, err := sim.Execute(ctx, &models.Device{}, cmd)
+
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestRegistry(t *testing.T) {
+	r := NewDefaultRegistry(time.Second, 0)
+
+	assert.True(t, r.Has(models.AdapterHTTP))
+	assert.True(t, r.Has(models.AdapterSimulator))
+	_, err := r.Get("mqtt")
+	assert.Error(t, err)
+}
@@ -0,0 +1,60 @@
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/rendley/vegshare/backend/internal/device/models"
+)
+
+// HTTPAdapter отправляет команду POST-запросом на Endpoint локального контроллера.
+//
+// Тело запроса - Command в JSON. Контроллер отвечает после выполнения действия:
+// 2xx с телом Result; любой другой код считается отказом устройства.
+type HTTPAdapter struct {
+	client *http.Client
+}
+
+// NewHTTPAdapter создает HTTP-адаптер с таймаутом на одну команду.
+func NewHTTPAdapter(timeout time.Duration) *HTTPAdapter {
+	return &HTTPAdapter{client: &http.Client{Timeout: timeout}}
+}
+
+func (a *HTTPAdapter) Execute(ctx context.Context, device *models.Device, cmd Command) (Result, error) {
+	body, err := json.Marshal(cmd)
+	if err != nil {
+		return Result{}, fmt.Errorf("не удалось сериализовать команду: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, device.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return Result{}, fmt.Errorf("некорректный адрес устройства '%s': %w", device.Endpoint, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return Result{}, fmt.Errorf("устройство %s недоступно: %w", device.ID, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return Result{}, fmt.Errorf("не удалось прочитать ответ устройства %s: %w", device.ID, err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return Result{Success: false, Message: fmt.Sprintf("HTTP %d: %s", resp.StatusCode, bytes.TrimSpace(respBody))}, nil
+	}
+
+	var result Result
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return Result{}, fmt.Errorf("некорректный ответ устройства %s: %w", device.ID, err)
+	}
+	return result, nil
+}
@@ -0,0 +1,51 @@
+package adapter
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rendley/vegshare/backend/internal/device/models"
+)
+
+// Simulator - адаптер без реального оборудования для разработки и тестов.
+// Выполняет любую команду за Delay с результатом Outcome и запоминает полученные команды.
+type Simulator struct {
+	Delay time.Duration
+	// Outcome - результат каждой команды; по умолчанию успех.
+	Outcome Result
+	// Err, если задана, возвращается вместо результата (имитация недоступного устройства).
+	Err error
+
+	mu       sync.Mutex
+	commands []Command
+}
+
+// NewSimulator создает симулятор, успешно выполняющий все команды.
+func NewSimulator(delay time.Duration) *Simulator {
+	return &Simulator{Delay: delay, Outcome: Result{Success: true, Message: "simulated"}}
+}
+
+func (s *Simulator) Execute(ctx context.Context, device *models.Device, cmd Command) (Result, error) {
+	s.mu.Lock()
+	s.commands = append(s.commands, cmd)
+	s.mu.Unlock()
+
+	select {
+	case <-time.After(s.Delay):
+	case <-ctx.Done():
+		return Result{}, ctx.Err()
+	}
+
+	if s.Err != nil {
+		return Result{}, s.Err
+	}
+	return s.Outcome, nil
+}
+
+// Commands возвращает команды, полученные симулятором.
+func (s *Simulator) Commands() []Command {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Command(nil), s.commands...)
+}
@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/rendley/vegshare/backend/internal/device/service"
+	"github.com/rendley/vegshare/backend/pkg/api"
+	"github.com/sirupsen/logrus"
+)
+
+// DeviceHandler обрабатывает HTTP-запросы администратора к IoT-устройствам.
+type DeviceHandler struct {
+	service  service.Service
+	logger   *logrus.Logger
+	validate *validator.Validate
+}
+
+// NewDeviceHandler - конструктор для DeviceHandler.
+func NewDeviceHandler(s service.Service, l *logrus.Logger) *DeviceHandler {
+	return &DeviceHandler{
+		service:  s,
+		logger:   l,
+		validate: validator.New(),
+	}
+}
+
+// CreateDevice регистрирует устройство на юните.
+func (h *DeviceHandler) CreateDevice(w http.ResponseWriter, r *http.Request) {
+	var req service.DeviceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.RespondWithError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := h.validate.Struct(req); err != nil {
+		api.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	device, err := h.service.CreateDevice(r.Context(), req)
+	if err != nil {
+		h.respondWithServiceError(w, "ошибка при создании устройства", err)
+		return
+	}
+
+	api.RespondWithJSON(h.logger, w, device, http.StatusCreated)
+}
+
+// GetDevices возвращает устройства (опционально ?unit_id=...).
+func (h *DeviceHandler) GetDevices(w http.ResponseWriter, r *http.Request) {
+	var unitID *uuid.UUID
+	if v := r.URL.Query().Get("unit_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			api.RespondWithError(w, "invalid unit_id query parameter", http.StatusBadRequest)
+			return
+		}
+		unitID = &id
+	}
+
+	devices, err := h.service.GetDevices(r.Context(), unitID)
+	if err != nil {
+		h.logger.Errorf("ошибка при получении устройств: %v", err)
+		api.RespondWithError(w, "could not retrieve devices", http.StatusInternalServerError)
+		return
+	}
+
+	api.RespondWithJSON(h.logger, w, devices, http.StatusOK)
+}
+
+// UpdateDevice изменяет устройство.
+func (h *DeviceHandler) UpdateDevice(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "deviceID"))
+	if err != nil {
+		api.RespondWithError(w, "invalid device ID in URL", http.StatusBadRequest)
+		return
+	}
+
+	var req service.DeviceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.RespondWithError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	// Юнит устройства не меняется, поэтому unit_id и unit_type в теле не обязательны.
+	if err := h.validate.StructExcept(req, "UnitID", "UnitType"); err != nil {
+		api.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	device, err := h.service.UpdateDevice(r.Context(), id, req)
+	if err != nil {
+		h.respondWithServiceError(w, "ошибка при обновлении устройства", err)
+		return
+	}
+
+	api.RespondWithJSON(h.logger, w, device, http.StatusOK)
+}
+
+// DeleteDevice удаляет устройство.
+func (h *DeviceHandler) DeleteDevice(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "deviceID"))
+	if err != nil {
+		api.RespondWithError(w, "invalid device ID in URL", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.DeleteDevice(r.Context(), id); err != nil {
+		h.respondWithServiceError(w, "ошибка при удалении устройства", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *DeviceHandler) respondWithServiceError(w http.ResponseWriter, msg string, err error) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		api.RespondWithError(w, "device not found", http.StatusNotFound)
+	case errors.Is(err, service.ErrInvalidDevice):
+		api.RespondWithError(w, err.Error(), http.StatusBadRequest)
+	default:
+		h.logger.Errorf("%s: %v", msg, err)
+		api.RespondWithError(w, err.Error(), http.StatusInternalServerError)
+	}
+}
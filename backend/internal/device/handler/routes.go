@@ -0,0 +1,24 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// AdminRoutes возвращает роутер для управления IoT-устройствами.
+// Монтируется внутри группы /admin, защищенной AdminMiddleware.
+func (h *DeviceHandler) AdminRoutes() http.Handler {
+	r := chi.NewRouter()
+
+	// POST /admin/devices - зарегистрировать устройство
+	r.Post("/", h.CreateDevice)
+	// GET /admin/devices?unit_id= - список устройств
+	r.Get("/", h.GetDevices)
+	// PUT /admin/devices/{deviceID} - изменить устройство
+	r.Put("/{deviceID}", h.UpdateDevice)
+	// DELETE /admin/devices/{deviceID} - удалить устройство
+	r.Delete("/{deviceID}", h.DeleteDevice)
+
+	return r
+}
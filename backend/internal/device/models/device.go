@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Адаптеры, через которые платформа управляет устройствами.
+const (
+	AdapterHTTP      = "http"
+	AdapterSimulator = "simulator"
+)
+
+// Статусы команды устройству.
+const (
+	CommandStatusSent      = "sent"
+	CommandStatusCompleted = "completed"
+	CommandStatusFailed    = "failed"
+)
+
+// Device - устройство на юните, выполняющее действия вместо персонала.
+type Device struct {
+	ID       uuid.UUID `db:"id" json:"id"`
+	Name     string    `db:"name" json:"name"`
+	UnitID   uuid.UUID `db:"unit_id" json:"unit_id"`
+	UnitType string    `db:"unit_type" json:"unit_type"`
+	Adapter  string    `db:"adapter" json:"adapter"`
+	// Endpoint - адрес контроллера (для http - URL, на который отправляются команды).
+	Endpoint string `db:"endpoint" json:"endpoint"`
+	// Actions - типы действий, которые устройство выполняет.
+	Actions   pq.StringArray `db:"actions" json:"actions"`
+	Enabled   bool           `db:"enabled" json:"enabled"`
+	CreatedAt time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time      `db:"updated_at" json:"updated_at"`
+}
+
+// Command - команда устройству по операции и ее результат.
+type Command struct {
+	ID          uuid.UUID  `db:"id" json:"id"`
+	OperationID uuid.UUID  `db:"operation_id" json:"operation_id"`
+	DeviceID    uuid.UUID  `db:"device_id" json:"device_id"`
+	Status      string     `db:"status" json:"status"`
+	Message     string     `db:"message" json:"message"`
+	SentAt      time.Time  `db:"sent_at" json:"sent_at"`
+	FinishedAt  *time.Time `db:"finished_at" json:"finished_at,omitempty"`
+}
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rendley/vegshare/backend/internal/device/models"
+	"github.com/rendley/vegshare/backend/pkg/database"
+)
+
+// Repository определяет контракт для хранилища устройств и команд.
+type Repository interface {
+	CreateDevice(ctx context.Context, device *models.Device) error
+	GetDeviceByID(ctx context.Context, id uuid.UUID) (*models.Device, error)
+	GetDevicesByUnit(ctx context.Context, unitID uuid.UUID) ([]models.Device, error)
+	GetAllDevices(ctx context.Context) ([]models.Device, error)
+	// FindDeviceForAction возвращает включенное устройство юнита, выполняющее actionType, или sql.ErrNoRows.
+	FindDeviceForAction(ctx context.Context, unitID uuid.UUID, unitType, actionType string) (*models.Device, error)
+	UpdateDevice(ctx context.Context, device *models.Device) error
+	DeleteDevice(ctx context.Context, id uuid.UUID) error
+
+	// CreateCommand создает команду, если для операции ее еще нет; created == false означает дубликат.
+	CreateCommand(ctx context.Context, cmd *models.Command) (created bool, err error)
+	GetCommandByOperationID(ctx context.Context, operationID uuid.UUID) (*models.Command, error)
+	FinishCommand(ctx context.Context, id uuid.UUID, status, message string) error
+}
+
+type repository struct {
+	db database.DBTX
+}
+
+// NewRepository - конструктор для репозитория устройств.
+func NewRepository(db database.DBTX) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) CreateDevice(ctx context.Context, device *models.Device) error {
+	query := `INSERT INTO devices (id, name, unit_id, unit_type, adapter, endpoint, actions, enabled, created_at, updated_at)
+	          VALUES (:id, :name, :unit_id, :unit_type, :adapter, :endpoint, :actions, :enabled, :created_at, :updated_at)`
+	if _, err := r.db.NamedExecContext(ctx, query, device); err != nil {
+		return fmt.Errorf("не удалось создать устройство: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetDeviceByID(ctx context.Context, id uuid.UUID) (*models.Device, error) {
+	var device models.Device
+	query := `SELECT * FROM devices WHERE id = $1`
+	if err := r.db.GetContext(ctx, &device, query, id); err != nil {
+		return nil, fmt.Errorf("не удалось получить устройство по ID: %w", err)
+	}
+	return &device, nil
+}
+
+func (r *repository) GetDevicesByUnit(ctx context.Context, unitID uuid.UUID) ([]models.Device, error) {
+	devices := []models.Device{}
+	query := `SELECT * FROM devices WHERE unit_id = $1 ORDER BY created_at`
+	if err := r.db.SelectContext(ctx, &devices, query, unitID); err != nil {
+		return nil, fmt.Errorf("не удалось получить устройства юнита: %w", err)
+	}
+	return devices, nil
+}
+
+func (r *repository) GetAllDevices(ctx context.Context) ([]models.Device, error) {
+	devices := []models.Device{}
+	query := `SELECT * FROM devices ORDER BY created_at`
+	if err := r.db.SelectContext(ctx, &devices, query); err != nil {
+		return nil, fmt.Errorf("не удалось получить устройства: %w", err)
+	}
+	return devices, nil
+}
+
+func (r *repository) FindDeviceForAction(ctx context.Context, unitID uuid.UUID, unitType, actionType string) (*models.Device, error) {
+	var device models.Device
+	query := `
+        SELECT * FROM devices
+        WHERE unit_id = $1 AND unit_type = $2 AND $3 = ANY(actions) AND enabled
+        ORDER BY created_at
+        LIMIT 1`
+	if err := r.db.GetContext(ctx, &device, query, unitID, unitType, actionType); err != nil {
+		return nil, err
+	}
+	return &device, nil
+}
+
+func (r *repository) UpdateDevice(ctx context.Context, device *models.Device) error {
+	device.UpdatedAt = time.Now()
+	query := `UPDATE devices SET name = :name, adapter = :adapter, endpoint = :endpoint, actions = :actions,
+	          enabled = :enabled, updated_at = :updated_at WHERE id = :id`
+	if _, err := r.db.NamedExecContext(ctx, query, device); err != nil {
+		return fmt.Errorf("не удалось обновить устройство: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) DeleteDevice(ctx context.Context, id uuid.UUID) error {
+	query := `DELETE FROM devices WHERE id = $1`
+	if _, err := r.db.ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("не удалось удалить устройство: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) CreateCommand(ctx context.Context, cmd *models.Command) (bool, error) {
+	query := `INSERT INTO device_commands (id, operation_id, device_id, status, message, sent_at)
+	          VALUES ($1, $2, $3, $4, $5, $6)
+	          ON CONFLICT (operation_id) DO NOTHING`
+	result, err := r.db.ExecContext(ctx, query, cmd.ID, cmd.OperationID, cmd.DeviceID, cmd.Status, cmd.Message, cmd.SentAt)
+	if err != nil {
+		return false, fmt.Errorf("не удалось создать команду устройству: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("не удалось проверить результат создания команды: %w", err)
+	}
+	return rowsAffected > 0, nil
+}
+
+func (r *repository) GetCommandByOperationID(ctx context.Context, operationID uuid.UUID) (*models.Command, error) {
+	var cmd models.Command
+	query := `SELECT * FROM device_commands WHERE operation_id = $1`
+	if err := r.db.GetContext(ctx, &cmd, query, operationID); err != nil {
+		return nil, fmt.Errorf("не удалось получить команду устройству: %w", err)
+	}
+	return &cmd, nil
+}
+
+func (r *repository) FinishCommand(ctx context.Context, id uuid.UUID, status, message string) error {
+	query := `UPDATE device_commands SET status = $1, message = $2, finished_at = NOW() WHERE id = $3`
+	if _, err := r.db.ExecContext(ctx, query, status, message, id); err != nil {
+		return fmt.Errorf("не удалось обновить команду устройству: %w", err)
+	}
+	return nil
+}
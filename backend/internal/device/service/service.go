@@ -0,0 +1,296 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/rendley/vegshare/backend/internal/device/adapter"
+	"github.com/rendley/vegshare/backend/internal/device/models"
+	"github.com/rendley/vegshare/backend/internal/device/repository"
+	"github.com/rendley/vegshare/backend/internal/operations/actionhandlers"
+	"github.com/rendley/vegshare/backend/internal/operations/actions"
+	operationsModels "github.com/rendley/vegshare/backend/internal/operations/models"
+	operationsRepository "github.com/rendley/vegshare/backend/internal/operations/repository"
+	"github.com/rendley/vegshare/backend/pkg/database"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrInvalidDevice - устройство описано неверно (неизвестный адаптер, неприменимое действие и т.п.).
+var ErrInvalidDevice = errors.New("некорректное устройство")
+
+// DeviceRequest - параметры создания или изменения устройства.
+type DeviceRequest struct {
+	Name     string    `json:"name" validate:"required"`
+	UnitID   uuid.UUID `json:"unit_id" validate:"required"`
+	UnitType string    `json:"unit_type" validate:"required"`
+	Adapter  string    `json:"adapter" validate:"required"`
+	Endpoint string    `json:"endpoint"`
+	Actions  []string  `json:"actions" validate:"required,min=1"`
+	// Enabled по умолчанию true.
+	Enabled *bool `json:"enabled"`
+}
+
+// Service определяет контракт для управления устройствами и выполнения действий на них.
+type Service interface {
+	CreateDevice(ctx context.Context, req DeviceRequest) (*models.Device, error)
+	// GetDevices возвращает устройства юнита или все устройства, если unitID == nil.
+	GetDevices(ctx context.Context, unitID *uuid.UUID) ([]models.Device, error)
+	UpdateDevice(ctx context.Context, id uuid.UUID, req DeviceRequest) (*models.Device, error)
+	DeleteDevice(ctx context.Context, id uuid.UUID) error
+
+	// Execute выполняет операцию на устройстве юнита, если оно умеет это действие.
+	// handled == false означает, что устройства нет и нужна задача персоналу.
+	Execute(ctx context.Context, op *operationsModels.OperationLog) (handled bool, err error)
+}
+
+type service struct {
+	db       *sqlx.DB
+	repo     repository.Repository
+	adapters *adapter.Registry
+	actions  *actions.Registry
+	handlers *actionhandlers.Registry
+	logger   *logrus.Logger
+
+	// Фабрики репозиториев поверх транзакции; в тестах подменяются моками.
+	newRepo    func(db database.DBTX) repository.Repository
+	newOpsRepo func(db database.DBTX) operationsRepository.Repository
+}
+
+// NewService - конструктор для сервиса устройств.
+func NewService(db *sqlx.DB, repo repository.Repository, adapters *adapter.Registry, actionRegistry *actions.Registry, handlers *actionhandlers.Registry, logger *logrus.Logger) Service {
+	return &service{
+		db:       db,
+		repo:     repo,
+		adapters: adapters,
+		actions:  actionRegistry,
+		handlers: handlers,
+		logger:   logger,
+
+		newRepo:    repository.NewRepository,
+		newOpsRepo: operationsRepository.NewRepository,
+	}
+}
+
+func (s *service) CreateDevice(ctx context.Context, req DeviceRequest) (*models.Device, error) {
+	if err := s.validate(req); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	device := &models.Device{
+		ID:        uuid.New(),
+		Name:      req.Name,
+		UnitID:    req.UnitID,
+		UnitType:  req.UnitType,
+		Adapter:   req.Adapter,
+		Endpoint:  req.Endpoint,
+		Actions:   req.Actions,
+		Enabled:   req.Enabled == nil || *req.Enabled,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.repo.CreateDevice(ctx, device); err != nil {
+		return nil, err
+	}
+	return device, nil
+}
+
+func (s *service) GetDevices(ctx context.Context, unitID *uuid.UUID) ([]models.Device, error) {
+	if unitID != nil {
+		return s.repo.GetDevicesByUnit(ctx, *unitID)
+	}
+	return s.repo.GetAllDevices(ctx)
+}
+
+func (s *service) UpdateDevice(ctx context.Context, id uuid.UUID, req DeviceRequest) (*models.Device, error) {
+	device, err := s.repo.GetDeviceByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	// Юнит устройства не меняется: переносить устройство нужно удалением и созданием.
+	req.UnitID, req.UnitType = device.UnitID, device.UnitType
+	if err := s.validate(req); err != nil {
+		return nil, err
+	}
+
+	device.Name = req.Name
+	device.Adapter = req.Adapter
+	device.Endpoint = req.Endpoint
+	device.Actions = req.Actions
+	if req.Enabled != nil {
+		device.Enabled = *req.Enabled
+	}
+	if err := s.repo.UpdateDevice(ctx, device); err != nil {
+		return nil, err
+	}
+	return device, nil
+}
+
+func (s *service) DeleteDevice(ctx context.Context, id uuid.UUID) error {
+	return s.repo.DeleteDevice(ctx, id)
+}
+
+// validate проверяет адаптер и то, что каждое действие существует и применимо к юниту устройства.
+func (s *service) validate(req DeviceRequest) error {
+	if !s.adapters.Has(req.Adapter) {
+		return fmt.Errorf("%w: неизвестный адаптер '%s'", ErrInvalidDevice, req.Adapter)
+	}
+	if req.Adapter == models.AdapterHTTP && req.Endpoint == "" {
+		return fmt.Errorf("%w: для адаптера '%s' нужен endpoint", ErrInvalidDevice, req.Adapter)
+	}
+	for _, name := range req.Actions {
+		actionType, ok := s.actions.Get(name)
+		if !ok {
+			return fmt.Errorf("%w: неизвестный тип действия '%s'", ErrInvalidDevice, name)
+		}
+		if !actionType.SupportsUnitType(req.UnitType) {
+			return fmt.Errorf("%w: действие '%s' неприменимо к юниту типа '%s'", ErrInvalidDevice, name, req.UnitType)
+		}
+	}
+	return nil
+}
+
+func (s *service) Execute(ctx context.Context, op *operationsModels.OperationLog) (bool, error) {
+	device, err := s.repo.FindDeviceForAction(ctx, op.UnitID, op.UnitType, op.ActionType)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("не удалось найти устройство для операции %s: %w", op.ID, err)
+	}
+
+	a, err := s.adapters.Get(device.Adapter)
+	if err != nil {
+		return false, err
+	}
+
+	cmd := &models.Command{
+		ID:          uuid.New(),
+		OperationID: op.ID,
+		DeviceID:    device.ID,
+		Status:      models.CommandStatusSent,
+		SentAt:      time.Now(),
+	}
+	sent, err := s.send(ctx, op, cmd)
+	if err != nil {
+		return true, err
+	}
+	if !sent {
+		return true, s.resume(context.WithoutCancel(ctx), op)
+	}
+
+	result, err := a.Execute(ctx, device, adapter.Command{OperationID: op.ID, ActionType: op.ActionType, Parameters: op.Parameters})
+	if err != nil {
+		// Результат неизвестен. Повторять физическое действие (второй полив) опаснее,
+		// чем сообщить о сбое, поэтому операция завершается ошибкой.
+		result = adapter.Result{Success: false, Message: err.Error()}
+	}
+
+	// Команда уже отправлена: результат нужно записать, даже если контекст сообщения отменен,
+	// иначе операция останется in_progress, а команда - sent.
+	return true, s.finish(context.WithoutCancel(ctx), op, cmd, result)
+}
+
+// send в одной транзакции регистрирует команду и переводит операцию в in_progress.
+// После этого операцию нельзя отменить, а повторная доставка сообщения не отправит команду второй раз.
+// sent == false означает, что команду отправлять не нужно: она уже зарегистрирована или операция не в processing.
+func (s *service) send(ctx context.Context, op *operationsModels.OperationLog, cmd *models.Command) (bool, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("не удалось начать транзакцию: %w", err)
+	}
+	defer tx.Rollback()
+
+	created, err := s.newRepo(tx).CreateCommand(ctx, cmd)
+	if err != nil {
+		return false, err
+	}
+	if !created {
+		s.logger.Infof("Command for operation %s was already sent to a device, skipping", op.ID)
+		return false, nil
+	}
+
+	ok, err := s.newOpsRepo(tx).TransitionOperationLogStatus(ctx, op.ID, operationsModels.StatusProcessing, operationsModels.StatusInProgress)
+	if err != nil {
+		return false, err
+	}
+	if !ok {
+		s.logger.Infof("Operation %s is no longer '%s', device command not sent", op.ID, operationsModels.StatusProcessing)
+		return false, nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("не удалось закоммитить транзакцию: %w", err)
+	}
+	return true, nil
+}
+
+// resume дописывает результат команды, отправленной при прошлой доставке сообщения, если он
+// так и не был записан (воркер упал после отправки или finish вернул ошибку). Результат такой
+// команды неизвестен, а повторять физическое действие опаснее, поэтому операция завершается ошибкой.
+func (s *service) resume(ctx context.Context, op *operationsModels.OperationLog) error {
+	cmd, err := s.repo.GetCommandByOperationID(ctx, op.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// Команда не регистрировалась: операция уже не в processing (например, отменена).
+			return nil
+		}
+		return err
+	}
+	if cmd.Status != models.CommandStatusSent {
+		return nil
+	}
+
+	s.logger.Warnf("Command %s for operation %s was sent but its result was not recorded, finishing it as failed", cmd.ID, op.ID)
+	return s.finish(ctx, op, cmd, adapter.Result{Success: false, Message: "результат команды неизвестен: обработка была прервана"})
+}
+
+// finish записывает результат команды в device_commands и operation_log, а при успехе
+// применяет последствия действия так же, как при завершении задачи персоналом.
+// Если операция уже не in_progress, результат команды сохраняется, но операция не меняется.
+func (s *service) finish(ctx context.Context, op *operationsModels.OperationLog, cmd *models.Command, result adapter.Result) error {
+	cmdStatus, opStatus := models.CommandStatusCompleted, operationsModels.StatusCompleted
+	if !result.Success {
+		cmdStatus, opStatus = models.CommandStatusFailed, operationsModels.StatusFailed
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("не удалось начать транзакцию: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := s.newRepo(tx).FinishCommand(ctx, cmd.ID, cmdStatus, result.Message); err != nil {
+		return err
+	}
+	ok, err := s.newOpsRepo(tx).TransitionOperationLogStatus(ctx, op.ID, operationsModels.StatusInProgress, opStatus)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		// Конфликт: операцию уже перевели в другой статус (например, ее завершила повторная доставка). Повтор сообщения это не исправит,
+		// поэтому результат команды записывается, а последствия действия не применяются.
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("не удалось закоммитить транзакцию: %w", err)
+		}
+		s.logger.Warnf("Operation %s is no longer '%s', device %s result '%s' recorded without changing the operation", op.ID, operationsModels.StatusInProgress, cmd.DeviceID, cmdStatus)
+		return nil
+	}
+	if result.Success {
+		if err := s.handlers.Complete(ctx, tx, op, actionhandlers.CompletionDetails{}); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("не удалось закоммитить транзакцию: %w", err)
+	}
+
+	s.logger.Infof("Device %s finished operation %s with status '%s': %s", cmd.DeviceID, op.ID, cmdStatus, result.Message)
+	return nil
+}
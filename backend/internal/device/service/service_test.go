@@ -0,0 +1,285 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rendley/vegshare/backend/internal/device/adapter"
+	"github.com/rendley/vegshare/backend/internal/device/models"
+	"github.com/rendley/vegshare/backend/internal/device/repository"
+	"github.com/rendley/vegshare/backend/internal/operations/actions"
+	operationsModels "github.com/rendley/vegshare/backend/internal/operations/models"
+	operationsRepository "github.com/rendley/vegshare/backend/internal/operations/repository"
+	"github.com/rendley/vegshare/backend/pkg/database"
+	"github.com/rendley/vegshare/backend/pkg/database/dbtest"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// --- Mocks ---
+
+type MockDeviceRepository struct {
+	mock.Mock
+}
+
+var _ repository.Repository = &MockDeviceRepository{}
+
+func (m *MockDeviceRepository) CreateDevice(ctx context.Context, device *models.Device) error {
+	args := m.Called(ctx, device)
+	return args.Error(0)
+}
+
+func (m *MockDeviceRepository) GetDeviceByID(ctx context.Context, id uuid.UUID) (*models.Device, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Device), args.Error(1)
+}
+
+func (m *MockDeviceRepository) GetDevicesByUnit(ctx context.Context, unitID uuid.UUID) ([]models.Device, error) {
+	args := m.Called(ctx, unitID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Device), args.Error(1)
+}
+
+func (m *MockDeviceRepository) GetAllDevices(ctx context.Context) ([]models.Device, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Device), args.Error(1)
+}
+
+func (m *MockDeviceRepository) UpdateDevice(ctx context.Context, device *models.Device) error {
+	args := m.Called(ctx, device)
+	return args.Error(0)
+}
+
+func (m *MockDeviceRepository) FindDeviceForAction(ctx context.Context, unitID uuid.UUID, unitType, actionType string) (*models.Device, error) {
+	args := m.Called(ctx, unitID, unitType, actionType)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Device), args.Error(1)
+}
+
+func (m *MockDeviceRepository) CreateCommand(ctx context.Context, cmd *models.Command) (bool, error) {
+	args := m.Called(ctx, cmd)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockDeviceRepository) GetCommandByOperationID(ctx context.Context, operationID uuid.UUID) (*models.Command, error) {
+	args := m.Called(ctx, operationID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Command), args.Error(1)
+}
+
+func (m *MockDeviceRepository) FinishCommand(ctx context.Context, id uuid.UUID, status, message string) error {
+	args := m.Called(ctx, id, status, message)
+	return args.Error(0)
+}
+
+// Dummy implementations for other repository methods to satisfy the interface
+func (m *MockDeviceRepository) DeleteDevice(ctx context.Context, id uuid.UUID) error { return nil }
+
+// MockOperationsRepository мокает только смену статуса операции; остальные методы не вызываются.
+type MockOperationsRepository struct {
+	operationsRepository.Repository
+	mock.Mock
+}
+
+func (m *MockOperationsRepository) TransitionOperationLogStatus(ctx context.Context, logID uuid.UUID, from, to string) (bool, error) {
+	args := m.Called(ctx, logID, from, to)
+	return args.Bool(0), args.Error(1)
+}
+
+func TestDeviceService(t *testing.T) {
+	ctx := context.Background()
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	// Шаги до транзакции; выполнение команды и запись результата проверяются ниже через newTxService.
+	newService := func(repo *MockDeviceRepository) Service {
+		return NewService(nil, repo, adapter.NewDefaultRegistry(0, 0), actions.NewDefaultRegistry(), nil, logger)
+	}
+
+	validRequest := func() DeviceRequest {
+		return DeviceRequest{
+			Name:     "Капельный полив",
+			UnitID:   uuid.New(),
+			UnitType: "plot",
+			Adapter:  models.AdapterHTTP,
+			Endpoint: "http://10.0.0.5/command",
+			Actions:  []string{actions.ActionWater},
+		}
+	}
+
+	t.Run("CreateDevice - Success", func(t *testing.T) {
+		mockRepo := new(MockDeviceRepository)
+		svc := newService(mockRepo)
+		mockRepo.On("CreateDevice", ctx, mock.AnythingOfType("*models.Device")).Return(nil).Once()
+
+		device, err := svc.CreateDevice(ctx, validRequest())
+
+		assert.NoError(t, err)
+		assert.True(t, device.Enabled)
+		assert.Equal(t, []string{actions.ActionWater}, []string(device.Actions))
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("CreateDevice - Invalid requests", func(t *testing.T) {
+		cases := map[string]func(req *DeviceRequest){
+			"unknown adapter":      func(req *DeviceRequest) { req.Adapter = "mqtt" },
+			"http without address": func(req *DeviceRequest) { req.Endpoint = "" },
+			"unknown action":       func(req *DeviceRequest) { req.Actions = []string{"dance"} },
+			"action for another unit": func(req *DeviceRequest) {
+				req.UnitType = "coop"
+				req.Actions = []string{actions.ActionWeed}
+			},
+		}
+		for name, modify := range cases {
+			mockRepo := new(MockDeviceRepository)
+			svc := newService(mockRepo)
+			req := validRequest()
+			modify(&req)
+
+			_, err := svc.CreateDevice(ctx, req)
+
+			assert.ErrorIs(t, err, ErrInvalidDevice, name)
+			mockRepo.AssertNotCalled(t, "CreateDevice", mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("UpdateDevice - Keeps unit of the device", func(t *testing.T) {
+		mockRepo := new(MockDeviceRepository)
+		svc := newService(mockRepo)
+		existing := &models.Device{ID: uuid.New(), UnitID: uuid.New(), UnitType: "coop", Enabled: true}
+		mockRepo.On("GetDeviceByID", ctx, existing.ID).Return(existing, nil).Once()
+		mockRepo.On("UpdateDevice", ctx, existing).Return(nil).Once()
+
+		req := validRequest()
+		req.Adapter = models.AdapterSimulator
+		disabled := false
+		req.Enabled = &disabled
+		device, err := svc.UpdateDevice(ctx, existing.ID, req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "coop", device.UnitType)
+		assert.NotEqual(t, req.UnitID, device.UnitID)
+		assert.False(t, device.Enabled)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Execute - Operation without device is not handled", func(t *testing.T) {
+		mockRepo := new(MockDeviceRepository)
+		svc := newService(mockRepo)
+		op := &operationsModels.OperationLog{ID: uuid.New(), UnitID: uuid.New(), UnitType: "plot", ActionType: actions.ActionWater}
+		mockRepo.On("FindDeviceForAction", ctx, op.UnitID, op.UnitType, op.ActionType).Return(nil, sql.ErrNoRows).Once()
+
+		handled, err := svc.Execute(ctx, op)
+
+		assert.NoError(t, err)
+		assert.False(t, handled)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Execute - Repository error is returned for retry", func(t *testing.T) {
+		mockRepo := new(MockDeviceRepository)
+		svc := newService(mockRepo)
+		op := &operationsModels.OperationLog{ID: uuid.New(), UnitID: uuid.New(), UnitType: "plot", ActionType: actions.ActionWater}
+		mockRepo.On("FindDeviceForAction", ctx, op.UnitID, op.UnitType, op.ActionType).Return(nil, errors.New("db down")).Once()
+
+		handled, err := svc.Execute(ctx, op)
+
+		assert.Error(t, err)
+		assert.False(t, handled)
+	})
+
+	// newTxService подключает поддельную БД и моки репозиториев, создаваемых поверх транзакции.
+	newTxService := func(repo *MockDeviceRepository, opsRepo *MockOperationsRepository) (Service, *dbtest.Stats) {
+		db, stats := dbtest.New()
+		svc := NewService(db, repo, adapter.NewDefaultRegistry(0, 0), actions.NewDefaultRegistry(), nil, logger)
+		svc.(*service).newRepo = func(database.DBTX) repository.Repository { return repo }
+		svc.(*service).newOpsRepo = func(database.DBTX) operationsRepository.Repository { return opsRepo }
+		return svc, stats
+	}
+	simulatorDevice := func(op *operationsModels.OperationLog) *models.Device {
+		return &models.Device{ID: uuid.New(), UnitID: op.UnitID, UnitType: op.UnitType, Adapter: models.AdapterSimulator, Enabled: true}
+	}
+
+	t.Run("Execute - Interrupted command is finished as failed on redelivery", func(t *testing.T) {
+		mockRepo := new(MockDeviceRepository)
+		opsRepo := new(MockOperationsRepository)
+		svc, stats := newTxService(mockRepo, opsRepo)
+		op := &operationsModels.OperationLog{ID: uuid.New(), UnitID: uuid.New(), UnitType: "plot", ActionType: actions.ActionWater}
+		device := simulatorDevice(op)
+		sentCmd := &models.Command{ID: uuid.New(), OperationID: op.ID, DeviceID: device.ID, Status: models.CommandStatusSent}
+
+		mockRepo.On("FindDeviceForAction", ctx, op.UnitID, op.UnitType, op.ActionType).Return(device, nil).Once()
+		mockRepo.On("CreateCommand", ctx, mock.AnythingOfType("*models.Command")).Return(false, nil).Once()
+		mockRepo.On("GetCommandByOperationID", mock.Anything, op.ID).Return(sentCmd, nil).Once()
+		mockRepo.On("FinishCommand", mock.Anything, sentCmd.ID, models.CommandStatusFailed, mock.AnythingOfType("string")).Return(nil).Once()
+		opsRepo.On("TransitionOperationLogStatus", mock.Anything, op.ID, operationsModels.StatusInProgress, operationsModels.StatusFailed).Return(true, nil).Once()
+
+		handled, err := svc.Execute(ctx, op)
+
+		assert.NoError(t, err)
+		assert.True(t, handled)
+		assert.Equal(t, 1, stats.Committed())
+		mockRepo.AssertExpectations(t)
+		opsRepo.AssertExpectations(t)
+	})
+
+	t.Run("Execute - Finished command is not resumed on redelivery", func(t *testing.T) {
+		mockRepo := new(MockDeviceRepository)
+		opsRepo := new(MockOperationsRepository)
+		svc, stats := newTxService(mockRepo, opsRepo)
+		op := &operationsModels.OperationLog{ID: uuid.New(), UnitID: uuid.New(), UnitType: "plot", ActionType: actions.ActionWater}
+		device := simulatorDevice(op)
+		doneCmd := &models.Command{ID: uuid.New(), OperationID: op.ID, DeviceID: device.ID, Status: models.CommandStatusCompleted}
+
+		mockRepo.On("FindDeviceForAction", ctx, op.UnitID, op.UnitType, op.ActionType).Return(device, nil).Once()
+		mockRepo.On("CreateCommand", ctx, mock.AnythingOfType("*models.Command")).Return(false, nil).Once()
+		mockRepo.On("GetCommandByOperationID", mock.Anything, op.ID).Return(doneCmd, nil).Once()
+
+		handled, err := svc.Execute(ctx, op)
+
+		assert.NoError(t, err)
+		assert.True(t, handled)
+		assert.Equal(t, 0, stats.Committed())
+		mockRepo.AssertNotCalled(t, "FinishCommand", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Execute - Result is recorded without completing an operation changed concurrently", func(t *testing.T) {
+		mockRepo := new(MockDeviceRepository)
+		opsRepo := new(MockOperationsRepository)
+		// handlers == nil: вызов Complete при конфликте уронил бы тест.
+		svc, stats := newTxService(mockRepo, opsRepo)
+		op := &operationsModels.OperationLog{ID: uuid.New(), UnitID: uuid.New(), UnitType: "plot", ActionType: actions.ActionWater}
+		device := simulatorDevice(op)
+
+		mockRepo.On("FindDeviceForAction", ctx, op.UnitID, op.UnitType, op.ActionType).Return(device, nil).Once()
+		mockRepo.On("CreateCommand", ctx, mock.AnythingOfType("*models.Command")).Return(true, nil).Once()
+		opsRepo.On("TransitionOperationLogStatus", ctx, op.ID, operationsModels.StatusProcessing, operationsModels.StatusInProgress).Return(true, nil).Once()
+		mockRepo.On("FinishCommand", mock.Anything, mock.AnythingOfType("uuid.UUID"), models.CommandStatusCompleted, "simulated").Return(nil).Once()
+		opsRepo.On("TransitionOperationLogStatus", mock.Anything, op.ID, operationsModels.StatusInProgress, operationsModels.StatusCompleted).Return(false, nil).Once()
+
+		handled, err := svc.Execute(ctx, op)
+
+		assert.NoError(t, err)
+		assert.True(t, handled)
+		assert.Equal(t, 2, stats.Committed())
+		mockRepo.AssertExpectations(t)
+		opsRepo.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,159 @@
+// Package mocks содержит общий testify-мок сервиса фермы для тестов модулей,
+// которые от него зависят (грядки, загоны, доставка).
+package mocks
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/rendley/vegshare/backend/internal/farm/models"
+	"github.com/rendley/vegshare/backend/internal/farm/service"
+	"github.com/stretchr/testify/mock"
+)
+
+// FarmService - мок service.Service.
+type FarmService struct {
+	mock.Mock
+}
+
+var _ service.Service = &FarmService{}
+
+func (m *FarmService) CreateRegion(ctx context.Context, name string) (*models.Region, error) {
+	args := m.Called(ctx, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Region), args.Error(1)
+}
+
+func (m *FarmService) GetRegionByID(ctx context.Context, id uuid.UUID) (*models.Region, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Region), args.Error(1)
+}
+
+func (m *FarmService) GetAllRegions(ctx context.Context) ([]models.Region, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Region), args.Error(1)
+}
+
+func (m *FarmService) UpdateRegion(ctx context.Context, id uuid.UUID, name string) (*models.Region, error) {
+	args := m.Called(ctx, id, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Region), args.Error(1)
+}
+
+func (m *FarmService) DeleteRegion(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *FarmService) RestoreRegion(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *FarmService) GetAllRegionsIncludingDeleted(ctx context.Context) ([]models.Region, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Region), args.Error(1)
+}
+
+func (m *FarmService) CreateLandParcel(ctx context.Context, name string, regionID uuid.UUID) (*models.LandParcel, error) {
+	args := m.Called(ctx, name, regionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.LandParcel), args.Error(1)
+}
+
+func (m *FarmService) GetLandParcelByID(ctx context.Context, id uuid.UUID) (*models.LandParcel, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.LandParcel), args.Error(1)
+}
+
+func (m *FarmService) GetLandParcelsByRegion(ctx context.Context, regionID uuid.UUID) ([]models.LandParcel, error) {
+	args := m.Called(ctx, regionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.LandParcel), args.Error(1)
+}
+
+func (m *FarmService) UpdateLandParcel(ctx context.Context, id uuid.UUID, name string) (*models.LandParcel, error) {
+	args := m.Called(ctx, id, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.LandParcel), args.Error(1)
+}
+
+func (m *FarmService) DeleteLandParcel(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *FarmService) RestoreLandParcel(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *FarmService) GetAllLandParcelsIncludingDeleted(ctx context.Context) ([]models.LandParcel, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.LandParcel), args.Error(1)
+}
+
+func (m *FarmService) CreateStructure(ctx context.Context, name, typeName string, landParcelID uuid.UUID) (*models.Structure, error) {
+	args := m.Called(ctx, name, typeName, landParcelID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Structure), args.Error(1)
+}
+
+func (m *FarmService) GetStructureByID(ctx context.Context, id uuid.UUID) (*models.Structure, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Structure), args.Error(1)
+}
+
+func (m *FarmService) GetStructuresByLandParcel(ctx context.Context, landParcelID uuid.UUID) ([]models.Structure, error) {
+	args := m.Called(ctx, landParcelID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Structure), args.Error(1)
+}
+
+func (m *FarmService) UpdateStructure(ctx context.Context, id uuid.UUID, name, typeName string) (*models.Structure, error) {
+	args := m.Called(ctx, id, name, typeName)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Structure), args.Error(1)
+}
+
+func (m *FarmService) DeleteStructure(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *FarmService) GetStructureTypes(ctx context.Context) ([]string, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
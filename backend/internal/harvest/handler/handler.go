@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/rendley/vegshare/backend/internal/harvest/service"
+	"github.com/rendley/vegshare/backend/pkg/api"
+	"github.com/rendley/vegshare/backend/pkg/middleware"
+	"github.com/sirupsen/logrus"
+)
+
+// HarvestHandler обрабатывает HTTP-запросы, связанные с урожаем.
+type HarvestHandler struct {
+	service service.Service
+	logger  *logrus.Logger
+}
+
+// NewHarvestHandler - конструктор для HarvestHandler.
+func NewHarvestHandler(s service.Service, l *logrus.Logger) *HarvestHandler {
+	return &HarvestHandler{
+		service: s,
+		logger:  l,
+	}
+}
+
+// GetMyHarvests возвращает историю урожая текущего пользователя (опционально ?unit_id=...).
+func (h *HarvestHandler) GetMyHarvests(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		api.RespondWithError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var unitID *uuid.UUID
+	if v := r.URL.Query().Get("unit_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			api.RespondWithError(w, "invalid unit_id query parameter", http.StatusBadRequest)
+			return
+		}
+		unitID = &id
+	}
+
+	records, err := h.service.GetHarvestHistory(r.Context(), userID, unitID)
+	if err != nil {
+		h.logger.Errorf("ошибка при получении истории урожая: %v", err)
+		api.RespondWithError(w, "could not retrieve harvest history", http.StatusInternalServerError)
+		return
+	}
+
+	api.RespondWithJSON(h.logger, w, records, http.StatusOK)
+}
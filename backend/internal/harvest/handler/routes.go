@@ -0,0 +1,17 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Routes возвращает роутер для модуля урожая.
+func (h *HarvestHandler) Routes() http.Handler {
+	r := chi.NewRouter()
+
+	// GET /harvests - история урожая текущего пользователя
+	r.Get("/", h.GetMyHarvests)
+
+	return r
+}
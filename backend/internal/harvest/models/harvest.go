@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// HarvestRecord - запись в журнале урожая: что, сколько и с какого юнита было собрано для пользователя.
+type HarvestRecord struct {
+	ID          uuid.UUID `db:"id" json:"id"`
+	OperationID uuid.UUID `db:"operation_id" json:"operation_id"`
+	UserID      uuid.UUID `db:"user_id" json:"user_id"`
+	UnitID      uuid.UUID `db:"unit_id" json:"unit_id"`
+	UnitType    string    `db:"unit_type" json:"unit_type"`
+	ItemID      uuid.UUID `db:"item_id" json:"item_id"`
+	// Count - количество собранных единиц (кочанов, яиц и т.д.).
+	Count int `db:"count" json:"count"`
+	// WeightGrams - общий вес урожая в граммах; 0, если урожай не взвешивали.
+	WeightGrams float64   `db:"weight_grams" json:"weight_grams"`
+	HarvestedAt time.Time `db:"harvested_at" json:"harvested_at"`
+}
+
+// EnrichedHarvestRecord - запись журнала урожая с названием собранной позиции каталога.
+type EnrichedHarvestRecord struct {
+	HarvestRecord
+	ItemName string `db:"item_name" json:"item_name"`
+}
+
+// HarvestRequest описывает сбор урожая с юнита.
+type HarvestRequest struct {
+	OperationID uuid.UUID
+	UserID      uuid.UUID
+	UnitID      uuid.UUID
+	UnitType    string
+	// ItemID - что собрать; если nil, собирается текущее содержимое юнита.
+	ItemID *uuid.UUID
+	// Quantity - сколько единиц содержимого убрать с юнита; 0 означает "все".
+	Quantity int
+	// Count - фактически собранное количество, если оно отличается от Quantity (например, яйца).
+	Count       int
+	WeightGrams float64
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/rendley/vegshare/backend/internal/harvest/models"
+	"github.com/rendley/vegshare/backend/pkg/database"
+)
+
+// Repository определяет контракт для хранилища журнала урожая.
+type Repository interface {
+	CreateRecord(ctx context.Context, record *models.HarvestRecord) error
+	GetRecordByID(ctx context.Context, id uuid.UUID) (*models.HarvestRecord, error)
+	GetRecordsByUserID(ctx context.Context, userID uuid.UUID, unitID *uuid.UUID) ([]models.EnrichedHarvestRecord, error)
+}
+
+type repository struct {
+	db database.DBTX
+}
+
+// NewRepository - конструктор для репозитория урожая.
+func NewRepository(db database.DBTX) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) CreateRecord(ctx context.Context, record *models.HarvestRecord) error {
+	query := `INSERT INTO harvests (id, operation_id, user_id, unit_id, unit_type, item_id, count, weight_grams, harvested_at)
+	          VALUES (:id, :operation_id, :user_id, :unit_id, :unit_type, :item_id, :count, :weight_grams, :harvested_at)`
+	if _, err := r.db.NamedExecContext(ctx, query, record); err != nil {
+		return fmt.Errorf("не удалось записать урожай в журнал: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetRecordByID(ctx context.Context, id uuid.UUID) (*models.HarvestRecord, error) {
+	var record models.HarvestRecord
+	query := `SELECT * FROM harvests WHERE id = $1`
+	if err := r.db.GetContext(ctx, &record, query, id); err != nil {
+		return nil, fmt.Errorf("не удалось получить запись урожая по ID: %w", err)
+	}
+	return &record, nil
+}
+
+// GetRecordsByUserID возвращает историю урожая пользователя, опционально только по одному юниту.
+func (r *repository) GetRecordsByUserID(ctx context.Context, userID uuid.UUID, unitID *uuid.UUID) ([]models.EnrichedHarvestRecord, error) {
+	query := `
+        SELECT h.*, ci.name AS item_name
+        FROM harvests h
+        JOIN catalog_items ci ON ci.id = h.item_id
+        WHERE h.user_id = $1 AND ($2::uuid IS NULL OR h.unit_id = $2)
+        ORDER BY h.harvested_at DESC`
+
+	records := []models.EnrichedHarvestRecord{}
+	if err := r.db.SelectContext(ctx, &records, query, userID, unitID); err != nil {
+		return nil, fmt.Errorf("не удалось получить историю урожая: %w", err)
+	}
+	return records, nil
+}
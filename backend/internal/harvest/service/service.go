@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/rendley/vegshare/backend/internal/harvest/models"
+	"github.com/rendley/vegshare/backend/internal/harvest/repository"
+	unitcontentService "github.com/rendley/vegshare/backend/internal/unitcontent/service"
+)
+
+// consumingUnitTypes - типы юнитов, у которых сбор урожая убирает само содержимое
+// (растения с грядки). У загонов собирается продукция (яйца), а животные остаются.
+var consumingUnitTypes = map[string]bool{
+	"plot": true,
+}
+
+// Service определяет контракт для сервиса урожая.
+type Service interface {
+	Harvest(ctx context.Context, req models.HarvestRequest) (*models.HarvestRecord, error)
+	GetHarvestHistory(ctx context.Context, userID uuid.UUID, unitID *uuid.UUID) ([]models.EnrichedHarvestRecord, error)
+	GetRecordByID(ctx context.Context, id uuid.UUID) (*models.HarvestRecord, error)
+	WithTx(tx *sqlx.Tx) Service
+}
+
+type service struct {
+	repo               repository.Repository
+	unitContentService unitcontentService.Service
+}
+
+// NewService - конструктор для сервиса урожая.
+func NewService(repo repository.Repository, ucs unitcontentService.Service) Service {
+	return &service{repo: repo, unitContentService: ucs}
+}
+
+// WithTx создает экземпляр сервиса, работающий в контексте транзакции.
+func (s *service) WithTx(tx *sqlx.Tx) Service {
+	return &service{
+		repo:               repository.NewRepository(tx),
+		unitContentService: s.unitContentService.WithTx(tx),
+	}
+}
+
+// Harvest записывает урожай в журнал и, для грядок, уменьшает или очищает содержимое юнита.
+func (s *service) Harvest(ctx context.Context, req models.HarvestRequest) (*models.HarvestRecord, error) {
+	var itemID uuid.UUID
+	count := req.Quantity
+
+	if consumingUnitTypes[req.UnitType] {
+		content, err := s.unitContentService.GetContent(ctx, req.UnitID, req.UnitType)
+		if err != nil {
+			return nil, fmt.Errorf("нечего собирать с юнита %s: %w", req.UnitID, err)
+		}
+		if req.ItemID != nil && *req.ItemID != content.ItemID {
+			return nil, fmt.Errorf("на юните %s растет другая культура", req.UnitID)
+		}
+		if count == 0 || count > content.Quantity {
+			count = content.Quantity
+		}
+		if err := s.unitContentService.RemoveContent(ctx, req.UnitID, req.UnitType, count); err != nil {
+			return nil, fmt.Errorf("не удалось обновить содержимое юнита: %w", err)
+		}
+		itemID = content.ItemID
+	} else {
+		if req.ItemID == nil {
+			return nil, fmt.Errorf("для сбора урожая с юнита типа '%s' необходимо указать item_id", req.UnitType)
+		}
+		itemID = *req.ItemID
+	}
+
+	if req.Count > 0 {
+		count = req.Count
+	}
+	if count <= 0 && req.WeightGrams <= 0 {
+		return nil, fmt.Errorf("не указано ни количество, ни вес собранного урожая")
+	}
+
+	record := &models.HarvestRecord{
+		ID:          uuid.New(),
+		OperationID: req.OperationID,
+		UserID:      req.UserID,
+		UnitID:      req.UnitID,
+		UnitType:    req.UnitType,
+		ItemID:      itemID,
+		Count:       count,
+		WeightGrams: req.WeightGrams,
+		HarvestedAt: time.Now(),
+	}
+
+	if err := s.repo.CreateRecord(ctx, record); err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
+
+func (s *service) GetHarvestHistory(ctx context.Context, userID uuid.UUID, unitID *uuid.UUID) ([]models.EnrichedHarvestRecord, error) {
+	return s.repo.GetRecordsByUserID(ctx, userID, unitID)
+}
+
+func (s *service) GetRecordByID(ctx context.Context, id uuid.UUID) (*models.HarvestRecord, error) {
+	return s.repo.GetRecordByID(ctx, id)
+}
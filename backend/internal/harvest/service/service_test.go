@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/rendley/vegshare/backend/internal/harvest/models"
+	"github.com/rendley/vegshare/backend/internal/harvest/repository"
+	unitcontentModels "github.com/rendley/vegshare/backend/internal/unitcontent/models"
+	unitcontentService "github.com/rendley/vegshare/backend/internal/unitcontent/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// --- Mocks ---
+
+type MockHarvestRepository struct {
+	mock.Mock
+}
+
+var _ repository.Repository = &MockHarvestRepository{}
+
+func (m *MockHarvestRepository) CreateRecord(ctx context.Context, record *models.HarvestRecord) error {
+	args := m.Called(ctx, record)
+	return args.Error(0)
+}
+
+func (m *MockHarvestRepository) GetRecordByID(ctx context.Context, id uuid.UUID) (*models.HarvestRecord, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.HarvestRecord), args.Error(1)
+}
+
+func (m *MockHarvestRepository) GetRecordsByUserID(ctx context.Context, userID uuid.UUID, unitID *uuid.UUID) ([]models.EnrichedHarvestRecord, error) {
+	args := m.Called(ctx, userID, unitID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.EnrichedHarvestRecord), args.Error(1)
+}
+
+type MockUnitContentService struct {
+	mock.Mock
+}
+
+var _ unitcontentService.Service = &MockUnitContentService{}
+
+func (m *MockUnitContentService) CreateOrUpdateContent(ctx context.Context, unitID, itemID uuid.UUID, unitType string, quantity int) error {
+	args := m.Called(ctx, unitID, itemID, unitType, quantity)
+	return args.Error(0)
+}
+
+func (m *MockUnitContentService) GetContent(ctx context.Context, unitID uuid.UUID, unitType string) (*unitcontentModels.UnitContent, error) {
+	args := m.Called(ctx, unitID, unitType)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*unitcontentModels.UnitContent), args.Error(1)
+}
+
+func (m *MockUnitContentService) RemoveContent(ctx context.Context, unitID uuid.UUID, unitType string, quantity int) error {
+	args := m.Called(ctx, unitID, unitType, quantity)
+	return args.Error(0)
+}
+
+func (m *MockUnitContentService) WithTx(tx *sqlx.Tx) unitcontentService.Service {
+	return m
+}
+
+// --- Tests ---
+
+func TestHarvestService(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(MockHarvestRepository)
+	mockContent := new(MockUnitContentService)
+	harvestSvc := NewService(mockRepo, mockContent)
+
+	t.Run("Harvest plot - partial quantity decrements content", func(t *testing.T) {
+		unitID := uuid.New()
+		itemID := uuid.New()
+		content := &unitcontentModels.UnitContent{UnitID: unitID, UnitType: "plot", ItemID: itemID, Quantity: 10}
+		mockContent.On("GetContent", ctx, unitID, "plot").Return(content, nil).Once()
+		mockContent.On("RemoveContent", ctx, unitID, "plot", 4).Return(nil).Once()
+		mockRepo.On("CreateRecord", ctx, mock.AnythingOfType("*models.HarvestRecord")).Return(nil).Once()
+
+		record, err := harvestSvc.Harvest(ctx, models.HarvestRequest{
+			OperationID: uuid.New(),
+			UserID:      uuid.New(),
+			UnitID:      unitID,
+			UnitType:    "plot",
+			Quantity:    4,
+			WeightGrams: 1500,
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, itemID, record.ItemID)
+		assert.Equal(t, 4, record.Count)
+		assert.Equal(t, 1500.0, record.WeightGrams)
+		mockContent.AssertExpectations(t)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Harvest plot - without quantity clears all content", func(t *testing.T) {
+		unitID := uuid.New()
+		content := &unitcontentModels.UnitContent{UnitID: unitID, UnitType: "plot", ItemID: uuid.New(), Quantity: 6}
+		mockContent.On("GetContent", ctx, unitID, "plot").Return(content, nil).Once()
+		mockContent.On("RemoveContent", ctx, unitID, "plot", 6).Return(nil).Once()
+		mockRepo.On("CreateRecord", ctx, mock.AnythingOfType("*models.HarvestRecord")).Return(nil).Once()
+
+		record, err := harvestSvc.Harvest(ctx, models.HarvestRequest{UnitID: unitID, UnitType: "plot"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 6, record.Count)
+		mockContent.AssertExpectations(t)
+	})
+
+	t.Run("Harvest plot - item mismatch", func(t *testing.T) {
+		unitID := uuid.New()
+		otherItem := uuid.New()
+		content := &unitcontentModels.UnitContent{UnitID: unitID, UnitType: "plot", ItemID: uuid.New(), Quantity: 6}
+		mockContent.On("GetContent", ctx, unitID, "plot").Return(content, nil).Once()
+
+		record, err := harvestSvc.Harvest(ctx, models.HarvestRequest{UnitID: unitID, UnitType: "plot", ItemID: &otherItem})
+
+		assert.Error(t, err)
+		assert.Nil(t, record)
+		mockContent.AssertExpectations(t)
+	})
+
+	t.Run("Harvest coop - keeps animals and records produce", func(t *testing.T) {
+		unitID := uuid.New()
+		eggsID := uuid.New()
+		mockRepo.On("CreateRecord", ctx, mock.AnythingOfType("*models.HarvestRecord")).Return(nil).Once()
+
+		record, err := harvestSvc.Harvest(ctx, models.HarvestRequest{UnitID: unitID, UnitType: "coop", ItemID: &eggsID, Count: 12})
+
+		assert.NoError(t, err)
+		assert.Equal(t, eggsID, record.ItemID)
+		assert.Equal(t, 12, record.Count)
+		mockContent.AssertNotCalled(t, "RemoveContent", ctx, unitID, "coop", mock.Anything)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Harvest coop - item required", func(t *testing.T) {
+		record, err := harvestSvc.Harvest(ctx, models.HarvestRequest{UnitID: uuid.New(), UnitType: "coop", Count: 5})
+
+		assert.Error(t, err)
+		assert.Nil(t, record)
+	})
+}
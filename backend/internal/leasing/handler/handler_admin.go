@@ -0,0 +1,222 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/rendley/vegshare/backend/internal/leasing/models"
+	"github.com/rendley/vegshare/backend/internal/leasing/service"
+	"github.com/rendley/vegshare/backend/pkg/api"
+)
+
+// dateLayout - формат дат в query-параметрах фильтров.
+const dateLayout = "2006-01-02"
+
+// adminCreateLeaseRequest - тело запроса на создание аренды администратором от имени пользователя.
+type adminCreateLeaseRequest struct {
+	UserID   uuid.UUID       `json:"user_id" validate:"required"`
+	UnitID   uuid.UUID       `json:"unit_id" validate:"required"`
+	UnitType models.UnitType `json:"unit_type" validate:"required"`
+}
+
+// extendLeaseRequest - тело запроса на продление аренды.
+type extendLeaseRequest struct {
+	EndDate time.Time `json:"end_date" validate:"required"`
+}
+
+// parseLeaseFilter собирает LeaseFilter из query-параметров запроса.
+func parseLeaseFilter(r *http.Request) (models.LeaseFilter, error) {
+	q := r.URL.Query()
+	filter := models.LeaseFilter{Status: q.Get("status")}
+
+	uuidParams := map[string]**uuid.UUID{
+		"user_id":      &filter.UserID,
+		"region_id":    &filter.RegionID,
+		"structure_id": &filter.StructureID,
+	}
+	for name, dest := range uuidParams {
+		if v := q.Get(name); v != "" {
+			id, err := uuid.Parse(v)
+			if err != nil {
+				return filter, fmt.Errorf("invalid %s query parameter", name)
+			}
+			*dest = &id
+		}
+	}
+
+	dateParams := map[string]**time.Time{
+		"from": &filter.From,
+		"to":   &filter.To,
+	}
+	for name, dest := range dateParams {
+		if v := q.Get(name); v != "" {
+			t, err := time.Parse(dateLayout, v)
+			if err != nil {
+				return filter, fmt.Errorf("invalid %s query parameter, expected YYYY-MM-DD", name)
+			}
+			if name == "to" {
+				// Включаем весь последний день периода.
+				t = t.Add(24*time.Hour - time.Nanosecond)
+			}
+			*dest = &t
+		}
+	}
+
+	return filter, nil
+}
+
+// AdminGetLeases возвращает список аренд с фильтрами (status, region_id, structure_id, user_id, from, to).
+func (h *LeasingHandler) AdminGetLeases(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseLeaseFilter(r)
+	if err != nil {
+		api.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	leases, err := h.service.GetLeases(r.Context(), filter)
+	if err != nil {
+		h.logger.Errorf("ошибка при получении списка аренд: %v", err)
+		api.RespondWithError(w, "could not retrieve leases", http.StatusInternalServerError)
+		return
+	}
+
+	api.RespondWithJSON(h.logger, w, leases, http.StatusOK)
+}
+
+// AdminExportLeases выгружает отфильтрованный список аренд в формате CSV.
+func (h *LeasingHandler) AdminExportLeases(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseLeaseFilter(r)
+	if err != nil {
+		api.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	leases, err := h.service.GetLeases(r.Context(), filter)
+	if err != nil {
+		h.logger.Errorf("ошибка при экспорте аренд: %v", err)
+		api.RespondWithError(w, "could not export leases", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=leases_%s.csv", time.Now().Format(dateLayout)))
+	w.WriteHeader(http.StatusOK)
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"id", "user_id", "user_email", "unit_type", "unit_id", "unit_name", "structure_id", "region_id", "status", "start_date", "end_date", "created_at"})
+	for _, l := range leases {
+		cw.Write([]string{
+			l.ID.String(),
+			l.UserID.String(),
+			l.UserEmail,
+			string(l.UnitType),
+			l.UnitID.String(),
+			l.UnitName,
+			uuidOrEmpty(l.StructureID),
+			uuidOrEmpty(l.RegionID),
+			l.Status,
+			l.StartDate.Format(time.RFC3339),
+			l.EndDate.Format(time.RFC3339),
+			l.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		h.logger.Errorf("ошибка при записи CSV: %v", err)
+	}
+}
+
+// AdminCreateLease создает аренду от имени указанного пользователя.
+func (h *LeasingHandler) AdminCreateLease(w http.ResponseWriter, r *http.Request) {
+	var req adminCreateLeaseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.RespondWithError(w, "некорректное тело запроса", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		api.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	lease, err := h.service.CreateLease(r.Context(), req.UserID, req.UnitID, req.UnitType)
+	if err != nil {
+		h.respondWithServiceError(w, "ошибка при создании аренды администратором", err)
+		return
+	}
+
+	api.RespondWithJSON(h.logger, w, lease, http.StatusCreated)
+}
+
+// AdminTerminateLease принудительно завершает аренду.
+func (h *LeasingHandler) AdminTerminateLease(w http.ResponseWriter, r *http.Request) {
+	leaseID, err := uuid.Parse(chi.URLParam(r, "leaseID"))
+	if err != nil {
+		api.RespondWithError(w, "invalid lease ID in URL", http.StatusBadRequest)
+		return
+	}
+
+	lease, err := h.service.TerminateLease(r.Context(), leaseID)
+	if err != nil {
+		h.respondWithServiceError(w, "ошибка при завершении аренды", err)
+		return
+	}
+
+	api.RespondWithJSON(h.logger, w, lease, http.StatusOK)
+}
+
+// AdminExtendLease переносит дату окончания аренды.
+func (h *LeasingHandler) AdminExtendLease(w http.ResponseWriter, r *http.Request) {
+	leaseID, err := uuid.Parse(chi.URLParam(r, "leaseID"))
+	if err != nil {
+		api.RespondWithError(w, "invalid lease ID in URL", http.StatusBadRequest)
+		return
+	}
+
+	var req extendLeaseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.RespondWithError(w, "некорректное тело запроса", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		api.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	lease, err := h.service.ExtendLease(r.Context(), leaseID, req.EndDate)
+	if err != nil {
+		h.respondWithServiceError(w, "ошибка при продлении аренды", err)
+		return
+	}
+
+	api.RespondWithJSON(h.logger, w, lease, http.StatusOK)
+}
+
+func (h *LeasingHandler) respondWithServiceError(w http.ResponseWriter, msg string, err error) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		api.RespondWithError(w, "lease or unit not found", http.StatusNotFound)
+	case errors.Is(err, service.ErrUnknownUnitType), errors.Is(err, service.ErrInvalidEndDate):
+		api.RespondWithError(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, service.ErrUnitNotAvailable), errors.Is(err, service.ErrLeaseNotActive):
+		api.RespondWithError(w, err.Error(), http.StatusConflict)
+	default:
+		h.logger.Errorf("%s: %v", msg, err)
+		api.RespondWithError(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+func uuidOrEmpty(id *uuid.UUID) string {
+	if id == nil {
+		return ""
+	}
+	return id.String()
+}
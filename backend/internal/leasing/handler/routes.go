@@ -17,3 +17,22 @@ func (h *LeasingHandler) Routes() http.Handler {
 
 	return r
 }
+
+// AdminRoutes возвращает роутер для административного управления арендами.
+// Монтируется внутри группы /admin, защищенной AdminMiddleware.
+func (h *LeasingHandler) AdminRoutes() http.Handler {
+	r := chi.NewRouter()
+
+	// GET /admin/leases?status=&region_id=&structure_id=&user_id=&from=&to=
+	r.Get("/", h.AdminGetLeases)
+	// GET /admin/leases/export - те же фильтры, ответ в CSV
+	r.Get("/export", h.AdminExportLeases)
+	// POST /admin/leases - создать аренду от имени пользователя
+	r.Post("/", h.AdminCreateLease)
+	// POST /admin/leases/{leaseID}/terminate - принудительно завершить аренду
+	r.Post("/{leaseID}/terminate", h.AdminTerminateLease)
+	// POST /admin/leases/{leaseID}/extend - продлить аренду
+	r.Post("/{leaseID}/extend", h.AdminExtendLease)
+
+	return r
+}
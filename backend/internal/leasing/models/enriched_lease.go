@@ -3,6 +3,7 @@ package models
 import (
 	cameraModels "github.com/rendley/vegshare/backend/internal/camera/models"
 	catalogModels "github.com/rendley/vegshare/backend/internal/catalog/models"
+	coopModels "github.com/rendley/vegshare/backend/internal/coop/models"
 	plotModels "github.com/rendley/vegshare/backend/internal/plot/models"
 	"github.com/google/uuid"
 )
@@ -21,10 +22,17 @@ type EnrichedPlot struct {
 	Contents []EnrichedContent     `json:"contents"`
 }
 
+// EnrichedCoop представляет собой загон с привязанными к нему камерами и содержимым (животными).
+type EnrichedCoop struct {
+	coopModels.Coop
+	Cameras  []cameraModels.Camera `json:"cameras"`
+	Contents []EnrichedContent     `json:"contents"`
+}
+
 // EnrichedLease - это обогащенная модель аренды, которая включает в себя
-// полную информацию об арендованном юните (пока только грядке).
+// полную информацию об арендованном юните. Заполнено только поле, соответствующее UnitType.
 type EnrichedLease struct {
 	Lease
 	Plot *EnrichedPlot `json:"plot,omitempty"`
-	// В будущем здесь могут быть другие типы юнитов, например, Coop *EnrichedCoop
+	Coop *EnrichedCoop `json:"coop,omitempty"`
 }
@@ -11,8 +11,40 @@ type UnitType string
 const (
 	// UnitTypePlot определяет юнит типа "Грядка".
 	UnitTypePlot UnitType = "plot"
+	// UnitTypeCoop определяет юнит типа "Загон" для животных.
+	UnitTypeCoop UnitType = "coop"
 )
 
+// Статусы аренды.
+const (
+	LeaseStatusActive     = "active"
+	LeaseStatusTerminated = "terminated"
+)
+
+// DefaultTariff - тариф новой аренды. От тарифа зависят лимиты операций (quotas в конфиге).
+const DefaultTariff = "standard"
+
+// LeaseFilter описывает фильтры для административного списка аренд.
+// Пустые (nil) поля не участвуют в фильтрации.
+type LeaseFilter struct {
+	Status      string
+	UserID      *uuid.UUID
+	RegionID    *uuid.UUID
+	StructureID *uuid.UUID
+	// From и To задают период: в выборку попадают аренды, пересекающиеся с ним.
+	From *time.Time
+	To   *time.Time
+}
+
+// AdminLease - аренда с информацией о расположении юнита, используется в админ-консоли.
+type AdminLease struct {
+	Lease
+	UnitName    string     `db:"unit_name" json:"unit_name"`
+	StructureID *uuid.UUID `db:"structure_id" json:"structure_id"`
+	RegionID    *uuid.UUID `db:"region_id" json:"region_id"`
+	UserEmail   string     `db:"user_email" json:"user_email"`
+}
+
 // Lease представляет собой универсальную модель аренды для любого типа юнита.
 type Lease struct {
 	ID        uuid.UUID `db:"id" json:"id"`
@@ -22,6 +54,7 @@ type Lease struct {
 	StartDate time.Time `db:"start_date" json:"start_date"`
 	EndDate   time.Time `db:"end_date" json:"end_date"`
 	Status    string    `db:"status" json:"status"`
+	Tariff    string    `db:"tariff" json:"tariff"`
 	CreatedAt time.Time `db:"created_at" json:"created_at"`
 	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
 }
@@ -5,11 +5,13 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	cameraModels "github.com/rendley/vegshare/backend/internal/camera/models"
 	catalogModels "github.com/rendley/vegshare/backend/internal/catalog/models"
+	coopModels "github.com/rendley/vegshare/backend/internal/coop/models"
 	leaseModels "github.com/rendley/vegshare/backend/internal/leasing/models"
 	plotModels "github.com/rendley/vegshare/backend/internal/plot/models"
 	"github.com/rendley/vegshare/backend/pkg/database"
@@ -20,6 +22,9 @@ type Repository interface {
 	CreateLease(ctx context.Context, lease *leaseModels.Lease) error
 	GetEnrichedLeasesByUserID(ctx context.Context, userID uuid.UUID) ([]leaseModels.EnrichedLease, error)
 	GetLeasesByUserID(ctx context.Context, userID uuid.UUID) ([]leaseModels.Lease, error)
+	GetLeaseByID(ctx context.Context, leaseID uuid.UUID) (*leaseModels.Lease, error)
+	GetLeases(ctx context.Context, filter leaseModels.LeaseFilter) ([]leaseModels.AdminLease, error)
+	UpdateLease(ctx context.Context, lease *leaseModels.Lease) error
 }
 
 type repository struct {
@@ -31,8 +36,8 @@ func NewRepository(db database.DBTX) Repository {
 }
 
 func (r *repository) CreateLease(ctx context.Context, lease *leaseModels.Lease) error {
-	query := `INSERT INTO leases (id, unit_id, unit_type, user_id, start_date, end_date, status, created_at, updated_at) 
-	          VALUES (:id, :unit_id, :unit_type, :user_id, :start_date, :end_date, :status, :created_at, :updated_at)`
+	query := `INSERT INTO leases (id, unit_id, unit_type, user_id, start_date, end_date, status, tariff, created_at, updated_at) 
+	          VALUES (:id, :unit_id, :unit_type, :user_id, :start_date, :end_date, :status, :tariff, :created_at, :updated_at)`
 	_, err := r.db.NamedExecContext(ctx, query, lease)
 	if err != nil {
 		return fmt.Errorf("не удалось создать запись аренды: %w", err)
@@ -40,8 +45,24 @@ func (r *repository) CreateLease(ctx context.Context, lease *leaseModels.Lease)
 	return nil
 }
 
-// GetEnrichedLeasesByUserID получает список аренд пользователя с полной информацией о грядках и камерах.
+// GetEnrichedLeasesByUserID получает список аренд пользователя с полной информацией о юнитах и камерах.
+// Каждый тип юнита обогащается собственным запросом, результаты объединяются.
 func (r *repository) GetEnrichedLeasesByUserID(ctx context.Context, userID uuid.UUID) ([]leaseModels.EnrichedLease, error) {
+	plotLeases, err := r.getEnrichedPlotLeases(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	coopLeases, err := r.getEnrichedCoopLeases(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(plotLeases, coopLeases...), nil
+}
+
+// getEnrichedPlotLeases получает аренды грядок пользователя вместе с камерами и содержимым.
+func (r *repository) getEnrichedPlotLeases(ctx context.Context, userID uuid.UUID) ([]leaseModels.EnrichedLease, error) {
 	query := `
         SELECT
             l.id AS "id",
@@ -49,6 +70,7 @@ func (r *repository) GetEnrichedLeasesByUserID(ctx context.Context, userID uuid.
             l.start_date AS "start_date",
             l.end_date AS "end_date",
             l.status AS "status",
+            l.tariff AS "tariff",
             p.id AS "plot.id",
             p.name AS "plot.name",
             p.size AS "plot.size",
@@ -84,6 +106,7 @@ func (r *repository) GetEnrichedLeasesByUserID(ctx context.Context, userID uuid.
 		StartDate           time.Time      `db:"start_date"`
 		EndDate             time.Time      `db:"end_date"`
 		Status              string         `db:"status"`
+		Tariff              string         `db:"tariff"`
 		PlotID              uuid.UUID      `db:"plot.id"`
 		PlotName            string         `db:"plot.name"`
 		PlotSize            string         `db:"plot.size"`
@@ -116,6 +139,7 @@ func (r *repository) GetEnrichedLeasesByUserID(ctx context.Context, userID uuid.
 					StartDate: row.StartDate,
 					EndDate:   row.EndDate,
 					Status:    row.Status,
+					Tariff:    row.Tariff,
 				},
 				Plot: &leaseModels.EnrichedPlot{
 					Plot: plotModels.Plot{
@@ -164,6 +188,134 @@ func (r *repository) GetEnrichedLeasesByUserID(ctx context.Context, userID uuid.
 	return enrichedLeases, nil
 }
 
+// getEnrichedCoopLeases получает аренды загонов пользователя вместе с камерами и содержимым.
+func (r *repository) getEnrichedCoopLeases(ctx context.Context, userID uuid.UUID) ([]leaseModels.EnrichedLease, error) {
+	query := `
+        SELECT
+            l.id AS "id",
+            l.user_id AS "user_id",
+            l.start_date AS "start_date",
+            l.end_date AS "end_date",
+            l.status AS "status",
+            l.tariff AS "tariff",
+            c.id AS "coop.id",
+            c.name AS "coop.name",
+            c.capacity AS "coop.capacity",
+            c.animal_type AS "coop.animal_type",
+            c.status AS "coop.status",
+            cam.id AS "coop.cameras.id",
+            cam.name AS "coop.cameras.name",
+            cam.rtsp_path_name AS "coop.cameras.rtsp_path_name",
+            uc.id AS "coop.content.id",
+            uc.quantity AS "coop.content.quantity",
+            ci.id AS "coop.content.item.id",
+            ci.name AS "coop.content.item.name",
+            ci.item_type AS "coop.content.item.item_type",
+            ci.description AS "coop.content.item.description"
+        FROM
+            leases l
+        JOIN
+            coops c ON l.unit_id = c.id AND l.unit_type = 'coop'
+        LEFT JOIN
+            cameras cam ON c.id = cam.unit_id AND cam.unit_type = 'coop'
+        LEFT JOIN
+            unit_contents uc ON c.id = uc.unit_id AND uc.unit_type = 'coop'
+        LEFT JOIN
+            catalog_items ci ON uc.item_id = ci.id
+        WHERE
+            l.user_id = $1 AND l.status = 'active'
+        ORDER BY
+            l.created_at DESC, cam.created_at ASC;
+    `
+
+	type flatCoopLeaseData struct {
+		ID              uuid.UUID      `db:"id"`
+		UserID          uuid.UUID      `db:"user_id"`
+		StartDate       time.Time      `db:"start_date"`
+		EndDate         time.Time      `db:"end_date"`
+		Status          string         `db:"status"`
+		Tariff          string         `db:"tariff"`
+		CoopID          uuid.UUID      `db:"coop.id"`
+		CoopName        string         `db:"coop.name"`
+		CoopCapacity    int            `db:"coop.capacity"`
+		CoopAnimalType  string         `db:"coop.animal_type"`
+		CoopStatus      string         `db:"coop.status"`
+		CameraID        uuid.NullUUID  `db:"coop.cameras.id"`
+		CameraName      sql.NullString `db:"coop.cameras.name"`
+		CameraRTSPPath  sql.NullString `db:"coop.cameras.rtsp_path_name"`
+		ContentID       uuid.NullUUID  `db:"coop.content.id"`
+		ContentQuantity sql.NullInt64  `db:"coop.content.quantity"`
+		ContentItemID   uuid.NullUUID  `db:"coop.content.item.id"`
+		ContentItemName sql.NullString `db:"coop.content.item.name"`
+		ContentItemType sql.NullString `db:"coop.content.item.item_type"`
+		ContentItemDesc sql.NullString `db:"coop.content.item.description"`
+	}
+
+	var flatData []flatCoopLeaseData
+	if err := r.db.SelectContext(ctx, &flatData, query, userID); err != nil {
+		return nil, fmt.Errorf("не удалось получить обогащенный список аренд загонов: %w", err)
+	}
+
+	leasesMap := make(map[uuid.UUID]*leaseModels.EnrichedLease)
+	for _, row := range flatData {
+		if _, ok := leasesMap[row.ID]; !ok {
+			leasesMap[row.ID] = &leaseModels.EnrichedLease{
+				Lease: leaseModels.Lease{
+					ID:        row.ID,
+					UserID:    row.UserID,
+					UnitID:    row.CoopID,
+					UnitType:  leaseModels.UnitTypeCoop,
+					StartDate: row.StartDate,
+					EndDate:   row.EndDate,
+					Status:    row.Status,
+					Tariff:    row.Tariff,
+				},
+				Coop: &leaseModels.EnrichedCoop{
+					Coop: coopModels.Coop{
+						ID:         row.CoopID,
+						Name:       row.CoopName,
+						Capacity:   row.CoopCapacity,
+						AnimalType: row.CoopAnimalType,
+						Status:     row.CoopStatus,
+					},
+					Cameras:  []cameraModels.Camera{},
+					Contents: []leaseModels.EnrichedContent{},
+				},
+			}
+		}
+
+		lease := leasesMap[row.ID]
+
+		if row.CameraID.Valid && !isCameraInSlice(lease.Coop.Cameras, row.CameraID.UUID) {
+			lease.Coop.Cameras = append(lease.Coop.Cameras, cameraModels.Camera{
+				ID:           row.CameraID.UUID,
+				Name:         row.CameraName.String,
+				RTSPPathName: row.CameraRTSPPath.String,
+			})
+		}
+
+		if row.ContentID.Valid && !isContentInSlice(lease.Coop.Contents, row.ContentID.UUID) {
+			lease.Coop.Contents = append(lease.Coop.Contents, leaseModels.EnrichedContent{
+				ID:       row.ContentID.UUID,
+				Quantity: int(row.ContentQuantity.Int64),
+				Item: catalogModels.CatalogItem{
+					ID:          row.ContentItemID.UUID,
+					Name:        row.ContentItemName.String,
+					ItemType:    row.ContentItemType.String,
+					Description: row.ContentItemDesc.String,
+				},
+			})
+		}
+	}
+
+	enrichedLeases := make([]leaseModels.EnrichedLease, 0, len(leasesMap))
+	for _, lease := range leasesMap {
+		enrichedLeases = append(enrichedLeases, *lease)
+	}
+
+	return enrichedLeases, nil
+}
+
 func (r *repository) GetLeasesByUserID(ctx context.Context, userID uuid.UUID) ([]leaseModels.Lease, error) {
 	var leases []leaseModels.Lease
 	query := `SELECT * FROM leases WHERE user_id = $1 AND status = 'active'`
@@ -173,6 +325,83 @@ func (r *repository) GetLeasesByUserID(ctx context.Context, userID uuid.UUID) ([
 	return leases, nil
 }
 
+func (r *repository) GetLeaseByID(ctx context.Context, leaseID uuid.UUID) (*leaseModels.Lease, error) {
+	var lease leaseModels.Lease
+	query := `SELECT * FROM leases WHERE id = $1`
+	if err := r.db.GetContext(ctx, &lease, query, leaseID); err != nil {
+		return nil, fmt.Errorf("не удалось получить аренду по ID: %w", err)
+	}
+	return &lease, nil
+}
+
+// GetLeases возвращает список аренд с расположением юнитов, отфильтрованный по LeaseFilter.
+func (r *repository) GetLeases(ctx context.Context, filter leaseModels.LeaseFilter) ([]leaseModels.AdminLease, error) {
+	query := `
+        SELECT
+            l.*,
+            COALESCE(p.name, c.name, '') AS unit_name,
+            s.id AS structure_id,
+            lp.region_id AS region_id,
+            u.email AS user_email
+        FROM
+            leases l
+        JOIN
+            users u ON u.id = l.user_id
+        LEFT JOIN
+            plots p ON l.unit_type = 'plot' AND p.id = l.unit_id
+        LEFT JOIN
+            coops c ON l.unit_type = 'coop' AND c.id = l.unit_id
+        LEFT JOIN
+            structures s ON s.id = COALESCE(p.structure_id, c.structure_id)
+        LEFT JOIN
+            land_parcels lp ON lp.id = s.land_parcel_id`
+
+	var conditions []string
+	var args []interface{}
+	addCondition := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(cond, len(args)))
+	}
+
+	if filter.Status != "" {
+		addCondition("l.status = $%d", filter.Status)
+	}
+	if filter.UserID != nil {
+		addCondition("l.user_id = $%d", *filter.UserID)
+	}
+	if filter.StructureID != nil {
+		addCondition("s.id = $%d", *filter.StructureID)
+	}
+	if filter.RegionID != nil {
+		addCondition("lp.region_id = $%d", *filter.RegionID)
+	}
+	if filter.From != nil {
+		addCondition("l.end_date >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		addCondition("l.start_date <= $%d", *filter.To)
+	}
+
+	if len(conditions) > 0 {
+		query += "\n        WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += "\n        ORDER BY l.created_at DESC"
+
+	leases := []leaseModels.AdminLease{}
+	if err := r.db.SelectContext(ctx, &leases, query, args...); err != nil {
+		return nil, fmt.Errorf("не удалось получить список аренд: %w", err)
+	}
+	return leases, nil
+}
+
+func (r *repository) UpdateLease(ctx context.Context, lease *leaseModels.Lease) error {
+	query := `UPDATE leases SET status = :status, end_date = :end_date, updated_at = :updated_at WHERE id = :id`
+	if _, err := r.db.NamedExecContext(ctx, query, lease); err != nil {
+		return fmt.Errorf("не удалось обновить аренду: %w", err)
+	}
+	return nil
+}
+
 // Вспомогательные функции для дедупликации
 func isCameraInSlice(cameras []cameraModels.Camera, id uuid.UUID) bool {
 	for _, c := range cameras {
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -10,14 +11,32 @@ import (
 	"github.com/rendley/vegshare/backend/internal/leasing/domain"
 	"github.com/rendley/vegshare/backend/internal/leasing/models"
 	"github.com/rendley/vegshare/backend/internal/leasing/repository"
+	"github.com/rendley/vegshare/backend/pkg/database"
 )
 
+// ErrUnknownUnitType возвращается, если для типа юнита не зарегистрирован менеджер.
+var ErrUnknownUnitType = errors.New("менеджер для типа юнита не зарегистрирован")
+
+// ErrUnitNotAvailable возвращается при попытке арендовать занятый юнит.
+var ErrUnitNotAvailable = errors.New("юнит недоступен для аренды")
+
+// ErrLeaseNotActive возвращается при попытке завершить или продлить неактивную аренду.
+var ErrLeaseNotActive = errors.New("изменить можно только активную аренду")
+
+// ErrInvalidEndDate возвращается, если новая дата окончания не позже текущей.
+var ErrInvalidEndDate = errors.New("новая дата окончания должна быть позже текущей")
+
 // Service определяет контракт для бизнес-логики аренды.
 type Service interface {
 	CreateLease(ctx context.Context, userID, unitID uuid.UUID, unitType models.UnitType) (*models.Lease, error)
 	GetMyEnrichedLeases(ctx context.Context, userID uuid.UUID) ([]models.EnrichedLease, error)
 	GetLeasesByUserID(ctx context.Context, userID uuid.UUID) ([]models.Lease, error) // Added for internal use
 	RegisterUnitManager(unitType models.UnitType, manager domain.UnitManager)
+
+	// Методы админ-консоли
+	GetLeases(ctx context.Context, filter models.LeaseFilter) ([]models.AdminLease, error)
+	TerminateLease(ctx context.Context, leaseID uuid.UUID) (*models.Lease, error)
+	ExtendLease(ctx context.Context, leaseID uuid.UUID, endDate time.Time) (*models.Lease, error)
 }
 
 // service реализует Service.
@@ -25,6 +44,8 @@ type service struct {
 	db               *sqlx.DB
 	repo             repository.Repository
 	unitManagers     map[models.UnitType]domain.UnitManager
+	// newRepo создает репозиторий поверх транзакции; в тестах подменяется моком.
+	newRepo          func(db database.DBTX) repository.Repository
 }
 
 // NewLeasingService - конструктор для нового, универсального сервиса аренды.
@@ -33,6 +54,7 @@ func NewLeasingService(db *sqlx.DB, repo repository.Repository) Service {
 		db:               db,
 		repo:             repo,
 		unitManagers:     make(map[models.UnitType]domain.UnitManager),
+		newRepo:          repository.NewRepository,
 	}
 }
 
@@ -43,7 +65,7 @@ func (s *service) RegisterUnitManager(unitType models.UnitType, manager domain.U
 func (s *service) CreateLease(ctx context.Context, userID, unitID uuid.UUID, unitType models.UnitType) (*models.Lease, error) {
 	manager, ok := s.unitManagers[unitType]
 	if !ok {
-		return nil, fmt.Errorf("менеджер для типа юнита '%s' не зарегистрирован", unitType)
+		return nil, fmt.Errorf("%w: '%s'", ErrUnknownUnitType, unitType)
 	}
 
 	unit, err := manager.GetLeasableUnit(ctx, unitID)
@@ -51,7 +73,7 @@ func (s *service) CreateLease(ctx context.Context, userID, unitID uuid.UUID, uni
 		return nil, fmt.Errorf("юнит с ID %s не найден: %w", unitID, err)
 	}
 	if unit.GetStatus() != "available" {
-		return nil, fmt.Errorf("юнит %s недоступен для аренды", unitID)
+		return nil, fmt.Errorf("юнит %s: %w", unitID, ErrUnitNotAvailable)
 	}
 
 	now := time.Now()
@@ -63,6 +85,7 @@ func (s *service) CreateLease(ctx context.Context, userID, unitID uuid.UUID, uni
 		StartDate: now,
 		EndDate:   now.AddDate(0, 3, 0),
 		Status:    "active",
+		Tariff:    models.DefaultTariff,
 		CreatedAt: now,
 		UpdatedAt: now,
 	}
@@ -73,7 +96,7 @@ func (s *service) CreateLease(ctx context.Context, userID, unitID uuid.UUID, uni
 	}
 	defer tx.Rollback()
 
-	leasingRepoTx := repository.NewRepository(tx)
+	leasingRepoTx := s.newRepo(tx)
 	unitManagerTx := manager.WithTx(tx)
 
 	if err := leasingRepoTx.CreateLease(ctx, lease); err != nil {
@@ -98,4 +121,72 @@ func (s *service) GetMyEnrichedLeases(ctx context.Context, userID uuid.UUID) ([]
 
 func (s *service) GetLeasesByUserID(ctx context.Context, userID uuid.UUID) ([]models.Lease, error) {
 	return s.repo.GetLeasesByUserID(ctx, userID)
-}
\ No newline at end of file
+}
+
+func (s *service) GetLeases(ctx context.Context, filter models.LeaseFilter) ([]models.AdminLease, error) {
+	return s.repo.GetLeases(ctx, filter)
+}
+
+// TerminateLease принудительно завершает активную аренду и освобождает юнит.
+func (s *service) TerminateLease(ctx context.Context, leaseID uuid.UUID) (*models.Lease, error) {
+	lease, err := s.repo.GetLeaseByID(ctx, leaseID)
+	if err != nil {
+		return nil, fmt.Errorf("аренда с ID %s не найдена: %w", leaseID, err)
+	}
+	if lease.Status != models.LeaseStatusActive {
+		return nil, fmt.Errorf("%w, текущий статус: '%s'", ErrLeaseNotActive, lease.Status)
+	}
+
+	manager, ok := s.unitManagers[lease.UnitType]
+	if !ok {
+		return nil, fmt.Errorf("менеджер для типа юнита '%s' не зарегистрирован", lease.UnitType)
+	}
+
+	now := time.Now()
+	lease.Status = models.LeaseStatusTerminated
+	lease.EndDate = now
+	lease.UpdatedAt = now
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось начать транзакцию: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := s.newRepo(tx).UpdateLease(ctx, lease); err != nil {
+		return nil, err
+	}
+
+	if err := manager.WithTx(tx).UpdateUnitStatus(ctx, lease.UnitID, "available"); err != nil {
+		return nil, fmt.Errorf("не удалось обновить статус юнита: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("не удалось закоммитить транзакцию: %w", err)
+	}
+
+	return lease, nil
+}
+
+// ExtendLease переносит дату окончания активной аренды на более позднюю.
+func (s *service) ExtendLease(ctx context.Context, leaseID uuid.UUID, endDate time.Time) (*models.Lease, error) {
+	lease, err := s.repo.GetLeaseByID(ctx, leaseID)
+	if err != nil {
+		return nil, fmt.Errorf("аренда с ID %s не найдена: %w", leaseID, err)
+	}
+	if lease.Status != models.LeaseStatusActive {
+		return nil, fmt.Errorf("%w, текущий статус: '%s'", ErrLeaseNotActive, lease.Status)
+	}
+	if !endDate.After(lease.EndDate) {
+		return nil, fmt.Errorf("%w (%s)", ErrInvalidEndDate, lease.EndDate.Format(time.RFC3339))
+	}
+
+	lease.EndDate = endDate
+	lease.UpdatedAt = time.Now()
+
+	if err := s.repo.UpdateLease(ctx, lease); err != nil {
+		return nil, err
+	}
+
+	return lease, nil
+}
@@ -4,11 +4,15 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 	"github.com/rendley/vegshare/backend/internal/leasing/domain"
 	"github.com/rendley/vegshare/backend/internal/leasing/models"
+	"github.com/rendley/vegshare/backend/internal/leasing/repository"
+	"github.com/rendley/vegshare/backend/pkg/database"
+	"github.com/rendley/vegshare/backend/pkg/database/dbtest"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -40,6 +44,27 @@ func (m *MockLeasingRepository) GetLeasesByUserID(ctx context.Context, userID uu
 	return args.Get(0).([]models.Lease), args.Error(1)
 }
 
+func (m *MockLeasingRepository) GetLeaseByID(ctx context.Context, leaseID uuid.UUID) (*models.Lease, error) {
+	args := m.Called(ctx, leaseID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Lease), args.Error(1)
+}
+
+func (m *MockLeasingRepository) GetLeases(ctx context.Context, filter models.LeaseFilter) ([]models.AdminLease, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.AdminLease), args.Error(1)
+}
+
+func (m *MockLeasingRepository) UpdateLease(ctx context.Context, lease *models.Lease) error {
+	args := m.Called(ctx, lease)
+	return args.Error(0)
+}
+
 type MockUnitManager struct {
 	mock.Mock
 }
@@ -108,7 +133,7 @@ func TestLeasingService(t *testing.T) {
 			lease, err := leasingSvc.CreateLease(ctx, userID, unitID, models.UnitTypePlot)
 
 			assert.Error(t, err)
-			assert.Contains(t, err.Error(), "недоступен для аренды")
+			assert.ErrorIs(t, err, ErrUnitNotAvailable)
 			assert.Nil(t, lease)
 			mockUnitMgr.AssertExpectations(t)
 		})
@@ -121,7 +146,7 @@ func TestLeasingService(t *testing.T) {
 			lease, err := leasingSvc.CreateLease(ctx, userID, unitID, "non_existent_type")
 
 			assert.Error(t, err)
-			assert.Contains(t, err.Error(), "не зарегистрирован")
+			assert.ErrorIs(t, err, ErrUnknownUnitType)
 			assert.Nil(t, lease)
 		})
 	})
@@ -137,4 +162,85 @@ func TestLeasingService(t *testing.T) {
 		assert.Equal(t, expectedLeases, leases)
 		mockLeasingRepo.AssertExpectations(t)
 	})
+
+	t.Run("TerminateLease", func(t *testing.T) {
+		t.Run("Success", func(t *testing.T) {
+			db, stats := dbtest.New()
+			txSvc := NewLeasingService(db, mockLeasingRepo).(*service)
+			txSvc.RegisterUnitManager(models.UnitTypePlot, mockUnitMgr)
+			txSvc.newRepo = func(database.DBTX) repository.Repository { return mockLeasingRepo }
+
+			leaseID := uuid.New()
+			unitID := uuid.New()
+			lease := &models.Lease{ID: leaseID, UnitID: unitID, UnitType: models.UnitTypePlot, Status: models.LeaseStatusActive, EndDate: time.Now().AddDate(0, 1, 0)}
+			mockLeasingRepo.On("GetLeaseByID", ctx, leaseID).Return(lease, nil).Once()
+			mockLeasingRepo.On("UpdateLease", ctx, mock.MatchedBy(func(l *models.Lease) bool {
+				return l.ID == leaseID && l.Status == models.LeaseStatusTerminated
+			})).Return(nil).Once()
+			mockUnitMgr.On("UpdateUnitStatus", ctx, unitID, "available").Return(nil).Once()
+
+			result, err := txSvc.TerminateLease(ctx, leaseID)
+
+			assert.NoError(t, err)
+			assert.Equal(t, models.LeaseStatusTerminated, result.Status)
+			assert.False(t, result.EndDate.After(time.Now()))
+			assert.Equal(t, 1, stats.Committed())
+			mockLeasingRepo.AssertExpectations(t)
+			mockUnitMgr.AssertExpectations(t)
+		})
+
+		t.Run("Lease not active", func(t *testing.T) {
+			leaseID := uuid.New()
+			lease := &models.Lease{ID: leaseID, UnitType: models.UnitTypePlot, Status: models.LeaseStatusTerminated}
+			mockLeasingRepo.On("GetLeaseByID", ctx, leaseID).Return(lease, nil).Once()
+
+			result, err := leasingSvc.TerminateLease(ctx, leaseID)
+
+			assert.Error(t, err)
+			assert.ErrorIs(t, err, ErrLeaseNotActive)
+			assert.Nil(t, result)
+			mockLeasingRepo.AssertExpectations(t)
+		})
+
+		t.Run("Lease not found", func(t *testing.T) {
+			leaseID := uuid.New()
+			mockLeasingRepo.On("GetLeaseByID", ctx, leaseID).Return(nil, errors.New("not found")).Once()
+
+			result, err := leasingSvc.TerminateLease(ctx, leaseID)
+
+			assert.Error(t, err)
+			assert.Nil(t, result)
+			mockLeasingRepo.AssertExpectations(t)
+		})
+	})
+
+	t.Run("ExtendLease", func(t *testing.T) {
+		t.Run("Success", func(t *testing.T) {
+			leaseID := uuid.New()
+			currentEnd := time.Now().AddDate(0, 1, 0)
+			newEnd := currentEnd.AddDate(0, 2, 0)
+			lease := &models.Lease{ID: leaseID, Status: models.LeaseStatusActive, EndDate: currentEnd}
+			mockLeasingRepo.On("GetLeaseByID", ctx, leaseID).Return(lease, nil).Once()
+			mockLeasingRepo.On("UpdateLease", ctx, lease).Return(nil).Once()
+
+			result, err := leasingSvc.ExtendLease(ctx, leaseID, newEnd)
+
+			assert.NoError(t, err)
+			assert.Equal(t, newEnd, result.EndDate)
+			mockLeasingRepo.AssertExpectations(t)
+		})
+
+		t.Run("End date before current", func(t *testing.T) {
+			leaseID := uuid.New()
+			currentEnd := time.Now().AddDate(0, 1, 0)
+			lease := &models.Lease{ID: leaseID, Status: models.LeaseStatusActive, EndDate: currentEnd}
+			mockLeasingRepo.On("GetLeaseByID", ctx, leaseID).Return(lease, nil).Once()
+
+			result, err := leasingSvc.ExtendLease(ctx, leaseID, currentEnd.AddDate(0, 0, -1))
+
+			assert.ErrorIs(t, err, ErrInvalidEndDate)
+			assert.Nil(t, result)
+			mockLeasingRepo.AssertExpectations(t)
+		})
+	})
 }
@@ -0,0 +1,74 @@
+package bot
+
+import (
+	"context"
+	"time"
+
+	"github.com/rendley/vegshare/backend/internal/notification/service"
+	"github.com/rendley/vegshare/backend/pkg/config"
+	"github.com/rendley/vegshare/backend/pkg/telegram"
+	"github.com/sirupsen/logrus"
+)
+
+// Значения по умолчанию, если в конфиге секция telegram заполнена не полностью.
+const (
+	defaultPollTimeout = 30 * time.Second
+	// pollGrace - сколько ждать ответа на getUpdates сверх таймаута long polling.
+	pollGrace     = 10 * time.Second
+	retryDelay    = 5 * time.Second
+	updateTimeout = 30 * time.Second
+)
+
+// Updates - получение обновлений бота; реализуется telegram.Client.
+type Updates interface {
+	GetUpdates(ctx context.Context, offset int64, timeout time.Duration) ([]telegram.Update, error)
+}
+
+// Bot получает нажатия кнопок и команды long polling'ом и передает их сервису уведомлений.
+// Bot API отдает обновления только одному получателю, поэтому Bot запускается в одном процессе.
+type Bot struct {
+	updates Updates
+	service service.Service
+	logger  *logrus.Logger
+	cfg     config.TelegramConfig
+}
+
+// New - конструктор для Bot.
+func New(u Updates, s service.Service, logger *logrus.Logger, cfg config.TelegramConfig) *Bot {
+	if cfg.PollTimeout <= 0 {
+		cfg.PollTimeout = defaultPollTimeout
+	}
+	return &Bot{updates: u, service: s, logger: logger, cfg: cfg}
+}
+
+// Run получает обновления до отмены контекста. Обновление подтверждается (offset сдвигается)
+// даже при ошибке обработки: иначе одно сломанное нажатие блокировало бы все следующие.
+func (b *Bot) Run(ctx context.Context) {
+	var offset int64
+	for {
+		pollCtx, cancel := context.WithTimeout(ctx, b.cfg.PollTimeout+pollGrace)
+		updates, err := b.updates.GetUpdates(pollCtx, offset, b.cfg.PollTimeout)
+		cancel()
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			b.logger.Errorf("ошибка при получении обновлений Telegram: %v", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(retryDelay):
+			}
+			continue
+		}
+
+		for _, update := range updates {
+			updateCtx, cancel := context.WithTimeout(context.Background(), updateTimeout)
+			if err := b.service.HandleUpdate(updateCtx, update); err != nil {
+				b.logger.Errorf("ошибка при обработке обновления Telegram %d: %v", update.UpdateID, err)
+			}
+			cancel()
+			offset = update.UpdateID + 1
+		}
+	}
+}
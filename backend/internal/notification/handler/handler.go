@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/rendley/vegshare/backend/internal/notification/service"
+	"github.com/rendley/vegshare/backend/pkg/api"
+	"github.com/rendley/vegshare/backend/pkg/middleware"
+	"github.com/sirupsen/logrus"
+)
+
+// NotificationHandler обрабатывает HTTP-запросы к привязке Telegram-бота.
+type NotificationHandler struct {
+	service service.Service
+	logger  *logrus.Logger
+}
+
+// NewNotificationHandler - конструктор для NotificationHandler.
+func NewNotificationHandler(s service.Service, l *logrus.Logger) *NotificationHandler {
+	return &NotificationHandler{
+		service: s,
+		logger:  l,
+	}
+}
+
+// GetTelegramLink возвращает, привязан ли чат Telegram к текущему пользователю.
+func (h *NotificationHandler) GetTelegramLink(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		api.RespondWithError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	status, err := h.service.GetLinkStatus(r.Context(), userID)
+	if err != nil {
+		h.logger.Errorf("ошибка при получении привязки Telegram: %v", err)
+		api.RespondWithError(w, "could not retrieve telegram link", http.StatusInternalServerError)
+		return
+	}
+
+	api.RespondWithJSON(h.logger, w, status, http.StatusOK)
+}
+
+// CreateTelegramLinkCode выдает одноразовый код и ссылку на бота для привязки чата.
+func (h *NotificationHandler) CreateTelegramLinkCode(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		api.RespondWithError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	code, err := h.service.CreateLinkCode(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, service.ErrTelegramDisabled) {
+			api.RespondWithError(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		h.logger.Errorf("ошибка при создании кода привязки Telegram: %v", err)
+		api.RespondWithError(w, "could not create link code", http.StatusInternalServerError)
+		return
+	}
+
+	api.RespondWithJSON(h.logger, w, code, http.StatusCreated)
+}
+
+// DeleteTelegramLink отвязывает чат: уведомления перестают приходить.
+func (h *NotificationHandler) DeleteTelegramLink(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		api.RespondWithError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.service.Unlink(r.Context(), userID); err != nil {
+		h.logger.Errorf("ошибка при удалении привязки Telegram: %v", err)
+		api.RespondWithError(w, "could not delete telegram link", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
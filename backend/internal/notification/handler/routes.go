@@ -0,0 +1,21 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Routes возвращает роутер для привязки Telegram текущего пользователя.
+func (h *NotificationHandler) Routes() http.Handler {
+	r := chi.NewRouter()
+
+	// GET /notifications/telegram - привязан ли чат
+	r.Get("/telegram", h.GetTelegramLink)
+	// POST /notifications/telegram/link-code - одноразовый код и ссылка на бота
+	r.Post("/telegram/link-code", h.CreateTelegramLinkCode)
+	// DELETE /notifications/telegram - отвязать чат
+	r.Delete("/telegram", h.DeleteTelegramLink)
+
+	return r
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TelegramLink - привязка пользователя к чату с ботом. ChatID пуст, пока пользователь
+// не отправил боту код привязки.
+type TelegramLink struct {
+	UserID            uuid.UUID  `json:"user_id" db:"user_id"`
+	ChatID            *int64     `json:"chat_id" db:"chat_id"`
+	TelegramUserID    *int64     `json:"-" db:"telegram_user_id"`
+	LinkCode          *string    `json:"-" db:"link_code"`
+	LinkCodeExpiresAt *time.Time `json:"-" db:"link_code_expires_at"`
+	LinkedAt          *time.Time `json:"linked_at" db:"linked_at"`
+	CreatedAt         time.Time  `json:"-" db:"created_at"`
+}
+
+// LinkStatus - состояние привязки Telegram для текущего пользователя.
+type LinkStatus struct {
+	Linked   bool       `json:"linked"`
+	LinkedAt *time.Time `json:"linked_at,omitempty"`
+}
+
+// LinkCode - одноразовый код привязки и ссылка, открывающая чат с ботом с этим кодом.
+type LinkCode struct {
+	Code      string    `json:"code"`
+	URL       string    `json:"url,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Recipient - привязанный чат сотрудника.
+type Recipient struct {
+	UserID uuid.UUID `db:"user_id"`
+	ChatID int64     `db:"chat_id"`
+}
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rendley/vegshare/backend/internal/notification/models"
+	"github.com/rendley/vegshare/backend/pkg/database"
+)
+
+// Repository определяет контракт для хранилища привязок Telegram и отправленных уведомлений.
+type Repository interface {
+	GetLinkByUserID(ctx context.Context, userID uuid.UUID) (*models.TelegramLink, error)
+	// SaveLinkCode выдает пользователю новый код привязки; прежний код перестает действовать.
+	SaveLinkCode(ctx context.Context, userID uuid.UUID, code string, expiresAt time.Time) error
+	// LinkChat привязывает чат и пользователя Telegram к владельцу действующего кода и гасит код.
+	// Возвращает sql.ErrNoRows, если код неизвестен или истек.
+	LinkChat(ctx context.Context, code string, chatID, telegramUserID int64, now time.Time) (uuid.UUID, error)
+	// ReleaseChat снимает прежнюю привязку чата или пользователя Telegram.
+	ReleaseChat(ctx context.Context, chatID, telegramUserID int64) error
+	DeleteLink(ctx context.Context, userID uuid.UUID) error
+	// GetStaffIDByTelegramUserID возвращает сотрудника с ролью role, к которому привязан пользователь Telegram.
+	// Возвращает sql.ErrNoRows, если пользователь не привязан или привязан к пользователю с другой ролью.
+	GetStaffIDByTelegramUserID(ctx context.Context, telegramUserID int64, role string) (uuid.UUID, error)
+	// GetStaffRecipients возвращает привязанные чаты сотрудников с ролью role.
+	GetStaffRecipients(ctx context.Context, role string) ([]models.Recipient, error)
+	// ClaimTaskMessage резервирует отправку уведомления о задаче в чат.
+	// false - уведомление в этот чат уже отправлено или отправляется.
+	ClaimTaskMessage(ctx context.Context, taskID uuid.UUID, chatID int64) (bool, error)
+	SetTaskMessageID(ctx context.Context, taskID uuid.UUID, chatID, messageID int64) error
+	// ReleaseTaskMessage снимает резерв, если отправить уведомление не удалось.
+	ReleaseTaskMessage(ctx context.Context, taskID uuid.UUID, chatID int64) error
+}
+
+type repository struct {
+	db database.DBTX
+}
+
+// NewRepository - конструктор для репозитория уведомлений.
+func NewRepository(db database.DBTX) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) GetLinkByUserID(ctx context.Context, userID uuid.UUID) (*models.TelegramLink, error) {
+	var link models.TelegramLink
+	query := `SELECT * FROM telegram_links WHERE user_id = $1`
+	if err := r.db.GetContext(ctx, &link, query, userID); err != nil {
+		return nil, fmt.Errorf("не удалось получить привязку Telegram: %w", err)
+	}
+	return &link, nil
+}
+
+func (r *repository) SaveLinkCode(ctx context.Context, userID uuid.UUID, code string, expiresAt time.Time) error {
+	query := `INSERT INTO telegram_links (user_id, link_code, link_code_expires_at)
+	          VALUES ($1, $2, $3)
+	          ON CONFLICT (user_id) DO UPDATE SET link_code = EXCLUDED.link_code, link_code_expires_at = EXCLUDED.link_code_expires_at`
+	if _, err := r.db.ExecContext(ctx, query, userID, code, expiresAt); err != nil {
+		return fmt.Errorf("не удалось сохранить код привязки Telegram: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) LinkChat(ctx context.Context, code string, chatID, telegramUserID int64, now time.Time) (uuid.UUID, error) {
+	query := `UPDATE telegram_links
+	          SET chat_id = $2, telegram_user_id = $3, linked_at = $4, link_code = NULL, link_code_expires_at = NULL
+	          WHERE link_code = $1 AND link_code_expires_at > $4
+	          RETURNING user_id`
+	var userID uuid.UUID
+	if err := r.db.GetContext(ctx, &userID, query, code, chatID, telegramUserID, now); err != nil {
+		return uuid.Nil, fmt.Errorf("не удалось привязать чат Telegram: %w", err)
+	}
+	return userID, nil
+}
+
+func (r *repository) ReleaseChat(ctx context.Context, chatID, telegramUserID int64) error {
+	query := `UPDATE telegram_links SET chat_id = NULL, telegram_user_id = NULL, linked_at = NULL
+	          WHERE chat_id = $1 OR telegram_user_id = $2`
+	if _, err := r.db.ExecContext(ctx, query, chatID, telegramUserID); err != nil {
+		return fmt.Errorf("не удалось отвязать чат Telegram: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) DeleteLink(ctx context.Context, userID uuid.UUID) error {
+	query := `DELETE FROM telegram_links WHERE user_id = $1`
+	if _, err := r.db.ExecContext(ctx, query, userID); err != nil {
+		return fmt.Errorf("не удалось удалить привязку Telegram: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetStaffIDByTelegramUserID(ctx context.Context, telegramUserID int64, role string) (uuid.UUID, error) {
+	var userID uuid.UUID
+	query := `
+        SELECT tl.user_id
+        FROM telegram_links tl
+        JOIN users u ON u.id = tl.user_id
+        WHERE tl.telegram_user_id = $1 AND u.role = $2`
+	if err := r.db.GetContext(ctx, &userID, query, telegramUserID, role); err != nil {
+		return uuid.Nil, fmt.Errorf("не удалось найти пользователя по аккаунту Telegram: %w", err)
+	}
+	return userID, nil
+}
+
+func (r *repository) GetStaffRecipients(ctx context.Context, role string) ([]models.Recipient, error) {
+	recipients := []models.Recipient{}
+	query := `
+        SELECT tl.user_id, tl.chat_id
+        FROM telegram_links tl
+        JOIN users u ON u.id = tl.user_id
+        WHERE tl.chat_id IS NOT NULL AND u.role = $1`
+	if err := r.db.SelectContext(ctx, &recipients, query, role); err != nil {
+		return nil, fmt.Errorf("не удалось получить чаты сотрудников: %w", err)
+	}
+	return recipients, nil
+}
+
+func (r *repository) ClaimTaskMessage(ctx context.Context, taskID uuid.UUID, chatID int64) (bool, error) {
+	query := `INSERT INTO telegram_task_messages (task_id, chat_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+	res, err := r.db.ExecContext(ctx, query, taskID, chatID)
+	if err != nil {
+		return false, fmt.Errorf("не удалось зарезервировать уведомление о задаче: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("не удалось зарезервировать уведомление о задаче: %w", err)
+	}
+	return n > 0, nil
+}
+
+func (r *repository) SetTaskMessageID(ctx context.Context, taskID uuid.UUID, chatID, messageID int64) error {
+	query := `UPDATE telegram_task_messages SET message_id = $3 WHERE task_id = $1 AND chat_id = $2`
+	if _, err := r.db.ExecContext(ctx, query, taskID, chatID, messageID); err != nil {
+		return fmt.Errorf("не удалось сохранить уведомление о задаче: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) ReleaseTaskMessage(ctx context.Context, taskID uuid.UUID, chatID int64) error {
+	query := `DELETE FROM telegram_task_messages WHERE task_id = $1 AND chat_id = $2 AND message_id IS NULL`
+	if _, err := r.db.ExecContext(ctx, query, taskID, chatID); err != nil {
+		return fmt.Errorf("не удалось снять резерв уведомления о задаче: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,504 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/rendley/vegshare/backend/internal/notification/models"
+	"github.com/rendley/vegshare/backend/internal/notification/repository"
+	"github.com/rendley/vegshare/backend/internal/operations/actions"
+	taskModels "github.com/rendley/vegshare/backend/internal/task/models"
+	taskService "github.com/rendley/vegshare/backend/internal/task/service"
+	"github.com/rendley/vegshare/backend/pkg/config"
+	"github.com/rendley/vegshare/backend/pkg/telegram"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrTelegramDisabled возвращается, если бот не настроен (telegram.enabled = false).
+var ErrTelegramDisabled = errors.New("уведомления в Telegram отключены")
+
+// defaultLinkCodeTTL - срок действия кода привязки, если telegram.link_code_ttl не задан.
+const defaultLinkCodeTTL = 15 * time.Minute
+
+// staffRole - роль пользователей, которые выполняют задачи.
+const staffRole = "admin"
+
+// Действия inline-кнопок; callback_data кнопки - "<действие>:<ID задачи>".
+const (
+	callbackAccept   = "accept"
+	callbackComplete = "complete"
+)
+
+// reportActions - действия, которые завершаются только в приложении: исполнитель
+// сообщает в отчете фактический результат (например, вес урожая).
+var reportActions = map[string]bool{
+	actions.ActionHarvest: true,
+}
+
+// priorityLabels - подписи приоритетов в уведомлениях.
+var priorityLabels = map[string]string{
+	taskModels.PriorityUrgent: "срочно",
+	taskModels.PriorityHigh:   "высокий",
+	taskModels.PriorityNormal: "обычный",
+	taskModels.PriorityLow:    "низкий",
+}
+
+// failureLabels - подписи причин невыполнения задачи в уведомлениях арендатору.
+var failureLabels = map[string]string{
+	taskModels.FailureWeather:    "погода не позволяет выполнить работу",
+	taskModels.FailureOutOfStock: "нет нужных материалов",
+	taskModels.FailurePlotIssue:  "проблема с грядкой или строением",
+}
+
+// Sender - методы Bot API, которые нужны сервису; реализуется telegram.Client.
+type Sender interface {
+	SendMessage(ctx context.Context, chatID int64, text string, markup *telegram.InlineKeyboardMarkup) (*telegram.Message, error)
+	EditMessageReplyMarkup(ctx context.Context, chatID, messageID int64, markup *telegram.InlineKeyboardMarkup) error
+	AnswerCallbackQuery(ctx context.Context, callbackQueryID, text string) error
+}
+
+// Service определяет интерфейс уведомлений через Telegram-бота: персоналу о задачах, арендаторам о невыполненных действиях.
+type Service interface {
+	GetLinkStatus(ctx context.Context, userID uuid.UUID) (*models.LinkStatus, error)
+	// CreateLinkCode выдает одноразовый код, который пользователь отправляет боту, чтобы привязать чат.
+	CreateLinkCode(ctx context.Context, userID uuid.UUID) (*models.LinkCode, error)
+	Unlink(ctx context.Context, userID uuid.UUID) error
+	// NotifyTaskCreated присылает задачу по операции исполнителю, а если он не назначен -
+	// всем сотрудникам с привязанным чатом. Повторный вызов не отправляет уведомление второй раз.
+	NotifyTaskCreated(ctx context.Context, operationID uuid.UUID) error
+	// NotifyTaskEvent сообщает о событии из очереди task_notifications: о просроченной задаче - исполнителю,
+	// а если он не назначен, всем сотрудникам; о невыполненной - арендатору, если он привязал чат.
+	// События других типов пропускаются.
+	NotifyTaskEvent(ctx context.Context, event taskModels.TaskEvent) error
+	// HandleUpdate обрабатывает входящее обновление бота: команду /start с кодом привязки
+	// или нажатие кнопки "Взять в работу"/"Выполнено".
+	HandleUpdate(ctx context.Context, update telegram.Update) error
+}
+
+type service struct {
+	db      *sqlx.DB
+	repo    repository.Repository
+	taskSvc taskService.Service
+	sender  Sender
+	cfg     config.TelegramConfig
+	logger  *logrus.Logger
+}
+
+// NewService - конструктор для сервиса уведомлений.
+func NewService(db *sqlx.DB, repo repository.Repository, taskSvc taskService.Service, sender Sender, cfg config.TelegramConfig, logger *logrus.Logger) Service {
+	if cfg.LinkCodeTTL <= 0 {
+		cfg.LinkCodeTTL = defaultLinkCodeTTL
+	}
+	return &service{
+		db:      db,
+		repo:    repo,
+		taskSvc: taskSvc,
+		sender:  sender,
+		cfg:     cfg,
+		logger:  logger,
+	}
+}
+
+func (s *service) GetLinkStatus(ctx context.Context, userID uuid.UUID) (*models.LinkStatus, error) {
+	link, err := s.repo.GetLinkByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &models.LinkStatus{}, nil
+		}
+		return nil, err
+	}
+	return &models.LinkStatus{Linked: link.ChatID != nil, LinkedAt: link.LinkedAt}, nil
+}
+
+func (s *service) CreateLinkCode(ctx context.Context, userID uuid.UUID) (*models.LinkCode, error) {
+	if !s.cfg.Enabled {
+		return nil, ErrTelegramDisabled
+	}
+
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, fmt.Errorf("не удалось сгенерировать код привязки: %w", err)
+	}
+	code := hex.EncodeToString(buf)
+	expiresAt := time.Now().Add(s.cfg.LinkCodeTTL)
+
+	if err := s.repo.SaveLinkCode(ctx, userID, code, expiresAt); err != nil {
+		return nil, err
+	}
+
+	linkCode := &models.LinkCode{Code: code, ExpiresAt: expiresAt}
+	if s.cfg.BotUsername != "" {
+		linkCode.URL = "https://t.me/" + s.cfg.BotUsername + "?start=" + code
+	}
+	return linkCode, nil
+}
+
+func (s *service) Unlink(ctx context.Context, userID uuid.UUID) error {
+	return s.repo.DeleteLink(ctx, userID)
+}
+
+func (s *service) NotifyTaskCreated(ctx context.Context, operationID uuid.UUID) error {
+	if !s.cfg.Enabled {
+		return nil
+	}
+
+	page, err := s.taskSvc.GetTasks(ctx, taskModels.TaskFilter{OperationID: &operationID, Limit: 1})
+	if err != nil {
+		return err
+	}
+	if len(page.Items) == 0 {
+		// Операцию выполнило устройство - задачи персоналу нет.
+		return nil
+	}
+	task := page.Items[0]
+	if task.Status.IsClosed() {
+		return nil
+	}
+
+	recipients, err := s.recipients(ctx, task.AssigneeID)
+	if err != nil {
+		return err
+	}
+
+	text := taskMessage(task)
+	markup := buttons(callbackAccept, task.ID)
+	if task.Status == taskModels.StatusInProgress {
+		markup = completeButtons(task)
+	}
+
+	var errs []error
+	for _, rcpt := range recipients {
+		if err := s.sendTask(ctx, task.ID, rcpt.ChatID, text, markup); err != nil {
+			errs = append(errs, fmt.Errorf("чат пользователя %s: %w", rcpt.UserID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *service) NotifyTaskEvent(ctx context.Context, event taskModels.TaskEvent) error {
+	if !s.cfg.Enabled {
+		return nil
+	}
+	switch event.Type {
+	case taskModels.EventTaskOverdue:
+		return s.notifyOverdue(ctx, event)
+	case taskModels.EventTaskFailed:
+		return s.notifyFailed(ctx, event)
+	default:
+		return nil
+	}
+}
+
+// notifyOverdue напоминает о просроченной задаче ее текущему исполнителю. В отличие от новых задач,
+// отправленные напоминания не запоминаются, поэтому повторная доставка события может повторить напоминание.
+func (s *service) notifyOverdue(ctx context.Context, event taskModels.TaskEvent) error {
+	page, err := s.taskSvc.GetTasks(ctx, taskModels.TaskFilter{OperationID: &event.OperationID, Limit: 1})
+	if err != nil {
+		return err
+	}
+	if len(page.Items) == 0 {
+		return nil
+	}
+	task := page.Items[0]
+	if task.Status.IsClosed() {
+		// Задачу закрыли, пока событие шло по очереди.
+		return nil
+	}
+
+	recipients, err := s.recipients(ctx, task.AssigneeID)
+	if err != nil {
+		return err
+	}
+
+	text := overdueMessage(task)
+	var errs []error
+	for _, rcpt := range recipients {
+		if _, err := s.sender.SendMessage(ctx, rcpt.ChatID, text, nil); err != nil {
+			errs = append(errs, fmt.Errorf("чат пользователя %s: %w", rcpt.UserID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// notifyFailed сообщает арендатору, что его действие не выполнено, с причиной и комментарием исполнителя.
+// Как и напоминания, повторная доставка события может повторить сообщение.
+func (s *service) notifyFailed(ctx context.Context, event taskModels.TaskEvent) error {
+	if event.UserID == nil {
+		return nil
+	}
+	recipients, err := s.linkedChat(ctx, *event.UserID)
+	if err != nil || len(recipients) == 0 {
+		return err
+	}
+
+	page, err := s.taskSvc.GetTasks(ctx, taskModels.TaskFilter{OperationID: &event.OperationID, Limit: 1})
+	if err != nil {
+		return err
+	}
+	if len(page.Items) == 0 {
+		return nil
+	}
+
+	_, err = s.sender.SendMessage(ctx, recipients[0].ChatID, failedMessage(page.Items[0], event), nil)
+	return err
+}
+
+// recipients - кому отправить задачу: назначенному исполнителю или, если его нет, всем сотрудникам.
+func (s *service) recipients(ctx context.Context, assigneeID *uuid.UUID) ([]models.Recipient, error) {
+	if assigneeID == nil {
+		return s.repo.GetStaffRecipients(ctx, staffRole)
+	}
+	return s.linkedChat(ctx, *assigneeID)
+}
+
+// linkedChat возвращает привязанный чат пользователя или пустой список, если чат не привязан.
+func (s *service) linkedChat(ctx context.Context, userID uuid.UUID) ([]models.Recipient, error) {
+	link, err := s.repo.GetLinkByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if link.ChatID == nil {
+		return nil, nil
+	}
+	return []models.Recipient{{UserID: link.UserID, ChatID: *link.ChatID}}, nil
+}
+
+// sendTask отправляет уведомление в чат, если оно еще не отправлено. При ошибке отправки
+// резерв снимается, чтобы повторная доставка сообщения воркеру смогла отправить его снова.
+func (s *service) sendTask(ctx context.Context, taskID uuid.UUID, chatID int64, text string, markup *telegram.InlineKeyboardMarkup) error {
+	claimed, err := s.repo.ClaimTaskMessage(ctx, taskID, chatID)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return nil
+	}
+
+	msg, err := s.sender.SendMessage(ctx, chatID, text, markup)
+	if err != nil {
+		if releaseErr := s.repo.ReleaseTaskMessage(ctx, taskID, chatID); releaseErr != nil {
+			s.logger.Errorf("ошибка при снятии резерва уведомления о задаче %s: %v", taskID, releaseErr)
+		}
+		return err
+	}
+	return s.repo.SetTaskMessageID(ctx, taskID, chatID, msg.MessageID)
+}
+
+func taskMessage(task taskModels.TaskListItem) string {
+	var b strings.Builder
+	b.WriteString("Новая задача: " + task.Title)
+	priority, ok := priorityLabels[task.Priority]
+	if !ok {
+		priority = task.Priority
+	}
+	b.WriteString("\nПриоритет: " + priority)
+	if task.DueAt != nil {
+		b.WriteString("\nСрок: " + task.DueAt.Local().Format("02.01.2006 15:04"))
+	}
+	if task.LocationPath != "" {
+		b.WriteString("\nГде: " + task.LocationPath)
+	}
+	if task.Description != nil && *task.Description != "" {
+		b.WriteString("\n\n" + *task.Description)
+	}
+	return b.String()
+}
+
+func overdueMessage(task taskModels.TaskListItem) string {
+	var b strings.Builder
+	b.WriteString("Задача просрочена: " + task.Title)
+	if task.DueAt != nil {
+		b.WriteString("\nСрок был: " + task.DueAt.Local().Format("02.01.2006 15:04"))
+	}
+	if task.LocationPath != "" {
+		b.WriteString("\nГде: " + task.LocationPath)
+	}
+	return b.String()
+}
+
+func failedMessage(task taskModels.TaskListItem, event taskModels.TaskEvent) string {
+	var b strings.Builder
+	b.WriteString("Не удалось выполнить: " + task.Title)
+	if task.LocationPath != "" {
+		b.WriteString("\nГде: " + task.LocationPath)
+	}
+	reason, ok := failureLabels[event.FailureReason]
+	if !ok {
+		reason = event.FailureReason
+	}
+	b.WriteString("\nПричина: " + reason)
+	if event.FailureComment != "" {
+		b.WriteString("\n\n" + event.FailureComment)
+	}
+	b.WriteString("\n\nПовторить действие можно в истории операций.")
+	return b.String()
+}
+
+// completeButtons - кнопка "Выполнено" для задачи в работе; nil, если результат нужно внести в приложении.
+func completeButtons(task taskModels.TaskListItem) *telegram.InlineKeyboardMarkup {
+	if reportActions[task.ActionType] {
+		return nil
+	}
+	return buttons(callbackComplete, task.ID)
+}
+
+func buttons(action string, taskID uuid.UUID) *telegram.InlineKeyboardMarkup {
+	text := "Взять в работу"
+	if action == callbackComplete {
+		text = "Выполнено"
+	}
+	return &telegram.InlineKeyboardMarkup{InlineKeyboard: [][]telegram.InlineKeyboardButton{{
+		{Text: text, CallbackData: action + ":" + taskID.String()},
+	}}}
+}
+
+func (s *service) HandleUpdate(ctx context.Context, update telegram.Update) error {
+	switch {
+	case update.CallbackQuery != nil:
+		return s.handleCallback(ctx, update.CallbackQuery)
+	case update.Message != nil:
+		return s.handleMessage(ctx, update.Message)
+	}
+	return nil
+}
+
+func (s *service) handleMessage(ctx context.Context, msg *telegram.Message) error {
+	command, code, _ := strings.Cut(strings.TrimSpace(msg.Text), " ")
+	if command != "/start" {
+		return s.reply(ctx, msg.Chat.ID, "Я присылаю уведомления VegShare. Чтобы получать их, привяжите чат в профиле.")
+	}
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return s.reply(ctx, msg.Chat.ID, "Откройте ссылку привязки из профиля или отправьте /start <код>.")
+	}
+
+	// Уведомления о задачах личные, а кнопки проверяются по пользователю Telegram,
+	// поэтому групповые чаты не привязываются.
+	if msg.Chat.Type != telegram.ChatTypePrivate || msg.From == nil {
+		return s.reply(ctx, msg.Chat.ID, "Привязать можно только личный чат с ботом.")
+	}
+
+	if _, err := s.linkChat(ctx, code, msg.Chat.ID, msg.From.ID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return s.reply(ctx, msg.Chat.ID, "Код привязки неверный или устарел. Получите новый в профиле.")
+		}
+		return err
+	}
+	return s.reply(ctx, msg.Chat.ID, "Чат привязан. Сюда будут приходить уведомления.")
+}
+
+// linkChat привязывает чат и пользователя Telegram к владельцу кода. Если они были привязаны
+// к другому пользователю, то переходят к новому в той же транзакции.
+func (s *service) linkChat(ctx context.Context, code string, chatID, telegramUserID int64) (uuid.UUID, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("не удалось начать транзакцию: %w", err)
+	}
+	defer tx.Rollback()
+
+	repoTx := repository.NewRepository(tx)
+	if err := repoTx.ReleaseChat(ctx, chatID, telegramUserID); err != nil {
+		return uuid.Nil, err
+	}
+	userID, err := repoTx.LinkChat(ctx, code, chatID, telegramUserID, time.Now())
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return uuid.Nil, fmt.Errorf("не удалось закоммитить транзакцию: %w", err)
+	}
+	return userID, nil
+}
+
+func (s *service) reply(ctx context.Context, chatID int64, text string) error {
+	_, err := s.sender.SendMessage(ctx, chatID, text, nil)
+	return err
+}
+
+func (s *service) handleCallback(ctx context.Context, cq *telegram.CallbackQuery) error {
+	action, rawID, _ := strings.Cut(cq.Data, ":")
+	taskID, err := uuid.Parse(rawID)
+	if err != nil || (action != callbackAccept && action != callbackComplete) {
+		return s.sender.AnswerCallbackQuery(ctx, cq.ID, "Неизвестная кнопка")
+	}
+
+	// Кнопку нажимает пользователь Telegram, а не чат: проверяем того, кто нажал.
+	// Арендаторы тоже привязывают чат, но брать и закрывать задачи могут только сотрудники.
+	userID, err := s.repo.GetStaffIDByTelegramUserID(ctx, cq.From.ID, staffRole)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return s.sender.AnswerCallbackQuery(ctx, cq.ID, "Аккаунт Telegram не привязан к сотруднику")
+		}
+		return s.answerFailure(ctx, cq, err)
+	}
+
+	var answer string
+	var next *telegram.InlineKeyboardMarkup
+	switch action {
+	case callbackAccept:
+		var task *taskModels.Task
+		task, err = s.taskSvc.AcceptTask(ctx, taskID, userID)
+		if err == nil {
+			answer = "Задача взята в работу"
+			next = s.nextButtons(ctx, task)
+			if next == nil {
+				answer += ". Завершите ее в приложении"
+			}
+		}
+	case callbackComplete:
+		_, err = s.taskSvc.CompleteTask(ctx, taskID, userID, taskService.CompletionDetails{}, taskService.Evidence{})
+		answer = "Задача выполнена"
+	}
+	if err != nil {
+		if errors.Is(err, taskService.ErrAssignedToAnother) || errors.Is(err, taskService.ErrInvalidTransition) {
+			// Задачу взял другой сотрудник или она уже закрыта - кнопки больше не нужны.
+			s.editButtons(ctx, cq, nil)
+			return s.sender.AnswerCallbackQuery(ctx, cq.ID, err.Error())
+		}
+		return s.answerFailure(ctx, cq, err)
+	}
+
+	s.editButtons(ctx, cq, next)
+	return s.sender.AnswerCallbackQuery(ctx, cq.ID, answer)
+}
+
+// nextButtons - кнопки под сообщением после того, как задачу взяли в работу. Если тип действия
+// узнать не удалось, кнопка не показывается: задачу можно завершить в приложении.
+func (s *service) nextButtons(ctx context.Context, task *taskModels.Task) *telegram.InlineKeyboardMarkup {
+	page, err := s.taskSvc.GetTasks(ctx, taskModels.TaskFilter{OperationID: &task.OperationID, Limit: 1})
+	if err != nil || len(page.Items) == 0 {
+		s.logger.Warnf("не удалось получить задачу %s для кнопок: %v", task.ID, err)
+		return nil
+	}
+	return completeButtons(page.Items[0])
+}
+
+// answerFailure сообщает сотруднику, что действие не выполнено, и возвращает исходную ошибку для лога.
+func (s *service) answerFailure(ctx context.Context, cq *telegram.CallbackQuery, cause error) error {
+	if err := s.sender.AnswerCallbackQuery(ctx, cq.ID, "Не удалось выполнить действие, попробуйте в приложении"); err != nil {
+		s.logger.Errorf("ошибка при ответе на нажатие кнопки: %v", err)
+	}
+	return cause
+}
+
+// editButtons меняет кнопки под сообщением с задачей. Ошибка не критична: действие уже выполнено.
+func (s *service) editButtons(ctx context.Context, cq *telegram.CallbackQuery, markup *telegram.InlineKeyboardMarkup) {
+	if cq.Message == nil {
+		return
+	}
+	if err := s.sender.EditMessageReplyMarkup(ctx, cq.Message.Chat.ID, cq.Message.MessageID, markup); err != nil {
+		s.logger.Warnf("не удалось обновить кнопки сообщения о задаче: %v", err)
+	}
+}
@@ -0,0 +1,464 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rendley/vegshare/backend/internal/notification/models"
+	"github.com/rendley/vegshare/backend/internal/notification/repository"
+	taskModels "github.com/rendley/vegshare/backend/internal/task/models"
+	taskService "github.com/rendley/vegshare/backend/internal/task/service"
+	"github.com/rendley/vegshare/backend/pkg/config"
+	"github.com/rendley/vegshare/backend/pkg/telegram"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// --- Mocks ---
+
+type MockRepository struct {
+	mock.Mock
+}
+
+var _ repository.Repository = &MockRepository{}
+
+func (m *MockRepository) GetLinkByUserID(ctx context.Context, userID uuid.UUID) (*models.TelegramLink, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TelegramLink), args.Error(1)
+}
+
+func (m *MockRepository) SaveLinkCode(ctx context.Context, userID uuid.UUID, code string, expiresAt time.Time) error {
+	args := m.Called(ctx, userID, code, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockRepository) LinkChat(ctx context.Context, code string, chatID, telegramUserID int64, now time.Time) (uuid.UUID, error) {
+	args := m.Called(ctx, code, chatID, telegramUserID, now)
+	return args.Get(0).(uuid.UUID), args.Error(1)
+}
+
+func (m *MockRepository) ReleaseChat(ctx context.Context, chatID, telegramUserID int64) error {
+	args := m.Called(ctx, chatID, telegramUserID)
+	return args.Error(0)
+}
+
+func (m *MockRepository) DeleteLink(ctx context.Context, userID uuid.UUID) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetStaffIDByTelegramUserID(ctx context.Context, telegramUserID int64, role string) (uuid.UUID, error) {
+	args := m.Called(ctx, telegramUserID, role)
+	return args.Get(0).(uuid.UUID), args.Error(1)
+}
+
+func (m *MockRepository) GetStaffRecipients(ctx context.Context, role string) ([]models.Recipient, error) {
+	args := m.Called(ctx, role)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Recipient), args.Error(1)
+}
+
+func (m *MockRepository) ClaimTaskMessage(ctx context.Context, taskID uuid.UUID, chatID int64) (bool, error) {
+	args := m.Called(ctx, taskID, chatID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) SetTaskMessageID(ctx context.Context, taskID uuid.UUID, chatID, messageID int64) error {
+	args := m.Called(ctx, taskID, chatID, messageID)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ReleaseTaskMessage(ctx context.Context, taskID uuid.UUID, chatID int64) error {
+	args := m.Called(ctx, taskID, chatID)
+	return args.Error(0)
+}
+
+// MockTaskService реализует только методы, которые вызывает сервис уведомлений;
+// вызов остальных методов встроенного интерфейса паникует.
+type MockTaskService struct {
+	taskService.Service
+	mock.Mock
+}
+
+func (m *MockTaskService) GetTasks(ctx context.Context, filter taskModels.TaskFilter) (*taskModels.TaskPage, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*taskModels.TaskPage), args.Error(1)
+}
+
+func (m *MockTaskService) AcceptTask(ctx context.Context, taskID, userID uuid.UUID) (*taskModels.Task, error) {
+	args := m.Called(ctx, taskID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*taskModels.Task), args.Error(1)
+}
+
+func (m *MockTaskService) CompleteTask(ctx context.Context, taskID, userID uuid.UUID, details taskService.CompletionDetails, evidence taskService.Evidence) (*taskModels.Task, error) {
+	args := m.Called(ctx, taskID, userID, details, evidence)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*taskModels.Task), args.Error(1)
+}
+
+type MockSender struct {
+	mock.Mock
+}
+
+var _ Sender = &MockSender{}
+
+func (m *MockSender) SendMessage(ctx context.Context, chatID int64, text string, markup *telegram.InlineKeyboardMarkup) (*telegram.Message, error) {
+	args := m.Called(ctx, chatID, text, markup)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*telegram.Message), args.Error(1)
+}
+
+func (m *MockSender) EditMessageReplyMarkup(ctx context.Context, chatID, messageID int64, markup *telegram.InlineKeyboardMarkup) error {
+	args := m.Called(ctx, chatID, messageID, markup)
+	return args.Error(0)
+}
+
+func (m *MockSender) AnswerCallbackQuery(ctx context.Context, callbackQueryID, text string) error {
+	args := m.Called(ctx, callbackQueryID, text)
+	return args.Error(0)
+}
+
+// --- Tests ---
+
+func TestNotificationService(t *testing.T) {
+	ctx := context.Background()
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	cfg := config.TelegramConfig{Enabled: true, BotUsername: "vegshare_bot"}
+
+	setup := func() (Service, *MockRepository, *MockTaskService, *MockSender) {
+		repo := new(MockRepository)
+		tasks := new(MockTaskService)
+		sender := new(MockSender)
+		return NewService(nil, repo, tasks, sender, cfg, logger), repo, tasks, sender
+	}
+
+	taskPage := func(task taskModels.TaskListItem) *taskModels.TaskPage {
+		return &taskModels.TaskPage{Items: []taskModels.TaskListItem{task}}
+	}
+
+	t.Run("CreateLinkCode returns a one-time code and a deep link to the bot", func(t *testing.T) {
+		svc, repo, _, _ := setup()
+		userID := uuid.New()
+		repo.On("SaveLinkCode", ctx, userID, mock.AnythingOfType("string"), mock.AnythingOfType("time.Time")).Return(nil)
+
+		code, err := svc.CreateLinkCode(ctx, userID)
+
+		require.NoError(t, err)
+		assert.Len(t, code.Code, 32)
+		assert.Equal(t, "https://t.me/vegshare_bot?start="+code.Code, code.URL)
+		assert.WithinDuration(t, time.Now().Add(defaultLinkCodeTTL), code.ExpiresAt, time.Minute)
+		repo.AssertCalled(t, "SaveLinkCode", ctx, userID, code.Code, code.ExpiresAt)
+	})
+
+	t.Run("CreateLinkCode fails when the bot is disabled", func(t *testing.T) {
+		svc := NewService(nil, new(MockRepository), new(MockTaskService), new(MockSender), config.TelegramConfig{}, logger)
+
+		_, err := svc.CreateLinkCode(ctx, uuid.New())
+
+		assert.ErrorIs(t, err, ErrTelegramDisabled)
+	})
+
+	t.Run("GetLinkStatus reports not linked when the user never linked a chat", func(t *testing.T) {
+		svc, repo, _, _ := setup()
+		userID := uuid.New()
+		repo.On("GetLinkByUserID", ctx, userID).Return(nil, sql.ErrNoRows)
+
+		status, err := svc.GetLinkStatus(ctx, userID)
+
+		require.NoError(t, err)
+		assert.False(t, status.Linked)
+	})
+
+	t.Run("Unassigned task is sent to every linked staff member with an accept button", func(t *testing.T) {
+		svc, repo, tasks, sender := setup()
+		opID := uuid.New()
+		task := taskModels.TaskListItem{
+			Task:         taskModels.Task{ID: uuid.New(), Status: taskModels.StatusNew, Title: "Полить грядку", Priority: taskModels.PriorityUrgent},
+			LocationPath: "Север / Участок 1 / Теплица / Грядка 3",
+		}
+		tasks.On("GetTasks", ctx, taskModels.TaskFilter{OperationID: &opID, Limit: 1}).Return(taskPage(task), nil)
+		repo.On("GetStaffRecipients", ctx, "admin").Return([]models.Recipient{
+			{UserID: uuid.New(), ChatID: 100},
+			{UserID: uuid.New(), ChatID: 200},
+		}, nil)
+		repo.On("ClaimTaskMessage", ctx, task.ID, int64(100)).Return(true, nil)
+		// Повторная доставка: во второй чат уведомление уже отправлено.
+		repo.On("ClaimTaskMessage", ctx, task.ID, int64(200)).Return(false, nil)
+		sender.On("SendMessage", ctx, int64(100), mock.AnythingOfType("string"), buttons(callbackAccept, task.ID)).
+			Return(&telegram.Message{MessageID: 7}, nil)
+		repo.On("SetTaskMessageID", ctx, task.ID, int64(100), int64(7)).Return(nil)
+
+		require.NoError(t, svc.NotifyTaskCreated(ctx, opID))
+
+		sender.AssertNumberOfCalls(t, "SendMessage", 1)
+		text := sender.Calls[0].Arguments.String(2)
+		assert.Contains(t, text, "Полить грядку")
+		assert.Contains(t, text, "срочно")
+		assert.Contains(t, text, "Север / Участок 1 / Теплица / Грядка 3")
+	})
+
+	t.Run("Assigned task is sent only to the assignee", func(t *testing.T) {
+		svc, repo, tasks, sender := setup()
+		opID := uuid.New()
+		assigneeID := uuid.New()
+		chatID := int64(300)
+		task := taskModels.TaskListItem{Task: taskModels.Task{ID: uuid.New(), Status: taskModels.StatusNew, AssigneeID: &assigneeID}}
+		tasks.On("GetTasks", ctx, mock.Anything).Return(taskPage(task), nil)
+		repo.On("GetLinkByUserID", ctx, assigneeID).Return(&models.TelegramLink{UserID: assigneeID, ChatID: &chatID}, nil)
+		repo.On("ClaimTaskMessage", ctx, task.ID, chatID).Return(true, nil)
+		sender.On("SendMessage", ctx, chatID, mock.Anything, mock.Anything).Return(&telegram.Message{MessageID: 8}, nil)
+		repo.On("SetTaskMessageID", ctx, task.ID, chatID, int64(8)).Return(nil)
+
+		require.NoError(t, svc.NotifyTaskCreated(ctx, opID))
+
+		repo.AssertNotCalled(t, "GetStaffRecipients", mock.Anything, mock.Anything)
+		sender.AssertNumberOfCalls(t, "SendMessage", 1)
+	})
+
+	t.Run("Failed send releases the claim so a redelivery can retry", func(t *testing.T) {
+		svc, repo, tasks, sender := setup()
+		task := taskModels.TaskListItem{Task: taskModels.Task{ID: uuid.New(), Status: taskModels.StatusNew}}
+		tasks.On("GetTasks", ctx, mock.Anything).Return(taskPage(task), nil)
+		repo.On("GetStaffRecipients", ctx, "admin").Return([]models.Recipient{{UserID: uuid.New(), ChatID: 100}}, nil)
+		repo.On("ClaimTaskMessage", ctx, task.ID, int64(100)).Return(true, nil)
+		sender.On("SendMessage", ctx, int64(100), mock.Anything, mock.Anything).Return(nil, errors.New("timeout"))
+		repo.On("ReleaseTaskMessage", ctx, task.ID, int64(100)).Return(nil)
+
+		err := svc.NotifyTaskCreated(ctx, uuid.New())
+
+		assert.Error(t, err)
+		repo.AssertCalled(t, "ReleaseTaskMessage", ctx, task.ID, int64(100))
+		repo.AssertNotCalled(t, "SetTaskMessageID", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Nothing is sent when the operation was automated and has no task", func(t *testing.T) {
+		svc, repo, tasks, sender := setup()
+		tasks.On("GetTasks", ctx, mock.Anything).Return(&taskModels.TaskPage{Items: []taskModels.TaskListItem{}}, nil)
+
+		require.NoError(t, svc.NotifyTaskCreated(ctx, uuid.New()))
+
+		repo.AssertNotCalled(t, "GetStaffRecipients", mock.Anything, mock.Anything)
+		sender.AssertNotCalled(t, "SendMessage", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Overdue task reminds its assignee without buttons", func(t *testing.T) {
+		svc, repo, tasks, sender := setup()
+		assigneeID := uuid.New()
+		chatID := int64(300)
+		dueAt := time.Now().Add(-time.Hour)
+		task := taskModels.TaskListItem{Task: taskModels.Task{ID: uuid.New(), OperationID: uuid.New(), Status: taskModels.StatusInProgress, Title: "Полить грядку", AssigneeID: &assigneeID, DueAt: &dueAt}}
+		event := taskModels.TaskEvent{Type: taskModels.EventTaskOverdue, TaskID: task.ID, OperationID: task.OperationID, AssigneeID: &assigneeID}
+		tasks.On("GetTasks", ctx, taskModels.TaskFilter{OperationID: &task.OperationID, Limit: 1}).Return(taskPage(task), nil)
+		repo.On("GetLinkByUserID", ctx, assigneeID).Return(&models.TelegramLink{UserID: assigneeID, ChatID: &chatID}, nil)
+		sender.On("SendMessage", ctx, chatID, mock.AnythingOfType("string"), (*telegram.InlineKeyboardMarkup)(nil)).Return(&telegram.Message{MessageID: 9}, nil)
+
+		require.NoError(t, svc.NotifyTaskEvent(ctx, event))
+
+		sender.AssertNumberOfCalls(t, "SendMessage", 1)
+		text := sender.Calls[0].Arguments.String(2)
+		assert.Contains(t, text, "Задача просрочена: Полить грядку")
+		repo.AssertNotCalled(t, "ClaimTaskMessage", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Overdue unassigned task reminds every linked staff member", func(t *testing.T) {
+		svc, repo, tasks, sender := setup()
+		task := taskModels.TaskListItem{Task: taskModels.Task{ID: uuid.New(), OperationID: uuid.New(), Status: taskModels.StatusNew, Title: "Собрать яйца"}}
+		tasks.On("GetTasks", ctx, mock.Anything).Return(taskPage(task), nil)
+		repo.On("GetStaffRecipients", ctx, "admin").Return([]models.Recipient{
+			{UserID: uuid.New(), ChatID: 100},
+			{UserID: uuid.New(), ChatID: 200},
+		}, nil)
+		sender.On("SendMessage", ctx, int64(100), mock.Anything, mock.Anything).Return(&telegram.Message{MessageID: 1}, nil)
+		sender.On("SendMessage", ctx, int64(200), mock.Anything, mock.Anything).Return(nil, errors.New("timeout"))
+
+		err := svc.NotifyTaskEvent(ctx, taskModels.TaskEvent{Type: taskModels.EventTaskOverdue, TaskID: task.ID, OperationID: task.OperationID})
+
+		// Ошибка возвращается, чтобы воркер повторил событие.
+		assert.Error(t, err)
+		sender.AssertNumberOfCalls(t, "SendMessage", 2)
+	})
+
+	t.Run("Overdue event of a task closed meanwhile is skipped", func(t *testing.T) {
+		svc, repo, tasks, sender := setup()
+		task := taskModels.TaskListItem{Task: taskModels.Task{ID: uuid.New(), OperationID: uuid.New(), Status: taskModels.StatusCompleted}}
+		tasks.On("GetTasks", ctx, mock.Anything).Return(taskPage(task), nil)
+
+		require.NoError(t, svc.NotifyTaskEvent(ctx, taskModels.TaskEvent{Type: taskModels.EventTaskOverdue, TaskID: task.ID, OperationID: task.OperationID}))
+
+		repo.AssertNotCalled(t, "GetStaffRecipients", mock.Anything, mock.Anything)
+		sender.AssertNotCalled(t, "SendMessage", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Failed task notifies the lessee with the reason and comment", func(t *testing.T) {
+		svc, repo, tasks, sender := setup()
+		lesseeID, assigneeID := uuid.New(), uuid.New()
+		chatID := int64(400)
+		task := taskModels.TaskListItem{Task: taskModels.Task{ID: uuid.New(), OperationID: uuid.New(), Status: taskModels.StatusFailed, Title: "Полить грядку"}, LocationPath: "Ферма / Грядка 3"}
+		event := taskModels.TaskEvent{
+			Type:           taskModels.EventTaskFailed,
+			TaskID:         task.ID,
+			OperationID:    task.OperationID,
+			AssigneeID:     &assigneeID,
+			UserID:         &lesseeID,
+			FailureReason:  taskModels.FailureWeather,
+			FailureComment: "Град, полив перенесем",
+		}
+		repo.On("GetLinkByUserID", ctx, lesseeID).Return(&models.TelegramLink{UserID: lesseeID, ChatID: &chatID}, nil)
+		tasks.On("GetTasks", ctx, taskModels.TaskFilter{OperationID: &task.OperationID, Limit: 1}).Return(taskPage(task), nil)
+		sender.On("SendMessage", ctx, chatID, mock.AnythingOfType("string"), (*telegram.InlineKeyboardMarkup)(nil)).Return(&telegram.Message{MessageID: 3}, nil)
+
+		require.NoError(t, svc.NotifyTaskEvent(ctx, event))
+
+		sender.AssertNumberOfCalls(t, "SendMessage", 1)
+		text := sender.Calls[0].Arguments.String(2)
+		assert.Contains(t, text, "Не удалось выполнить: Полить грядку")
+		assert.Contains(t, text, "Причина: погода не позволяет выполнить работу")
+		assert.Contains(t, text, "Град, полив перенесем")
+		repo.AssertNotCalled(t, "GetLinkByUserID", mock.Anything, assigneeID)
+	})
+
+	t.Run("Failed task is skipped when the lessee has no linked chat", func(t *testing.T) {
+		svc, repo, tasks, sender := setup()
+		lesseeID := uuid.New()
+		repo.On("GetLinkByUserID", ctx, lesseeID).Return(nil, sql.ErrNoRows)
+
+		err := svc.NotifyTaskEvent(ctx, taskModels.TaskEvent{Type: taskModels.EventTaskFailed, TaskID: uuid.New(), OperationID: uuid.New(), UserID: &lesseeID, FailureReason: taskModels.FailurePlotIssue})
+
+		require.NoError(t, err)
+		tasks.AssertNotCalled(t, "GetTasks", mock.Anything, mock.Anything)
+		sender.AssertNotCalled(t, "SendMessage", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Accept button takes the task and swaps in a complete button", func(t *testing.T) {
+		svc, repo, tasks, sender := setup()
+		taskID, userID := uuid.New(), uuid.New()
+		cq := &telegram.CallbackQuery{ID: "cb1", From: telegram.User{ID: 100}, Data: "accept:" + taskID.String(), Message: &telegram.Message{MessageID: 7, Chat: telegram.Chat{ID: 100}}}
+		repo.On("GetStaffIDByTelegramUserID", ctx, int64(100), "admin").Return(userID, nil)
+		accepted := taskModels.Task{ID: taskID, OperationID: uuid.New(), Status: taskModels.StatusInProgress}
+		tasks.On("AcceptTask", ctx, taskID, userID).Return(&accepted, nil)
+		tasks.On("GetTasks", ctx, taskModels.TaskFilter{OperationID: &accepted.OperationID, Limit: 1}).
+			Return(taskPage(taskModels.TaskListItem{Task: accepted, ActionType: "water"}), nil)
+		sender.On("EditMessageReplyMarkup", ctx, int64(100), int64(7), buttons(callbackComplete, taskID)).Return(nil)
+		sender.On("AnswerCallbackQuery", ctx, "cb1", "Задача взята в работу").Return(nil)
+
+		require.NoError(t, svc.HandleUpdate(ctx, telegram.Update{CallbackQuery: cq}))
+
+		sender.AssertExpectations(t)
+	})
+
+	t.Run("Accepted harvest task has no complete button: the yield is reported in the app", func(t *testing.T) {
+		svc, repo, tasks, sender := setup()
+		taskID, userID := uuid.New(), uuid.New()
+		cq := &telegram.CallbackQuery{ID: "cb5", From: telegram.User{ID: 100}, Data: "accept:" + taskID.String(), Message: &telegram.Message{MessageID: 7, Chat: telegram.Chat{ID: 100}}}
+		repo.On("GetStaffIDByTelegramUserID", ctx, int64(100), "admin").Return(userID, nil)
+		accepted := taskModels.Task{ID: taskID, OperationID: uuid.New(), Status: taskModels.StatusInProgress}
+		tasks.On("AcceptTask", ctx, taskID, userID).Return(&accepted, nil)
+		tasks.On("GetTasks", ctx, mock.Anything).Return(taskPage(taskModels.TaskListItem{Task: accepted, ActionType: "harvest"}), nil)
+		sender.On("EditMessageReplyMarkup", ctx, int64(100), int64(7), (*telegram.InlineKeyboardMarkup)(nil)).Return(nil)
+		sender.On("AnswerCallbackQuery", ctx, "cb5", "Задача взята в работу. Завершите ее в приложении").Return(nil)
+
+		require.NoError(t, svc.HandleUpdate(ctx, telegram.Update{CallbackQuery: cq}))
+
+		sender.AssertExpectations(t)
+	})
+
+	t.Run("Complete button completes the task and removes the buttons", func(t *testing.T) {
+		svc, repo, tasks, sender := setup()
+		taskID, userID := uuid.New(), uuid.New()
+		cq := &telegram.CallbackQuery{ID: "cb2", From: telegram.User{ID: 100}, Data: "complete:" + taskID.String(), Message: &telegram.Message{MessageID: 7, Chat: telegram.Chat{ID: 100}}}
+		repo.On("GetStaffIDByTelegramUserID", ctx, int64(100), "admin").Return(userID, nil)
+		tasks.On("CompleteTask", ctx, taskID, userID, taskService.CompletionDetails{}, taskService.Evidence{}).
+			Return(&taskModels.Task{ID: taskID, Status: taskModels.StatusCompleted}, nil)
+		sender.On("EditMessageReplyMarkup", ctx, int64(100), int64(7), (*telegram.InlineKeyboardMarkup)(nil)).Return(nil)
+		sender.On("AnswerCallbackQuery", ctx, "cb2", "Задача выполнена").Return(nil)
+
+		require.NoError(t, svc.HandleUpdate(ctx, telegram.Update{CallbackQuery: cq}))
+
+		sender.AssertExpectations(t)
+	})
+
+	t.Run("Task taken by someone else loses its buttons", func(t *testing.T) {
+		svc, repo, tasks, sender := setup()
+		taskID, userID := uuid.New(), uuid.New()
+		cq := &telegram.CallbackQuery{ID: "cb3", From: telegram.User{ID: 100}, Data: "accept:" + taskID.String(), Message: &telegram.Message{MessageID: 7, Chat: telegram.Chat{ID: 100}}}
+		repo.On("GetStaffIDByTelegramUserID", ctx, int64(100), "admin").Return(userID, nil)
+		tasks.On("AcceptTask", ctx, taskID, userID).Return(nil, taskService.ErrAssignedToAnother)
+		sender.On("EditMessageReplyMarkup", ctx, int64(100), int64(7), (*telegram.InlineKeyboardMarkup)(nil)).Return(nil)
+		sender.On("AnswerCallbackQuery", ctx, "cb3", taskService.ErrAssignedToAnother.Error()).Return(nil)
+
+		require.NoError(t, svc.HandleUpdate(ctx, telegram.Update{CallbackQuery: cq}))
+
+		sender.AssertExpectations(t)
+	})
+
+	t.Run("Buttons pressed by an unlinked user do not touch tasks", func(t *testing.T) {
+		svc, repo, tasks, sender := setup()
+		cq := &telegram.CallbackQuery{ID: "cb4", From: telegram.User{ID: 999}, Data: "accept:" + uuid.NewString(), Message: &telegram.Message{MessageID: 7, Chat: telegram.Chat{ID: 999}}}
+		repo.On("GetStaffIDByTelegramUserID", ctx, int64(999), "admin").Return(uuid.Nil, sql.ErrNoRows)
+		sender.On("AnswerCallbackQuery", ctx, "cb4", "Аккаунт Telegram не привязан к сотруднику").Return(nil)
+
+		require.NoError(t, svc.HandleUpdate(ctx, telegram.Update{CallbackQuery: cq}))
+
+		tasks.AssertNotCalled(t, "AcceptTask", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Buttons in a group are checked against the user who pressed them, not the chat", func(t *testing.T) {
+		svc, repo, tasks, sender := setup()
+		// Чат -500 когда-то был привязан, но кнопку нажал другой участник группы.
+		cq := &telegram.CallbackQuery{ID: "cb6", From: telegram.User{ID: 777}, Data: "accept:" + uuid.NewString(), Message: &telegram.Message{MessageID: 7, Chat: telegram.Chat{ID: -500, Type: "group"}}}
+		repo.On("GetStaffIDByTelegramUserID", ctx, int64(777), "admin").Return(uuid.Nil, sql.ErrNoRows)
+		sender.On("AnswerCallbackQuery", ctx, "cb6", "Аккаунт Telegram не привязан к сотруднику").Return(nil)
+
+		require.NoError(t, svc.HandleUpdate(ctx, telegram.Update{CallbackQuery: cq}))
+
+		repo.AssertNotCalled(t, "GetStaffIDByTelegramUserID", mock.Anything, int64(-500), mock.Anything)
+		tasks.AssertNotCalled(t, "AcceptTask", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("/start with a code in a group is refused", func(t *testing.T) {
+		svc, repo, _, sender := setup()
+		sender.On("SendMessage", ctx, int64(-500), "Привязать можно только личный чат с ботом.", (*telegram.InlineKeyboardMarkup)(nil)).
+			Return(&telegram.Message{}, nil)
+
+		msg := &telegram.Message{Chat: telegram.Chat{ID: -500, Type: "group"}, From: &telegram.User{ID: 100}, Text: "/start abc123"}
+		require.NoError(t, svc.HandleUpdate(ctx, telegram.Update{Message: msg}))
+
+		repo.AssertNotCalled(t, "ReleaseChat", mock.Anything, mock.Anything, mock.Anything)
+		repo.AssertNotCalled(t, "LinkChat", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		sender.AssertExpectations(t)
+	})
+
+	t.Run("/start without a code explains how to link the chat", func(t *testing.T) {
+		svc, repo, _, sender := setup()
+		sender.On("SendMessage", ctx, int64(100), mock.AnythingOfType("string"), (*telegram.InlineKeyboardMarkup)(nil)).
+			Return(&telegram.Message{}, nil)
+
+		require.NoError(t, svc.HandleUpdate(ctx, telegram.Update{Message: &telegram.Message{Chat: telegram.Chat{ID: 100, Type: "private"}, From: &telegram.User{ID: 100}, Text: "/start"}}))
+
+		repo.AssertNotCalled(t, "LinkChat", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		sender.AssertNumberOfCalls(t, "SendMessage", 1)
+	})
+}
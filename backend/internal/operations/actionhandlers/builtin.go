@@ -0,0 +1,233 @@
+package actionhandlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	deliveryModels "github.com/rendley/vegshare/backend/internal/delivery/models"
+	harvestModels "github.com/rendley/vegshare/backend/internal/harvest/models"
+	"github.com/rendley/vegshare/backend/internal/operations/actions"
+	operationsModels "github.com/rendley/vegshare/backend/internal/operations/models"
+	taskModels "github.com/rendley/vegshare/backend/internal/task/models"
+)
+
+// defaultSLA - срок выполнения задачи, если тип действия не задает свой.
+const defaultSLA = 24 * time.Hour
+
+// NewDefaultRegistry создает реестр с обработчиками всех встроенных действий.
+func NewDefaultRegistry(deps Deps) *Registry {
+	r := NewRegistry(deps)
+
+	r.Register(actions.ActionPlant, &plantHandler{deps: deps})
+	r.Register(actions.ActionHarvest, &harvestHandler{deps: deps})
+	// Полив откладывать нельзя: растения могут погибнуть.
+	r.Register(actions.ActionWater, &simpleHandler{skills: []string{SkillWatering}, duration: 15 * time.Minute, priority: taskModels.PriorityHigh, sla: 4 * time.Hour})
+	r.Register(actions.ActionFertilize, &simpleHandler{skills: []string{SkillFertilizing}, duration: 20 * time.Minute})
+	r.Register(actions.ActionWeed, &simpleHandler{skills: []string{SkillWeeding}, duration: 30 * time.Minute, priority: taskModels.PriorityLow, sla: 48 * time.Hour})
+	r.Register(actions.ActionPhoto, &simpleHandler{skills: []string{SkillPhotography}, duration: 10 * time.Minute, priority: taskModels.PriorityLow})
+	r.Register(actions.ActionPickup, &deliveryHandler{duration: 15 * time.Minute})
+	r.Register(actions.ActionDelivery, &deliveryHandler{duration: time.Hour})
+
+	return r
+}
+
+// simpleHandler - действие без параметров, влияющих на задачу, и без последствий.
+// Пустой priority - обычный приоритет, нулевой sla - defaultSLA.
+type simpleHandler struct {
+	skills   []string
+	duration time.Duration
+	priority string
+	sla      time.Duration
+}
+
+func (h *simpleHandler) Describe(ctx context.Context, op *operationsModels.OperationLog, unitName string) (string, string, error) {
+	return fmt.Sprintf("Выполнить '%s' на '%s'", op.ActionType, unitName), rawParameters(op), nil
+}
+
+func (h *simpleHandler) Skills() []string { return h.skills }
+
+func (h *simpleHandler) EstimatedDuration(op *operationsModels.OperationLog) time.Duration {
+	return h.duration
+}
+
+func (h *simpleHandler) Priority(op *operationsModels.OperationLog) string {
+	if h.priority == "" {
+		return taskModels.PriorityNormal
+	}
+	return h.priority
+}
+
+func (h *simpleHandler) DueAt(op *operationsModels.OperationLog, createdAt time.Time) time.Time {
+	if h.sla == 0 {
+		return createdAt.Add(defaultSLA)
+	}
+	return createdAt.Add(h.sla)
+}
+
+func (h *simpleHandler) Complete(ctx context.Context, tx *sqlx.Tx, op *operationsModels.OperationLog, details CompletionDetails) error {
+	return nil
+}
+
+// PlantActionParams - структура для парсинга параметров операции 'plant'.
+type PlantActionParams struct {
+	ItemID   uuid.UUID `json:"item_id"`
+	Quantity int       `json:"quantity"`
+}
+
+// plantHandler - посадка: после выполнения культура появляется в содержимом юнита.
+type plantHandler struct {
+	deps Deps
+}
+
+func (h *plantHandler) Describe(ctx context.Context, op *operationsModels.OperationLog, unitName string) (string, string, error) {
+	params, err := parsePlantParams(op)
+	if err != nil {
+		return "", "", err
+	}
+	item, err := h.deps.Catalog.GetItemByID(ctx, params.ItemID)
+	if err != nil {
+		return "", "", err
+	}
+	return fmt.Sprintf("Посадить '%s' (x%d) на грядке '%s'", item.Name, params.Quantity, unitName), rawParameters(op), nil
+}
+
+func (h *plantHandler) Skills() []string { return []string{SkillPlanting} }
+
+// EstimatedDuration: подготовка грядки плюс время на каждое растение.
+func (h *plantHandler) EstimatedDuration(op *operationsModels.OperationLog) time.Duration {
+	params, err := parsePlantParams(op)
+	if err != nil {
+		return 0
+	}
+	return 10*time.Minute + time.Duration(params.Quantity)*time.Minute
+}
+
+func (h *plantHandler) Priority(op *operationsModels.OperationLog) string {
+	return taskModels.PriorityNormal
+}
+
+func (h *plantHandler) DueAt(op *operationsModels.OperationLog, createdAt time.Time) time.Time {
+	return createdAt.Add(defaultSLA)
+}
+
+func (h *plantHandler) Complete(ctx context.Context, tx *sqlx.Tx, op *operationsModels.OperationLog, details CompletionDetails) error {
+	params, err := parsePlantParams(op)
+	if err != nil {
+		return err
+	}
+	if err := h.deps.UnitContent.WithTx(tx).CreateOrUpdateContent(ctx, op.UnitID, params.ItemID, op.UnitType, params.Quantity); err != nil {
+		return fmt.Errorf("не удалось обновить содержимое юнита: %w", err)
+	}
+	return nil
+}
+
+func parsePlantParams(op *operationsModels.OperationLog) (PlantActionParams, error) {
+	var params PlantActionParams
+	if err := json.Unmarshal(op.Parameters, &params); err != nil {
+		return params, fmt.Errorf("ошибка парсинга параметров для операции plant: %w", err)
+	}
+	return params, nil
+}
+
+// HarvestActionParams - структура для парсинга параметров операции 'harvest'.
+type HarvestActionParams struct {
+	ItemID   *uuid.UUID `json:"item_id"`
+	Quantity int        `json:"quantity"`
+}
+
+// harvestHandler - сбор урожая: урожай записывается в журнал, собранное убирается с юнита.
+type harvestHandler struct {
+	deps Deps
+}
+
+func (h *harvestHandler) Describe(ctx context.Context, op *operationsModels.OperationLog, unitName string) (string, string, error) {
+	return fmt.Sprintf("Собрать урожай на '%s'", unitName), rawParameters(op), nil
+}
+
+func (h *harvestHandler) Skills() []string { return []string{SkillHarvesting} }
+
+func (h *harvestHandler) EstimatedDuration(op *operationsModels.OperationLog) time.Duration {
+	return 45 * time.Minute
+}
+
+// Priority: созревший урожай портится, если его вовремя не собрать.
+func (h *harvestHandler) Priority(op *operationsModels.OperationLog) string {
+	return taskModels.PriorityHigh
+}
+
+func (h *harvestHandler) DueAt(op *operationsModels.OperationLog, createdAt time.Time) time.Time {
+	return createdAt.Add(12 * time.Hour)
+}
+
+func (h *harvestHandler) Complete(ctx context.Context, tx *sqlx.Tx, op *operationsModels.OperationLog, details CompletionDetails) error {
+	var params HarvestActionParams
+	if len(op.Parameters) > 0 {
+		if err := json.Unmarshal(op.Parameters, &params); err != nil {
+			return fmt.Errorf("ошибка парсинга параметров для операции harvest: %w", err)
+		}
+	}
+
+	_, err := h.deps.Harvest.WithTx(tx).Harvest(ctx, harvestModels.HarvestRequest{
+		OperationID: op.ID,
+		UserID:      op.UserID,
+		UnitID:      op.UnitID,
+		UnitType:    op.UnitType,
+		ItemID:      params.ItemID,
+		Quantity:    params.Quantity,
+		Count:       details.HarvestCount,
+		WeightGrams: details.HarvestWeightGrams,
+	})
+	if err != nil {
+		return fmt.Errorf("не удалось записать урожай: %w", err)
+	}
+	return nil
+}
+
+// deliveryHandler - выдача урожая самовывозом или курьером.
+type deliveryHandler struct {
+	duration time.Duration
+}
+
+func (h *deliveryHandler) Describe(ctx context.Context, op *operationsModels.OperationLog, unitName string) (string, string, error) {
+	var params deliveryModels.ActionParams
+	if err := json.Unmarshal(op.Parameters, &params); err != nil {
+		return "", "", fmt.Errorf("ошибка парсинга параметров для операции %s: %w", op.ActionType, err)
+	}
+	slotTime := params.StartsAt.Format("02.01.2006 15:04")
+	if params.Address != nil {
+		return fmt.Sprintf("Доставить урожай с '%s' курьером по адресу '%s' (слот %s)", unitName, *params.Address, slotTime), rawParameters(op), nil
+	}
+	return fmt.Sprintf("Подготовить урожай с '%s' к самовывозу (слот %s)", unitName, slotTime), rawParameters(op), nil
+}
+
+func (h *deliveryHandler) Skills() []string { return []string{SkillLogistics} }
+
+func (h *deliveryHandler) EstimatedDuration(op *operationsModels.OperationLog) time.Duration {
+	return h.duration
+}
+
+func (h *deliveryHandler) Priority(op *operationsModels.OperationLog) string {
+	return taskModels.PriorityUrgent
+}
+
+// DueAt: урожай должен быть готов к началу слота, который выбрал арендатор.
+func (h *deliveryHandler) DueAt(op *operationsModels.OperationLog, createdAt time.Time) time.Time {
+	var params deliveryModels.ActionParams
+	if err := json.Unmarshal(op.Parameters, &params); err != nil || params.StartsAt.IsZero() {
+		return createdAt.Add(defaultSLA)
+	}
+	return params.StartsAt
+}
+
+func (h *deliveryHandler) Complete(ctx context.Context, tx *sqlx.Tx, op *operationsModels.OperationLog, details CompletionDetails) error {
+	return nil
+}
+
+// rawParameters - описание задачи по умолчанию: параметры операции как есть.
+func rawParameters(op *operationsModels.OperationLog) string {
+	return string(op.Parameters)
+}
@@ -0,0 +1,150 @@
+// Пакет actionhandlers описывает, как персонал выполняет каждый тип действия: как выглядит
+// задача, какие навыки и сколько времени она требует и что меняется после ее завершения.
+// Реестр обработчиков один и используется и воркером (создание задачи), и task.Service (завершение).
+package actionhandlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	catalogService "github.com/rendley/vegshare/backend/internal/catalog/service"
+	coopService "github.com/rendley/vegshare/backend/internal/coop/service"
+	harvestService "github.com/rendley/vegshare/backend/internal/harvest/service"
+	operationsModels "github.com/rendley/vegshare/backend/internal/operations/models"
+	plotService "github.com/rendley/vegshare/backend/internal/plot/service"
+	unitcontentService "github.com/rendley/vegshare/backend/internal/unitcontent/service"
+)
+
+// Навыки, которые могут требоваться от исполнителя задачи.
+const (
+	SkillPlanting    = "planting"
+	SkillWatering    = "watering"
+	SkillFertilizing = "fertilizing"
+	SkillHarvesting  = "harvesting"
+	SkillWeeding     = "weeding"
+	SkillPhotography = "photography"
+	SkillLogistics   = "logistics"
+)
+
+// Handler - поведение одного типа действия.
+type Handler interface {
+	// Describe формирует заголовок и описание задачи; unitName - человекочитаемое имя юнита.
+	Describe(ctx context.Context, op *operationsModels.OperationLog, unitName string) (title, description string, err error)
+	// Skills возвращает навыки, необходимые исполнителю.
+	Skills() []string
+	// EstimatedDuration оценивает время выполнения; 0 - оценки нет.
+	EstimatedDuration(op *operationsModels.OperationLog) time.Duration
+	// Priority возвращает приоритет задачи (taskModels.Priority*).
+	Priority(op *operationsModels.OperationLog) string
+	// DueAt возвращает срок выполнения задачи, созданной в момент createdAt.
+	DueAt(op *operationsModels.OperationLog, createdAt time.Time) time.Time
+	// Complete применяет последствия выполнения задачи в транзакции ее завершения.
+	Complete(ctx context.Context, tx *sqlx.Tx, op *operationsModels.OperationLog, details CompletionDetails) error
+}
+
+// TaskSpec - все, что нужно для создания задачи по операции.
+type TaskSpec struct {
+	Title             string
+	Description       string
+	Skills            []string
+	EstimatedDuration time.Duration
+	Priority          string
+	// DueAt - срок выполнения; нулевое время - без срока.
+	DueAt time.Time
+}
+
+// CompletionDetails - данные, которые исполнитель сообщает при завершении задачи.
+type CompletionDetails struct {
+	// HarvestWeightGrams - фактический вес урожая (для операции 'harvest').
+	HarvestWeightGrams float64
+	// HarvestCount - фактическое количество собранных единиц (для операции 'harvest').
+	HarvestCount int
+}
+
+// Deps - сервисы, которые нужны встроенным обработчикам.
+type Deps struct {
+	Plots       plotService.Service
+	Coops       coopService.Service
+	Catalog     catalogService.Service
+	UnitContent unitcontentService.Service
+	Harvest     harvestService.Service
+}
+
+// Registry хранит обработчики по типу действия. Для незарегистрированных типов
+// используется обработчик по умолчанию: общий заголовок, без навыков и последствий.
+type Registry struct {
+	deps     Deps
+	handlers map[string]Handler
+	fallback Handler
+}
+
+// NewRegistry создает пустой реестр.
+func NewRegistry(deps Deps) *Registry {
+	return &Registry{
+		deps:     deps,
+		handlers: make(map[string]Handler),
+		fallback: &simpleHandler{},
+	}
+}
+
+// Register добавляет или заменяет обработчик для типа действия.
+func (r *Registry) Register(actionType string, h Handler) {
+	r.handlers[actionType] = h
+}
+
+// Get возвращает обработчик типа действия (или обработчик по умолчанию).
+func (r *Registry) Get(actionType string) Handler {
+	if h, ok := r.handlers[actionType]; ok {
+		return h
+	}
+	return r.fallback
+}
+
+// Render собирает TaskSpec для операции.
+func (r *Registry) Render(ctx context.Context, op *operationsModels.OperationLog) (TaskSpec, error) {
+	unitName, err := r.unitName(ctx, op)
+	if err != nil {
+		return TaskSpec{}, err
+	}
+
+	h := r.Get(op.ActionType)
+	title, description, err := h.Describe(ctx, op, unitName)
+	if err != nil {
+		return TaskSpec{}, err
+	}
+
+	return TaskSpec{
+		Title:             title,
+		Description:       description,
+		Skills:            h.Skills(),
+		EstimatedDuration: h.EstimatedDuration(op),
+		Priority:          h.Priority(op),
+		DueAt:             h.DueAt(op, op.CreatedAt),
+	}, nil
+}
+
+// Complete применяет последствия завершения задачи по операции.
+func (r *Registry) Complete(ctx context.Context, tx *sqlx.Tx, op *operationsModels.OperationLog, details CompletionDetails) error {
+	return r.Get(op.ActionType).Complete(ctx, tx, op, details)
+}
+
+func (r *Registry) unitName(ctx context.Context, op *operationsModels.OperationLog) (string, error) {
+	switch op.UnitType {
+	case "plot":
+		plot, err := r.deps.Plots.GetPlotByID(ctx, op.UnitID)
+		if err != nil {
+			return "", fmt.Errorf("не удалось получить грядку %s: %w", op.UnitID, err)
+		}
+		return plot.Name, nil
+	case "coop":
+		coop, err := r.deps.Coops.GetCoopByID(ctx, op.UnitID)
+		if err != nil {
+			return "", fmt.Errorf("не удалось получить загон %s: %w", op.UnitID, err)
+		}
+		return coop.Name, nil
+	default:
+		return op.UnitID.String(), nil
+	}
+}
@@ -0,0 +1,87 @@
+package actionhandlers
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	deliveryModels "github.com/rendley/vegshare/backend/internal/delivery/models"
+	"github.com/rendley/vegshare/backend/internal/operations/actions"
+	operationsModels "github.com/rendley/vegshare/backend/internal/operations/models"
+	taskModels "github.com/rendley/vegshare/backend/internal/task/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	ctx := context.Background()
+	// Юнит неизвестного типа не требует сервисов грядок и загонов - имя берется из ID.
+	registry := NewDefaultRegistry(Deps{})
+	unitID := uuid.New()
+
+	t.Run("Render uses handler of the action type", func(t *testing.T) {
+		createdAt := time.Now().Add(-time.Hour)
+		op := &operationsModels.OperationLog{UnitID: unitID, UnitType: "greenhouse", ActionType: actions.ActionWater, Parameters: json.RawMessage(`{"volume_liters":5}`), CreatedAt: createdAt}
+
+		spec, err := registry.Render(ctx, op)
+
+		require.NoError(t, err)
+		assert.Equal(t, "Выполнить 'water' на '"+unitID.String()+"'", spec.Title)
+		assert.Equal(t, `{"volume_liters":5}`, spec.Description)
+		assert.Equal(t, []string{SkillWatering}, spec.Skills)
+		assert.Equal(t, 15*time.Minute, spec.EstimatedDuration)
+		assert.Equal(t, taskModels.PriorityHigh, spec.Priority)
+		// Срок отсчитывается от создания операции, а не от момента, когда воркер ее обработал.
+		assert.Equal(t, createdAt.Add(4*time.Hour), spec.DueAt)
+	})
+
+	t.Run("Unknown action type falls back to the default handler", func(t *testing.T) {
+		createdAt := time.Now().Add(-time.Hour)
+		op := &operationsModels.OperationLog{UnitID: unitID, UnitType: "greenhouse", ActionType: "prune", CreatedAt: createdAt}
+
+		spec, err := registry.Render(ctx, op)
+
+		require.NoError(t, err)
+		assert.Equal(t, "Выполнить 'prune' на '"+unitID.String()+"'", spec.Title)
+		assert.Empty(t, spec.Skills)
+		assert.Zero(t, spec.EstimatedDuration)
+		assert.Equal(t, taskModels.PriorityNormal, spec.Priority)
+		assert.Equal(t, createdAt.Add(defaultSLA), spec.DueAt)
+		assert.NoError(t, registry.Complete(ctx, nil, op, CompletionDetails{}))
+	})
+
+	t.Run("Delivery title includes address and slot", func(t *testing.T) {
+		address := "ул. Полевая, 1"
+		params, err := json.Marshal(deliveryModels.ActionParams{Address: &address, StartsAt: time.Date(2025, 9, 1, 10, 0, 0, 0, time.UTC)})
+		require.NoError(t, err)
+		op := &operationsModels.OperationLog{UnitID: unitID, UnitType: "greenhouse", ActionType: actions.ActionDelivery, Parameters: params}
+
+		spec, err := registry.Render(ctx, op)
+
+		require.NoError(t, err)
+		assert.Contains(t, spec.Title, address)
+		assert.Contains(t, spec.Title, "01.09.2025 10:00")
+		assert.Equal(t, []string{SkillLogistics}, spec.Skills)
+		assert.Equal(t, taskModels.PriorityUrgent, spec.Priority)
+		assert.Equal(t, time.Date(2025, 9, 1, 10, 0, 0, 0, time.UTC), spec.DueAt)
+	})
+
+	t.Run("Plant duration grows with quantity", func(t *testing.T) {
+		op := &operationsModels.OperationLog{ActionType: actions.ActionPlant, Parameters: json.RawMessage(`{"item_id":"` + uuid.NewString() + `","quantity":20}`)}
+
+		assert.Equal(t, 30*time.Minute, registry.Get(actions.ActionPlant).EstimatedDuration(op))
+	})
+
+	t.Run("Custom handler replaces the built-in one", func(t *testing.T) {
+		custom := NewDefaultRegistry(Deps{})
+		custom.Register(actions.ActionWater, &simpleHandler{skills: []string{"irrigation"}, duration: time.Minute})
+		op := &operationsModels.OperationLog{UnitID: unitID, UnitType: "greenhouse", ActionType: actions.ActionWater}
+
+		spec, err := custom.Render(ctx, op)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"irrigation"}, spec.Skills)
+	})
+}
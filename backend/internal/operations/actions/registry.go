@@ -0,0 +1,150 @@
+// Пакет actions содержит реестр типов действий, которые пользователь может заказать над юнитом,
+// вместе со схемами их параметров и списком применимых типов юнитов.
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Имена поддерживаемых типов действий.
+const (
+	ActionPlant     = "plant"
+	ActionWater     = "water"
+	ActionFertilize = "fertilize"
+	ActionHarvest   = "harvest"
+	ActionWeed      = "weed"
+	ActionPhoto     = "photo"
+)
+
+// Системные действия. Они не регистрируются в реестре и не доступны через POST /operations/actions,
+// а создаются другими модулями через operations.Service.CreateInternalAction.
+const (
+	ActionDelivery = "delivery"
+	ActionPickup   = "pickup"
+)
+
+// ActionType описывает один тип действия.
+type ActionType struct {
+	Name      string   `json:"name"`
+	Title     string   `json:"title"`
+	UnitTypes []string `json:"unit_types"`
+	Schema    *Schema  `json:"parameters_schema"`
+	// RequiredByUnitType - параметры, обязательные только для юнитов определенного типа,
+	// в дополнение к Schema.Required.
+	RequiredByUnitType map[string][]string `json:"required_by_unit_type,omitempty"`
+}
+
+// SupportsUnitType сообщает, применимо ли действие к юниту данного типа.
+func (a *ActionType) SupportsUnitType(unitType string) bool {
+	return contains(a.UnitTypes, unitType)
+}
+
+// Registry хранит зарегистрированные типы действий.
+type Registry struct {
+	types map[string]*ActionType
+}
+
+// NewRegistry создает пустой реестр.
+func NewRegistry() *Registry {
+	return &Registry{types: make(map[string]*ActionType)}
+}
+
+// NewDefaultRegistry создает реестр со всеми встроенными типами действий.
+func NewDefaultRegistry() *Registry {
+	r := NewRegistry()
+
+	r.Register(&ActionType{
+		Name:      ActionPlant,
+		Title:     "Посадка",
+		UnitTypes: []string{"plot"},
+		Schema: object([]string{"item_id", "quantity"}, map[string]*Schema{
+			"item_id":  uuidField("ID культуры из каталога"),
+			"quantity": intField("Количество растений", 1, 1000),
+		}),
+	})
+	r.Register(&ActionType{
+		Name:      ActionWater,
+		Title:     "Полив",
+		UnitTypes: []string{"plot", "coop"},
+		Schema: object(nil, map[string]*Schema{
+			"volume_liters": numberField("Объем воды в литрах", 0.1, 100),
+		}),
+	})
+	r.Register(&ActionType{
+		Name:      ActionFertilize,
+		Title:     "Подкормка",
+		UnitTypes: []string{"plot"},
+		Schema: object([]string{"item_id"}, map[string]*Schema{
+			"item_id":      uuidField("ID удобрения из каталога"),
+			"amount_grams": numberField("Количество удобрения в граммах", 1, 5000),
+		}),
+	})
+	r.Register(&ActionType{
+		Name:      ActionHarvest,
+		Title:     "Сбор урожая",
+		UnitTypes: []string{"plot", "coop"},
+		Schema: object(nil, map[string]*Schema{
+			"item_id":  uuidField("ID культуры для сбора; если не указан, собирается все содержимое"),
+			"quantity": intField("Количество единиц для сбора; если не указано, собирается все", 1, 1000),
+		}),
+		// У загона нет учета содержимого, поэтому продукцию нужно указать явно.
+		RequiredByUnitType: map[string][]string{"coop": {"item_id"}},
+	})
+	r.Register(&ActionType{
+		Name:      ActionWeed,
+		Title:     "Прополка",
+		UnitTypes: []string{"plot"},
+		Schema:    object(nil, map[string]*Schema{}),
+	})
+	r.Register(&ActionType{
+		Name:      ActionPhoto,
+		Title:     "Фотоотчет",
+		UnitTypes: []string{"plot", "coop"},
+		Schema: object(nil, map[string]*Schema{
+			"comment": stringField("Пожелания к фотографии", 500),
+		}),
+	})
+
+	return r
+}
+
+// Register добавляет или заменяет тип действия.
+func (r *Registry) Register(actionType *ActionType) {
+	r.types[actionType.Name] = actionType
+}
+
+// Get возвращает тип действия по имени.
+func (r *Registry) Get(name string) (*ActionType, bool) {
+	t, ok := r.types[name]
+	return t, ok
+}
+
+// All возвращает все типы действий, отсортированные по имени.
+func (r *Registry) All() []*ActionType {
+	result := make([]*ActionType, 0, len(r.types))
+	for _, t := range r.types {
+		result = append(result, t)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// Validate проверяет, что действие существует, применимо к типу юнита и что его параметры
+// соответствуют схеме. Ошибки параметров возвращаются в виде *ValidationError.
+func (r *Registry) Validate(actionType, unitType string, params json.RawMessage) error {
+	t, ok := r.Get(actionType)
+	if !ok {
+		return &ValidationError{Fields: []FieldError{{Field: "action_type", Message: fmt.Sprintf("неизвестный тип действия '%s'", actionType)}}}
+	}
+	if !t.SupportsUnitType(unitType) {
+		return &ValidationError{Fields: []FieldError{{Field: "unit_type", Message: fmt.Sprintf("действие '%s' неприменимо к юниту типа '%s'", actionType, unitType)}}}
+	}
+	errs := t.Schema.Validate(params)
+	errs = append(errs, missingFields(params, t.RequiredByUnitType[unitType])...)
+	if len(errs) > 0 {
+		return &ValidationError{Fields: errs}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package actions
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistryValidate(t *testing.T) {
+	registry := NewDefaultRegistry()
+
+	const itemID = `"6f1c2a4e-3b1d-4c8e-9a2f-1d2e3f4a5b6c"`
+
+	tests := []struct {
+		name       string
+		actionType string
+		unitType   string
+		params     string
+		want       []FieldError
+	}{
+		{name: "Unknown action type", actionType: "dig", unitType: "plot", params: `{}`, want: []FieldError{{Field: "action_type", Message: "неизвестный тип действия 'dig'"}}},
+		{name: "Unsupported unit type", actionType: ActionPlant, unitType: "coop", params: `{"item_id":` + itemID + `,"quantity":1}`, want: []FieldError{{Field: "unit_type", Message: "действие 'plant' неприменимо к юниту типа 'coop'"}}},
+		{name: "System action is not registered", actionType: ActionDelivery, unitType: "plot", params: `{}`, want: []FieldError{{Field: "action_type", Message: "неизвестный тип действия 'delivery'"}}},
+		{name: "Plant", actionType: ActionPlant, unitType: "plot", params: `{"item_id":` + itemID + `,"quantity":5}`},
+		{
+			name:       "Plant without required fields",
+			actionType: ActionPlant,
+			unitType:   "plot",
+			params:     `{}`,
+			want: []FieldError{
+				{Field: "item_id", Message: "обязательное поле"},
+				{Field: "quantity", Message: "обязательное поле"},
+			},
+		},
+		{name: "Plant with unknown field", actionType: ActionPlant, unitType: "plot", params: `{"item_id":` + itemID + `,"quantity":5,"depth":3}`, want: []FieldError{{Field: "depth", Message: "неизвестное поле"}}},
+		{name: "Fertilize above maximum", actionType: ActionFertilize, unitType: "plot", params: `{"item_id":` + itemID + `,"amount_grams":5001}`, want: []FieldError{{Field: "amount_grams", Message: "должно быть не больше 5000"}}},
+		{name: "Water without parameters", actionType: ActionWater, unitType: "coop", params: ``},
+		{name: "Weed accepts no parameters", actionType: ActionWeed, unitType: "plot", params: `{"hours":1}`, want: []FieldError{{Field: "hours", Message: "неизвестное поле"}}},
+		{name: "Photo comment too long", actionType: ActionPhoto, unitType: "plot", params: `{"comment":"` + strings.Repeat("я", 501) + `"}`, want: []FieldError{{Field: "comment", Message: "длина не должна превышать 500 символов"}}},
+		{name: "Harvest on plot without item_id", actionType: ActionHarvest, unitType: "plot", params: `{}`},
+		{name: "Harvest on coop with item_id", actionType: ActionHarvest, unitType: "coop", params: `{"item_id":` + itemID + `}`},
+		{name: "Harvest on coop without item_id", actionType: ActionHarvest, unitType: "coop", params: `{"quantity":10}`, want: []FieldError{{Field: "item_id", Message: "обязательное поле"}}},
+		{name: "Harvest on coop with null parameters", actionType: ActionHarvest, unitType: "coop", params: `null`, want: []FieldError{{Field: "item_id", Message: "обязательное поле"}}},
+		{name: "Harvest on coop with invalid item_id", actionType: ActionHarvest, unitType: "coop", params: `{"item_id":"egg"}`, want: []FieldError{{Field: "item_id", Message: "должно быть UUID"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := registry.Validate(tt.actionType, tt.unitType, json.RawMessage(tt.params))
+			if tt.want == nil {
+				assert.NoError(t, err)
+				return
+			}
+			var validationErr *ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, tt.want, validationErr.Fields)
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package actions
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Schema - подмножество JSON Schema, достаточное для описания параметров действий.
+// Сериализуется в JSON как обычная JSON Schema, поэтому клиент может использовать ее для построения форм.
+type Schema struct {
+	Type                 string             `json:"type"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+}
+
+// FieldError описывает ошибку валидации конкретного поля параметров.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError возвращается, когда параметры действия не прошли валидацию.
+type ValidationError struct {
+	Fields []FieldError `json:"fields"`
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		parts = append(parts, fmt.Sprintf("%s: %s", f.Field, f.Message))
+	}
+	return "некорректные параметры действия: " + strings.Join(parts, "; ")
+}
+
+// Validate проверяет JSON-документ на соответствие схеме объекта и возвращает ошибки по полям.
+func (s *Schema) Validate(raw json.RawMessage) []FieldError {
+	if len(bytes.TrimSpace(raw)) == 0 || bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+		raw = json.RawMessage("{}")
+	}
+
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return []FieldError{{Field: "parameters", Message: "должен быть JSON-объектом"}}
+	}
+
+	errs := requiredErrors(doc, s.Required)
+
+	names := make([]string, 0, len(doc))
+	for name := range doc {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		prop, ok := s.Properties[name]
+		if !ok {
+			if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				errs = append(errs, FieldError{Field: name, Message: "неизвестное поле"})
+			}
+			continue
+		}
+		if msg := prop.validateValue(doc[name]); msg != "" {
+			errs = append(errs, FieldError{Field: name, Message: msg})
+		}
+	}
+
+	return errs
+}
+
+// missingFields возвращает ошибки для полей required, которых нет в JSON-объекте raw.
+// Некорректный документ пропускается: о нем уже сообщает Schema.Validate.
+func missingFields(raw json.RawMessage, required []string) []FieldError {
+	if len(required) == 0 {
+		return nil
+	}
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &doc); err != nil && len(bytes.TrimSpace(raw)) > 0 {
+		return nil
+	}
+	return requiredErrors(doc, required)
+}
+
+func requiredErrors(doc map[string]json.RawMessage, required []string) []FieldError {
+	var errs []FieldError
+	for _, name := range required {
+		if _, ok := doc[name]; !ok {
+			errs = append(errs, FieldError{Field: name, Message: "обязательное поле"})
+		}
+	}
+	return errs
+}
+
+// validateValue проверяет скалярное значение и возвращает текст ошибки или пустую строку.
+func (s *Schema) validateValue(raw json.RawMessage) string {
+	switch s.Type {
+	case "string":
+		var v string
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return "должно быть строкой"
+		}
+		if s.Format == "uuid" {
+			if _, err := uuid.Parse(v); err != nil {
+				return "должно быть UUID"
+			}
+		}
+		if s.MaxLength != nil && len([]rune(v)) > *s.MaxLength {
+			return fmt.Sprintf("длина не должна превышать %d символов", *s.MaxLength)
+		}
+		if len(s.Enum) > 0 && !contains(s.Enum, v) {
+			return fmt.Sprintf("допустимые значения: %s", strings.Join(s.Enum, ", "))
+		}
+	case "integer", "number":
+		var v float64
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return "должно быть числом"
+		}
+		if s.Type == "integer" && v != float64(int64(v)) {
+			return "должно быть целым числом"
+		}
+		if s.Minimum != nil && v < *s.Minimum {
+			return fmt.Sprintf("должно быть не меньше %g", *s.Minimum)
+		}
+		if s.Maximum != nil && v > *s.Maximum {
+			return fmt.Sprintf("должно быть не больше %g", *s.Maximum)
+		}
+	case "boolean":
+		var v bool
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return "должно быть логическим значением"
+		}
+	}
+	return ""
+}
+
+func contains(values []string, v string) bool {
+	for _, item := range values {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Вспомогательные конструкторы для компактного описания схем в реестре.
+
+func object(required []string, props map[string]*Schema) *Schema {
+	closed := false
+	return &Schema{Type: "object", Properties: props, Required: required, AdditionalProperties: &closed}
+}
+
+func uuidField(description string) *Schema {
+	return &Schema{Type: "string", Format: "uuid", Description: description}
+}
+
+func intField(description string, min, max float64) *Schema {
+	return &Schema{Type: "integer", Description: description, Minimum: &min, Maximum: &max}
+}
+
+func numberField(description string, min, max float64) *Schema {
+	return &Schema{Type: "number", Description: description, Minimum: &min, Maximum: &max}
+}
+
+func stringField(description string, maxLength int) *Schema {
+	return &Schema{Type: "string", Description: description, MaxLength: &maxLength}
+}
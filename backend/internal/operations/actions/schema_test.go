@@ -0,0 +1,67 @@
+package actions
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchemaValidate(t *testing.T) {
+	enabled := true
+	schema := object([]string{"item_id"}, map[string]*Schema{
+		"item_id":  uuidField("ID"),
+		"quantity": intField("Количество", 1, 1000),
+		"volume":   numberField("Объем", 0.1, 100),
+		"comment":  stringField("Комментарий", 5),
+		"mode":     {Type: "string", Enum: []string{"fast", "slow"}},
+		"urgent":   {Type: "boolean"},
+	})
+	open := &Schema{Type: "object", Properties: map[string]*Schema{}, AdditionalProperties: &enabled}
+
+	const itemID = `"6f1c2a4e-3b1d-4c8e-9a2f-1d2e3f4a5b6c"`
+
+	tests := []struct {
+		name   string
+		schema *Schema
+		raw    string
+		want   []FieldError
+	}{
+		{name: "Valid", schema: schema, raw: `{"item_id":` + itemID + `,"quantity":10,"volume":0.5,"comment":"ok","mode":"fast","urgent":true}`},
+		{name: "Missing required field", schema: schema, raw: `{"quantity":10}`, want: []FieldError{{Field: "item_id", Message: "обязательное поле"}}},
+		{name: "Empty body treated as empty object", schema: schema, raw: ``, want: []FieldError{{Field: "item_id", Message: "обязательное поле"}}},
+		{name: "Null treated as empty object", schema: schema, raw: `null`, want: []FieldError{{Field: "item_id", Message: "обязательное поле"}}},
+		{name: "Not an object", schema: schema, raw: `[1,2]`, want: []FieldError{{Field: "parameters", Message: "должен быть JSON-объектом"}}},
+		{name: "Unknown field in closed schema", schema: schema, raw: `{"item_id":` + itemID + `,"extra":1}`, want: []FieldError{{Field: "extra", Message: "неизвестное поле"}}},
+		{name: "Unknown field in open schema", schema: open, raw: `{"extra":1}`},
+		{name: "Invalid UUID", schema: schema, raw: `{"item_id":"not-a-uuid"}`, want: []FieldError{{Field: "item_id", Message: "должно быть UUID"}}},
+		{name: "UUID must be a string", schema: schema, raw: `{"item_id":42}`, want: []FieldError{{Field: "item_id", Message: "должно быть строкой"}}},
+		{name: "Integer below minimum", schema: schema, raw: `{"item_id":` + itemID + `,"quantity":0}`, want: []FieldError{{Field: "quantity", Message: "должно быть не меньше 1"}}},
+		{name: "Integer above maximum", schema: schema, raw: `{"item_id":` + itemID + `,"quantity":1001}`, want: []FieldError{{Field: "quantity", Message: "должно быть не больше 1000"}}},
+		{name: "Integer bounds are inclusive", schema: schema, raw: `{"item_id":` + itemID + `,"quantity":1000}`},
+		{name: "Fractional integer", schema: schema, raw: `{"item_id":` + itemID + `,"quantity":1.5}`, want: []FieldError{{Field: "quantity", Message: "должно быть целым числом"}}},
+		{name: "Integer must be a number", schema: schema, raw: `{"item_id":` + itemID + `,"quantity":"10"}`, want: []FieldError{{Field: "quantity", Message: "должно быть числом"}}},
+		{name: "Number below minimum", schema: schema, raw: `{"item_id":` + itemID + `,"volume":0.05}`, want: []FieldError{{Field: "volume", Message: "должно быть не меньше 0.1"}}},
+		{name: "String length counted in runes", schema: schema, raw: `{"item_id":` + itemID + `,"comment":"ёжики"}`},
+		{name: "String too long", schema: schema, raw: `{"item_id":` + itemID + `,"comment":"ёжики!"}`, want: []FieldError{{Field: "comment", Message: "длина не должна превышать 5 символов"}}},
+		{name: "Value outside enum", schema: schema, raw: `{"item_id":` + itemID + `,"mode":"turbo"}`, want: []FieldError{{Field: "mode", Message: "допустимые значения: fast, slow"}}},
+		{name: "Invalid boolean", schema: schema, raw: `{"item_id":` + itemID + `,"urgent":"yes"}`, want: []FieldError{{Field: "urgent", Message: "должно быть логическим значением"}}},
+		{
+			name:   "Errors are reported for every field in name order",
+			schema: schema,
+			raw:    `{"quantity":0,"comment":"слишком длинно","extra":true}`,
+			want: []FieldError{
+				{Field: "item_id", Message: "обязательное поле"},
+				{Field: "comment", Message: "длина не должна превышать 5 символов"},
+				{Field: "extra", Message: "неизвестное поле"},
+				{Field: "quantity", Message: "должно быть не меньше 1"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.schema.Validate(json.RawMessage(tt.raw)))
+		})
+	}
+}
@@ -1,18 +1,32 @@
 package handler
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
+	"github.com/rendley/vegshare/backend/internal/operations/actions"
+	"github.com/rendley/vegshare/backend/internal/operations/models"
+	"github.com/rendley/vegshare/backend/internal/operations/repository"
 	"github.com/rendley/vegshare/backend/internal/operations/service"
 	"github.com/rendley/vegshare/backend/pkg/api"
 	"github.com/rendley/vegshare/backend/pkg/middleware"
+	"github.com/rendley/vegshare/backend/pkg/storage"
 	"github.com/sirupsen/logrus"
 )
 
+// dateLayout - формат дат в query-параметрах фильтров.
+const dateLayout = "2006-01-02"
+
 // --- Handler ---
 
 type OperationsHandler struct {
@@ -42,10 +56,33 @@ func (h *OperationsHandler) CreateAction(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// TODO: Add validation for the request
+	if err := h.validate.Struct(req); err != nil {
+		api.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	logEntry, err := h.service.CreateAction(r.Context(), userID, req)
 	if err != nil {
+		var validationErr *actions.ValidationError
+		if errors.As(err, &validationErr) {
+			api.RespondWithJSON(h.logger, w, map[string]interface{}{
+				"error":  validationErr.Error(),
+				"fields": validationErr.Fields,
+			}, http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, service.ErrNoActiveLease) {
+			api.RespondWithError(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		var quotaErr *service.QuotaExceededError
+		if errors.As(err, &quotaErr) {
+			api.RespondWithJSON(h.logger, w, map[string]interface{}{
+				"error": quotaErr.Error(),
+				"quota": quotaErr.Usage,
+			}, http.StatusTooManyRequests)
+			return
+		}
 		h.logger.Errorf("ошибка при создании действия: %v", err)
 		api.RespondWithError(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -54,7 +91,93 @@ func (h *OperationsHandler) CreateAction(w http.ResponseWriter, r *http.Request)
 	api.RespondWithJSON(h.logger, w, logEntry, http.StatusCreated)
 }
 
+// GetActionTypes возвращает реестр типов действий со схемами параметров.
+func (h *OperationsHandler) GetActionTypes(w http.ResponseWriter, r *http.Request) {
+	api.RespondWithJSON(h.logger, w, h.service.GetActionTypes(), http.StatusOK)
+}
+
+// parseActionFilter собирает ActionFilter из query-параметров запроса
+// (status, action_type, unit_id, from, to, cursor, limit).
+func parseActionFilter(r *http.Request) (models.ActionFilter, error) {
+	q := r.URL.Query()
+	filter := models.ActionFilter{Status: q.Get("status"), ActionType: q.Get("action_type")}
+
+	if v := q.Get("unit_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			return filter, fmt.Errorf("invalid unit_id query parameter")
+		}
+		filter.UnitID = &id
+	}
+
+	dateParams := map[string]**time.Time{
+		"from": &filter.From,
+		"to":   &filter.To,
+	}
+	for name, dest := range dateParams {
+		if v := q.Get(name); v != "" {
+			t, err := time.Parse(dateLayout, v)
+			if err != nil {
+				return filter, fmt.Errorf("invalid %s query parameter, expected YYYY-MM-DD", name)
+			}
+			if name == "to" {
+				// Включаем весь последний день периода.
+				t = t.Add(24*time.Hour - time.Nanosecond)
+			}
+			*dest = &t
+		}
+	}
+
+	if v := q.Get("cursor"); v != "" {
+		cursor, err := models.DecodeCursor(v)
+		if err != nil {
+			return filter, fmt.Errorf("invalid cursor query parameter")
+		}
+		filter.Cursor = cursor
+	}
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return filter, fmt.Errorf("invalid limit query parameter")
+		}
+		filter.Limit = limit
+	}
+
+	return filter, nil
+}
+
+// GetMyActions возвращает историю операций текущего пользователя с фильтрами и курсорной пагинацией.
+func (h *OperationsHandler) GetMyActions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		api.RespondWithError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	filter, err := parseActionFilter(r)
+	if err != nil {
+		api.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := h.service.GetMyActions(r.Context(), userID, filter)
+	if err != nil {
+		h.logger.Errorf("ошибка при получении истории действий: %v", err)
+		api.RespondWithError(w, "could not retrieve actions", http.StatusInternalServerError)
+		return
+	}
+
+	api.RespondWithJSON(h.logger, w, page, http.StatusOK)
+}
+
+// GetActionsForUnit возвращает историю юнита, который арендует текущий пользователь.
 func (h *OperationsHandler) GetActionsForUnit(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		api.RespondWithError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
 	unitIDStr := chi.URLParam(r, "unitID")
 	unitID, err := uuid.Parse(unitIDStr)
 	if err != nil {
@@ -62,17 +185,62 @@ func (h *OperationsHandler) GetActionsForUnit(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	logs, err := h.service.GetActionsForUnit(r.Context(), unitID)
+	filter, err := parseActionFilter(r)
+	if err != nil {
+		api.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := h.service.GetActionsForUnit(r.Context(), userID, unitID, filter)
 	if err != nil {
+		if errors.Is(err, service.ErrNoActiveLease) {
+			api.RespondWithError(w, err.Error(), http.StatusForbidden)
+			return
+		}
 		h.logger.Errorf("ошибка при получении журнала действий: %v", err)
 		api.RespondWithError(w, "could not retrieve actions", http.StatusInternalServerError)
 		return
 	}
 
-	api.RespondWithJSON(h.logger, w, logs, http.StatusOK)
+	api.RespondWithJSON(h.logger, w, page, http.StatusOK)
 }
 
+// GetQuotas возвращает лимиты тарифа аренды юнита и сколько действий осталось в текущем периоде.
+func (h *OperationsHandler) GetQuotas(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		api.RespondWithError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	unitID, err := uuid.Parse(chi.URLParam(r, "unitID"))
+	if err != nil {
+		api.RespondWithError(w, "invalid unit ID in URL", http.StatusBadRequest)
+		return
+	}
+
+	quotas, err := h.service.GetQuotas(r.Context(), userID, unitID)
+	if err != nil {
+		if errors.Is(err, service.ErrNoActiveLease) {
+			api.RespondWithError(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		h.logger.Errorf("ошибка при получении лимитов: %v", err)
+		api.RespondWithError(w, "could not retrieve quotas", http.StatusInternalServerError)
+		return
+	}
+
+	api.RespondWithJSON(h.logger, w, quotas, http.StatusOK)
+}
+
+// CancelAction отменяет операцию текущего пользователя и связанную с ней задачу.
 func (h *OperationsHandler) CancelAction(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		api.RespondWithError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
 	logIDStr := chi.URLParam(r, "actionID")
 	logID, err := uuid.Parse(logIDStr)
 	if err != nil {
@@ -80,11 +248,109 @@ func (h *OperationsHandler) CancelAction(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if err := h.service.CancelAction(r.Context(), logID); err != nil {
-		h.logger.Errorf("ошибка при отмене действия: %v", err)
-		api.RespondWithError(w, "could not cancel action", http.StatusInternalServerError)
+	logEntry, err := h.service.CancelAction(r.Context(), userID, logID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrNotActionOwner):
+			api.RespondWithError(w, err.Error(), http.StatusForbidden)
+			return
+		case errors.Is(err, repository.ErrNotCancellable):
+			api.RespondWithError(w, err.Error(), http.StatusConflict)
+			return
+		default:
+			h.logger.Errorf("ошибка при отмене действия: %v", err)
+			api.RespondWithError(w, "could not cancel action", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	api.RespondWithJSON(h.logger, w, logEntry, http.StatusOK)
+}
+
+// RetryAction повторяет проваленную операцию текущего пользователя новой операцией.
+func (h *OperationsHandler) RetryAction(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		api.RespondWithError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	logID, err := uuid.Parse(chi.URLParam(r, "actionID"))
+	if err != nil {
+		api.RespondWithError(w, "invalid action ID in URL", http.StatusBadRequest)
+		return
+	}
+
+	logEntry, err := h.service.RetryAction(r.Context(), userID, logID)
+	if err != nil {
+		var validationErr *actions.ValidationError
+		var quotaErr *service.QuotaExceededError
+		switch {
+		case errors.As(err, &validationErr):
+			api.RespondWithJSON(h.logger, w, map[string]interface{}{
+				"error":  validationErr.Error(),
+				"fields": validationErr.Fields,
+			}, http.StatusBadRequest)
+		case errors.As(err, &quotaErr):
+			api.RespondWithJSON(h.logger, w, map[string]interface{}{
+				"error": quotaErr.Error(),
+				"quota": quotaErr.Usage,
+			}, http.StatusTooManyRequests)
+		case errors.Is(err, service.ErrNotActionOwner), errors.Is(err, service.ErrNoActiveLease):
+			api.RespondWithError(w, err.Error(), http.StatusForbidden)
+		case errors.Is(err, sql.ErrNoRows):
+			api.RespondWithError(w, "action not found", http.StatusNotFound)
+		case errors.Is(err, service.ErrNotRetryable), errors.Is(err, repository.ErrAlreadyRetried):
+			api.RespondWithError(w, err.Error(), http.StatusConflict)
+		default:
+			h.logger.Errorf("ошибка при повторе действия: %v", err)
+			api.RespondWithError(w, "could not retry action", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	api.RespondWithJSON(h.logger, w, logEntry, http.StatusCreated)
+}
+
+// GetAttachment отдает фото, которое исполнитель приложил к выполненной операции.
+func (h *OperationsHandler) GetAttachment(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		api.RespondWithError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	logID, err := uuid.Parse(chi.URLParam(r, "actionID"))
+	if err != nil {
+		api.RespondWithError(w, "invalid action ID in URL", http.StatusBadRequest)
+		return
+	}
+	attachmentID, err := uuid.Parse(chi.URLParam(r, "attachmentID"))
+	if err != nil {
+		api.RespondWithError(w, "invalid attachment ID in URL", http.StatusBadRequest)
+		return
+	}
+
+	attachment, content, err := h.service.OpenAttachment(r.Context(), userID, logID, attachmentID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrNotActionOwner):
+			api.RespondWithError(w, err.Error(), http.StatusForbidden)
+		case errors.Is(err, sql.ErrNoRows), errors.Is(err, storage.ErrNotFound):
+			api.RespondWithError(w, "attachment not found", http.StatusNotFound)
+		default:
+			h.logger.Errorf("ошибка при получении фото выполнения: %v", err)
+			api.RespondWithError(w, "could not retrieve attachment", http.StatusInternalServerError)
+		}
 		return
 	}
+	defer content.Close()
 
-	w.WriteHeader(http.StatusNoContent)
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(attachment.SizeBytes, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": attachment.FileName}))
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, content); err != nil {
+		h.logger.Errorf("ошибка при отправке фото выполнения %s: %v", attachmentID, err)
+	}
 }
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/rendley/vegshare/backend/internal/operations/actions"
+	"github.com/rendley/vegshare/backend/internal/operations/models"
+	"github.com/rendley/vegshare/backend/internal/operations/quota"
+	"github.com/rendley/vegshare/backend/internal/operations/repository"
+	"github.com/rendley/vegshare/backend/internal/operations/service"
+	"github.com/rendley/vegshare/backend/internal/operations/service/mocks"
+	"github.com/rendley/vegshare/backend/pkg/middleware"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOperationsHandler(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	newHandler := func() (*OperationsHandler, *mocks.OperationsService) {
+		svc := new(mocks.OperationsService)
+		return NewOperationsHandler(svc, logger), svc
+	}
+	// withUser добавляет в запрос пользователя, как это делает middleware аутентификации.
+	withUser := func(r *http.Request, userID uuid.UUID) *http.Request {
+		return r.WithContext(context.WithValue(r.Context(), middleware.UserIDKey, userID))
+	}
+	// retryRequest собирает запрос на повтор операции logID от имени userID.
+	retryRequest := func(userID, logID uuid.UUID) *http.Request {
+		routeCtx := chi.NewRouteContext()
+		routeCtx.URLParams.Add("actionID", logID.String())
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/operations/actions/"+logID.String()+"/retry", nil)
+		return withUser(req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, routeCtx)), userID)
+	}
+
+	quotaErr := &service.QuotaExceededError{Usage: models.QuotaUsage{
+		ActionType: actions.ActionWater,
+		Limit:      2,
+		Period:     quota.PeriodDay,
+		Used:       2,
+		Remaining:  0,
+	}}
+
+	// quotaBody разбирает ответ с исчерпанным лимитом.
+	type quotaBody struct {
+		Error string            `json:"error"`
+		Quota models.QuotaUsage `json:"quota"`
+	}
+
+	t.Run("CreateAction - Over quota returns 429 with usage", func(t *testing.T) {
+		h, svc := newHandler()
+		userID, unitID := uuid.New(), uuid.New()
+		svc.On("CreateAction", mock.Anything, userID, mock.MatchedBy(func(req service.ActionRequest) bool {
+			return req.UnitID == unitID && req.ActionType == actions.ActionWater
+		})).Return(nil, quotaErr).Once()
+
+		body := `{"unit_id": "` + unitID.String() + `", "unit_type": "plot", "action_type": "water", "parameters": {}}`
+		req := withUser(httptest.NewRequest(http.MethodPost, "/api/v1/operations/actions", strings.NewReader(body)), userID)
+		rec := httptest.NewRecorder()
+
+		h.CreateAction(rec, req)
+
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+		var resp quotaBody
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		assert.Equal(t, quotaErr.Error(), resp.Error)
+		assert.Equal(t, 2, resp.Quota.Limit)
+		assert.Equal(t, 0, resp.Quota.Remaining)
+		svc.AssertExpectations(t)
+	})
+
+	t.Run("RetryAction - Over quota returns 429 with usage", func(t *testing.T) {
+		h, svc := newHandler()
+		userID, logID := uuid.New(), uuid.New()
+		svc.On("RetryAction", mock.Anything, userID, logID).Return(nil, quotaErr).Once()
+
+		rec := httptest.NewRecorder()
+
+		h.RetryAction(rec, retryRequest(userID, logID))
+
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+		var resp quotaBody
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		assert.Equal(t, actions.ActionWater, resp.Quota.ActionType)
+		assert.Equal(t, 0, resp.Quota.Remaining)
+		svc.AssertExpectations(t)
+	})
+
+	t.Run("RetryAction - Success returns the new operation linked to the failed one", func(t *testing.T) {
+		h, svc := newHandler()
+		userID, logID := uuid.New(), uuid.New()
+		retry := &models.OperationLog{ID: uuid.New(), UserID: userID, ActionType: actions.ActionWater, Status: models.StatusPending, RetryOf: &logID}
+		svc.On("RetryAction", mock.Anything, userID, logID).Return(retry, nil).Once()
+		rec := httptest.NewRecorder()
+
+		h.RetryAction(rec, retryRequest(userID, logID))
+
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		var resp models.OperationLog
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		assert.Equal(t, retry.ID, resp.ID)
+		require.NotNil(t, resp.RetryOf)
+		assert.Equal(t, logID, *resp.RetryOf)
+		svc.AssertExpectations(t)
+	})
+
+	t.Run("RetryAction - Second retry returns 409", func(t *testing.T) {
+		h, svc := newHandler()
+		userID, logID := uuid.New(), uuid.New()
+		svc.On("RetryAction", mock.Anything, userID, logID).Return(nil, repository.ErrAlreadyRetried).Once()
+		rec := httptest.NewRecorder()
+
+		h.RetryAction(rec, retryRequest(userID, logID))
+
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		svc.AssertExpectations(t)
+	})
+}
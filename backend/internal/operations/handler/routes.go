@@ -10,9 +10,20 @@ import (
 func (h *OperationsHandler) Routes() http.Handler {
 	r := chi.NewRouter()
 
+	r.Get("/action-types", h.GetActionTypes)
 	r.Post("/actions", h.CreateAction)
+	// GET /actions?status=&action_type=&unit_id=&from=&to=&cursor=&limit= - история операций текущего пользователя
+	r.Get("/actions", h.GetMyActions)
+	// GET /units/{unitID}/actions - история юнита (только для текущего арендатора), те же фильтры
 	r.Get("/units/{unitID}/actions", h.GetActionsForUnit)
+	// GET /units/{unitID}/quotas - лимиты тарифа аренды и остаток в текущем периоде
+	r.Get("/units/{unitID}/quotas", h.GetQuotas)
+	// DELETE /actions/{actionID} - отменить операцию (запись остается в журнале со статусом cancelled)
 	r.Delete("/actions/{actionID}", h.CancelAction)
+	// POST /actions/{actionID}/retry - повторить проваленную операцию (новая операция со ссылкой retry_of)
+	r.Post("/actions/{actionID}/retry", h.RetryAction)
+	// GET /actions/{actionID}/attachments/{attachmentID} - фото выполнения из истории операций
+	r.Get("/actions/{actionID}/attachments/{attachmentID}", h.GetAttachment)
 
 	return r
 }
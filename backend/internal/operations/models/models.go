@@ -1,10 +1,25 @@
 package models
 
 import (
+	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	taskModels "github.com/rendley/vegshare/backend/internal/task/models"
+)
+
+// Статусы операции. Переходы: pending -> processing -> in_progress -> completed/failed;
+// отмена возможна только из pending и processing.
+const (
+	StatusPending    = "pending"
+	StatusProcessing = "processing"
+	StatusInProgress = "in_progress"
+	StatusCompleted  = "completed"
+	StatusFailed     = "failed"
+	StatusCancelled  = "cancelled"
 )
 
 // OperationLog - это запись о любом действии, выполненном над юнитом.
@@ -19,4 +34,88 @@ type OperationLog struct {
 	ExecutedAt  time.Time       `db:"executed_at" json:"executed_at"`
 	CreatedAt   time.Time       `db:"created_at" json:"created_at"`
 	UpdatedAt   time.Time       `db:"updated_at" json:"updated_at"`
+	// ScheduleID - расписание, по которому создана операция; nil для разовых действий.
+	ScheduleID  *uuid.UUID      `db:"schedule_id" json:"schedule_id,omitempty"`
+	// RetryOf - проваленная операция, повтором которой является эта; nil для обычных операций.
+	RetryOf     *uuid.UUID      `db:"retry_of" json:"retry_of,omitempty"`
+}
+
+// ActionFilter - фильтры истории операций. Пустые (nil) поля не участвуют в фильтрации.
+type ActionFilter struct {
+	UserID     *uuid.UUID
+	UnitID     *uuid.UUID
+	Status     string
+	ActionType string
+	// From и To ограничивают created_at операции (включительно).
+	From *time.Time
+	To   *time.Time
+	// Cursor - позиция, после которой начинается страница; nil - первая страница.
+	Cursor *Cursor
+	Limit  int
+}
+
+// Cursor - позиция в истории, отсортированной по (created_at, id) от новых к старым.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// Encode кодирует курсор в непрозрачную строку для query-параметра cursor.
+func (c Cursor) Encode() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(c.CreatedAt.Format(time.RFC3339Nano) + "|" + c.ID.String()))
+}
+
+// DecodeCursor разбирает строку, полученную от Encode.
+func DecodeCursor(s string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.New("некорректный курсор")
+	}
+	createdAt, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return nil, errors.New("некорректный курсор")
+	}
+	t, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return nil, errors.New("некорректный курсор")
+	}
+	u, err := uuid.Parse(id)
+	if err != nil {
+		return nil, errors.New("некорректный курсор")
+	}
+	return &Cursor{CreatedAt: t, ID: u}, nil
+}
+
+// ActionHistoryItem - операция вместе с задачей персоналу, созданной по ней.
+type ActionHistoryItem struct {
+	OperationLog
+	TaskID     *uuid.UUID `db:"task_id" json:"task_id,omitempty"`
+	TaskStatus *string    `db:"task_status" json:"task_status,omitempty"`
+	// CompletionNote и Attachments - заметка и фото исполнителя, подтверждающие выполнение.
+	CompletionNote *string                     `db:"completion_note" json:"completion_note,omitempty"`
+	Attachments    []taskModels.TaskAttachment `db:"-" json:"attachments,omitempty"`
+	// FailureReason и FailureComment - почему персонал не смог выполнить операцию.
+	FailureReason  *string `db:"failure_reason" json:"failure_reason,omitempty"`
+	FailureComment *string `db:"failure_comment" json:"failure_comment,omitempty"`
+	// RetriedBy - повтор этой операции, если пользователь его уже создал.
+	RetriedBy *uuid.UUID `db:"retried_by" json:"retried_by,omitempty"`
+}
+
+// ActionPage - страница истории операций. NextCursor пуст на последней странице.
+type ActionPage struct {
+	Items      []ActionHistoryItem `json:"items"`
+	NextCursor string              `json:"next_cursor,omitempty"`
+}
+
+// QuotaUsage - лимит действия по тарифу аренды и его использование в текущем периоде.
+type QuotaUsage struct {
+	ActionType string `json:"action_type"`
+	Limit      int    `json:"limit"`
+	// Period - day, week, month или season (весь срок аренды).
+	Period    string `json:"period"`
+	Used      int    `json:"used"`
+	Remaining int    `json:"remaining"`
+	// PeriodStart и PeriodEnd - границы текущего периода; с PeriodEnd лимит снова доступен.
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
 }
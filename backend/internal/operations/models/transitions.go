@@ -0,0 +1,33 @@
+package models
+
+// operationTransitions - конечный автомат статусов операции: из какого статуса в какие можно перейти.
+// completed, failed и cancelled - конечные статусы.
+var operationTransitions = map[string][]string{
+	// Воркер берет операцию в обработку; пока ее не взял персонал, арендатор может ее отменить.
+	StatusPending: {StatusProcessing, StatusCancelled},
+	// Задачу по операции взяли в работу (или устройство приняло команду), провалили, не начав, или операцию отменили.
+	StatusProcessing: {StatusInProgress, StatusFailed, StatusCancelled},
+	// Работа выполнена или провалена; если задача вернулась в пул, операция снова ждет исполнителя.
+	StatusInProgress: {StatusCompleted, StatusFailed, StatusProcessing},
+}
+
+// CanTransition сообщает, разрешен ли переход операции из статуса from в статус to.
+func CanTransition(from, to string) bool {
+	for _, allowed := range operationTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// SourceStatuses возвращает статусы, из которых операция может перейти в статус to.
+func SourceStatuses(to string) []string {
+	var sources []string
+	for from := range operationTransitions {
+		if CanTransition(from, to) {
+			sources = append(sources, from)
+		}
+	}
+	return sources
+}
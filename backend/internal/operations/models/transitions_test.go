@@ -0,0 +1,43 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOperationTransitions(t *testing.T) {
+	t.Run("CanTransition", func(t *testing.T) {
+		tests := []struct {
+			from, to string
+			allowed  bool
+		}{
+			{StatusPending, StatusProcessing, true},
+			{StatusPending, StatusCancelled, true},
+			{StatusPending, StatusInProgress, false},
+			{StatusProcessing, StatusInProgress, true},
+			{StatusProcessing, StatusFailed, true},
+			{StatusProcessing, StatusCancelled, true},
+			{StatusProcessing, StatusCompleted, false},
+			{StatusInProgress, StatusCompleted, true},
+			{StatusInProgress, StatusFailed, true},
+			{StatusInProgress, StatusProcessing, true},
+			{StatusInProgress, StatusCancelled, false},
+			{StatusCompleted, StatusProcessing, false},
+			{StatusFailed, StatusPending, false},
+			{StatusCancelled, StatusPending, false},
+		}
+		for _, tt := range tests {
+			assert.Equal(t, tt.allowed, CanTransition(tt.from, tt.to), "%s -> %s", tt.from, tt.to)
+		}
+	})
+
+	t.Run("SourceStatuses", func(t *testing.T) {
+		assert.ElementsMatch(t, []string{StatusProcessing, StatusInProgress}, SourceStatuses(StatusFailed))
+		assert.ElementsMatch(t, []string{StatusPending, StatusProcessing}, SourceStatuses(StatusCancelled))
+		assert.ElementsMatch(t, []string{StatusProcessing}, SourceStatuses(StatusInProgress))
+		assert.ElementsMatch(t, []string{StatusInProgress}, SourceStatuses(StatusCompleted))
+		// В pending операция только создается, перейти в него нельзя.
+		assert.Empty(t, SourceStatuses(StatusPending))
+	})
+}
@@ -0,0 +1,111 @@
+package processor
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/rendley/vegshare/backend/internal/operations/actionhandlers"
+	"github.com/rendley/vegshare/backend/internal/operations/models"
+	"github.com/rendley/vegshare/backend/internal/operations/repository"
+	taskService "github.com/rendley/vegshare/backend/internal/task/service"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrInvalidMessage означает, что сообщение нельзя обработать ни при какой повторной доставке.
+var ErrInvalidMessage = errors.New("некорректное сообщение операции")
+
+// Renderer формирует задачу персоналу для операции; реализуется actionhandlers.Registry.
+type Renderer interface {
+	Render(ctx context.Context, operation *models.OperationLog) (actionhandlers.TaskSpec, error)
+}
+
+// Automation выполняет операцию без участия персонала; реализуется сервисом устройств.
+// handled == false означает, что автоматически операцию выполнить нельзя.
+type Automation interface {
+	Execute(ctx context.Context, operation *models.OperationLog) (handled bool, err error)
+}
+
+// Processor превращает сообщения очереди actions в задачи персоналу
+// или, если у юнита есть подходящее устройство, в команды устройству.
+// Обработка идемпотентна: одно и то же сообщение можно доставить несколько раз,
+// задача по операции будет создана только один раз.
+type Processor struct {
+	opsRepo     repository.Repository
+	taskService taskService.Service
+	renderer    Renderer
+	automation  Automation
+	logger      *logrus.Logger
+}
+
+// New - конструктор для Processor. automation может быть nil - тогда все операции выполняет персонал.
+func New(opsRepo repository.Repository, ts taskService.Service, renderer Renderer, automation Automation, logger *logrus.Logger) *Processor {
+	return &Processor{
+		opsRepo:     opsRepo,
+		taskService: ts,
+		renderer:    renderer,
+		automation:  automation,
+		logger:      logger,
+	}
+}
+
+// Handle обрабатывает одно сообщение. Ошибка, обернутая в ErrInvalidMessage, не исправится
+// повторной доставкой; остальные ошибки временные.
+func (p *Processor) Handle(ctx context.Context, body []byte) error {
+	var message models.OperationLog
+	if err := json.Unmarshal(body, &message); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+	}
+
+	// Статус берем из БД, а не из сообщения: оно могло устареть, пока лежало в очереди.
+	operation, err := p.opsRepo.GetOperationLogByID(ctx, message.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: операция %s не найдена", ErrInvalidMessage, message.ID)
+		}
+		return err
+	}
+
+	switch operation.Status {
+	case models.StatusPending:
+		ok, err := p.opsRepo.TransitionOperationLogStatus(ctx, operation.ID, models.StatusPending, models.StatusProcessing)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			// Статус изменился между чтением и обновлением (например, операцию отменили) - перечитываем.
+			return p.Handle(ctx, body)
+		}
+		operation.Status = models.StatusProcessing
+	case models.StatusProcessing:
+		// Повторная доставка после сбоя: задача могла уже появиться, CreateTask вернет существующую.
+	default:
+		p.logger.Infof("Operation %s is already '%s', skipping message", operation.ID, operation.Status)
+		return nil
+	}
+
+	if p.automation != nil {
+		handled, err := p.automation.Execute(ctx, operation)
+		if err != nil {
+			return err
+		}
+		if handled {
+			return nil
+		}
+	}
+
+	spec, err := p.renderer.Render(ctx, operation)
+	if err != nil {
+		return fmt.Errorf("не удалось сформировать задачу: %w", err)
+	}
+
+	task, err := p.taskService.CreateTask(ctx, operation.ID, spec)
+	if err != nil {
+		return err
+	}
+
+	p.logger.Infof("Task %s is ready for operation %s.", task.ID, operation.ID)
+	return nil
+}
@@ -0,0 +1,320 @@
+package processor
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rendley/vegshare/backend/internal/operations/actionhandlers"
+	"github.com/rendley/vegshare/backend/internal/operations/models"
+	"github.com/rendley/vegshare/backend/internal/operations/repository"
+	taskModels "github.com/rendley/vegshare/backend/internal/task/models"
+	taskRepository "github.com/rendley/vegshare/backend/internal/task/repository"
+	taskService "github.com/rendley/vegshare/backend/internal/task/service"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// --- Fakes ---
+// In-memory хранилища повторяют ограничения БД, на которых держится идемпотентность:
+// условную смену статуса операции и уникальность задачи по operation_id.
+
+type fakeOperationsRepository struct {
+	mu         sync.Mutex
+	operations map[uuid.UUID]models.OperationLog
+}
+
+var _ repository.Repository = &fakeOperationsRepository{}
+
+func newFakeOperationsRepository(ops ...models.OperationLog) *fakeOperationsRepository {
+	r := &fakeOperationsRepository{operations: map[uuid.UUID]models.OperationLog{}}
+	for _, op := range ops {
+		r.operations[op.ID] = op
+	}
+	return r
+}
+
+func (r *fakeOperationsRepository) status(id uuid.UUID) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.operations[id].Status
+}
+
+func (r *fakeOperationsRepository) CreateOperationLog(ctx context.Context, log *models.OperationLog) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.operations[log.ID] = *log
+	return nil
+}
+
+func (r *fakeOperationsRepository) GetOperationLogByID(ctx context.Context, logID uuid.UUID) (*models.OperationLog, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	op, ok := r.operations[logID]
+	if !ok {
+		return nil, fmt.Errorf("не удалось получить операцию по ID: %w", sql.ErrNoRows)
+	}
+	return &op, nil
+}
+
+func (r *fakeOperationsRepository) ListOperationLogs(ctx context.Context, filter models.ActionFilter) ([]models.ActionHistoryItem, error) {
+	return nil, nil
+}
+
+func (r *fakeOperationsRepository) DeleteOperationLog(ctx context.Context, logID uuid.UUID) error {
+	return nil
+}
+
+func (r *fakeOperationsRepository) UpdateOperationLogStatus(ctx context.Context, logID uuid.UUID, status string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	op := r.operations[logID]
+	op.Status = status
+	r.operations[logID] = op
+	return nil
+}
+
+func (r *fakeOperationsRepository) TransitionOperationLogStatus(ctx context.Context, logID uuid.UUID, from, to string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	op := r.operations[logID]
+	if op.Status != from {
+		return false, nil
+	}
+	op.Status = to
+	r.operations[logID] = op
+	return true, nil
+}
+
+func (r *fakeOperationsRepository) CancelOperationLog(ctx context.Context, logID uuid.UUID) error {
+	return nil
+}
+
+func (r *fakeOperationsRepository) CountOperations(ctx context.Context, userID, unitID uuid.UUID, actionType string, from, to time.Time) (int, error) {
+	return 0, nil
+}
+
+func (r *fakeOperationsRepository) LockQuota(ctx context.Context, userID, unitID uuid.UUID) error {
+	return nil
+}
+
+func (r *fakeOperationsRepository) ListAttachments(ctx context.Context, taskIDs []uuid.UUID) ([]taskModels.TaskAttachment, error) {
+	return nil, nil
+}
+
+func (r *fakeOperationsRepository) GetAttachment(ctx context.Context, operationID, attachmentID uuid.UUID) (*taskModels.TaskAttachment, error) {
+	return nil, sql.ErrNoRows
+}
+
+type fakeTaskRepository struct {
+	mu    sync.Mutex
+	tasks map[uuid.UUID]taskModels.Task // по operation_id
+}
+
+var _ taskRepository.Repository = &fakeTaskRepository{}
+
+func (r *fakeTaskRepository) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.tasks)
+}
+
+func (r *fakeTaskRepository) CreateTask(ctx context.Context, task *taskModels.Task) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.tasks[task.OperationID]; exists {
+		return false, nil
+	}
+	r.tasks[task.OperationID] = *task
+	return true, nil
+}
+
+func (r *fakeTaskRepository) GetTaskByOperationID(ctx context.Context, operationID uuid.UUID) (*taskModels.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	task, ok := r.tasks[operationID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &task, nil
+}
+
+func (r *fakeTaskRepository) GetTaskByID(ctx context.Context, taskID uuid.UUID) (*taskModels.Task, error) {
+	return nil, sql.ErrNoRows
+}
+func (r *fakeTaskRepository) GetTasks(ctx context.Context, filter taskModels.TaskFilter) ([]taskModels.TaskListItem, error) {
+	return nil, nil
+}
+func (r *fakeTaskRepository) UpdateTask(ctx context.Context, task *taskModels.Task, from taskModels.TaskStatus) error {
+	return nil
+}
+func (r *fakeTaskRepository) CancelTasksByOperationID(ctx context.Context, operationID uuid.UUID, cancelledBy *uuid.UUID) error {
+	return nil
+}
+func (r *fakeTaskRepository) GetTaskForUpdate(ctx context.Context, taskID uuid.UUID) (*taskModels.Task, error) {
+	return nil, sql.ErrNoRows
+}
+func (r *fakeTaskRepository) FetchExpiredClaims(ctx context.Context, claimedBefore time.Time, limit int) ([]taskModels.Task, error) {
+	return nil, nil
+}
+func (r *fakeTaskRepository) CreateAssignment(ctx context.Context, assignment *taskModels.TaskAssignment) error {
+	return nil
+}
+func (r *fakeTaskRepository) FetchOverdue(ctx context.Context, now time.Time, limit int) ([]taskModels.Task, error) {
+	return nil, nil
+}
+func (r *fakeTaskRepository) MarkOverdue(ctx context.Context, taskID uuid.UUID, at time.Time) error {
+	return nil
+}
+func (r *fakeTaskRepository) CreateAttachment(ctx context.Context, attachment *taskModels.TaskAttachment) error {
+	return nil
+}
+func (r *fakeTaskRepository) CreateTransition(ctx context.Context, transition *taskModels.TaskTransition) error {
+	return nil
+}
+func (r *fakeTaskRepository) GetTransitionsByTaskID(ctx context.Context, taskID uuid.UUID) ([]taskModels.TaskTransition, error) {
+	return nil, nil
+}
+func (r *fakeTaskRepository) GetAssignmentsByTaskID(ctx context.Context, taskID uuid.UUID) ([]taskModels.TaskAssignment, error) {
+	return nil, nil
+}
+
+// fakeRenderer возвращает одну и ту же задачу для любой операции.
+type fakeRenderer actionhandlers.TaskSpec
+
+func (r fakeRenderer) Render(ctx context.Context, op *models.OperationLog) (actionhandlers.TaskSpec, error) {
+	return actionhandlers.TaskSpec(r), nil
+}
+
+// fakeAutomation выполняет операции, если handled == true, и считает вызовы.
+type fakeAutomation struct {
+	handled bool
+	calls   int
+}
+
+func (a *fakeAutomation) Execute(ctx context.Context, op *models.OperationLog) (bool, error) {
+	a.calls++
+	return a.handled, nil
+}
+
+// --- Tests ---
+
+func TestProcessor(t *testing.T) {
+	ctx := context.Background()
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	renderer := fakeRenderer{Title: "Полить грядку"}
+
+	setup := func(op models.OperationLog) (*Processor, *fakeOperationsRepository, *fakeTaskRepository, []byte) {
+		opsRepo := newFakeOperationsRepository(op)
+		taskRepo := &fakeTaskRepository{tasks: map[uuid.UUID]taskModels.Task{}}
+		taskSvc := taskService.NewService(nil, taskRepo, opsRepo, nil, nil, nil, nil)
+		body, err := json.Marshal(op)
+		require.NoError(t, err)
+		return New(opsRepo, taskSvc, renderer, nil, logger), opsRepo, taskRepo, body
+	}
+
+	newOperation := func(status string) models.OperationLog {
+		return models.OperationLog{ID: uuid.New(), UnitID: uuid.New(), UnitType: "plot", ActionType: "water", Status: status}
+	}
+
+	t.Run("Replaying the same message creates one task", func(t *testing.T) {
+		op := newOperation(models.StatusPending)
+		proc, opsRepo, taskRepo, body := setup(op)
+
+		for i := 0; i < 3; i++ {
+			assert.NoError(t, proc.Handle(ctx, body))
+		}
+
+		assert.Equal(t, 1, taskRepo.count())
+		assert.Equal(t, models.StatusProcessing, opsRepo.status(op.ID))
+	})
+
+	t.Run("Concurrent redeliveries create one task", func(t *testing.T) {
+		op := newOperation(models.StatusPending)
+		proc, _, taskRepo, body := setup(op)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.NoError(t, proc.Handle(ctx, body))
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, 1, taskRepo.count())
+	})
+
+	t.Run("Redelivery after crash in processing completes the task", func(t *testing.T) {
+		// Воркер успел перевести операцию в processing, но упал до создания задачи.
+		op := newOperation(models.StatusProcessing)
+		proc, _, taskRepo, body := setup(op)
+
+		assert.NoError(t, proc.Handle(ctx, body))
+		assert.NoError(t, proc.Handle(ctx, body))
+
+		assert.Equal(t, 1, taskRepo.count())
+	})
+
+	t.Run("Stale message for advanced operation is skipped", func(t *testing.T) {
+		for _, status := range []string{models.StatusCancelled, models.StatusInProgress, models.StatusCompleted, models.StatusFailed} {
+			op := newOperation(status)
+			proc, opsRepo, taskRepo, body := setup(op)
+
+			assert.NoError(t, proc.Handle(ctx, body))
+
+			assert.Equal(t, 0, taskRepo.count(), status)
+			assert.Equal(t, status, opsRepo.status(op.ID))
+		}
+	})
+
+	t.Run("Operation handled by a device creates no task", func(t *testing.T) {
+		op := newOperation(models.StatusPending)
+		proc, _, taskRepo, body := setup(op)
+		automation := &fakeAutomation{handled: true}
+		proc.automation = automation
+
+		assert.NoError(t, proc.Handle(ctx, body))
+
+		assert.Equal(t, 1, automation.calls)
+		assert.Equal(t, 0, taskRepo.count())
+	})
+
+	t.Run("Operation without a device falls back to a task", func(t *testing.T) {
+		op := newOperation(models.StatusPending)
+		proc, _, taskRepo, body := setup(op)
+		automation := &fakeAutomation{handled: false}
+		proc.automation = automation
+
+		assert.NoError(t, proc.Handle(ctx, body))
+
+		assert.Equal(t, 1, automation.calls)
+		assert.Equal(t, 1, taskRepo.count())
+	})
+
+	t.Run("Malformed message is not retried", func(t *testing.T) {
+		proc, _, _, _ := setup(newOperation(models.StatusPending))
+
+		err := proc.Handle(ctx, []byte("not json"))
+
+		assert.ErrorIs(t, err, ErrInvalidMessage)
+	})
+
+	t.Run("Unknown operation is not retried", func(t *testing.T) {
+		proc, _, _, _ := setup(newOperation(models.StatusPending))
+		body, _ := json.Marshal(newOperation(models.StatusPending))
+
+		err := proc.Handle(ctx, body)
+
+		assert.ErrorIs(t, err, ErrInvalidMessage)
+	})
+}
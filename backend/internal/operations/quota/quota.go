@@ -0,0 +1,102 @@
+// Пакет quota содержит лимиты операций по тарифам аренды (например, 2 полива в день)
+// и вычисляет границы периода, за который считается использование лимита.
+package quota
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rendley/vegshare/backend/pkg/config"
+)
+
+// Периоды лимитов.
+const (
+	PeriodDay   = "day"
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+	// PeriodSeason - весь срок аренды.
+	PeriodSeason = "season"
+)
+
+// Policy хранит лимиты тарифов. Nil-политика ничего не ограничивает.
+type Policy struct {
+	defaultTariff string
+	tariffs       map[string]map[string]config.QuotaRule
+	loc           *time.Location
+}
+
+// NewPolicy проверяет лимиты из конфига и создает политику.
+func NewPolicy(cfg config.QuotasConfig) (*Policy, error) {
+	timezone := cfg.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("неизвестный часовой пояс лимитов '%s': %w", timezone, err)
+	}
+
+	if cfg.DefaultTariff != "" {
+		if _, ok := cfg.Tariffs[cfg.DefaultTariff]; !ok {
+			return nil, fmt.Errorf("тариф по умолчанию '%s' не описан в tariffs", cfg.DefaultTariff)
+		}
+	}
+	for tariff, rules := range cfg.Tariffs {
+		for actionType, rule := range rules {
+			if rule.Limit < 0 {
+				return nil, fmt.Errorf("тариф '%s', действие '%s': отрицательный лимит", tariff, actionType)
+			}
+			switch rule.Period {
+			case PeriodDay, PeriodWeek, PeriodMonth, PeriodSeason:
+			default:
+				return nil, fmt.Errorf("тариф '%s', действие '%s': неизвестный период '%s'", tariff, actionType, rule.Period)
+			}
+		}
+	}
+
+	return &Policy{defaultTariff: cfg.DefaultTariff, tariffs: cfg.Tariffs, loc: loc}, nil
+}
+
+// Rules возвращает лимиты тарифа. Тариф, которого нет в конфиге, получает лимиты тарифа по умолчанию.
+func (p *Policy) Rules(tariff string) map[string]config.QuotaRule {
+	if p == nil {
+		return nil
+	}
+	if rules, ok := p.tariffs[tariff]; ok {
+		return rules
+	}
+	return p.tariffs[p.defaultTariff]
+}
+
+// Rule возвращает лимит действия по тарифу; ok == false, если действие не ограничено.
+func (p *Policy) Rule(tariff, actionType string) (rule config.QuotaRule, ok bool) {
+	rule, ok = p.Rules(tariff)[actionType]
+	return rule, ok
+}
+
+// Window возвращает границы [start, end) периода, в который попадает now.
+// Дни, недели (с понедельника) и месяцы считаются в часовом поясе политики,
+// сезон совпадает со сроком аренды.
+func (p *Policy) Window(period string, now, leaseStart, leaseEnd time.Time) (start, end time.Time) {
+	loc := time.UTC
+	if p != nil {
+		loc = p.loc
+	}
+	local := now.In(loc)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+
+	switch period {
+	case PeriodDay:
+		return midnight, midnight.AddDate(0, 0, 1)
+	case PeriodWeek:
+		// В Go неделя начинается с воскресенья (Weekday == 0), у нас - с понедельника.
+		offset := (int(local.Weekday()) + 6) % 7
+		start = midnight.AddDate(0, 0, -offset)
+		return start, start.AddDate(0, 0, 7)
+	case PeriodMonth:
+		start = time.Date(local.Year(), local.Month(), 1, 0, 0, 0, 0, loc)
+		return start, start.AddDate(0, 1, 0)
+	default:
+		return leaseStart, leaseEnd
+	}
+}
@@ -0,0 +1,86 @@
+package quota
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rendley/vegshare/backend/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPolicy(t *testing.T) {
+	t.Run("Unknown period", func(t *testing.T) {
+		_, err := NewPolicy(config.QuotasConfig{Tariffs: map[string]map[string]config.QuotaRule{
+			"standard": {"water": {Limit: 2, Period: "year"}},
+		}})
+		assert.Error(t, err)
+	})
+
+	t.Run("Default tariff must be described", func(t *testing.T) {
+		_, err := NewPolicy(config.QuotasConfig{DefaultTariff: "standard"})
+		assert.Error(t, err)
+	})
+
+	t.Run("Unknown tariff falls back to default", func(t *testing.T) {
+		policy, err := NewPolicy(config.QuotasConfig{
+			DefaultTariff: "standard",
+			Tariffs: map[string]map[string]config.QuotaRule{
+				"standard": {"water": {Limit: 2, Period: PeriodDay}},
+				"premium":  {"water": {Limit: 4, Period: PeriodDay}},
+			},
+		})
+		require.NoError(t, err)
+
+		rule, ok := policy.Rule("legacy", "water")
+		assert.True(t, ok)
+		assert.Equal(t, 2, rule.Limit)
+
+		rule, ok = policy.Rule("premium", "water")
+		assert.True(t, ok)
+		assert.Equal(t, 4, rule.Limit)
+
+		_, ok = policy.Rule("premium", "harvest")
+		assert.False(t, ok)
+	})
+
+	t.Run("Nil policy limits nothing", func(t *testing.T) {
+		var policy *Policy
+		_, ok := policy.Rule("standard", "water")
+		assert.False(t, ok)
+	})
+}
+
+func TestWindow(t *testing.T) {
+	policy, err := NewPolicy(config.QuotasConfig{Timezone: "Europe/Moscow"})
+	require.NoError(t, err)
+	msk, _ := time.LoadLocation("Europe/Moscow")
+
+	// Среда, 22:30 UTC - в Москве уже четверг 01:30.
+	now := time.Date(2025, 9, 3, 22, 30, 0, 0, time.UTC)
+	leaseStart := time.Date(2025, 8, 15, 10, 0, 0, 0, time.UTC)
+	leaseEnd := leaseStart.AddDate(0, 3, 0)
+
+	cases := []struct {
+		period     string
+		start, end time.Time
+	}{
+		{PeriodDay, time.Date(2025, 9, 4, 0, 0, 0, 0, msk), time.Date(2025, 9, 5, 0, 0, 0, 0, msk)},
+		{PeriodWeek, time.Date(2025, 9, 1, 0, 0, 0, 0, msk), time.Date(2025, 9, 8, 0, 0, 0, 0, msk)},
+		{PeriodMonth, time.Date(2025, 9, 1, 0, 0, 0, 0, msk), time.Date(2025, 10, 1, 0, 0, 0, 0, msk)},
+		{PeriodSeason, leaseStart, leaseEnd},
+	}
+	for _, c := range cases {
+		t.Run(c.period, func(t *testing.T) {
+			start, end := policy.Window(c.period, now, leaseStart, leaseEnd)
+			assert.True(t, c.start.Equal(start), "start: %s", start)
+			assert.True(t, c.end.Equal(end), "end: %s", end)
+		})
+	}
+
+	t.Run("Week starts on Monday", func(t *testing.T) {
+		sunday := time.Date(2025, 9, 7, 12, 0, 0, 0, msk)
+		start, _ := policy.Window(PeriodWeek, sunday, leaseStart, leaseEnd)
+		assert.True(t, time.Date(2025, 9, 1, 0, 0, 0, 0, msk).Equal(start))
+	})
+}
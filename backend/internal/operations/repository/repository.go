@@ -2,20 +2,53 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/rendley/vegshare/backend/internal/operations/models"
+	taskModels "github.com/rendley/vegshare/backend/internal/task/models"
 	"github.com/rendley/vegshare/backend/pkg/database"
 )
 
+// ErrNotCancellable возвращается, если операция уже взята в работу или завершена.
+var ErrNotCancellable = errors.New("операцию нельзя отменить в текущем статусе")
+
+// ErrInvalidTransition возвращается, если конечный автомат операции не разрешает переход
+// из текущего статуса в запрошенный.
+var ErrInvalidTransition = errors.New("недопустимый переход статуса операции")
+
+// ErrAlreadyRetried возвращается, если у проваленной операции уже есть повтор.
+var ErrAlreadyRetried = errors.New("операция уже повторена")
+
 // Repository определяет контракт для хранилища операций.
 type Repository interface {
 	CreateOperationLog(ctx context.Context, log *models.OperationLog) error
 	GetOperationLogByID(ctx context.Context, logID uuid.UUID) (*models.OperationLog, error)
-	GetOperationLogsForUnit(ctx context.Context, unitID uuid.UUID) ([]models.OperationLog, error)
+	// ListOperationLogs возвращает историю операций по фильтру вместе со статусом задачи,
+	// от новых к старым, не больше filter.Limit записей после filter.Cursor.
+	ListOperationLogs(ctx context.Context, filter models.ActionFilter) ([]models.ActionHistoryItem, error)
 	DeleteOperationLog(ctx context.Context, logID uuid.UUID) error
+	// UpdateOperationLogStatus переводит операцию в статус status, если конечный автомат разрешает
+	// переход из текущего статуса; иначе возвращает ErrInvalidTransition.
 	UpdateOperationLogStatus(ctx context.Context, logID uuid.UUID, status string) error
+	// TransitionOperationLogStatus меняет статус, только если текущий равен from; ok == false, если статус уже другой.
+	TransitionOperationLogStatus(ctx context.Context, logID uuid.UUID, from, to string) (ok bool, err error)
+	// CancelOperationLog переводит операцию в cancelled, только если она еще в pending или processing.
+	CancelOperationLog(ctx context.Context, logID uuid.UUID) error
+	// CountOperations считает операции пользователя над юнитом с типом actionType, созданные
+	// в [from, to). Отмененные и завершившиеся ошибкой операции не считаются.
+	CountOperations(ctx context.Context, userID, unitID uuid.UUID, actionType string, from, to time.Time) (int, error)
+	// LockQuota блокирует лимиты пользователя на юните до конца транзакции, чтобы параллельные
+	// запросы не превысили лимит. Вызывается только внутри транзакции.
+	LockQuota(ctx context.Context, userID, unitID uuid.UUID) error
+	// ListAttachments возвращает фото выполнения задач taskIDs от старых к новым.
+	ListAttachments(ctx context.Context, taskIDs []uuid.UUID) ([]taskModels.TaskAttachment, error)
+	// GetAttachment возвращает фото выполнения задачи операции operationID.
+	GetAttachment(ctx context.Context, operationID, attachmentID uuid.UUID) (*taskModels.TaskAttachment, error)
 }
 
 type repository struct {
@@ -27,10 +60,14 @@ func NewRepository(db database.DBTX) Repository {
 }
 
 func (r *repository) CreateOperationLog(ctx context.Context, log *models.OperationLog) error {
-	query := `INSERT INTO operation_log (id, unit_id, unit_type, user_id, action_type, parameters, status, executed_at, created_at, updated_at) 
-	          VALUES (:id, :unit_id, :unit_type, :user_id, :action_type, :parameters, :status, :executed_at, :created_at, :updated_at)`
+	query := `INSERT INTO operation_log (id, unit_id, unit_type, user_id, action_type, parameters, status, executed_at, created_at, updated_at, schedule_id, retry_of) 
+	          VALUES (:id, :unit_id, :unit_type, :user_id, :action_type, :parameters, :status, :executed_at, :created_at, :updated_at, :schedule_id, :retry_of)`
 	_, err := r.db.NamedExecContext(ctx, query, log)
 	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "operation_log_retry_of_key" {
+			return ErrAlreadyRetried
+		}
 		return fmt.Errorf("не удалось создать запись в журнале операций: %w", err)
 	}
 	return nil
@@ -46,14 +83,54 @@ func (r *repository) GetOperationLogByID(ctx context.Context, logID uuid.UUID) (
 	return &log, nil
 }
 
-func (r *repository) GetOperationLogsForUnit(ctx context.Context, unitID uuid.UUID) ([]models.OperationLog, error) {
-	var logs []models.OperationLog
-	query := `SELECT * FROM operation_log WHERE unit_id = $1 ORDER BY created_at DESC`
-	err := r.db.SelectContext(ctx, &logs, query, unitID)
-	if err != nil {
-		return nil, fmt.Errorf("не удалось получить журнал операций для юнита: %w", err)
+func (r *repository) ListOperationLogs(ctx context.Context, filter models.ActionFilter) ([]models.ActionHistoryItem, error) {
+	query := `
+        SELECT o.*, t.id AS task_id, t.status AS task_status, t.completion_note, t.failure_reason, t.failure_comment,
+            (SELECT r.id FROM operation_log r WHERE r.retry_of = o.id) AS retried_by
+        FROM operation_log o
+        LEFT JOIN tasks t ON t.operation_id = o.id`
+
+	var conditions []string
+	var args []interface{}
+	addCondition := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(cond, len(args)))
+	}
+
+	if filter.UserID != nil {
+		addCondition("o.user_id = $%d", *filter.UserID)
+	}
+	if filter.UnitID != nil {
+		addCondition("o.unit_id = $%d", *filter.UnitID)
+	}
+	if filter.Status != "" {
+		addCondition("o.status = $%d", filter.Status)
+	}
+	if filter.ActionType != "" {
+		addCondition("o.action_type = $%d", filter.ActionType)
+	}
+	if filter.From != nil {
+		addCondition("o.created_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		addCondition("o.created_at <= $%d", *filter.To)
+	}
+	if filter.Cursor != nil {
+		args = append(args, filter.Cursor.CreatedAt, filter.Cursor.ID)
+		conditions = append(conditions, fmt.Sprintf("(o.created_at, o.id) < ($%d, $%d)", len(args)-1, len(args)))
 	}
-	return logs, nil
+
+	if len(conditions) > 0 {
+		query += "\n        WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, filter.Limit)
+	query += fmt.Sprintf("\n        ORDER BY o.created_at DESC, o.id DESC\n        LIMIT $%d", len(args))
+
+	items := []models.ActionHistoryItem{}
+	if err := r.db.SelectContext(ctx, &items, query, args...); err != nil {
+		return nil, fmt.Errorf("не удалось получить историю операций: %w", err)
+	}
+	return items, nil
 }
 
 func (r *repository) DeleteOperationLog(ctx context.Context, logID uuid.UUID) error {
@@ -66,10 +143,93 @@ func (r *repository) DeleteOperationLog(ctx context.Context, logID uuid.UUID) er
 }
 
 func (r *repository) UpdateOperationLogStatus(ctx context.Context, logID uuid.UUID, status string) error {
-	query := `UPDATE operation_log SET status = $1, updated_at = NOW() WHERE id = $2`
-	_, err := r.db.ExecContext(ctx, query, status, logID)
+	query := `UPDATE operation_log SET status = $1, updated_at = NOW() WHERE id = $2 AND status = ANY($3)`
+	result, err := r.db.ExecContext(ctx, query, status, logID, pq.Array(models.SourceStatuses(status)))
 	if err != nil {
 		return fmt.Errorf("не удалось обновить статус операции: %w", err)
 	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("не удалось проверить результат обновления статуса: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: операция %s -> '%s'", ErrInvalidTransition, logID, status)
+	}
+	return nil
+}
+
+func (r *repository) CancelOperationLog(ctx context.Context, logID uuid.UUID) error {
+	query := `UPDATE operation_log SET status = $1, updated_at = NOW() WHERE id = $2 AND status IN ($3, $4)`
+	result, err := r.db.ExecContext(ctx, query, models.StatusCancelled, logID, models.StatusPending, models.StatusProcessing)
+	if err != nil {
+		return fmt.Errorf("не удалось отменить операцию: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("не удалось проверить результат отмены: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrNotCancellable
+	}
 	return nil
 }
+
+func (r *repository) TransitionOperationLogStatus(ctx context.Context, logID uuid.UUID, from, to string) (bool, error) {
+	if !models.CanTransition(from, to) {
+		return false, fmt.Errorf("%w: '%s' -> '%s'", ErrInvalidTransition, from, to)
+	}
+	query := `UPDATE operation_log SET status = $1, updated_at = NOW() WHERE id = $2 AND status = $3`
+	result, err := r.db.ExecContext(ctx, query, to, logID, from)
+	if err != nil {
+		return false, fmt.Errorf("не удалось обновить статус операции: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("не удалось проверить результат обновления статуса: %w", err)
+	}
+	return rowsAffected > 0, nil
+}
+
+func (r *repository) CountOperations(ctx context.Context, userID, unitID uuid.UUID, actionType string, from, to time.Time) (int, error) {
+	query := `SELECT COUNT(*) FROM operation_log
+	          WHERE user_id = $1 AND unit_id = $2 AND action_type = $3
+	            AND created_at >= $4 AND created_at < $5 AND status NOT IN ($6, $7)`
+	var count int
+	err := r.db.GetContext(ctx, &count, query, userID, unitID, actionType, from, to, models.StatusCancelled, models.StatusFailed)
+	if err != nil {
+		return 0, fmt.Errorf("не удалось посчитать операции: %w", err)
+	}
+	return count, nil
+}
+
+func (r *repository) LockQuota(ctx context.Context, userID, unitID uuid.UUID) error {
+	// Транзакционная advisory-блокировка снимается при коммите или откате.
+	query := `SELECT pg_advisory_xact_lock(hashtext($1))`
+	if _, err := r.db.ExecContext(ctx, query, "quota:"+userID.String()+":"+unitID.String()); err != nil {
+		return fmt.Errorf("не удалось заблокировать лимиты операций: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) ListAttachments(ctx context.Context, taskIDs []uuid.UUID) ([]taskModels.TaskAttachment, error) {
+	attachments := []taskModels.TaskAttachment{}
+	if len(taskIDs) == 0 {
+		return attachments, nil
+	}
+	query := `SELECT * FROM task_attachments WHERE task_id = ANY($1) ORDER BY created_at, id`
+	if err := r.db.SelectContext(ctx, &attachments, query, pq.Array(taskIDs)); err != nil {
+		return nil, fmt.Errorf("не удалось получить фото выполнения: %w", err)
+	}
+	return attachments, nil
+}
+
+func (r *repository) GetAttachment(ctx context.Context, operationID, attachmentID uuid.UUID) (*taskModels.TaskAttachment, error) {
+	var attachment taskModels.TaskAttachment
+	query := `SELECT a.* FROM task_attachments a
+	          JOIN tasks t ON t.id = a.task_id
+	          WHERE a.id = $1 AND t.operation_id = $2`
+	if err := r.db.GetContext(ctx, &attachment, query, attachmentID, operationID); err != nil {
+		return nil, fmt.Errorf("не удалось получить фото выполнения: %w", err)
+	}
+	return &attachment, nil
+}
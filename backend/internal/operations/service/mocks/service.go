@@ -0,0 +1,120 @@
+// Package mocks содержит общий testify-мок сервиса операций для тестов модулей,
+// которые создают операции от имени пользователя или системы (расписания, доставка).
+package mocks
+
+import (
+	"context"
+	"io"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/rendley/vegshare/backend/internal/operations/actions"
+	"github.com/rendley/vegshare/backend/internal/operations/models"
+	"github.com/rendley/vegshare/backend/internal/operations/service"
+	taskModels "github.com/rendley/vegshare/backend/internal/task/models"
+	"github.com/stretchr/testify/mock"
+)
+
+// OperationsService - мок service.Service.
+type OperationsService struct {
+	mock.Mock
+}
+
+var _ service.Service = &OperationsService{}
+
+func (m *OperationsService) CreateAction(ctx context.Context, userID uuid.UUID, req service.ActionRequest) (*models.OperationLog, error) {
+	args := m.Called(ctx, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.OperationLog), args.Error(1)
+}
+
+func (m *OperationsService) ValidateAction(ctx context.Context, userID uuid.UUID, req service.ActionRequest) error {
+	return m.Called(ctx, userID, req).Error(0)
+}
+
+func (m *OperationsService) CreateScheduledAction(ctx context.Context, tx *sqlx.Tx, userID, scheduleID uuid.UUID, req service.ActionRequest) (*models.OperationLog, error) {
+	args := m.Called(ctx, tx, userID, scheduleID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.OperationLog), args.Error(1)
+}
+
+func (m *OperationsService) CreateInternalAction(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, req service.ActionRequest) (*models.OperationLog, error) {
+	args := m.Called(ctx, tx, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.OperationLog), args.Error(1)
+}
+
+func (m *OperationsService) GetMyActions(ctx context.Context, userID uuid.UUID, filter models.ActionFilter) (*models.ActionPage, error) {
+	args := m.Called(ctx, userID, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ActionPage), args.Error(1)
+}
+
+func (m *OperationsService) GetActionsForUnit(ctx context.Context, userID, unitID uuid.UUID, filter models.ActionFilter) (*models.ActionPage, error) {
+	args := m.Called(ctx, userID, unitID, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ActionPage), args.Error(1)
+}
+
+func (m *OperationsService) RetryAction(ctx context.Context, userID, logID uuid.UUID) (*models.OperationLog, error) {
+	args := m.Called(ctx, userID, logID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.OperationLog), args.Error(1)
+}
+
+func (m *OperationsService) CancelAction(ctx context.Context, userID, logID uuid.UUID) (*models.OperationLog, error) {
+	args := m.Called(ctx, userID, logID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.OperationLog), args.Error(1)
+}
+
+func (m *OperationsService) CancelInternalAction(ctx context.Context, tx *sqlx.Tx, userID, logID uuid.UUID) (*models.OperationLog, error) {
+	args := m.Called(ctx, tx, userID, logID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.OperationLog), args.Error(1)
+}
+
+func (m *OperationsService) GetQuotas(ctx context.Context, userID, unitID uuid.UUID) ([]models.QuotaUsage, error) {
+	args := m.Called(ctx, userID, unitID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.QuotaUsage), args.Error(1)
+}
+
+func (m *OperationsService) OpenAttachment(ctx context.Context, userID, logID, attachmentID uuid.UUID) (*taskModels.TaskAttachment, io.ReadCloser, error) {
+	args := m.Called(ctx, userID, logID, attachmentID)
+	var attachment *taskModels.TaskAttachment
+	if a := args.Get(0); a != nil {
+		attachment = a.(*taskModels.TaskAttachment)
+	}
+	var body io.ReadCloser
+	if b := args.Get(1); b != nil {
+		body = b.(io.ReadCloser)
+	}
+	return attachment, body, args.Error(2)
+}
+
+func (m *OperationsService) GetActionTypes() []*actions.ActionType {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).([]*actions.ActionType)
+}
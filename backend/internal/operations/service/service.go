@@ -3,114 +3,532 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	leasingModels "github.com/rendley/vegshare/backend/internal/leasing/models"
 	leasingRepository "github.com/rendley/vegshare/backend/internal/leasing/repository"
+	"github.com/rendley/vegshare/backend/internal/operations/actions"
 	operationsModels "github.com/rendley/vegshare/backend/internal/operations/models"
+	"github.com/rendley/vegshare/backend/internal/operations/quota"
 	"github.com/rendley/vegshare/backend/internal/operations/repository"
+	outboxModels "github.com/rendley/vegshare/backend/internal/outbox/models"
+	outboxRepository "github.com/rendley/vegshare/backend/internal/outbox/repository"
+	taskModels "github.com/rendley/vegshare/backend/internal/task/models"
+	taskRepository "github.com/rendley/vegshare/backend/internal/task/repository"
 	"github.com/rendley/vegshare/backend/pkg/config"
-	"github.com/rendley/vegshare/backend/pkg/rabbitmq"
+	"github.com/rendley/vegshare/backend/pkg/database"
+	"github.com/rendley/vegshare/backend/pkg/storage"
+)
+
+// ErrNotActionOwner возвращается при попытке отменить чужую операцию.
+var ErrNotActionOwner = errors.New("операция принадлежит другому пользователю")
+
+// ErrNotRetryable возвращается при попытке повторить операцию, которая не провалена.
+var ErrNotRetryable = errors.New("повторить можно только проваленную операцию")
+
+// ErrNoActiveLease возвращается, если у пользователя нет активной аренды юнита.
+var ErrNoActiveLease = errors.New("у пользователя нет активной аренды юнита")
+
+// ErrQuotaExceeded возвращается, если лимит действия по тарифу аренды исчерпан.
+var ErrQuotaExceeded = errors.New("лимит действия исчерпан")
+
+// QuotaExceededError описывает исчерпанный лимит; errors.Is(err, ErrQuotaExceeded) == true.
+type QuotaExceededError struct {
+	Usage operationsModels.QuotaUsage
+}
+
+// periodNames - периоды лимитов для сообщений об ошибках.
+var periodNames = map[string]string{
+	quota.PeriodDay:    "день",
+	quota.PeriodWeek:   "неделю",
+	quota.PeriodMonth:  "месяц",
+	quota.PeriodSeason: "сезон",
+}
+
+func (e *QuotaExceededError) Error() string {
+	u := e.Usage
+	msg := fmt.Sprintf("%s: '%s' не больше %d за %s", ErrQuotaExceeded, u.ActionType, u.Limit, periodNames[u.Period])
+	if u.Period == quota.PeriodSeason {
+		return msg + " аренды"
+	}
+	return msg + ", снова доступно с " + u.PeriodEnd.Format("2006-01-02 15:04 MST")
+}
+
+func (e *QuotaExceededError) Unwrap() error {
+	return ErrQuotaExceeded
+}
+
+// Размер страницы истории операций.
+const (
+	defaultHistoryLimit = 50
+	maxHistoryLimit     = 200
 )
 
 // ActionRequest - это структура для запроса на создание нового действия.
 type ActionRequest struct {
-	UnitID     uuid.UUID       `json:"unit_id"`
-	UnitType   string          `json:"unit_type"`
-	ActionType string          `json:"action_type"`
+	UnitID     uuid.UUID       `json:"unit_id" validate:"required"`
+	UnitType   string          `json:"unit_type" validate:"required"`
+	ActionType string          `json:"action_type" validate:"required"`
 	Parameters json.RawMessage `json:"parameters"`
 }
 
 // Service определяет контракт для бизнес-логики операций.
 type Service interface {
 	CreateAction(ctx context.Context, userID uuid.UUID, req ActionRequest) (*operationsModels.OperationLog, error)
-	GetActionsForUnit(ctx context.Context, unitID uuid.UUID) ([]operationsModels.OperationLog, error)
-	CancelAction(ctx context.Context, logID uuid.UUID) error
+	// ValidateAction выполняет проверки CreateAction (аренда, тип действия, параметры), не создавая операцию.
+	ValidateAction(ctx context.Context, userID uuid.UUID, req ActionRequest) error
+	// CreateScheduledAction создает операцию по расписанию внутри транзакции планировщика tx
+	// с теми же проверками, что и CreateAction.
+	CreateScheduledAction(ctx context.Context, tx *sqlx.Tx, userID, scheduleID uuid.UUID, req ActionRequest) (*operationsModels.OperationLog, error)
+	// CreateInternalAction создает операцию от имени системы (например, доставку урожая)
+	// внутри транзакции вызывающего модуля tx, без проверки аренды и реестра пользовательских действий.
+	CreateInternalAction(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, req ActionRequest) (*operationsModels.OperationLog, error)
+	// GetMyActions возвращает историю операций пользователя по фильтру.
+	GetMyActions(ctx context.Context, userID uuid.UUID, filter operationsModels.ActionFilter) (*operationsModels.ActionPage, error)
+	// GetActionsForUnit возвращает историю юнита. Она доступна только текущему арендатору
+	// и только с начала его аренды: операции прошлых арендаторов он не видит.
+	GetActionsForUnit(ctx context.Context, userID, unitID uuid.UUID, filter operationsModels.ActionFilter) (*operationsModels.ActionPage, error)
+	// RetryAction создает новую операцию с теми же параметрами взамен проваленной и связывает ее
+	// с исходной через retry_of. Проверки те же, что у CreateAction; повторить операцию можно один раз.
+	RetryAction(ctx context.Context, userID, logID uuid.UUID) (*operationsModels.OperationLog, error)
+	// CancelAction отменяет операцию владельца, пока она не взята персоналом в работу.
+	CancelAction(ctx context.Context, userID, logID uuid.UUID) (*operationsModels.OperationLog, error)
+	// CancelInternalAction отменяет операцию внутри транзакции вызывающего модуля tx с теми же
+	// проверками, что и CancelAction. Уже отмененная операция не считается ошибкой.
+	CancelInternalAction(ctx context.Context, tx *sqlx.Tx, userID, logID uuid.UUID) (*operationsModels.OperationLog, error)
+	// GetQuotas возвращает лимиты тарифа текущей аренды юнита и сколько из них осталось.
+	GetQuotas(ctx context.Context, userID, unitID uuid.UUID) ([]operationsModels.QuotaUsage, error)
+	// OpenAttachment открывает фото выполнения операции. Фото доступны владельцу операции
+	// и текущему арендатору юнита - тем же, кто видит операцию в истории.
+	OpenAttachment(ctx context.Context, userID, logID, attachmentID uuid.UUID) (*taskModels.TaskAttachment, io.ReadCloser, error)
+	GetActionTypes() []*actions.ActionType
 }
 
 type service struct {
+	db          *sqlx.DB
 	repo        repository.Repository
 	leasingRepo leasingRepository.Repository
-	rabbitmq    rabbitmq.ClientInterface
+	registry    *actions.Registry
+	quotas      *quota.Policy
+	storage     storage.Storage
 	cfg         *config.Config
+
+	// Фабрики репозиториев поверх транзакции; в тестах подменяются моками.
+	newRepo     func(db database.DBTX) repository.Repository
+	newTaskRepo func(db database.DBTX) taskRepository.Repository
+	newOutbox   func(db database.DBTX) outboxRepository.Repository
 }
 
-// NewOperationsService - конструктор для сервиса.
-func NewOperationsService(repo repository.Repository, leasingRepo leasingRepository.Repository, rabbitmq rabbitmq.ClientInterface, cfg *config.Config) Service {
+// NewOperationsService - конструктор для сервиса. Nil quotas - без лимитов.
+func NewOperationsService(db *sqlx.DB, repo repository.Repository, leasingRepo leasingRepository.Repository, registry *actions.Registry, quotas *quota.Policy, store storage.Storage, cfg *config.Config) Service {
 	return &service{
+		db:          db,
 		repo:        repo,
 		leasingRepo: leasingRepo,
-		rabbitmq:    rabbitmq,
+		registry:    registry,
+		quotas:      quotas,
+		storage:     store,
 		cfg:         cfg,
+		newRepo:     repository.NewRepository,
+		newTaskRepo: taskRepository.NewRepository,
+		newOutbox:   outboxRepository.NewRepository,
 	}
 }
 
 func (s *service) CreateAction(ctx context.Context, userID uuid.UUID, req ActionRequest) (*operationsModels.OperationLog, error) {
+	lease, err := s.validate(ctx, userID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.createAndPublish(ctx, newLogEntry(userID, req), lease)
+}
+
+func (s *service) ValidateAction(ctx context.Context, userID uuid.UUID, req ActionRequest) error {
+	_, err := s.validate(ctx, userID, req)
+	return err
+}
+
+// validate проверяет аренду и параметры действия и возвращает аренду, по тарифу которой считаются лимиты.
+func (s *service) validate(ctx context.Context, userID uuid.UUID, req ActionRequest) (*leasingModels.Lease, error) {
 	// 1. Проверяем, что у пользователя есть активная аренда для данного юнита
+	lease, err := s.activeLease(ctx, userID, req.UnitID)
+	if err != nil {
+		return nil, err
+	}
+	if string(lease.UnitType) != req.UnitType {
+		return nil, fmt.Errorf("%w %s", ErrNoActiveLease, req.UnitID)
+	}
+
+	// 2. Валидируем тип действия, его применимость к юниту и параметры по схеме из реестра
+	if err := s.registry.Validate(req.ActionType, req.UnitType, req.Parameters); err != nil {
+		return nil, err
+	}
+	return lease, nil
+}
+
+// activeLease возвращает активную аренду юнита пользователем или ErrNoActiveLease.
+func (s *service) activeLease(ctx context.Context, userID, unitID uuid.UUID) (*leasingModels.Lease, error) {
 	leases, err := s.leasingRepo.GetLeasesByUserID(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("не удалось проверить аренду: %w", err)
 	}
 
-	var hasActiveLease bool
-	for _, lease := range leases {
-		if lease.UnitID == req.UnitID && string(lease.UnitType) == req.UnitType && lease.Status == "active" {
-			hasActiveLease = true
-			break
+	for i := range leases {
+		if leases[i].UnitID == unitID && leases[i].Status == leasingModels.LeaseStatusActive {
+			return &leases[i], nil
 		}
 	}
-	if !hasActiveLease {
-		return nil, fmt.Errorf("у пользователя нет активной аренды для юнита %s", req.UnitID)
+	return nil, fmt.Errorf("%w %s", ErrNoActiveLease, unitID)
+}
+
+func (s *service) CreateScheduledAction(ctx context.Context, tx *sqlx.Tx, userID, scheduleID uuid.UUID, req ActionRequest) (*operationsModels.OperationLog, error) {
+	lease, err := s.validate(ctx, userID, req)
+	if err != nil {
+		return nil, err
 	}
 
-	// 2. TODO: Валидация action_type и parameters (например, через отдельный сервис-валидатор)
-	//    Сейчас мы доверяем данным, приходящим от клиента.
+	logEntry := newLogEntry(userID, req)
+	logEntry.ScheduleID = &scheduleID
+	if err := s.checkQuota(ctx, s.newRepo(tx), lease, req.ActionType, logEntry.CreatedAt); err != nil {
+		return nil, err
+	}
+	if err := s.create(ctx, tx, logEntry); err != nil {
+		return nil, err
+	}
+	return logEntry, nil
+}
+
+func (s *service) CreateInternalAction(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, req ActionRequest) (*operationsModels.OperationLog, error) {
+	logEntry := newLogEntry(userID, req)
+	if err := s.create(ctx, tx, logEntry); err != nil {
+		return nil, err
+	}
+	return logEntry, nil
+}
+
+func (s *service) RetryAction(ctx context.Context, userID, logID uuid.UUID) (*operationsModels.OperationLog, error) {
+	failed, err := s.repo.GetOperationLogByID(ctx, logID)
+	if err != nil {
+		return nil, err
+	}
+	if failed.UserID != userID {
+		return nil, ErrNotActionOwner
+	}
+	if failed.Status != operationsModels.StatusFailed {
+		return nil, fmt.Errorf("%w: '%s'", ErrNotRetryable, failed.Status)
+	}
+
+	req := ActionRequest{UnitID: failed.UnitID, UnitType: failed.UnitType, ActionType: failed.ActionType, Parameters: failed.Parameters}
+	lease, err := s.validate(ctx, userID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Проваленные операции не входят в лимиты тарифа, поэтому повтор занимает место исходной.
+	logEntry := newLogEntry(userID, req)
+	logEntry.RetryOf = &failed.ID
+	return s.createAndPublish(ctx, logEntry, lease)
+}
+
+// createAndPublish записывает операцию в журнал и, в той же транзакции, сообщение для воркера в outbox.
+// Публикацией в RabbitMQ занимается relay, поэтому операция не может остаться без сообщения.
+// В той же транзакции проверяется лимит тарифа аренды lease.
+func (s *service) createAndPublish(ctx context.Context, logEntry *operationsModels.OperationLog, lease *leasingModels.Lease) (*operationsModels.OperationLog, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось начать транзакцию: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := s.checkQuota(ctx, s.newRepo(tx), lease, logEntry.ActionType, logEntry.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	if err := s.create(ctx, tx, logEntry); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("не удалось закоммитить транзакцию: %w", err)
+	}
+
+	return logEntry, nil
+}
+
+// checkQuota возвращает *QuotaExceededError, если лимит действия по тарифу аренды исчерпан.
+// repoTx должен работать в транзакции, в которой создается операция: блокировка лимитов
+// держится до ее конца, поэтому параллельные запросы не проходят проверку одновременно.
+func (s *service) checkQuota(ctx context.Context, repoTx repository.Repository, lease *leasingModels.Lease, actionType string, now time.Time) error {
+	rule, ok := s.quotas.Rule(lease.Tariff, actionType)
+	if !ok {
+		return nil
+	}
+	if err := repoTx.LockQuota(ctx, lease.UserID, lease.UnitID); err != nil {
+		return err
+	}
+	usage, err := s.usage(ctx, repoTx, lease, actionType, rule, now)
+	if err != nil {
+		return err
+	}
+	if usage.Remaining == 0 {
+		return &QuotaExceededError{Usage: usage}
+	}
+	return nil
+}
+
+// usage считает использование лимита rule в текущем периоде.
+func (s *service) usage(ctx context.Context, repo repository.Repository, lease *leasingModels.Lease, actionType string, rule config.QuotaRule, now time.Time) (operationsModels.QuotaUsage, error) {
+	start, end := s.quotas.Window(rule.Period, now, lease.StartDate, lease.EndDate)
+	used, err := repo.CountOperations(ctx, lease.UserID, lease.UnitID, actionType, start, end)
+	if err != nil {
+		return operationsModels.QuotaUsage{}, err
+	}
+	return operationsModels.QuotaUsage{
+		ActionType:  actionType,
+		Limit:       rule.Limit,
+		Period:      rule.Period,
+		Used:        used,
+		Remaining:   max(rule.Limit-used, 0),
+		PeriodStart: start,
+		PeriodEnd:   end,
+	}, nil
+}
+
+func (s *service) GetQuotas(ctx context.Context, userID, unitID uuid.UUID) ([]operationsModels.QuotaUsage, error) {
+	lease, err := s.activeLease(ctx, userID, unitID)
+	if err != nil {
+		return nil, err
+	}
 
-	// 3. Создаем запись в журнале операций
 	now := time.Now()
-	logEntry := &operationsModels.OperationLog{
+	result := []operationsModels.QuotaUsage{}
+	for actionType, rule := range s.quotas.Rules(lease.Tariff) {
+		usage, err := s.usage(ctx, s.repo, lease, actionType, rule, now)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, usage)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ActionType < result[j].ActionType })
+	return result, nil
+}
+
+// newLogEntry создает новую операцию в статусе pending.
+func newLogEntry(userID uuid.UUID, req ActionRequest) *operationsModels.OperationLog {
+	now := time.Now()
+	return &operationsModels.OperationLog{
 		ID:         uuid.New(),
 		UnitID:     req.UnitID,
 		UnitType:   req.UnitType,
 		UserID:     userID,
 		ActionType: req.ActionType,
 		Parameters: req.Parameters,
-		Status:     "pending", // Начальный статус
-		ExecutedAt: now,     // Можно установить в null и обновлять в воркере
+		Status:     operationsModels.StatusPending, // Начальный статус
+		ExecutedAt: now,                            // Можно установить в null и обновлять в воркере
 		CreatedAt:  now,
 		UpdatedAt:  now,
 	}
+}
 
-	if err := s.repo.CreateOperationLog(ctx, logEntry); err != nil {
-		return nil, err
+// create записывает операцию в журнал и сообщение для воркера в outbox в рамках транзакции tx.
+func (s *service) create(ctx context.Context, tx *sqlx.Tx, logEntry *operationsModels.OperationLog) error {
+	// 3. Создаем запись в журнале операций
+	if err := s.newRepo(tx).CreateOperationLog(ctx, logEntry); err != nil {
+		return err
 	}
 
-	// 4. Отправляем сообщение в RabbitMQ
+	// 4. Ставим сообщение для воркера в outbox
+	return s.enqueue(ctx, tx, s.cfg.RabbitMQ.Queues["actions"], logEntry)
+}
+
+// enqueue сериализует операцию и записывает ее в outbox в рамках транзакции tx.
+func (s *service) enqueue(ctx context.Context, tx *sqlx.Tx, queue string, logEntry *operationsModels.OperationLog) error {
 	body, err := json.Marshal(logEntry)
 	if err != nil {
-		// В реальном приложении здесь нужна более сложная логика обработки ошибок,
-		// возможно, откат транзакции создания записи в логе.
-		return nil, fmt.Errorf("failed to marshal action message: %w", err)
+		return fmt.Errorf("failed to marshal action message: %w", err)
+	}
+	return s.newOutbox(tx).Create(ctx, outboxModels.NewMessage(queue, string(body)))
+}
+
+func (s *service) GetMyActions(ctx context.Context, userID uuid.UUID, filter operationsModels.ActionFilter) (*operationsModels.ActionPage, error) {
+	filter.UserID = &userID
+	return s.listActions(ctx, filter)
+}
+
+func (s *service) GetActionsForUnit(ctx context.Context, userID, unitID uuid.UUID, filter operationsModels.ActionFilter) (*operationsModels.ActionPage, error) {
+	lease, err := s.activeLease(ctx, userID, unitID)
+	if err != nil {
+		return nil, err
+	}
+
+	filter.UnitID = &unitID
+	filter.UserID = nil
+	if filter.From == nil || filter.From.Before(lease.StartDate) {
+		filter.From = &lease.StartDate
+	}
+	return s.listActions(ctx, filter)
+}
+
+// listActions читает страницу истории. Запрашивается на одну запись больше лимита,
+// чтобы понять, есть ли следующая страница.
+func (s *service) listActions(ctx context.Context, filter operationsModels.ActionFilter) (*operationsModels.ActionPage, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultHistoryLimit
+	}
+	if limit > maxHistoryLimit {
+		limit = maxHistoryLimit
+	}
+	filter.Limit = limit + 1
+
+	items, err := s.repo.ListOperationLogs(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &operationsModels.ActionPage{Items: items}
+	if len(items) > limit {
+		page.Items = items[:limit]
+		last := page.Items[limit-1]
+		page.NextCursor = operationsModels.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
+	}
+	if err := s.attachPhotos(ctx, page.Items); err != nil {
+		return nil, err
 	}
+	return page, nil
+}
 
-	queueName := s.cfg.RabbitMQ.Queues["actions"] // Универсальная очередь
-	if err := s.rabbitmq.Publish(queueName, string(body)); err != nil {
-		// Та же логика обработки ошибок
-		return nil, fmt.Errorf("failed to publish action message: %w", err)
+// attachPhotos добавляет к операциям страницы фото выполнения их задач одним запросом.
+func (s *service) attachPhotos(ctx context.Context, items []operationsModels.ActionHistoryItem) error {
+	var taskIDs []uuid.UUID
+	byTask := make(map[uuid.UUID]*operationsModels.ActionHistoryItem)
+	for i := range items {
+		if items[i].TaskID != nil && items[i].TaskStatus != nil && *items[i].TaskStatus == string(taskModels.StatusCompleted) {
+			taskIDs = append(taskIDs, *items[i].TaskID)
+			byTask[*items[i].TaskID] = &items[i]
+		}
+	}
+	if len(taskIDs) == 0 {
+		return nil
+	}
+
+	attachments, err := s.repo.ListAttachments(ctx, taskIDs)
+	if err != nil {
+		return err
+	}
+	for _, a := range attachments {
+		item := byTask[a.TaskID]
+		item.Attachments = append(item.Attachments, a)
+	}
+	return nil
+}
+
+func (s *service) OpenAttachment(ctx context.Context, userID, logID, attachmentID uuid.UUID) (*taskModels.TaskAttachment, io.ReadCloser, error) {
+	logEntry, err := s.repo.GetOperationLogByID(ctx, logID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if logEntry.UserID != userID {
+		// Как в истории юнита: текущий арендатор видит операции с начала своей аренды.
+		lease, err := s.activeLease(ctx, userID, logEntry.UnitID)
+		if err != nil || logEntry.CreatedAt.Before(lease.StartDate) {
+			return nil, nil, ErrNotActionOwner
+		}
+	}
+
+	attachment, err := s.repo.GetAttachment(ctx, logID, attachmentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	content, err := s.storage.Get(ctx, attachment.StorageKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("не удалось открыть фото %s: %w", attachmentID, err)
+	}
+	return attachment, content, nil
+}
+
+// GetActionTypes возвращает все доступные типы действий со схемами параметров.
+func (s *service) GetActionTypes() []*actions.ActionType {
+	return s.registry.All()
+}
+
+func (s *service) CancelAction(ctx context.Context, userID, logID uuid.UUID) (*operationsModels.OperationLog, error) {
+	logEntry, err := s.repo.GetOperationLogByID(ctx, logID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkCancellable(logEntry, userID); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось начать транзакцию: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := s.cancel(ctx, tx, userID, logEntry); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("не удалось закоммитить транзакцию: %w", err)
 	}
 
 	return logEntry, nil
 }
 
-func (s *service) GetActionsForUnit(ctx context.Context, unitID uuid.UUID) ([]operationsModels.OperationLog, error) {
-	return s.repo.GetOperationLogsForUnit(ctx, unitID)
+func (s *service) CancelInternalAction(ctx context.Context, tx *sqlx.Tx, userID, logID uuid.UUID) (*operationsModels.OperationLog, error) {
+	logEntry, err := s.newRepo(tx).GetOperationLogByID(ctx, logID)
+	if err != nil {
+		return nil, err
+	}
+	// Повторный вызов после уже выполненной отмены (например, если операцию отменили
+	// напрямую через API) не должен мешать вызывающему модулю завершить свою часть.
+	if logEntry.UserID == userID && logEntry.Status == operationsModels.StatusCancelled {
+		return logEntry, nil
+	}
+	if err := checkCancellable(logEntry, userID); err != nil {
+		return nil, err
+	}
+	if err := s.cancel(ctx, tx, userID, logEntry); err != nil {
+		return nil, err
+	}
+	return logEntry, nil
+}
+
+// checkCancellable проверяет, что операция принадлежит userID и еще не взята персоналом в работу.
+func checkCancellable(logEntry *operationsModels.OperationLog, userID uuid.UUID) error {
+	if logEntry.UserID != userID {
+		return ErrNotActionOwner
+	}
+	if logEntry.Status != operationsModels.StatusPending && logEntry.Status != operationsModels.StatusProcessing {
+		return fmt.Errorf("%w: '%s'", repository.ErrNotCancellable, logEntry.Status)
+	}
+	return nil
 }
 
-func (s *service) CancelAction(ctx context.Context, logID uuid.UUID) error {
-	// TODO: Добавить логику отмены. 
-	// Например, можно обновить статус в БД на 'cancelled' 
-	// и, возможно, отправить компенсирующее событие в RabbitMQ.
-	// Сейчас просто удаляем для простоты.
-	return s.repo.DeleteOperationLog(ctx, logID)
-}
\ No newline at end of file
+// cancel отменяет операцию и ее задачу в транзакции tx и записывает событие отмены в outbox.
+func (s *service) cancel(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, logEntry *operationsModels.OperationLog) error {
+	// Условное обновление защищает от гонки с персоналом, который мог взять задачу в работу.
+	if err := s.newRepo(tx).CancelOperationLog(ctx, logEntry.ID); err != nil {
+		return err
+	}
+	if err := s.newTaskRepo(tx).CancelTasksByOperationID(ctx, logEntry.ID, &userID); err != nil {
+		return fmt.Errorf("не удалось отменить задачу операции: %w", err)
+	}
+
+	logEntry.Status = operationsModels.StatusCancelled
+	logEntry.UpdatedAt = time.Now()
+
+	// Событие отмены нужно воркеру, чтобы отбросить сообщение, если задача по операции
+	// создается прямо сейчас. Оно записывается в outbox вместе с отменой.
+	return s.enqueue(ctx, tx, s.cfg.RabbitMQ.Queues["cancellations"], logEntry)
+}
@@ -3,18 +3,30 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"io"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	leasingModels "github.com/rendley/vegshare/backend/internal/leasing/models"
 	leasingRepository "github.com/rendley/vegshare/backend/internal/leasing/repository"
+	"github.com/rendley/vegshare/backend/internal/operations/actions"
 	operationsModels "github.com/rendley/vegshare/backend/internal/operations/models"
+	"github.com/rendley/vegshare/backend/internal/operations/quota"
 	operationsRepository "github.com/rendley/vegshare/backend/internal/operations/repository"
+	outboxModels "github.com/rendley/vegshare/backend/internal/outbox/models"
+	outboxRepository "github.com/rendley/vegshare/backend/internal/outbox/repository"
+	outboxMocks "github.com/rendley/vegshare/backend/internal/outbox/repository/mocks"
+	taskModels "github.com/rendley/vegshare/backend/internal/task/models"
+	taskRepository "github.com/rendley/vegshare/backend/internal/task/repository"
 	"github.com/rendley/vegshare/backend/pkg/config"
-	"github.com/rendley/vegshare/backend/pkg/rabbitmq"
-	"github.com/streadway/amqp"
+	"github.com/rendley/vegshare/backend/pkg/database"
+	"github.com/rendley/vegshare/backend/pkg/database/dbtest"
+	"github.com/rendley/vegshare/backend/pkg/storage"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // --- Mocks ---
@@ -28,12 +40,12 @@ func (m *MockOperationsRepository) CreateOperationLog(ctx context.Context, log *
 	return args.Error(0)
 }
 
-func (m *MockOperationsRepository) GetOperationLogsForUnit(ctx context.Context, unitID uuid.UUID) ([]operationsModels.OperationLog, error) {
-	args := m.Called(ctx, unitID)
+func (m *MockOperationsRepository) ListOperationLogs(ctx context.Context, filter operationsModels.ActionFilter) ([]operationsModels.ActionHistoryItem, error) {
+	args := m.Called(ctx, filter)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).([]operationsModels.OperationLog), args.Error(1)
+	return args.Get(0).([]operationsModels.ActionHistoryItem), args.Error(1)
 }
 
 func (m *MockOperationsRepository) DeleteOperationLog(ctx context.Context, logID uuid.UUID) error {
@@ -54,6 +66,41 @@ func (m *MockOperationsRepository) UpdateOperationLogStatus(ctx context.Context,
 	return args.Error(0)
 }
 
+func (m *MockOperationsRepository) CancelOperationLog(ctx context.Context, logID uuid.UUID) error {
+	args := m.Called(ctx, logID)
+	return args.Error(0)
+}
+
+func (m *MockOperationsRepository) TransitionOperationLogStatus(ctx context.Context, logID uuid.UUID, from, to string) (bool, error) {
+	args := m.Called(ctx, logID, from, to)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockOperationsRepository) CountOperations(ctx context.Context, userID, unitID uuid.UUID, actionType string, from, to time.Time) (int, error) {
+	args := m.Called(ctx, userID, unitID, actionType, from, to)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockOperationsRepository) LockQuota(ctx context.Context, userID, unitID uuid.UUID) error {
+	return m.Called(ctx, userID, unitID).Error(0)
+}
+
+func (m *MockOperationsRepository) ListAttachments(ctx context.Context, taskIDs []uuid.UUID) ([]taskModels.TaskAttachment, error) {
+	args := m.Called(ctx, taskIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]taskModels.TaskAttachment), args.Error(1)
+}
+
+func (m *MockOperationsRepository) GetAttachment(ctx context.Context, operationID, attachmentID uuid.UUID) (*taskModels.TaskAttachment, error) {
+	args := m.Called(ctx, operationID, attachmentID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*taskModels.TaskAttachment), args.Error(1)
+}
+
 var _ operationsRepository.Repository = &MockOperationsRepository{}
 
 type MockLeasingRepository struct {
@@ -80,20 +127,37 @@ func (m *MockLeasingRepository) CreateLease(ctx context.Context, lease *leasingM
 	return m.Called(ctx, lease).Error(0)
 }
 
+func (m *MockLeasingRepository) GetLeaseByID(ctx context.Context, leaseID uuid.UUID) (*leasingModels.Lease, error) {
+	args := m.Called(ctx, leaseID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*leasingModels.Lease), args.Error(1)
+}
+
+func (m *MockLeasingRepository) GetLeases(ctx context.Context, filter leasingModels.LeaseFilter) ([]leasingModels.AdminLease, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]leasingModels.AdminLease), args.Error(1)
+}
+
+func (m *MockLeasingRepository) UpdateLease(ctx context.Context, lease *leasingModels.Lease) error {
+	return m.Called(ctx, lease).Error(0)
+}
+
 var _ leasingRepository.Repository = &MockLeasingRepository{}
 
-type MockRabbitMQClient struct {
+// MockTaskRepository - мок репозитория задач; операциям из него нужна только отмена задач.
+type MockTaskRepository struct {
+	taskRepository.Repository
 	mock.Mock
 }
 
-func (m *MockRabbitMQClient) Publish(queueName, body string) error {
-	args := m.Called(queueName, body)
-	return args.Error(0)
+func (m *MockTaskRepository) CancelTasksByOperationID(ctx context.Context, operationID uuid.UUID, cancelledBy *uuid.UUID) error {
+	return m.Called(ctx, operationID, cancelledBy).Error(0)
 }
-func (m *MockRabbitMQClient) Consume(queueName string) (<-chan amqp.Delivery, error) { return nil, nil }
-func (m *MockRabbitMQClient) Close()                                                 { m.Called() }
-
-var _ rabbitmq.ClientInterface = &MockRabbitMQClient{}
 
 // --- Tests ---
 
@@ -101,14 +165,33 @@ func TestOperationsService(t *testing.T) {
 	ctx := context.Background()
 	mockOpsRepo := new(MockOperationsRepository)
 	mockLeasingRepo := new(MockLeasingRepository)
-	mockRabbitMQ := new(MockRabbitMQClient)
+	mockTaskRepo := new(MockTaskRepository)
+	mockOutbox := new(outboxMocks.Repository)
 	cfg := &config.Config{
 		RabbitMQ: config.RabbitMQConfig{
-			Queues: map[string]string{"actions": "actions_queue_test"},
+			Queues: map[string]string{"actions": "actions_queue_test", "cancellations": "cancellations_queue_test"},
 		},
 	}
 
-	opsSvc := NewOperationsService(mockOpsRepo, mockLeasingRepo, mockRabbitMQ, cfg)
+	quotas, err := quota.NewPolicy(config.QuotasConfig{
+		DefaultTariff: "standard",
+		Tariffs: map[string]map[string]config.QuotaRule{
+			"standard": {
+				"water": {Limit: 2, Period: quota.PeriodDay},
+				"plant": {Limit: 1, Period: quota.PeriodSeason},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	store, err := storage.NewLocal(t.TempDir())
+	require.NoError(t, err)
+
+	db, stats := dbtest.New()
+	opsSvc := NewOperationsService(db, mockOpsRepo, mockLeasingRepo, actions.NewDefaultRegistry(), quotas, store, cfg)
+	opsSvc.(*service).newRepo = func(database.DBTX) operationsRepository.Repository { return mockOpsRepo }
+	opsSvc.(*service).newTaskRepo = func(database.DBTX) taskRepository.Repository { return mockTaskRepo }
+	opsSvc.(*service).newOutbox = func(database.DBTX) outboxRepository.Repository { return mockOutbox }
 
 	t.Run("CreateAction", func(t *testing.T) {
 		t.Run("Success", func(t *testing.T) {
@@ -116,17 +199,22 @@ func TestOperationsService(t *testing.T) {
 			userID := uuid.New()
 			unitID := uuid.New()
 			leaseID := uuid.New()
-			activeLease := []leasingModels.Lease{{ID: leaseID, UnitID: unitID, UserID: userID, Status: "active", UnitType: "plot"}}
+			leaseStart := time.Now().AddDate(0, -1, 0)
+			activeLease := []leasingModels.Lease{{ID: leaseID, UnitID: unitID, UserID: userID, Status: "active", UnitType: "plot", Tariff: "standard", StartDate: leaseStart, EndDate: leaseStart.AddDate(0, 3, 0)}}
 			req := ActionRequest{
 				UnitID:     unitID,
 				UnitType:   "plot",
 				ActionType: "plant",
-				Parameters: json.RawMessage(`{"crop_id": "` + uuid.New().String() + `"}`),
+				Parameters: json.RawMessage(`{"item_id": "` + uuid.New().String() + `", "quantity": 3}`),
 			}
 
 			mockLeasingRepo.On("GetLeasesByUserID", ctx, userID).Return(activeLease, nil).Once()
+			mockOpsRepo.On("LockQuota", ctx, userID, unitID).Return(nil).Once()
+			mockOpsRepo.On("CountOperations", ctx, userID, unitID, "plant", mock.Anything, mock.Anything).Return(0, nil).Once()
 			mockOpsRepo.On("CreateOperationLog", ctx, mock.AnythingOfType("*models.OperationLog")).Return(nil).Once()
-			mockRabbitMQ.On("Publish", cfg.RabbitMQ.Queues["actions"], mock.AnythingOfType("string")).Return(nil).Once()
+			mockOutbox.On("Create", ctx, mock.MatchedBy(func(msg *outboxModels.Message) bool {
+				return msg.Queue == cfg.RabbitMQ.Queues["actions"]
+			})).Return(nil).Once()
 
 			// Act
 			logEntry, err := opsSvc.CreateAction(ctx, userID, req)
@@ -137,7 +225,7 @@ func TestOperationsService(t *testing.T) {
 			assert.Equal(t, "pending", logEntry.Status)
 			mockLeasingRepo.AssertExpectations(t)
 			mockOpsRepo.AssertExpectations(t)
-			mockRabbitMQ.AssertExpectations(t)
+			mockOutbox.AssertExpectations(t)
 		})
 
 		t.Run("No active lease", func(t *testing.T) {
@@ -157,9 +245,555 @@ func TestOperationsService(t *testing.T) {
 			logEntry, err := opsSvc.CreateAction(ctx, userID, req)
 
 			// Assert
-			assert.Error(t, err)
+			assert.ErrorIs(t, err, ErrNoActiveLease)
+			assert.Nil(t, logEntry)
+			mockLeasingRepo.AssertExpectations(t)
+		})
+
+		t.Run("Invalid parameters", func(t *testing.T) {
+			// Arrange
+			userID := uuid.New()
+			unitID := uuid.New()
+			activeLease := []leasingModels.Lease{{UnitID: unitID, UserID: userID, Status: "active", UnitType: "plot"}}
+			req := ActionRequest{
+				UnitID:     unitID,
+				UnitType:   "plot",
+				ActionType: "plant",
+				Parameters: json.RawMessage(`{"item_id": "not-a-uuid", "quantity": 0}`),
+			}
+
+			mockLeasingRepo.On("GetLeasesByUserID", ctx, userID).Return(activeLease, nil).Once()
+
+			// Act
+			logEntry, err := opsSvc.CreateAction(ctx, userID, req)
+
+			// Assert
+			assert.Nil(t, logEntry)
+			var validationErr *actions.ValidationError
+			assert.ErrorAs(t, err, &validationErr)
+			assert.Len(t, validationErr.Fields, 2)
+			mockLeasingRepo.AssertExpectations(t)
+		})
+
+		t.Run("Over quota is rejected", func(t *testing.T) {
+			// Arrange
+			userID := uuid.New()
+			unitID := uuid.New()
+			leaseStart := time.Now().AddDate(0, -1, 0)
+			activeLease := []leasingModels.Lease{{ID: uuid.New(), UnitID: unitID, UserID: userID, Status: "active", UnitType: "plot", Tariff: "standard", StartDate: leaseStart, EndDate: leaseStart.AddDate(0, 3, 0)}}
+			req := ActionRequest{
+				UnitID:     unitID,
+				UnitType:   "plot",
+				ActionType: "plant",
+				Parameters: json.RawMessage(`{"item_id": "` + uuid.New().String() + `", "quantity": 3}`),
+			}
+			rolledBack := stats.RolledBack()
+
+			mockLeasingRepo.On("GetLeasesByUserID", ctx, userID).Return(activeLease, nil).Once()
+			mockOpsRepo.On("LockQuota", ctx, userID, unitID).Return(nil).Once()
+			mockOpsRepo.On("CountOperations", ctx, userID, unitID, "plant", mock.Anything, mock.Anything).Return(1, nil).Once()
+
+			// Act
+			logEntry, err := opsSvc.CreateAction(ctx, userID, req)
+
+			// Assert
 			assert.Nil(t, logEntry)
+			assert.ErrorIs(t, err, ErrQuotaExceeded)
+			var quotaErr *QuotaExceededError
+			require.ErrorAs(t, err, &quotaErr)
+			assert.Equal(t, 1, quotaErr.Usage.Limit)
+			assert.Equal(t, 1, quotaErr.Usage.Used)
+			assert.Equal(t, 0, quotaErr.Usage.Remaining)
+			assert.Equal(t, rolledBack+1, stats.RolledBack())
+			mockOpsRepo.AssertNotCalled(t, "CreateOperationLog", ctx, mock.MatchedBy(func(op *operationsModels.OperationLog) bool {
+				return op.UserID == userID
+			}))
 			mockLeasingRepo.AssertExpectations(t)
+			mockOpsRepo.AssertExpectations(t)
 		})
 	})
-}
\ No newline at end of file
+
+	t.Run("CreateInternalAction skips lease check", func(t *testing.T) {
+		// Arrange
+		userID := uuid.New()
+		req := ActionRequest{
+			UnitID:     uuid.New(),
+			UnitType:   "plot",
+			ActionType: actions.ActionPickup,
+			Parameters: json.RawMessage(`{}`),
+		}
+
+		tx, err := db.Beginx()
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		mockOpsRepo.On("CreateOperationLog", ctx, mock.AnythingOfType("*models.OperationLog")).Return(nil).Once()
+		mockOutbox.On("Create", ctx, mock.MatchedBy(func(msg *outboxModels.Message) bool {
+			return msg.Queue == cfg.RabbitMQ.Queues["actions"]
+		})).Return(nil).Once()
+
+		// Act
+		logEntry, err := opsSvc.CreateInternalAction(ctx, tx, userID, req)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, actions.ActionPickup, logEntry.ActionType)
+		mockLeasingRepo.AssertNotCalled(t, "GetLeasesByUserID", ctx, userID)
+		mockOpsRepo.AssertExpectations(t)
+		mockOutbox.AssertExpectations(t)
+	})
+
+	t.Run("CreateScheduledAction - Over quota is rejected", func(t *testing.T) {
+		// Arrange
+		userID := uuid.New()
+		unitID := uuid.New()
+		scheduleID := uuid.New()
+		leaseStart := time.Now().AddDate(0, -1, 0)
+		activeLease := []leasingModels.Lease{{ID: uuid.New(), UnitID: unitID, UserID: userID, Status: "active", UnitType: "plot", Tariff: "standard", StartDate: leaseStart, EndDate: leaseStart.AddDate(0, 3, 0)}}
+		req := ActionRequest{
+			UnitID:     unitID,
+			UnitType:   "plot",
+			ActionType: "water",
+			Parameters: json.RawMessage(`{}`),
+		}
+
+		tx, err := db.Beginx()
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		mockLeasingRepo.On("GetLeasesByUserID", ctx, userID).Return(activeLease, nil).Once()
+		mockOpsRepo.On("LockQuota", ctx, userID, unitID).Return(nil).Once()
+		mockOpsRepo.On("CountOperations", ctx, userID, unitID, "water", mock.Anything, mock.Anything).Return(2, nil).Once()
+
+		// Act
+		logEntry, err := opsSvc.CreateScheduledAction(ctx, tx, userID, scheduleID, req)
+
+		// Assert
+		assert.Nil(t, logEntry)
+		var quotaErr *QuotaExceededError
+		require.ErrorAs(t, err, &quotaErr)
+		assert.Equal(t, "water", quotaErr.Usage.ActionType)
+		assert.Equal(t, 2, quotaErr.Usage.Limit)
+		assert.Equal(t, 0, quotaErr.Usage.Remaining)
+		mockOpsRepo.AssertNotCalled(t, "CreateOperationLog", ctx, mock.MatchedBy(func(op *operationsModels.OperationLog) bool {
+			return op.ScheduleID != nil && *op.ScheduleID == scheduleID
+		}))
+		mockLeasingRepo.AssertExpectations(t)
+		mockOpsRepo.AssertExpectations(t)
+	})
+
+	t.Run("CancelAction", func(t *testing.T) {
+		t.Run("Not owner", func(t *testing.T) {
+			// Arrange
+			logID := uuid.New()
+			logEntry := &operationsModels.OperationLog{ID: logID, UserID: uuid.New(), Status: operationsModels.StatusPending}
+			mockOpsRepo.On("GetOperationLogByID", ctx, logID).Return(logEntry, nil).Once()
+
+			// Act
+			cancelled, err := opsSvc.CancelAction(ctx, uuid.New(), logID)
+
+			// Assert
+			assert.ErrorIs(t, err, ErrNotActionOwner)
+			assert.Nil(t, cancelled)
+			mockOpsRepo.AssertNotCalled(t, "CancelOperationLog", ctx, logID)
+		})
+
+		t.Run("Already in progress", func(t *testing.T) {
+			// Arrange
+			userID := uuid.New()
+			logID := uuid.New()
+			logEntry := &operationsModels.OperationLog{ID: logID, UserID: userID, Status: operationsModels.StatusInProgress}
+			mockOpsRepo.On("GetOperationLogByID", ctx, logID).Return(logEntry, nil).Once()
+
+			// Act
+			cancelled, err := opsSvc.CancelAction(ctx, userID, logID)
+
+			// Assert
+			assert.ErrorIs(t, err, operationsRepository.ErrNotCancellable)
+			assert.Nil(t, cancelled)
+			mockOpsRepo.AssertNotCalled(t, "CancelOperationLog", ctx, logID)
+		})
+
+		t.Run("Success", func(t *testing.T) {
+			// Arrange
+			userID := uuid.New()
+			logID := uuid.New()
+			committed := stats.Committed()
+			logEntry := &operationsModels.OperationLog{ID: logID, UserID: userID, Status: operationsModels.StatusPending}
+			mockOpsRepo.On("GetOperationLogByID", ctx, logID).Return(logEntry, nil).Once()
+			mockOpsRepo.On("CancelOperationLog", ctx, logID).Return(nil).Once()
+			mockTaskRepo.On("CancelTasksByOperationID", ctx, logID, &userID).Return(nil).Once()
+			mockOutbox.On("Create", ctx, mock.MatchedBy(func(msg *outboxModels.Message) bool {
+				var published operationsModels.OperationLog
+				if err := json.Unmarshal([]byte(msg.Payload), &published); err != nil {
+					return false
+				}
+				return msg.Queue == cfg.RabbitMQ.Queues["cancellations"] && published.ID == logID && published.Status == operationsModels.StatusCancelled
+			})).Return(nil).Once()
+
+			// Act
+			cancelled, err := opsSvc.CancelAction(ctx, userID, logID)
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, operationsModels.StatusCancelled, cancelled.Status)
+			assert.Equal(t, committed+1, stats.Committed())
+			mockOpsRepo.AssertExpectations(t)
+			mockTaskRepo.AssertExpectations(t)
+			mockOutbox.AssertExpectations(t)
+		})
+	})
+
+	t.Run("CancelInternalAction", func(t *testing.T) {
+		t.Run("Cancels inside the caller's transaction", func(t *testing.T) {
+			// Arrange
+			userID := uuid.New()
+			logID := uuid.New()
+			committed := stats.Committed()
+			tx, err := db.Beginx()
+			require.NoError(t, err)
+			defer tx.Rollback()
+			logEntry := &operationsModels.OperationLog{ID: logID, UserID: userID, Status: operationsModels.StatusPending}
+			mockOpsRepo.On("GetOperationLogByID", ctx, logID).Return(logEntry, nil).Once()
+			mockOpsRepo.On("CancelOperationLog", ctx, logID).Return(nil).Once()
+			mockTaskRepo.On("CancelTasksByOperationID", ctx, logID, &userID).Return(nil).Once()
+			mockOutbox.On("Create", ctx, mock.MatchedBy(func(msg *outboxModels.Message) bool {
+				return msg.Queue == cfg.RabbitMQ.Queues["cancellations"] && strings.Contains(msg.Payload, logID.String())
+			})).Return(nil).Once()
+
+			// Act
+			cancelled, err := opsSvc.CancelInternalAction(ctx, tx, userID, logID)
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, operationsModels.StatusCancelled, cancelled.Status)
+			assert.Equal(t, committed, stats.Committed())
+			mockOpsRepo.AssertExpectations(t)
+			mockTaskRepo.AssertExpectations(t)
+			mockOutbox.AssertExpectations(t)
+		})
+
+		t.Run("Already cancelled is not an error", func(t *testing.T) {
+			// Arrange
+			userID := uuid.New()
+			logID := uuid.New()
+			tx, err := db.Beginx()
+			require.NoError(t, err)
+			defer tx.Rollback()
+			logEntry := &operationsModels.OperationLog{ID: logID, UserID: userID, Status: operationsModels.StatusCancelled}
+			mockOpsRepo.On("GetOperationLogByID", ctx, logID).Return(logEntry, nil).Once()
+
+			// Act
+			cancelled, err := opsSvc.CancelInternalAction(ctx, tx, userID, logID)
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, operationsModels.StatusCancelled, cancelled.Status)
+			mockOpsRepo.AssertNotCalled(t, "CancelOperationLog", ctx, logID)
+		})
+
+		t.Run("Already in progress", func(t *testing.T) {
+			// Arrange
+			userID := uuid.New()
+			logID := uuid.New()
+			tx, err := db.Beginx()
+			require.NoError(t, err)
+			defer tx.Rollback()
+			logEntry := &operationsModels.OperationLog{ID: logID, UserID: userID, Status: operationsModels.StatusInProgress}
+			mockOpsRepo.On("GetOperationLogByID", ctx, logID).Return(logEntry, nil).Once()
+
+			// Act
+			cancelled, err := opsSvc.CancelInternalAction(ctx, tx, userID, logID)
+
+			// Assert
+			assert.ErrorIs(t, err, operationsRepository.ErrNotCancellable)
+			assert.Nil(t, cancelled)
+			mockOpsRepo.AssertNotCalled(t, "CancelOperationLog", ctx, logID)
+		})
+	})
+
+	t.Run("RetryAction", func(t *testing.T) {
+		t.Run("Success links the retry and a second retry is rejected", func(t *testing.T) {
+			// Arrange
+			userID, unitID, logID := uuid.New(), uuid.New(), uuid.New()
+			leaseStart := time.Now().AddDate(0, -1, 0)
+			activeLease := []leasingModels.Lease{{ID: uuid.New(), UnitID: unitID, UserID: userID, Status: "active", UnitType: "plot", Tariff: "standard", StartDate: leaseStart, EndDate: leaseStart.AddDate(0, 3, 0)}}
+			failed := &operationsModels.OperationLog{ID: logID, UserID: userID, UnitID: unitID, UnitType: "plot", ActionType: "water", Parameters: json.RawMessage(`{"volume_liters": 5}`), Status: operationsModels.StatusFailed}
+			isRetry := mock.MatchedBy(func(l *operationsModels.OperationLog) bool { return l.RetryOf != nil && *l.RetryOf == logID })
+			mockOpsRepo.On("GetOperationLogByID", ctx, logID).Return(failed, nil).Twice()
+			mockLeasingRepo.On("GetLeasesByUserID", ctx, userID).Return(activeLease, nil).Twice()
+			mockOpsRepo.On("LockQuota", ctx, userID, unitID).Return(nil).Twice()
+			mockOpsRepo.On("CountOperations", ctx, userID, unitID, "water", mock.Anything, mock.Anything).Return(0, nil).Twice()
+			mockOpsRepo.On("CreateOperationLog", ctx, isRetry).Return(nil).Once()
+			mockOutbox.On("Create", ctx, mock.MatchedBy(func(msg *outboxModels.Message) bool {
+				return msg.Queue == cfg.RabbitMQ.Queues["actions"] && strings.Contains(msg.Payload, logID.String())
+			})).Return(nil).Once()
+			// Уникальный индекс по retry_of не дает создать второй повтор той же операции.
+			mockOpsRepo.On("CreateOperationLog", ctx, isRetry).Return(operationsRepository.ErrAlreadyRetried).Once()
+
+			// Act
+			retry, err := opsSvc.RetryAction(ctx, userID, logID)
+			require.NoError(t, err)
+			second, secondErr := opsSvc.RetryAction(ctx, userID, logID)
+
+			// Assert
+			assert.Equal(t, logID, *retry.RetryOf)
+			assert.NotEqual(t, logID, retry.ID)
+			assert.Equal(t, "pending", retry.Status)
+			assert.Equal(t, "water", retry.ActionType)
+			assert.ErrorIs(t, secondErr, operationsRepository.ErrAlreadyRetried)
+			assert.Nil(t, second)
+			mockOpsRepo.AssertExpectations(t)
+			mockOutbox.AssertExpectations(t)
+		})
+
+		t.Run("Not owner", func(t *testing.T) {
+			// Arrange
+			logID := uuid.New()
+			logEntry := &operationsModels.OperationLog{ID: logID, UserID: uuid.New(), Status: operationsModels.StatusFailed}
+			mockOpsRepo.On("GetOperationLogByID", ctx, logID).Return(logEntry, nil).Once()
+
+			// Act
+			retry, err := opsSvc.RetryAction(ctx, uuid.New(), logID)
+
+			// Assert
+			assert.ErrorIs(t, err, ErrNotActionOwner)
+			assert.Nil(t, retry)
+		})
+
+		t.Run("Only failed operations can be retried", func(t *testing.T) {
+			// Arrange
+			userID := uuid.New()
+			logID := uuid.New()
+			logEntry := &operationsModels.OperationLog{ID: logID, UserID: userID, Status: operationsModels.StatusCompleted}
+			mockOpsRepo.On("GetOperationLogByID", ctx, logID).Return(logEntry, nil).Once()
+
+			// Act
+			retry, err := opsSvc.RetryAction(ctx, userID, logID)
+
+			// Assert
+			assert.ErrorIs(t, err, ErrNotRetryable)
+			assert.Nil(t, retry)
+		})
+
+		t.Run("Lease has ended since the failure", func(t *testing.T) {
+			// Arrange
+			userID := uuid.New()
+			logID := uuid.New()
+			logEntry := &operationsModels.OperationLog{ID: logID, UserID: userID, UnitID: uuid.New(), UnitType: "plot", ActionType: "water", Status: operationsModels.StatusFailed}
+			mockOpsRepo.On("GetOperationLogByID", ctx, logID).Return(logEntry, nil).Once()
+			mockLeasingRepo.On("GetLeasesByUserID", ctx, userID).Return([]leasingModels.Lease{}, nil).Once()
+
+			// Act
+			retry, err := opsSvc.RetryAction(ctx, userID, logID)
+
+			// Assert
+			assert.ErrorIs(t, err, ErrNoActiveLease)
+			assert.Nil(t, retry)
+			mockOpsRepo.AssertNotCalled(t, "CreateOperationLog", ctx, mock.MatchedBy(func(l *operationsModels.OperationLog) bool {
+				return l.RetryOf != nil && *l.RetryOf == logID
+			}))
+		})
+	})
+
+	t.Run("GetMyActions", func(t *testing.T) {
+		t.Run("Filters by user and returns next cursor", func(t *testing.T) {
+			// Arrange
+			userID := uuid.New()
+			now := time.Now()
+			items := []operationsModels.ActionHistoryItem{
+				{OperationLog: operationsModels.OperationLog{ID: uuid.New(), CreatedAt: now}},
+				{OperationLog: operationsModels.OperationLog{ID: uuid.New(), CreatedAt: now.Add(-time.Minute)}},
+				{OperationLog: operationsModels.OperationLog{ID: uuid.New(), CreatedAt: now.Add(-2 * time.Minute)}},
+			}
+			mockOpsRepo.On("ListOperationLogs", ctx, mock.MatchedBy(func(f operationsModels.ActionFilter) bool {
+				// На одну запись больше лимита - чтобы узнать, есть ли следующая страница.
+				return f.UserID != nil && *f.UserID == userID && f.Limit == 3 && f.Status == operationsModels.StatusCompleted
+			})).Return(items, nil).Once()
+
+			// Act
+			page, err := opsSvc.GetMyActions(ctx, userID, operationsModels.ActionFilter{Status: operationsModels.StatusCompleted, Limit: 2})
+
+			// Assert
+			assert.NoError(t, err)
+			assert.Len(t, page.Items, 2)
+			cursor, err := operationsModels.DecodeCursor(page.NextCursor)
+			assert.NoError(t, err)
+			assert.Equal(t, items[1].ID, cursor.ID)
+			assert.True(t, items[1].CreatedAt.Equal(cursor.CreatedAt))
+			mockOpsRepo.AssertExpectations(t)
+		})
+
+		t.Run("Last page has no cursor", func(t *testing.T) {
+			// Arrange
+			userID := uuid.New()
+			items := []operationsModels.ActionHistoryItem{{OperationLog: operationsModels.OperationLog{ID: uuid.New()}}}
+			mockOpsRepo.On("ListOperationLogs", ctx, mock.MatchedBy(func(f operationsModels.ActionFilter) bool {
+				return f.UserID != nil && *f.UserID == userID && f.Limit == defaultHistoryLimit+1
+			})).Return(items, nil).Once()
+
+			// Act
+			page, err := opsSvc.GetMyActions(ctx, userID, operationsModels.ActionFilter{})
+
+			// Assert
+			assert.NoError(t, err)
+			assert.Len(t, page.Items, 1)
+			assert.Empty(t, page.NextCursor)
+		})
+
+		t.Run("Completed tasks carry their photos", func(t *testing.T) {
+			// Arrange
+			userID := uuid.New()
+			completedTaskID, newTaskID := uuid.New(), uuid.New()
+			completed, open := string(taskModels.StatusCompleted), string(taskModels.StatusNew)
+			note := "Посадили 10 кустов фасоли"
+			items := []operationsModels.ActionHistoryItem{
+				{OperationLog: operationsModels.OperationLog{ID: uuid.New()}, TaskID: &completedTaskID, TaskStatus: &completed, CompletionNote: &note},
+				{OperationLog: operationsModels.OperationLog{ID: uuid.New()}, TaskID: &newTaskID, TaskStatus: &open},
+				{OperationLog: operationsModels.OperationLog{ID: uuid.New()}},
+			}
+			photo := taskModels.TaskAttachment{ID: uuid.New(), TaskID: completedTaskID, FileName: "beans.jpg"}
+			mockOpsRepo.On("ListOperationLogs", ctx, mock.MatchedBy(func(f operationsModels.ActionFilter) bool {
+				return f.UserID != nil && *f.UserID == userID
+			})).Return(items, nil).Once()
+			// Фото запрашиваются одним запросом и только для выполненных задач.
+			mockOpsRepo.On("ListAttachments", ctx, []uuid.UUID{completedTaskID}).Return([]taskModels.TaskAttachment{photo}, nil).Once()
+
+			// Act
+			page, err := opsSvc.GetMyActions(ctx, userID, operationsModels.ActionFilter{})
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, []taskModels.TaskAttachment{photo}, page.Items[0].Attachments)
+			assert.Equal(t, &note, page.Items[0].CompletionNote)
+			assert.Empty(t, page.Items[1].Attachments)
+			mockOpsRepo.AssertExpectations(t)
+		})
+	})
+
+	t.Run("OpenAttachment", func(t *testing.T) {
+		t.Run("Owner reads the stored photo", func(t *testing.T) {
+			// Arrange
+			userID := uuid.New()
+			logID := uuid.New()
+			attachment := &taskModels.TaskAttachment{ID: uuid.New(), StorageKey: "tasks/1/photo.jpg", ContentType: "image/jpeg"}
+			require.NoError(t, store.Put(ctx, attachment.StorageKey, strings.NewReader("jpeg")))
+			mockOpsRepo.On("GetOperationLogByID", ctx, logID).Return(&operationsModels.OperationLog{ID: logID, UserID: userID}, nil).Once()
+			mockOpsRepo.On("GetAttachment", ctx, logID, attachment.ID).Return(attachment, nil).Once()
+
+			// Act
+			got, content, err := opsSvc.OpenAttachment(ctx, userID, logID, attachment.ID)
+
+			// Assert
+			require.NoError(t, err)
+			defer content.Close()
+			data, _ := io.ReadAll(content)
+			assert.Equal(t, attachment, got)
+			assert.Equal(t, "jpeg", string(data))
+		})
+
+		t.Run("Stranger without a lease is forbidden", func(t *testing.T) {
+			// Arrange
+			userID := uuid.New()
+			logID := uuid.New()
+			mockOpsRepo.On("GetOperationLogByID", ctx, logID).Return(&operationsModels.OperationLog{ID: logID, UserID: uuid.New(), UnitID: uuid.New()}, nil).Once()
+			mockLeasingRepo.On("GetLeasesByUserID", ctx, userID).Return([]leasingModels.Lease{}, nil).Once()
+
+			// Act
+			_, content, err := opsSvc.OpenAttachment(ctx, userID, logID, uuid.New())
+
+			// Assert
+			assert.ErrorIs(t, err, ErrNotActionOwner)
+			assert.Nil(t, content)
+		})
+	})
+
+	t.Run("GetActionsForUnit", func(t *testing.T) {
+		t.Run("Not a lessee", func(t *testing.T) {
+			// Arrange
+			userID := uuid.New()
+			unitID := uuid.New()
+			leases := []leasingModels.Lease{{UnitID: unitID, UserID: userID, Status: "terminated", UnitType: "plot"}}
+			mockLeasingRepo.On("GetLeasesByUserID", ctx, userID).Return(leases, nil).Once()
+
+			// Act
+			page, err := opsSvc.GetActionsForUnit(ctx, userID, unitID, operationsModels.ActionFilter{})
+
+			// Assert
+			assert.ErrorIs(t, err, ErrNoActiveLease)
+			assert.Nil(t, page)
+		})
+
+		t.Run("History starts with the lease", func(t *testing.T) {
+			// Arrange
+			userID := uuid.New()
+			unitID := uuid.New()
+			leaseStart := time.Now().AddDate(0, -1, 0)
+			leases := []leasingModels.Lease{{UnitID: unitID, UserID: userID, Status: "active", UnitType: "plot", StartDate: leaseStart}}
+			mockLeasingRepo.On("GetLeasesByUserID", ctx, userID).Return(leases, nil).Once()
+			mockOpsRepo.On("ListOperationLogs", ctx, mock.MatchedBy(func(f operationsModels.ActionFilter) bool {
+				// Фильтр по пользователю не применяется: в истории юнита видны и системные операции.
+				return f.UnitID != nil && *f.UnitID == unitID && f.UserID == nil && f.From != nil && f.From.Equal(leaseStart)
+			})).Return([]operationsModels.ActionHistoryItem{}, nil).Once()
+
+			// Act
+			from := leaseStart.AddDate(-1, 0, 0)
+			page, err := opsSvc.GetActionsForUnit(ctx, userID, unitID, operationsModels.ActionFilter{From: &from})
+
+			// Assert
+			assert.NoError(t, err)
+			assert.Empty(t, page.Items)
+			mockOpsRepo.AssertExpectations(t)
+		})
+	})
+
+	t.Run("GetQuotas", func(t *testing.T) {
+		t.Run("Not a lessee", func(t *testing.T) {
+			// Arrange
+			userID := uuid.New()
+			mockLeasingRepo.On("GetLeasesByUserID", ctx, userID).Return([]leasingModels.Lease{}, nil).Once()
+
+			// Act
+			usage, err := opsSvc.GetQuotas(ctx, userID, uuid.New())
+
+			// Assert
+			assert.ErrorIs(t, err, ErrNoActiveLease)
+			assert.Nil(t, usage)
+		})
+
+		t.Run("Remaining allowances of the lease tariff", func(t *testing.T) {
+			// Arrange
+			userID := uuid.New()
+			unitID := uuid.New()
+			leaseStart := time.Now().AddDate(0, -1, 0)
+			leaseEnd := leaseStart.AddDate(0, 3, 0)
+			// Тарифа нет в конфиге - применяются лимиты тарифа по умолчанию.
+			leases := []leasingModels.Lease{{UnitID: unitID, UserID: userID, Status: "active", UnitType: "plot", Tariff: "legacy", StartDate: leaseStart, EndDate: leaseEnd}}
+			mockLeasingRepo.On("GetLeasesByUserID", ctx, userID).Return(leases, nil).Once()
+			mockOpsRepo.On("CountOperations", ctx, userID, unitID, "water", mock.Anything, mock.Anything).Return(1, nil).Once()
+			mockOpsRepo.On("CountOperations", ctx, userID, unitID, "plant", leaseStart, leaseEnd).Return(3, nil).Once()
+
+			// Act
+			usage, err := opsSvc.GetQuotas(ctx, userID, unitID)
+
+			// Assert
+			require.NoError(t, err)
+			require.Len(t, usage, 2)
+			assert.Equal(t, "plant", usage[0].ActionType)
+			assert.Equal(t, 0, usage[0].Remaining) // превышение (например, после смены тарифа) не уходит в минус
+			assert.Equal(t, "water", usage[1].ActionType)
+			assert.Equal(t, 1, usage[1].Used)
+			assert.Equal(t, 1, usage[1].Remaining)
+			assert.Equal(t, 24*time.Hour, usage[1].PeriodEnd.Sub(usage[1].PeriodStart))
+			mockOpsRepo.AssertExpectations(t)
+		})
+	})
+}
+
+func TestQuotaExceededError(t *testing.T) {
+	periodEnd := time.Date(2025, 9, 2, 0, 0, 0, 0, time.UTC)
+	err := error(&QuotaExceededError{Usage: operationsModels.QuotaUsage{ActionType: "water", Limit: 2, Period: quota.PeriodDay, Used: 2, PeriodEnd: periodEnd}})
+
+	assert.ErrorIs(t, err, ErrQuotaExceeded)
+	assert.Equal(t, "лимит действия исчерпан: 'water' не больше 2 за день, снова доступно с 2025-09-02 00:00 UTC", err.Error())
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Message - сообщение, ожидающее публикации в очередь RabbitMQ.
+type Message struct {
+	ID       uuid.UUID `db:"id" json:"id"`
+	Queue    string    `db:"queue" json:"queue"`
+	Payload  string    `db:"payload" json:"payload"`
+	Attempts int       `db:"attempts" json:"attempts"`
+	// LastError - текст ошибки последней неудачной попытки публикации.
+	LastError     *string    `db:"last_error" json:"last_error,omitempty"`
+	NextAttemptAt time.Time  `db:"next_attempt_at" json:"next_attempt_at"`
+	CreatedAt     time.Time  `db:"created_at" json:"created_at"`
+	SentAt        *time.Time `db:"sent_at" json:"sent_at,omitempty"`
+}
+
+// NewMessage создает сообщение для очереди queue, готовое к немедленной отправке.
+func NewMessage(queue, payload string) *Message {
+	now := time.Now()
+	return &Message{
+		ID:            uuid.New(),
+		Queue:         queue,
+		Payload:       payload,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}
+}
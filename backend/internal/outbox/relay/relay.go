@@ -0,0 +1,129 @@
+package relay
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rendley/vegshare/backend/internal/outbox/models"
+	"github.com/rendley/vegshare/backend/internal/outbox/repository"
+	"github.com/rendley/vegshare/backend/pkg/config"
+	"github.com/rendley/vegshare/backend/pkg/rabbitmq"
+	"github.com/sirupsen/logrus"
+)
+
+// Значения по умолчанию, если в конфиге секция outbox не заполнена.
+const (
+	defaultPollInterval   = time.Second
+	defaultBatchSize      = 100
+	defaultRetryBaseDelay = time.Second
+	defaultRetryMaxDelay  = 5 * time.Minute
+)
+
+// Relay переносит сообщения из таблицы outbox в RabbitMQ.
+// Сообщение помечается отправленным только после успешной публикации, поэтому доставка
+// гарантируется "хотя бы один раз": при сбое между публикацией и отметкой оно уйдет повторно.
+type Relay struct {
+	db        *sqlx.DB
+	publisher rabbitmq.ClientInterface
+	logger    *logrus.Logger
+	cfg       config.OutboxConfig
+}
+
+// New - конструктор для Relay.
+func New(db *sqlx.DB, publisher rabbitmq.ClientInterface, logger *logrus.Logger, cfg config.OutboxConfig) *Relay {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = defaultPollInterval
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultBatchSize
+	}
+	if cfg.RetryBaseDelay <= 0 {
+		cfg.RetryBaseDelay = defaultRetryBaseDelay
+	}
+	if cfg.RetryMaxDelay <= 0 {
+		cfg.RetryMaxDelay = defaultRetryMaxDelay
+	}
+	return &Relay{db: db, publisher: publisher, logger: logger, cfg: cfg}
+}
+
+// Run опрашивает outbox до отмены контекста.
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Пока есть полные пачки, разбираем их без ожидания тика.
+		for {
+			sent, err := r.processBatch(ctx)
+			if err != nil {
+				r.logger.Errorf("ошибка при обработке outbox: %v", err)
+				break
+			}
+			if sent < r.cfg.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// processBatch выбирает пачку сообщений в транзакции и публикует их. Возвращает размер пачки.
+func (r *Relay) processBatch(ctx context.Context) (int, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("не удалось начать транзакцию: %w", err)
+	}
+	defer tx.Rollback()
+
+	repoTx := repository.NewRepository(tx)
+	messages, err := repoTx.FetchDue(ctx, r.cfg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := r.dispatch(ctx, repoTx, messages); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("не удалось закоммитить транзакцию: %w", err)
+	}
+	return len(messages), nil
+}
+
+// dispatch публикует сообщения и фиксирует результат каждой попытки.
+func (r *Relay) dispatch(ctx context.Context, repo repository.Repository, messages []models.Message) error {
+	for _, msg := range messages {
+		if err := r.publisher.Publish(msg.Queue, msg.Payload); err != nil {
+			next := time.Now().Add(r.backoff(msg.Attempts))
+			r.logger.Warnf("не удалось опубликовать сообщение %s (попытка %d), следующая попытка в %s: %v", msg.ID, msg.Attempts+1, next.Format(time.RFC3339), err)
+			if err := repo.MarkFailed(ctx, msg.ID, err.Error(), next); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := repo.MarkSent(ctx, msg.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backoff возвращает экспоненциальную задержку перед следующей попыткой с ограничением сверху.
+func (r *Relay) backoff(attempts int) time.Duration {
+	delay := r.cfg.RetryBaseDelay
+	for i := 0; i < attempts; i++ {
+		delay *= 2
+		if delay >= r.cfg.RetryMaxDelay {
+			return r.cfg.RetryMaxDelay
+		}
+	}
+	return delay
+}
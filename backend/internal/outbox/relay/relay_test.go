@@ -0,0 +1,127 @@
+package relay
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rendley/vegshare/backend/internal/outbox/models"
+	"github.com/rendley/vegshare/backend/internal/outbox/repository"
+	"github.com/rendley/vegshare/backend/pkg/config"
+	"github.com/rendley/vegshare/backend/pkg/rabbitmq"
+	"github.com/sirupsen/logrus"
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// --- Mocks ---
+
+type MockOutboxRepository struct {
+	mock.Mock
+}
+
+var _ repository.Repository = &MockOutboxRepository{}
+
+func (m *MockOutboxRepository) Create(ctx context.Context, msg *models.Message) error {
+	return m.Called(ctx, msg).Error(0)
+}
+
+func (m *MockOutboxRepository) FetchDue(ctx context.Context, limit int) ([]models.Message, error) {
+	args := m.Called(ctx, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Message), args.Error(1)
+}
+
+func (m *MockOutboxRepository) MarkSent(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockOutboxRepository) MarkFailed(ctx context.Context, id uuid.UUID, lastError string, nextAttemptAt time.Time) error {
+	return m.Called(ctx, id, lastError, nextAttemptAt).Error(0)
+}
+
+type MockRabbitMQClient struct {
+	mock.Mock
+}
+
+func (m *MockRabbitMQClient) Publish(queueName, body string) error {
+	args := m.Called(queueName, body)
+	return args.Error(0)
+}
+func (m *MockRabbitMQClient) Consume(queueName string) (<-chan amqp.Delivery, error) { return nil, nil }
+func (m *MockRabbitMQClient) DeclareTopology(t rabbitmq.Topology) error              { return nil }
+func (m *MockRabbitMQClient) Retry(t rabbitmq.Topology, d amqp.Delivery, cause error) error {
+	return nil
+}
+func (m *MockRabbitMQClient) DeadLetter(t rabbitmq.Topology, d amqp.Delivery, cause error) error {
+	return nil
+}
+func (m *MockRabbitMQClient) Close() { m.Called() }
+
+var _ rabbitmq.ClientInterface = &MockRabbitMQClient{}
+
+// --- Tests ---
+
+func TestRelay(t *testing.T) {
+	ctx := context.Background()
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	cfg := config.OutboxConfig{RetryBaseDelay: time.Second, RetryMaxDelay: 10 * time.Second}
+
+	t.Run("Dispatch - Published messages are marked sent", func(t *testing.T) {
+		mockRepo := new(MockOutboxRepository)
+		mockPublisher := new(MockRabbitMQClient)
+		r := New(nil, mockPublisher, logger, cfg)
+		messages := []models.Message{*models.NewMessage("actions", `{"a":1}`), *models.NewMessage("actions", `{"a":2}`)}
+
+		mockPublisher.On("Publish", "actions", `{"a":1}`).Return(nil).Once()
+		mockPublisher.On("Publish", "actions", `{"a":2}`).Return(nil).Once()
+		mockRepo.On("MarkSent", ctx, messages[0].ID).Return(nil).Once()
+		mockRepo.On("MarkSent", ctx, messages[1].ID).Return(nil).Once()
+
+		err := r.dispatch(ctx, mockRepo, messages)
+
+		assert.NoError(t, err)
+		mockPublisher.AssertExpectations(t)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Dispatch - Failed publish is rescheduled and others still sent", func(t *testing.T) {
+		mockRepo := new(MockOutboxRepository)
+		mockPublisher := new(MockRabbitMQClient)
+		r := New(nil, mockPublisher, logger, cfg)
+		failing := *models.NewMessage("actions", "first")
+		failing.Attempts = 2
+		ok := *models.NewMessage("actions", "second")
+
+		mockPublisher.On("Publish", "actions", "first").Return(errors.New("connection reset")).Once()
+		mockPublisher.On("Publish", "actions", "second").Return(nil).Once()
+		before := time.Now()
+		mockRepo.On("MarkFailed", ctx, failing.ID, "connection reset", mock.MatchedBy(func(next time.Time) bool {
+			// Третья попытка: 1s * 2^2 = 4s
+			return !next.Before(before.Add(4*time.Second)) && next.Before(time.Now().Add(5*time.Second))
+		})).Return(nil).Once()
+		mockRepo.On("MarkSent", ctx, ok.ID).Return(nil).Once()
+
+		err := r.dispatch(ctx, mockRepo, []models.Message{failing, ok})
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+		mockRepo.AssertNotCalled(t, "MarkSent", ctx, failing.ID)
+	})
+
+	t.Run("Backoff is capped", func(t *testing.T) {
+		r := New(nil, nil, logger, cfg)
+
+		assert.Equal(t, time.Second, r.backoff(0))
+		assert.Equal(t, 8*time.Second, r.backoff(3))
+		assert.Equal(t, 10*time.Second, r.backoff(4))
+		assert.Equal(t, 10*time.Second, r.backoff(100))
+	})
+}
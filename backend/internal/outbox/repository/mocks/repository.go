@@ -0,0 +1,40 @@
+// Package mocks содержит общий testify-мок репозитория outbox для тестов сервисов,
+// которые пишут сообщения в outbox в своей транзакции.
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rendley/vegshare/backend/internal/outbox/models"
+	"github.com/rendley/vegshare/backend/internal/outbox/repository"
+	"github.com/stretchr/testify/mock"
+)
+
+// Repository - мок repository.Repository.
+type Repository struct {
+	mock.Mock
+}
+
+var _ repository.Repository = &Repository{}
+
+func (m *Repository) Create(ctx context.Context, msg *models.Message) error {
+	return m.Called(ctx, msg).Error(0)
+}
+
+func (m *Repository) FetchDue(ctx context.Context, limit int) ([]models.Message, error) {
+	args := m.Called(ctx, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Message), args.Error(1)
+}
+
+func (m *Repository) MarkSent(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *Repository) MarkFailed(ctx context.Context, id uuid.UUID, lastError string, nextAttemptAt time.Time) error {
+	return m.Called(ctx, id, lastError, nextAttemptAt).Error(0)
+}
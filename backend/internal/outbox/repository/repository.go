@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rendley/vegshare/backend/internal/outbox/models"
+	"github.com/rendley/vegshare/backend/pkg/database"
+)
+
+// Repository определяет контракт для хранилища исходящих сообщений.
+type Repository interface {
+	// Create записывает сообщение; вызывается в транзакции вместе с бизнес-данными.
+	Create(ctx context.Context, msg *models.Message) error
+	// FetchDue выбирает неотправленные сообщения, срок попытки которых наступил.
+	// В транзакции строки блокируются, чтобы параллельные relay не брали одни и те же сообщения.
+	FetchDue(ctx context.Context, limit int) ([]models.Message, error)
+	MarkSent(ctx context.Context, id uuid.UUID) error
+	MarkFailed(ctx context.Context, id uuid.UUID, lastError string, nextAttemptAt time.Time) error
+}
+
+type repository struct {
+	db database.DBTX
+}
+
+// NewRepository - конструктор для репозитория outbox.
+func NewRepository(db database.DBTX) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) Create(ctx context.Context, msg *models.Message) error {
+	query := `INSERT INTO outbox (id, queue, payload, attempts, next_attempt_at, created_at)
+	          VALUES (:id, :queue, :payload, :attempts, :next_attempt_at, :created_at)`
+	if _, err := r.db.NamedExecContext(ctx, query, msg); err != nil {
+		return fmt.Errorf("не удалось записать сообщение в outbox: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) FetchDue(ctx context.Context, limit int) ([]models.Message, error) {
+	query := `
+        SELECT * FROM outbox
+        WHERE sent_at IS NULL AND next_attempt_at <= NOW()
+        ORDER BY created_at
+        LIMIT $1
+        FOR UPDATE SKIP LOCKED`
+
+	messages := []models.Message{}
+	if err := r.db.SelectContext(ctx, &messages, query, limit); err != nil {
+		return nil, fmt.Errorf("не удалось получить сообщения из outbox: %w", err)
+	}
+	return messages, nil
+}
+
+func (r *repository) MarkSent(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE outbox SET sent_at = NOW(), attempts = attempts + 1, last_error = NULL WHERE id = $1`
+	if _, err := r.db.ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("не удалось отметить сообщение отправленным: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) MarkFailed(ctx context.Context, id uuid.UUID, lastError string, nextAttemptAt time.Time) error {
+	query := `UPDATE outbox SET attempts = attempts + 1, last_error = $1, next_attempt_at = $2 WHERE id = $3`
+	if _, err := r.db.ExecContext(ctx, query, lastError, nextAttemptAt, id); err != nil {
+		return fmt.Errorf("не удалось записать ошибку отправки сообщения: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/rendley/vegshare/backend/internal/operations/actions"
+	operationsService "github.com/rendley/vegshare/backend/internal/operations/service"
+	"github.com/rendley/vegshare/backend/internal/schedule/service"
+	"github.com/rendley/vegshare/backend/pkg/api"
+	"github.com/rendley/vegshare/backend/pkg/middleware"
+	"github.com/sirupsen/logrus"
+)
+
+// ScheduleHandler обрабатывает HTTP-запросы к расписаниям действий.
+type ScheduleHandler struct {
+	service  service.Service
+	logger   *logrus.Logger
+	validate *validator.Validate
+}
+
+// NewScheduleHandler - конструктор для ScheduleHandler.
+func NewScheduleHandler(s service.Service, l *logrus.Logger) *ScheduleHandler {
+	return &ScheduleHandler{
+		service:  s,
+		logger:   l,
+		validate: validator.New(),
+	}
+}
+
+// CreateSchedule создает расписание для юнита текущего пользователя.
+func (h *ScheduleHandler) CreateSchedule(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		api.RespondWithError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req service.ScheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.RespondWithError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := h.validate.Struct(req); err != nil {
+		api.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	schedule, err := h.service.CreateSchedule(r.Context(), userID, req)
+	if err != nil {
+		h.respondWithServiceError(w, "ошибка при создании расписания", err)
+		return
+	}
+
+	api.RespondWithJSON(h.logger, w, schedule, http.StatusCreated)
+}
+
+// GetSchedules возвращает расписания текущего пользователя.
+func (h *ScheduleHandler) GetSchedules(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		api.RespondWithError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	schedules, err := h.service.GetSchedules(r.Context(), userID)
+	if err != nil {
+		h.logger.Errorf("ошибка при получении расписаний: %v", err)
+		api.RespondWithError(w, "could not retrieve schedules", http.StatusInternalServerError)
+		return
+	}
+
+	api.RespondWithJSON(h.logger, w, schedules, http.StatusOK)
+}
+
+// UpdateSchedule меняет параметры и повторение расписания.
+func (h *ScheduleHandler) UpdateSchedule(w http.ResponseWriter, r *http.Request) {
+	userID, scheduleID, ok := h.parseRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var req service.ScheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.RespondWithError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	// Юнит и тип действия расписания не меняются, поэтому в теле они не обязательны.
+	if err := h.validate.StructExcept(req, "UnitID", "UnitType", "ActionType"); err != nil {
+		api.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	schedule, err := h.service.UpdateSchedule(r.Context(), userID, scheduleID, req)
+	if err != nil {
+		h.respondWithServiceError(w, "ошибка при обновлении расписания", err)
+		return
+	}
+
+	api.RespondWithJSON(h.logger, w, schedule, http.StatusOK)
+}
+
+// PauseSchedule приостанавливает расписание.
+func (h *ScheduleHandler) PauseSchedule(w http.ResponseWriter, r *http.Request) {
+	userID, scheduleID, ok := h.parseRequest(w, r)
+	if !ok {
+		return
+	}
+
+	schedule, err := h.service.PauseSchedule(r.Context(), userID, scheduleID)
+	if err != nil {
+		h.respondWithServiceError(w, "ошибка при приостановке расписания", err)
+		return
+	}
+
+	api.RespondWithJSON(h.logger, w, schedule, http.StatusOK)
+}
+
+// ResumeSchedule возобновляет приостановленное расписание.
+func (h *ScheduleHandler) ResumeSchedule(w http.ResponseWriter, r *http.Request) {
+	userID, scheduleID, ok := h.parseRequest(w, r)
+	if !ok {
+		return
+	}
+
+	schedule, err := h.service.ResumeSchedule(r.Context(), userID, scheduleID)
+	if err != nil {
+		h.respondWithServiceError(w, "ошибка при возобновлении расписания", err)
+		return
+	}
+
+	api.RespondWithJSON(h.logger, w, schedule, http.StatusOK)
+}
+
+// DeleteSchedule удаляет расписание; уже созданные по нему операции остаются в журнале.
+func (h *ScheduleHandler) DeleteSchedule(w http.ResponseWriter, r *http.Request) {
+	userID, scheduleID, ok := h.parseRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteSchedule(r.Context(), userID, scheduleID); err != nil {
+		h.respondWithServiceError(w, "ошибка при удалении расписания", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parseRequest достает пользователя и ID расписания; при ошибке ответ уже отправлен.
+func (h *ScheduleHandler) parseRequest(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		api.RespondWithError(w, "Unauthorized", http.StatusUnauthorized)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	scheduleID, err := uuid.Parse(chi.URLParam(r, "scheduleID"))
+	if err != nil {
+		api.RespondWithError(w, "invalid schedule ID in URL", http.StatusBadRequest)
+		return uuid.Nil, uuid.Nil, false
+	}
+	return userID, scheduleID, true
+}
+
+func (h *ScheduleHandler) respondWithServiceError(w http.ResponseWriter, msg string, err error) {
+	var validationErr *actions.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		api.RespondWithJSON(h.logger, w, map[string]interface{}{
+			"error":  validationErr.Error(),
+			"fields": validationErr.Fields,
+		}, http.StatusBadRequest)
+	case errors.Is(err, service.ErrInvalidSchedule):
+		api.RespondWithError(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, sql.ErrNoRows):
+		api.RespondWithError(w, "schedule not found", http.StatusNotFound)
+	case errors.Is(err, service.ErrNotScheduleOwner), errors.Is(err, operationsService.ErrNoActiveLease):
+		api.RespondWithError(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, service.ErrScheduleFinished):
+		api.RespondWithError(w, err.Error(), http.StatusConflict)
+	default:
+		h.logger.Errorf("%s: %v", msg, err)
+		api.RespondWithError(w, err.Error(), http.StatusInternalServerError)
+	}
+}
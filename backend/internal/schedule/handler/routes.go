@@ -0,0 +1,26 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Routes возвращает роутер для расписаний текущего пользователя.
+func (h *ScheduleHandler) Routes() http.Handler {
+	r := chi.NewRouter()
+
+	// POST /schedules - создать расписание
+	r.Post("/", h.CreateSchedule)
+	// GET /schedules - расписания пользователя
+	r.Get("/", h.GetSchedules)
+	// PUT /schedules/{scheduleID} - изменить параметры и повторение
+	r.Put("/{scheduleID}", h.UpdateSchedule)
+	// POST /schedules/{scheduleID}/pause и /resume - приостановить и возобновить
+	r.Post("/{scheduleID}/pause", h.PauseSchedule)
+	r.Post("/{scheduleID}/resume", h.ResumeSchedule)
+	// DELETE /schedules/{scheduleID} - удалить расписание
+	r.Delete("/{scheduleID}", h.DeleteSchedule)
+
+	return r
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Статусы расписания.
+const (
+	StatusActive   = "active"
+	StatusPaused   = "paused"
+	StatusFinished = "finished"
+)
+
+// Schedule - повторяющееся действие арендатора над юнитом.
+// Повторение задается либо выражением Cron, либо IntervalDays ("каждые N дней, начиная со StartsAt").
+type Schedule struct {
+	ID           uuid.UUID       `db:"id" json:"id"`
+	UserID       uuid.UUID       `db:"user_id" json:"user_id"`
+	UnitID       uuid.UUID       `db:"unit_id" json:"unit_id"`
+	UnitType     string          `db:"unit_type" json:"unit_type"`
+	ActionType   string          `db:"action_type" json:"action_type"`
+	Parameters   json.RawMessage `db:"parameters" json:"parameters"`
+	Cron         *string         `db:"cron" json:"cron,omitempty"`
+	IntervalDays *int            `db:"interval_days" json:"interval_days,omitempty"`
+	// Timezone - часовой пояс, в котором считаются срабатывания (IANA, например Europe/Moscow).
+	Timezone string     `db:"timezone" json:"timezone"`
+	StartsAt time.Time  `db:"starts_at" json:"starts_at"`
+	EndsAt   *time.Time `db:"ends_at" json:"ends_at,omitempty"`
+	Status   string     `db:"status" json:"status"`
+	// NextRunAt - ближайшее срабатывание; nil, если срабатываний больше не будет.
+	NextRunAt *time.Time `db:"next_run_at" json:"next_run_at,omitempty"`
+	LastRunAt *time.Time `db:"last_run_at" json:"last_run_at,omitempty"`
+	// LastError - почему последнее срабатывание пропущено или расписание остановлено планировщиком.
+	LastError string    `db:"last_error" json:"last_error,omitempty"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
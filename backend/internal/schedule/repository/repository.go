@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rendley/vegshare/backend/internal/schedule/models"
+	"github.com/rendley/vegshare/backend/pkg/database"
+)
+
+// Repository определяет контракт для хранилища расписаний.
+type Repository interface {
+	CreateSchedule(ctx context.Context, schedule *models.Schedule) error
+	GetScheduleByID(ctx context.Context, id uuid.UUID) (*models.Schedule, error)
+	// GetScheduleForUpdate читает расписание и блокирует строку до конца транзакции,
+	// чтобы планировщик не перезаписал изменение пользователя своим срабатыванием.
+	GetScheduleForUpdate(ctx context.Context, id uuid.UUID) (*models.Schedule, error)
+	GetSchedulesByUserID(ctx context.Context, userID uuid.UUID) ([]models.Schedule, error)
+	UpdateSchedule(ctx context.Context, schedule *models.Schedule) error
+	DeleteSchedule(ctx context.Context, id uuid.UUID) error
+	// FetchDue выбирает активные расписания, срок срабатывания которых наступил к now.
+	// В транзакции строки блокируются, чтобы параллельные планировщики не создали операцию дважды.
+	FetchDue(ctx context.Context, now time.Time, limit int) ([]models.Schedule, error)
+}
+
+type repository struct {
+	db database.DBTX
+}
+
+// NewRepository - конструктор для репозитория расписаний.
+func NewRepository(db database.DBTX) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) CreateSchedule(ctx context.Context, schedule *models.Schedule) error {
+	query := `INSERT INTO operation_schedules (id, user_id, unit_id, unit_type, action_type, parameters, cron, interval_days,
+	              timezone, starts_at, ends_at, status, next_run_at, last_run_at, last_error, created_at, updated_at)
+	          VALUES (:id, :user_id, :unit_id, :unit_type, :action_type, :parameters, :cron, :interval_days,
+	              :timezone, :starts_at, :ends_at, :status, :next_run_at, :last_run_at, :last_error, :created_at, :updated_at)`
+	if _, err := r.db.NamedExecContext(ctx, query, schedule); err != nil {
+		return fmt.Errorf("не удалось создать расписание: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetScheduleByID(ctx context.Context, id uuid.UUID) (*models.Schedule, error) {
+	var schedule models.Schedule
+	query := `SELECT * FROM operation_schedules WHERE id = $1`
+	if err := r.db.GetContext(ctx, &schedule, query, id); err != nil {
+		return nil, fmt.Errorf("не удалось получить расписание по ID: %w", err)
+	}
+	return &schedule, nil
+}
+
+func (r *repository) GetScheduleForUpdate(ctx context.Context, id uuid.UUID) (*models.Schedule, error) {
+	var schedule models.Schedule
+	query := `SELECT * FROM operation_schedules WHERE id = $1 FOR UPDATE`
+	if err := r.db.GetContext(ctx, &schedule, query, id); err != nil {
+		return nil, fmt.Errorf("не удалось получить расписание по ID: %w", err)
+	}
+	return &schedule, nil
+}
+
+func (r *repository) GetSchedulesByUserID(ctx context.Context, userID uuid.UUID) ([]models.Schedule, error) {
+	schedules := []models.Schedule{}
+	query := `SELECT * FROM operation_schedules WHERE user_id = $1 ORDER BY created_at DESC`
+	if err := r.db.SelectContext(ctx, &schedules, query, userID); err != nil {
+		return nil, fmt.Errorf("не удалось получить расписания пользователя: %w", err)
+	}
+	return schedules, nil
+}
+
+func (r *repository) UpdateSchedule(ctx context.Context, schedule *models.Schedule) error {
+	schedule.UpdatedAt = time.Now()
+	query := `UPDATE operation_schedules SET parameters = :parameters, cron = :cron, interval_days = :interval_days,
+	              timezone = :timezone, starts_at = :starts_at, ends_at = :ends_at, status = :status,
+	              next_run_at = :next_run_at, last_run_at = :last_run_at, last_error = :last_error, updated_at = :updated_at
+	          WHERE id = :id`
+	if _, err := r.db.NamedExecContext(ctx, query, schedule); err != nil {
+		return fmt.Errorf("не удалось обновить расписание: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) DeleteSchedule(ctx context.Context, id uuid.UUID) error {
+	query := `DELETE FROM operation_schedules WHERE id = $1`
+	if _, err := r.db.ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("не удалось удалить расписание: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) FetchDue(ctx context.Context, now time.Time, limit int) ([]models.Schedule, error) {
+	query := `
+        SELECT * FROM operation_schedules
+        WHERE status = $1 AND next_run_at <= $2
+        ORDER BY next_run_at
+        LIMIT $3
+        FOR UPDATE SKIP LOCKED`
+
+	schedules := []models.Schedule{}
+	if err := r.db.SelectContext(ctx, &schedules, query, models.StatusActive, now, limit); err != nil {
+		return nil, fmt.Errorf("не удалось получить расписания к запуску: %w", err)
+	}
+	return schedules, nil
+}
@@ -0,0 +1,62 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/rendley/vegshare/backend/internal/schedule/service"
+	"github.com/rendley/vegshare/backend/pkg/config"
+	"github.com/sirupsen/logrus"
+)
+
+// Значения по умолчанию, если в конфиге секция scheduler не заполнена.
+const (
+	defaultPollInterval = 30 * time.Second
+	defaultBatchSize    = 100
+)
+
+// Scheduler периодически превращает наступившие срабатывания расписаний в операции.
+// Расписания выбираются с блокировкой строк, поэтому несколько экземпляров API
+// могут запускать планировщик одновременно без дублей.
+type Scheduler struct {
+	service service.Service
+	logger  *logrus.Logger
+	cfg     config.SchedulerConfig
+}
+
+// New - конструктор для Scheduler.
+func New(s service.Service, logger *logrus.Logger, cfg config.SchedulerConfig) *Scheduler {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = defaultPollInterval
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultBatchSize
+	}
+	return &Scheduler{service: s, logger: logger, cfg: cfg}
+}
+
+// Run опрашивает расписания до отмены контекста.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Пока есть полные пачки, разбираем их без ожидания тика.
+		for {
+			n, err := s.service.RunDue(ctx, s.cfg.BatchSize)
+			if err != nil {
+				s.logger.Errorf("ошибка при запуске расписаний: %v", err)
+				break
+			}
+			if n < s.cfg.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,415 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/rendley/vegshare/backend/internal/operations/actions"
+	operationsService "github.com/rendley/vegshare/backend/internal/operations/service"
+	"github.com/rendley/vegshare/backend/internal/schedule/models"
+	"github.com/rendley/vegshare/backend/internal/schedule/repository"
+	"github.com/rendley/vegshare/backend/pkg/cron"
+	"github.com/rendley/vegshare/backend/pkg/database"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrNotScheduleOwner возвращается при попытке изменить чужое расписание.
+	ErrNotScheduleOwner = errors.New("расписание принадлежит другому пользователю")
+	// ErrInvalidSchedule - повторение расписания задано неверно.
+	ErrInvalidSchedule = errors.New("некорректное расписание")
+	// ErrScheduleFinished возвращается при попытке возобновить завершенное расписание.
+	ErrScheduleFinished = errors.New("расписание завершено")
+)
+
+// ScheduleRequest - параметры создания или изменения расписания.
+// Нужно указать ровно одно из Cron и IntervalDays.
+type ScheduleRequest struct {
+	UnitID     uuid.UUID       `json:"unit_id" validate:"required"`
+	UnitType   string          `json:"unit_type" validate:"required"`
+	ActionType string          `json:"action_type" validate:"required"`
+	Parameters json.RawMessage `json:"parameters"`
+	// Cron - выражение crontab из 5 полей, например "0 7 * * *" (каждый день в 7:00).
+	Cron string `json:"cron"`
+	// IntervalDays - "каждые N дней" во время StartsAt.
+	IntervalDays int `json:"interval_days" validate:"omitempty,min=1"`
+	// Timezone - часовой пояс IANA, по умолчанию UTC.
+	Timezone string `json:"timezone"`
+	// StartsAt по умолчанию - момент создания.
+	StartsAt *time.Time `json:"starts_at"`
+	EndsAt   *time.Time `json:"ends_at"`
+}
+
+// Service определяет контракт для бизнес-логики расписаний.
+type Service interface {
+	CreateSchedule(ctx context.Context, userID uuid.UUID, req ScheduleRequest) (*models.Schedule, error)
+	GetSchedules(ctx context.Context, userID uuid.UUID) ([]models.Schedule, error)
+	// UpdateSchedule меняет параметры и повторение расписания; юнит и тип действия не меняются.
+	UpdateSchedule(ctx context.Context, userID, id uuid.UUID, req ScheduleRequest) (*models.Schedule, error)
+	PauseSchedule(ctx context.Context, userID, id uuid.UUID) (*models.Schedule, error)
+	// ResumeSchedule возобновляет расписание со следующего срабатывания; пропущенные за паузу не выполняются.
+	ResumeSchedule(ctx context.Context, userID, id uuid.UUID) (*models.Schedule, error)
+	DeleteSchedule(ctx context.Context, userID, id uuid.UUID) error
+
+	// RunDue создает операции по наступившим срабатываниям (не больше limit расписаний)
+	// и возвращает число обработанных расписаний. Вызывается планировщиком.
+	RunDue(ctx context.Context, limit int) (int, error)
+}
+
+type service struct {
+	db                *sqlx.DB
+	repo              repository.Repository
+	operationsService operationsService.Service
+	logger            *logrus.Logger
+
+	// newRepo создает репозиторий поверх транзакции; в тестах подменяется моком.
+	newRepo func(db database.DBTX) repository.Repository
+}
+
+// NewService - конструктор для сервиса расписаний.
+func NewService(db *sqlx.DB, repo repository.Repository, ops operationsService.Service, logger *logrus.Logger) Service {
+	return &service{
+		db:                db,
+		repo:              repo,
+		operationsService: ops,
+		logger:            logger,
+		newRepo:           repository.NewRepository,
+	}
+}
+
+func (s *service) CreateSchedule(ctx context.Context, userID uuid.UUID, req ScheduleRequest) (*models.Schedule, error) {
+	now := time.Now()
+	schedule := &models.Schedule{
+		ID:         uuid.New(),
+		UserID:     userID,
+		UnitID:     req.UnitID,
+		UnitType:   req.UnitType,
+		ActionType: req.ActionType,
+		Status:     models.StatusActive,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := s.apply(ctx, schedule, req, now); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreateSchedule(ctx, schedule); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+func (s *service) GetSchedules(ctx context.Context, userID uuid.UUID) ([]models.Schedule, error) {
+	return s.repo.GetSchedulesByUserID(ctx, userID)
+}
+
+func (s *service) UpdateSchedule(ctx context.Context, userID, id uuid.UUID, req ScheduleRequest) (*models.Schedule, error) {
+	return s.modify(ctx, userID, id, func(schedule *models.Schedule) error {
+		req.UnitID, req.UnitType, req.ActionType = schedule.UnitID, schedule.UnitType, schedule.ActionType
+		if err := s.apply(ctx, schedule, req, time.Now()); err != nil {
+			return err
+		}
+		// Изменение завершенного расписания (например, продление EndsAt) снова делает его активным.
+		if schedule.Status == models.StatusFinished {
+			schedule.Status = models.StatusActive
+			schedule.LastError = ""
+		}
+		return nil
+	})
+}
+
+func (s *service) PauseSchedule(ctx context.Context, userID, id uuid.UUID) (*models.Schedule, error) {
+	return s.modify(ctx, userID, id, func(schedule *models.Schedule) error {
+		if schedule.Status == models.StatusFinished {
+			return ErrScheduleFinished
+		}
+		schedule.Status = models.StatusPaused
+		return nil
+	})
+}
+
+func (s *service) ResumeSchedule(ctx context.Context, userID, id uuid.UUID) (*models.Schedule, error) {
+	return s.modify(ctx, userID, id, func(schedule *models.Schedule) error {
+		if schedule.Status == models.StatusFinished {
+			return ErrScheduleFinished
+		}
+
+		next, err := nextRun(schedule, time.Now())
+		if err != nil {
+			return err
+		}
+		schedule.Status = models.StatusActive
+		schedule.NextRunAt = next
+		if next == nil {
+			schedule.Status = models.StatusFinished
+		}
+		return nil
+	})
+}
+
+// modify применяет change к расписанию пользователя и сохраняет его в одной транзакции.
+// Строка блокируется на время изменения: планировщик пропускает заблокированные расписания,
+// а если он успел взять расписание первым, изменение применяется к уже перенесенному срабатыванию.
+func (s *service) modify(ctx context.Context, userID, id uuid.UUID, change func(schedule *models.Schedule) error) (*models.Schedule, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось начать транзакцию: %w", err)
+	}
+	defer tx.Rollback()
+
+	repoTx := s.newRepo(tx)
+	schedule, err := repoTx.GetScheduleForUpdate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if schedule.UserID != userID {
+		return nil, ErrNotScheduleOwner
+	}
+	if err := change(schedule); err != nil {
+		return nil, err
+	}
+	if err := repoTx.UpdateSchedule(ctx, schedule); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("не удалось закоммитить транзакцию: %w", err)
+	}
+	return schedule, nil
+}
+
+func (s *service) DeleteSchedule(ctx context.Context, userID, id uuid.UUID) error {
+	if _, err := s.getOwned(ctx, userID, id); err != nil {
+		return err
+	}
+	return s.repo.DeleteSchedule(ctx, id)
+}
+
+func (s *service) getOwned(ctx context.Context, userID, id uuid.UUID) (*models.Schedule, error) {
+	schedule, err := s.repo.GetScheduleByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if schedule.UserID != userID {
+		return nil, ErrNotScheduleOwner
+	}
+	return schedule, nil
+}
+
+// apply проверяет запрос и переносит параметры и повторение в schedule, вычисляя ближайшее срабатывание.
+func (s *service) apply(ctx context.Context, schedule *models.Schedule, req ScheduleRequest, now time.Time) error {
+	if (req.Cron == "") == (req.IntervalDays == 0) {
+		return fmt.Errorf("%w: нужно указать ровно одно из cron и interval_days", ErrInvalidSchedule)
+	}
+	if req.Cron != "" {
+		if _, err := cron.Parse(req.Cron); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+		}
+	}
+	if req.IntervalDays < 0 {
+		return fmt.Errorf("%w: interval_days должен быть положительным", ErrInvalidSchedule)
+	}
+	if req.Timezone == "" {
+		req.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(req.Timezone); err != nil {
+		return fmt.Errorf("%w: неизвестный часовой пояс '%s'", ErrInvalidSchedule, req.Timezone)
+	}
+	startsAt := now
+	if req.StartsAt != nil {
+		startsAt = *req.StartsAt
+	}
+	if req.EndsAt != nil && !req.EndsAt.After(startsAt) {
+		return fmt.Errorf("%w: ends_at должен быть позже starts_at", ErrInvalidSchedule)
+	}
+
+	// Проверки аренды и параметров те же, что у разового действия.
+	if err := s.operationsService.ValidateAction(ctx, schedule.UserID, operationsService.ActionRequest{
+		UnitID:     req.UnitID,
+		UnitType:   req.UnitType,
+		ActionType: req.ActionType,
+		Parameters: req.Parameters,
+	}); err != nil {
+		return err
+	}
+
+	schedule.Parameters = req.Parameters
+	schedule.Cron, schedule.IntervalDays = nil, nil
+	if req.Cron != "" {
+		schedule.Cron = &req.Cron
+	} else {
+		schedule.IntervalDays = &req.IntervalDays
+	}
+	schedule.Timezone = req.Timezone
+	schedule.StartsAt = startsAt
+	schedule.EndsAt = req.EndsAt
+
+	next, err := nextRun(schedule, now)
+	if err != nil {
+		return err
+	}
+	if next == nil {
+		return fmt.Errorf("%w: до ends_at нет ни одного срабатывания", ErrInvalidSchedule)
+	}
+	schedule.NextRunAt = next
+	return nil
+}
+
+func (s *service) RunDue(ctx context.Context, limit int) (int, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("не удалось начать транзакцию: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	repoTx := s.newRepo(tx)
+	due, err := repoTx.FetchDue(ctx, now, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range due {
+		if err := s.runIsolated(ctx, tx, repoTx, &due[i], now); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("не удалось закоммитить транзакцию: %w", err)
+	}
+	return len(due), nil
+}
+
+// runIsolated выполняет run в точке сохранения, чтобы ошибка одного расписания не откатывала
+// остальные срабатывания пачки. Неудачное срабатывание пропускается: ошибка записывается в LastError,
+// а расписание переносится дальше, иначе оно срывало бы каждый следующий запуск планировщика.
+func (s *service) runIsolated(ctx context.Context, tx *sqlx.Tx, repoTx repository.Repository, schedule *models.Schedule, now time.Time) error {
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT schedule_run"); err != nil {
+		return fmt.Errorf("не удалось создать точку сохранения: %w", err)
+	}
+
+	original := *schedule
+	runErr := s.run(ctx, tx, repoTx, schedule, now)
+	if runErr == nil {
+		if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT schedule_run"); err != nil {
+			return fmt.Errorf("не удалось освободить точку сохранения: %w", err)
+		}
+		return nil
+	}
+
+	if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT schedule_run"); err != nil {
+		return fmt.Errorf("не удалось откатиться к точке сохранения: %w", err)
+	}
+	s.logger.Errorf("Schedule %s failed a run: %v", schedule.ID, runErr)
+
+	*schedule = original
+	schedule.LastError = runErr.Error()
+	next, err := nextRun(schedule, now)
+	if err != nil {
+		// Повторение больше не вычисляется - расписание завершается, чтобы не срабатывать вечно.
+		schedule.Status = models.StatusFinished
+		schedule.LastError = err.Error()
+	} else if next == nil {
+		schedule.Status = models.StatusFinished
+	}
+	schedule.NextRunAt = next
+	return repoTx.UpdateSchedule(ctx, schedule)
+}
+
+// run создает операцию по расписанию и переносит его на следующее срабатывание.
+// Если планировщик простаивал, пропущенные срабатывания не догоняются: создается одна операция.
+func (s *service) run(ctx context.Context, tx *sqlx.Tx, repoTx repository.Repository, schedule *models.Schedule, now time.Time) error {
+	op, err := s.operationsService.CreateScheduledAction(ctx, tx, schedule.UserID, schedule.ID, operationsService.ActionRequest{
+		UnitID:     schedule.UnitID,
+		UnitType:   schedule.UnitType,
+		ActionType: schedule.ActionType,
+		Parameters: schedule.Parameters,
+	})
+	if err != nil {
+		if errors.Is(err, operationsService.ErrQuotaExceeded) {
+			// Лимит тарифа исчерпан: срабатывание пропускается, расписание продолжает работать.
+			s.logger.Warnf("Schedule %s skipped a run: %v", schedule.ID, err)
+			schedule.LastError = err.Error()
+			return s.advance(ctx, repoTx, schedule, now)
+		}
+		var validationErr *actions.ValidationError
+		if !errors.Is(err, operationsService.ErrNoActiveLease) && !errors.As(err, &validationErr) {
+			return err
+		}
+		// Аренда закончилась или действие больше не проходит проверку - повтор не поможет.
+		s.logger.Warnf("Schedule %s is finished: %v", schedule.ID, err)
+		schedule.Status = models.StatusFinished
+		schedule.NextRunAt = nil
+		schedule.LastError = err.Error()
+		return repoTx.UpdateSchedule(ctx, schedule)
+	}
+
+	schedule.LastRunAt = &now
+	schedule.LastError = ""
+	s.logger.Infof("Schedule %s created operation %s", schedule.ID, op.ID)
+	return s.advance(ctx, repoTx, schedule, now)
+}
+
+// advance переносит расписание на следующее срабатывание после now или завершает его.
+func (s *service) advance(ctx context.Context, repoTx repository.Repository, schedule *models.Schedule, now time.Time) error {
+	next, err := nextRun(schedule, now)
+	if err != nil {
+		return err
+	}
+	schedule.NextRunAt = next
+	if next == nil {
+		schedule.Status = models.StatusFinished
+	}
+	return repoTx.UpdateSchedule(ctx, schedule)
+}
+
+// nextRun возвращает первое срабатывание расписания строго после after (и не раньше StartsAt)
+// или nil, если до EndsAt срабатываний больше нет.
+func nextRun(schedule *models.Schedule, after time.Time) (*time.Time, error) {
+	loc, err := time.LoadLocation(schedule.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("%w: неизвестный часовой пояс '%s'", ErrInvalidSchedule, schedule.Timezone)
+	}
+
+	var next time.Time
+	switch {
+	case schedule.Cron != nil:
+		expr, err := cron.Parse(*schedule.Cron)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+		}
+		if schedule.StartsAt.After(after) {
+			// Срабатывание ровно в StartsAt тоже подходит.
+			after = schedule.StartsAt.Add(-time.Nanosecond)
+		}
+		next = expr.Next(after.In(loc))
+		if next.IsZero() {
+			return nil, nil
+		}
+	case schedule.IntervalDays != nil:
+		n := *schedule.IntervalDays
+		// Дни прибавляются в часовом поясе расписания, чтобы время срабатывания
+		// не сдвигалось при переходе на летнее время.
+		next = schedule.StartsAt.In(loc)
+		if !next.After(after) {
+			days := int(after.Sub(next).Hours()/24) / n * n
+			next = next.AddDate(0, 0, days)
+			for !next.After(after) {
+				next = next.AddDate(0, 0, n)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("%w: не задано повторение", ErrInvalidSchedule)
+	}
+
+	if schedule.EndsAt != nil && next.After(*schedule.EndsAt) {
+		return nil, nil
+	}
+	return &next, nil
+}
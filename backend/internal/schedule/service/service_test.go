@@ -0,0 +1,446 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rendley/vegshare/backend/internal/operations/actions"
+	operationsModels "github.com/rendley/vegshare/backend/internal/operations/models"
+	operationsService "github.com/rendley/vegshare/backend/internal/operations/service"
+	operationsMocks "github.com/rendley/vegshare/backend/internal/operations/service/mocks"
+	"github.com/rendley/vegshare/backend/internal/schedule/models"
+	"github.com/rendley/vegshare/backend/internal/schedule/repository"
+	"github.com/rendley/vegshare/backend/pkg/database"
+	"github.com/rendley/vegshare/backend/pkg/database/dbtest"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// --- Mocks ---
+
+type MockScheduleRepository struct {
+	mock.Mock
+}
+
+var _ repository.Repository = &MockScheduleRepository{}
+
+func (m *MockScheduleRepository) CreateSchedule(ctx context.Context, schedule *models.Schedule) error {
+	args := m.Called(ctx, schedule)
+	return args.Error(0)
+}
+
+func (m *MockScheduleRepository) GetScheduleByID(ctx context.Context, id uuid.UUID) (*models.Schedule, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Schedule), args.Error(1)
+}
+
+func (m *MockScheduleRepository) GetScheduleForUpdate(ctx context.Context, id uuid.UUID) (*models.Schedule, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Schedule), args.Error(1)
+}
+
+func (m *MockScheduleRepository) GetSchedulesByUserID(ctx context.Context, userID uuid.UUID) ([]models.Schedule, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Schedule), args.Error(1)
+}
+
+func (m *MockScheduleRepository) UpdateSchedule(ctx context.Context, schedule *models.Schedule) error {
+	args := m.Called(ctx, schedule)
+	return args.Error(0)
+}
+
+func (m *MockScheduleRepository) DeleteSchedule(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockScheduleRepository) FetchDue(ctx context.Context, now time.Time, limit int) ([]models.Schedule, error) {
+	args := m.Called(ctx, now, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Schedule), args.Error(1)
+}
+
+// --- Tests ---
+
+func TestNextRun(t *testing.T) {
+	msk, err := time.LoadLocation("Europe/Moscow")
+	require.NoError(t, err)
+	start := time.Date(2025, 9, 1, 7, 0, 0, 0, msk)
+	days := func(n int) *int { return &n }
+	expr := func(s string) *string { return &s }
+	at := func(month time.Month, day, hour int) time.Time {
+		return time.Date(2025, month, day, hour, 0, 0, 0, msk)
+	}
+
+	cases := []struct {
+		name     string
+		schedule models.Schedule
+		after    time.Time
+		want     *time.Time
+	}{
+		{
+			name:     "Interval before start fires at start",
+			schedule: models.Schedule{IntervalDays: days(2), StartsAt: start},
+			after:    at(8, 20, 12),
+			want:     &start,
+		},
+		{
+			name:     "Interval skips to the next multiple of N days",
+			schedule: models.Schedule{IntervalDays: days(3), StartsAt: start},
+			after:    at(9, 5, 12),
+			want:     ptr(at(9, 7, 7)),
+		},
+		{
+			name:     "Interval fires strictly after the given moment",
+			schedule: models.Schedule{IntervalDays: days(1), StartsAt: start},
+			after:    at(9, 3, 7),
+			want:     ptr(at(9, 4, 7)),
+		},
+		{
+			name:     "Cron before start fires at first match from start",
+			schedule: models.Schedule{Cron: expr("0 7 * * *"), StartsAt: start},
+			after:    at(8, 1, 0),
+			want:     &start,
+		},
+		{
+			name:     "Cron uses schedule timezone",
+			schedule: models.Schedule{Cron: expr("0 19 * * *"), StartsAt: start},
+			after:    at(9, 10, 20),
+			want:     ptr(at(9, 11, 19)),
+		},
+		{
+			name:     "No runs after end",
+			schedule: models.Schedule{IntervalDays: days(7), StartsAt: start, EndsAt: ptr(at(9, 10, 0))},
+			after:    at(9, 8, 12),
+			want:     nil,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			c.schedule.Timezone = "Europe/Moscow"
+			got, err := nextRun(&c.schedule, c.after)
+			require.NoError(t, err)
+			if c.want == nil {
+				assert.Nil(t, got)
+				return
+			}
+			require.NotNil(t, got)
+			assert.True(t, c.want.Equal(*got), fmt.Sprintf("want %s, got %s", c.want, got))
+		})
+	}
+}
+
+func ptr(t time.Time) *time.Time { return &t }
+
+func TestScheduleService(t *testing.T) {
+	ctx := context.Background()
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	newService := func() (Service, *MockScheduleRepository, *operationsMocks.OperationsService) {
+		db, _ := dbtest.New()
+		mockRepo := new(MockScheduleRepository)
+		mockOps := new(operationsMocks.OperationsService)
+		svc := NewService(db, mockRepo, mockOps, logger)
+		svc.(*service).newRepo = func(database.DBTX) repository.Repository { return mockRepo }
+		return svc, mockRepo, mockOps
+	}
+
+	waterRequest := func() ScheduleRequest {
+		return ScheduleRequest{UnitID: uuid.New(), UnitType: "plot", ActionType: actions.ActionWater, IntervalDays: 2}
+	}
+
+	t.Run("CreateSchedule - Success", func(t *testing.T) {
+		svc, mockRepo, mockOps := newService()
+		userID := uuid.New()
+		req := waterRequest()
+		mockOps.On("ValidateAction", ctx, userID, mock.AnythingOfType("service.ActionRequest")).Return(nil).Once()
+		mockRepo.On("CreateSchedule", ctx, mock.AnythingOfType("*models.Schedule")).Return(nil).Once()
+
+		schedule, err := svc.CreateSchedule(ctx, userID, req)
+
+		require.NoError(t, err)
+		assert.Equal(t, models.StatusActive, schedule.Status)
+		assert.Equal(t, "UTC", schedule.Timezone)
+		require.NotNil(t, schedule.NextRunAt)
+		assert.Nil(t, schedule.Cron)
+		mockRepo.AssertExpectations(t)
+		mockOps.AssertExpectations(t)
+	})
+
+	t.Run("CreateSchedule - Invalid recurrence", func(t *testing.T) {
+		cases := map[string]func(req *ScheduleRequest){
+			"both cron and interval": func(req *ScheduleRequest) { req.Cron = "0 7 * * *" },
+			"no recurrence":          func(req *ScheduleRequest) { req.IntervalDays = 0 },
+			"bad cron": func(req *ScheduleRequest) {
+				req.IntervalDays = 0
+				req.Cron = "every day"
+			},
+			"unknown timezone": func(req *ScheduleRequest) { req.Timezone = "Mars/Olympus" },
+			"ends before start": func(req *ScheduleRequest) {
+				req.StartsAt = ptr(time.Now().Add(time.Hour))
+				req.EndsAt = ptr(time.Now())
+			},
+		}
+		for name, modify := range cases {
+			svc, mockRepo, mockOps := newService()
+			req := waterRequest()
+			modify(&req)
+
+			_, err := svc.CreateSchedule(ctx, uuid.New(), req)
+
+			assert.ErrorIs(t, err, ErrInvalidSchedule, name)
+			mockOps.AssertNotCalled(t, "ValidateAction", mock.Anything, mock.Anything, mock.Anything)
+			mockRepo.AssertNotCalled(t, "CreateSchedule", mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("CreateSchedule - No runs before end", func(t *testing.T) {
+		svc, mockRepo, mockOps := newService()
+		userID := uuid.New()
+		req := waterRequest()
+		req.IntervalDays = 0
+		req.Cron = "0 7 1 1 *"
+		req.StartsAt = ptr(time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC))
+		req.EndsAt = ptr(time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC))
+		mockOps.On("ValidateAction", ctx, userID, mock.Anything).Return(nil).Once()
+
+		_, err := svc.CreateSchedule(ctx, userID, req)
+
+		assert.ErrorIs(t, err, ErrInvalidSchedule)
+		mockRepo.AssertNotCalled(t, "CreateSchedule", mock.Anything, mock.Anything)
+	})
+
+	t.Run("CreateSchedule - No active lease", func(t *testing.T) {
+		svc, mockRepo, mockOps := newService()
+		userID := uuid.New()
+		mockOps.On("ValidateAction", ctx, userID, mock.Anything).Return(operationsService.ErrNoActiveLease).Once()
+
+		_, err := svc.CreateSchedule(ctx, userID, waterRequest())
+
+		assert.ErrorIs(t, err, operationsService.ErrNoActiveLease)
+		mockRepo.AssertNotCalled(t, "CreateSchedule", mock.Anything, mock.Anything)
+	})
+
+	t.Run("UpdateSchedule - Not owner", func(t *testing.T) {
+		svc, mockRepo, _ := newService()
+		schedule := &models.Schedule{ID: uuid.New(), UserID: uuid.New()}
+		mockRepo.On("GetScheduleForUpdate", ctx, schedule.ID).Return(schedule, nil).Once()
+
+		_, err := svc.UpdateSchedule(ctx, uuid.New(), schedule.ID, waterRequest())
+
+		assert.ErrorIs(t, err, ErrNotScheduleOwner)
+		mockRepo.AssertNotCalled(t, "UpdateSchedule", mock.Anything, mock.Anything)
+	})
+
+	t.Run("UpdateSchedule - Keeps unit and action, reactivates finished schedule", func(t *testing.T) {
+		svc, mockRepo, mockOps := newService()
+		userID := uuid.New()
+		schedule := &models.Schedule{
+			ID: uuid.New(), UserID: userID, UnitID: uuid.New(), UnitType: "plot", ActionType: actions.ActionWater,
+			Status: models.StatusFinished, LastError: "аренда закончилась",
+		}
+		mockRepo.On("GetScheduleForUpdate", ctx, schedule.ID).Return(schedule, nil).Once()
+		mockOps.On("ValidateAction", ctx, userID, operationsService.ActionRequest{
+			UnitID: schedule.UnitID, UnitType: "plot", ActionType: actions.ActionWater,
+		}).Return(nil).Once()
+		mockRepo.On("UpdateSchedule", ctx, schedule).Return(nil).Once()
+
+		req := ScheduleRequest{Cron: "0 7 * * *", Timezone: "Europe/Moscow"}
+		updated, err := svc.UpdateSchedule(ctx, userID, schedule.ID, req)
+
+		require.NoError(t, err)
+		assert.Equal(t, models.StatusActive, updated.Status)
+		assert.Empty(t, updated.LastError)
+		assert.Equal(t, "0 7 * * *", *updated.Cron)
+		assert.Nil(t, updated.IntervalDays)
+		mockRepo.AssertExpectations(t)
+		mockOps.AssertExpectations(t)
+	})
+
+	t.Run("Pause and resume", func(t *testing.T) {
+		svc, mockRepo, _ := newService()
+		userID := uuid.New()
+		stale := time.Now().Add(-72 * time.Hour)
+		schedule := &models.Schedule{
+			ID: uuid.New(), UserID: userID, IntervalDays: new(int), Timezone: "UTC",
+			StartsAt: stale, NextRunAt: &stale, Status: models.StatusActive,
+		}
+		*schedule.IntervalDays = 1
+		mockRepo.On("GetScheduleForUpdate", ctx, schedule.ID).Return(schedule, nil).Twice()
+		mockRepo.On("UpdateSchedule", ctx, schedule).Return(nil).Twice()
+
+		paused, err := svc.PauseSchedule(ctx, userID, schedule.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.StatusPaused, paused.Status)
+
+		resumed, err := svc.ResumeSchedule(ctx, userID, schedule.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.StatusActive, resumed.Status)
+		// Пропущенные за паузу срабатывания не выполняются.
+		assert.True(t, resumed.NextRunAt.After(time.Now()))
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Resume finished schedule", func(t *testing.T) {
+		svc, mockRepo, _ := newService()
+		userID := uuid.New()
+		schedule := &models.Schedule{ID: uuid.New(), UserID: userID, Status: models.StatusFinished}
+		mockRepo.On("GetScheduleForUpdate", ctx, schedule.ID).Return(schedule, nil).Once()
+
+		_, err := svc.ResumeSchedule(ctx, userID, schedule.ID)
+
+		assert.ErrorIs(t, err, ErrScheduleFinished)
+		mockRepo.AssertNotCalled(t, "UpdateSchedule", mock.Anything, mock.Anything)
+	})
+
+	// newRunService подключает поддельную БД для RunDue; репозиторий транзакции - тот же мок.
+	newRunService := func() (Service, *MockScheduleRepository, *operationsMocks.OperationsService, *dbtest.Stats) {
+		db, stats := dbtest.New()
+		mockRepo := new(MockScheduleRepository)
+		mockOps := new(operationsMocks.OperationsService)
+		svc := NewService(db, mockRepo, mockOps, logger)
+		svc.(*service).newRepo = func(database.DBTX) repository.Repository { return mockRepo }
+		return svc, mockRepo, mockOps, stats
+	}
+	dueSchedule := func() models.Schedule {
+		interval := 1
+		return models.Schedule{
+			ID: uuid.New(), UserID: uuid.New(), UnitID: uuid.New(), UnitType: "plot", ActionType: actions.ActionWater,
+			IntervalDays: &interval, Timezone: "UTC", StartsAt: time.Now().Add(-48 * time.Hour),
+			Status: models.StatusActive, NextRunAt: ptr(time.Now().Add(-time.Minute)),
+		}
+	}
+	scheduleWithID := func(id uuid.UUID) interface{} {
+		return mock.MatchedBy(func(s *models.Schedule) bool { return s.ID == id })
+	}
+
+	t.Run("PauseSchedule - Locks the row and saves in one transaction", func(t *testing.T) {
+		svc, mockRepo, _, stats := newRunService()
+		userID := uuid.New()
+		schedule := &models.Schedule{ID: uuid.New(), UserID: userID, Status: models.StatusActive}
+		mockRepo.On("GetScheduleForUpdate", ctx, schedule.ID).Return(schedule, nil).Once()
+		mockRepo.On("UpdateSchedule", ctx, scheduleWithID(schedule.ID)).Return(nil).Once()
+
+		paused, err := svc.PauseSchedule(ctx, userID, schedule.ID)
+
+		require.NoError(t, err)
+		assert.Equal(t, models.StatusPaused, paused.Status)
+		assert.Equal(t, 1, stats.Committed())
+		mockRepo.AssertNotCalled(t, "GetScheduleByID", mock.Anything, mock.Anything)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("PauseSchedule - Finished schedule rolls the transaction back", func(t *testing.T) {
+		svc, mockRepo, _, stats := newRunService()
+		userID := uuid.New()
+		schedule := &models.Schedule{ID: uuid.New(), UserID: userID, Status: models.StatusFinished}
+		mockRepo.On("GetScheduleForUpdate", ctx, schedule.ID).Return(schedule, nil).Once()
+
+		_, err := svc.PauseSchedule(ctx, userID, schedule.ID)
+
+		assert.ErrorIs(t, err, ErrScheduleFinished)
+		assert.Equal(t, 0, stats.Committed())
+		assert.Equal(t, 1, stats.RolledBack())
+		mockRepo.AssertNotCalled(t, "UpdateSchedule", mock.Anything, mock.Anything)
+	})
+
+	t.Run("RunDue - Success", func(t *testing.T) {
+		svc, mockRepo, mockOps, stats := newRunService()
+		schedule := dueSchedule()
+		mockRepo.On("FetchDue", ctx, mock.AnythingOfType("time.Time"), 10).Return([]models.Schedule{schedule}, nil).Once()
+		mockOps.On("CreateScheduledAction", ctx, mock.Anything, schedule.UserID, schedule.ID, mock.Anything).
+			Return(&operationsModels.OperationLog{ID: uuid.New()}, nil).Once()
+		var updated models.Schedule
+		mockRepo.On("UpdateSchedule", ctx, scheduleWithID(schedule.ID)).
+			Run(func(args mock.Arguments) { updated = *args.Get(1).(*models.Schedule) }).Return(nil).Once()
+
+		n, err := svc.RunDue(ctx, 10)
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+		assert.Equal(t, models.StatusActive, updated.Status)
+		assert.NotNil(t, updated.LastRunAt)
+		assert.Empty(t, updated.LastError)
+		require.NotNil(t, updated.NextRunAt)
+		assert.True(t, updated.NextRunAt.After(time.Now()))
+		assert.Equal(t, 1, stats.Committed())
+		mockRepo.AssertExpectations(t)
+		mockOps.AssertExpectations(t)
+	})
+
+	t.Run("RunDue - Run over quota is skipped", func(t *testing.T) {
+		svc, mockRepo, mockOps, stats := newRunService()
+		schedule := dueSchedule()
+		mockRepo.On("FetchDue", ctx, mock.AnythingOfType("time.Time"), 10).Return([]models.Schedule{schedule}, nil).Once()
+		mockOps.On("CreateScheduledAction", ctx, mock.Anything, schedule.UserID, schedule.ID, mock.Anything).
+			Return(nil, fmt.Errorf("%w: полив 30 из 30 в месяц", operationsService.ErrQuotaExceeded)).Once()
+		var updated models.Schedule
+		mockRepo.On("UpdateSchedule", ctx, scheduleWithID(schedule.ID)).
+			Run(func(args mock.Arguments) { updated = *args.Get(1).(*models.Schedule) }).Return(nil).Once()
+
+		n, err := svc.RunDue(ctx, 10)
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+		assert.Equal(t, models.StatusActive, updated.Status)
+		assert.Nil(t, updated.LastRunAt)
+		assert.Contains(t, updated.LastError, "лимит действия исчерпан")
+		require.NotNil(t, updated.NextRunAt)
+		assert.True(t, updated.NextRunAt.After(time.Now()))
+		assert.Equal(t, 1, stats.Committed())
+		assert.Equal(t, 0, stats.RolledBackToSavepoint())
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("RunDue - Failing schedule does not roll back the others", func(t *testing.T) {
+		svc, mockRepo, mockOps, stats := newRunService()
+		failing, healthy := dueSchedule(), dueSchedule()
+		mockRepo.On("FetchDue", ctx, mock.AnythingOfType("time.Time"), 10).Return([]models.Schedule{failing, healthy}, nil).Once()
+		mockOps.On("CreateScheduledAction", ctx, mock.Anything, failing.UserID, failing.ID, mock.Anything).
+			Return(nil, errors.New("outbox недоступен")).Once()
+		mockOps.On("CreateScheduledAction", ctx, mock.Anything, healthy.UserID, healthy.ID, mock.Anything).
+			Return(&operationsModels.OperationLog{ID: uuid.New()}, nil).Once()
+		var failed, succeeded models.Schedule
+		mockRepo.On("UpdateSchedule", ctx, scheduleWithID(failing.ID)).
+			Run(func(args mock.Arguments) { failed = *args.Get(1).(*models.Schedule) }).Return(nil).Once()
+		mockRepo.On("UpdateSchedule", ctx, scheduleWithID(healthy.ID)).
+			Run(func(args mock.Arguments) { succeeded = *args.Get(1).(*models.Schedule) }).Return(nil).Once()
+
+		n, err := svc.RunDue(ctx, 10)
+
+		require.NoError(t, err)
+		assert.Equal(t, 2, n)
+		// Срабатывание с ошибкой откатывается к точке сохранения и переносится дальше.
+		assert.Equal(t, models.StatusActive, failed.Status)
+		assert.Nil(t, failed.LastRunAt)
+		assert.Equal(t, "outbox недоступен", failed.LastError)
+		require.NotNil(t, failed.NextRunAt)
+		assert.True(t, failed.NextRunAt.After(time.Now()))
+		assert.NotNil(t, succeeded.LastRunAt)
+		assert.Empty(t, succeeded.LastError)
+		assert.Equal(t, 2, stats.Savepoints())
+		assert.Equal(t, 1, stats.RolledBackToSavepoint())
+		assert.Equal(t, 1, stats.Committed())
+		mockRepo.AssertExpectations(t)
+		mockOps.AssertExpectations(t)
+	})
+}
@@ -1,9 +1,21 @@
 package handler
 
 import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"path/filepath"
+	"strconv"
+	"time"
+
 	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
+	"github.com/rendley/vegshare/backend/internal/task/models"
 	"github.com/rendley/vegshare/backend/internal/task/service"
 	"github.com/rendley/vegshare/backend/pkg/api"
 	"github.com/rendley/vegshare/backend/pkg/middleware"
@@ -11,12 +23,43 @@ import (
 	"net/http"
 )
 
+// dateLayout - короткий формат даты в query-параметрах фильтров.
+const dateLayout = "2006-01-02"
+
+// Ограничения на загрузку фото при завершении задачи.
+const (
+	maxPhotoBytes          = 5 << 20
+	maxCompletionBodyBytes = 55 << 20
+	// multipartMemoryBytes - сколько формы держать в памяти; остальное уходит во временные файлы.
+	multipartMemoryBytes = 8 << 20
+)
+
 type TaskHandler struct {
 	service  service.Service
 	logger   *logrus.Logger
 	validate *validator.Validate
 }
 
+// completeTaskRequest - необязательное тело запроса при завершении задачи.
+type completeTaskRequest struct {
+	HarvestWeightGrams float64 `json:"harvest_weight_grams" validate:"gte=0"`
+	HarvestCount       int     `json:"harvest_count" validate:"gte=0"`
+	// Note - заметка для арендатора о том, как выполнена задача.
+	Note string `json:"note" validate:"max=2000"`
+}
+
+// failTaskRequest - тело запроса на провал задачи.
+type failTaskRequest struct {
+	// Reason - weather, out_of_stock или plot_issue.
+	Reason  string `json:"reason" validate:"required"`
+	Comment string `json:"comment" validate:"max=1000"`
+}
+
+// assignTaskRequest - тело запроса на назначение исполнителя.
+type assignTaskRequest struct {
+	AssigneeID uuid.UUID `json:"assignee_id" validate:"required"`
+}
+
 func NewTaskHandler(s service.Service, l *logrus.Logger) *TaskHandler {
 	return &TaskHandler{
 		service:  s,
@@ -25,15 +68,134 @@ func NewTaskHandler(s service.Service, l *logrus.Logger) *TaskHandler {
 	}
 }
 
+// parseTaskFilter собирает TaskFilter из query-параметров запроса (status, priority, overdue,
+// due_before, assignee_id, region_id, structure_id, action_type, from, to, sort, cursor, limit).
+func parseTaskFilter(r *http.Request) (models.TaskFilter, error) {
+	q := r.URL.Query()
+	filter := models.TaskFilter{
+		Status:     models.TaskStatus(q.Get("status")),
+		Priority:   q.Get("priority"),
+		ActionType: q.Get("action_type"),
+		Sort:       q.Get("sort"),
+	}
+
+	idParams := map[string]**uuid.UUID{
+		"assignee_id":  &filter.AssigneeID,
+		"region_id":    &filter.RegionID,
+		"structure_id": &filter.StructureID,
+	}
+	for name, dest := range idParams {
+		if v := q.Get(name); v != "" {
+			id, err := uuid.Parse(v)
+			if err != nil {
+				return filter, fmt.Errorf("invalid %s query parameter", name)
+			}
+			*dest = &id
+		}
+	}
+
+	dateParams := map[string]**time.Time{
+		"from": &filter.From,
+		"to":   &filter.To,
+	}
+	for name, dest := range dateParams {
+		if v := q.Get(name); v != "" {
+			t, err := time.Parse(dateLayout, v)
+			if err != nil {
+				return filter, fmt.Errorf("invalid %s query parameter, expected YYYY-MM-DD", name)
+			}
+			if name == "to" {
+				// Включаем весь последний день периода.
+				t = t.Add(24*time.Hour - time.Nanosecond)
+			}
+			*dest = &t
+		}
+	}
+
+	if v := q.Get("overdue"); v != "" {
+		overdue, err := strconv.ParseBool(v)
+		if err != nil {
+			return filter, fmt.Errorf("invalid overdue query parameter")
+		}
+		filter.Overdue = &overdue
+	}
+	if v := q.Get("due_before"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			t, err = time.Parse(dateLayout, v)
+			if err != nil {
+				return filter, fmt.Errorf("invalid due_before query parameter, expected RFC3339 or YYYY-MM-DD")
+			}
+		}
+		filter.DueBefore = &t
+	}
+
+	if v := q.Get("cursor"); v != "" {
+		cursor, err := models.DecodeTaskCursor(v)
+		if err != nil {
+			return filter, fmt.Errorf("invalid cursor query parameter")
+		}
+		filter.Cursor = cursor
+	}
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return filter, fmt.Errorf("invalid limit query parameter")
+		}
+		filter.Limit = limit
+	}
+
+	return filter, nil
+}
+
+// GetAllTasks возвращает страницу задач с фильтрами, выбранной сортировкой и курсорной пагинацией.
 func (h *TaskHandler) GetAllTasks(w http.ResponseWriter, r *http.Request) {
-	tasks, err := h.service.GetAllTasks(r.Context())
+	filter, err := parseTaskFilter(r)
 	if err != nil {
+		api.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := h.service.GetTasks(r.Context(), filter)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidTaskFilter) {
+			api.RespondWithError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		h.logger.Errorf("ошибка при получении всех задач: %v", err)
 		api.RespondWithError(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	api.RespondWithJSON(h.logger, w, tasks, http.StatusOK)
+	api.RespondWithJSON(h.logger, w, page, http.StatusOK)
+}
+
+// GetMyTasks возвращает очередь задач текущего исполнителя с теми же фильтрами, кроме assignee_id.
+func (h *TaskHandler) GetMyTasks(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		api.RespondWithError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	filter, err := parseTaskFilter(r)
+	if err != nil {
+		api.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := h.service.GetMyTasks(r.Context(), userID, filter)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidTaskFilter) {
+			api.RespondWithError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		h.logger.Errorf("ошибка при получении задач исполнителя: %v", err)
+		api.RespondWithError(w, "could not retrieve tasks", http.StatusInternalServerError)
+		return
+	}
+
+	api.RespondWithJSON(h.logger, w, page, http.StatusOK)
 }
 
 func (h *TaskHandler) AcceptTask(w http.ResponseWriter, r *http.Request) {
@@ -52,6 +214,10 @@ func (h *TaskHandler) AcceptTask(w http.ResponseWriter, r *http.Request) {
 
 	task, err := h.service.AcceptTask(r.Context(), taskID, userID)
 	if err != nil {
+		if errors.Is(err, service.ErrAssignedToAnother) || errors.Is(err, service.ErrInvalidTransition) {
+			api.RespondWithError(w, err.Error(), http.StatusConflict)
+			return
+		}
 		h.logger.Errorf("ошибка при принятии задачи в работу: %v", err)
 		api.RespondWithError(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -74,8 +240,44 @@ func (h *TaskHandler) CompleteTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	task, err := h.service.CompleteTask(r.Context(), taskID, userID)
+	var req completeTaskRequest
+	var evidence service.Evidence
+	if isMultipart(r) {
+		// Фото передаются в multipart/form-data (поле photos), остальные поля - там же текстом.
+		r.Body = http.MaxBytesReader(w, r.Body, maxCompletionBodyBytes)
+		photos, cleanup, err := parseCompletionForm(r, &req)
+		if err != nil {
+			api.RespondWithError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer cleanup()
+		evidence.Photos = photos
+	} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		api.RespondWithError(w, "некорректное тело запроса", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		api.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	details := service.CompletionDetails{
+		HarvestWeightGrams: req.HarvestWeightGrams,
+		HarvestCount:       req.HarvestCount,
+	}
+	evidence.Note = req.Note
+
+	task, err := h.service.CompleteTask(r.Context(), taskID, userID, details, evidence)
 	if err != nil {
+		if errors.Is(err, service.ErrInvalidEvidence) {
+			api.RespondWithError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, service.ErrInvalidTransition) {
+			api.RespondWithError(w, err.Error(), http.StatusConflict)
+			return
+		}
 		h.logger.Errorf("ошибка при завершении задачи: %v", err)
 		api.RespondWithError(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -98,12 +300,195 @@ func (h *TaskHandler) FailTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	task, err := h.service.FailTask(r.Context(), taskID, userID)
+	var req failTaskRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.RespondWithError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := h.validate.Struct(req); err != nil {
+		api.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	task, err := h.service.FailTask(r.Context(), taskID, userID, service.Failure{Reason: req.Reason, Comment: req.Comment})
 	if err != nil {
-		h.logger.Errorf("ошибка при провале задачи: %v", err)
-		api.RespondWithError(w, err.Error(), http.StatusInternalServerError)
+		switch {
+		case errors.Is(err, service.ErrInvalidFailureReason):
+			api.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, service.ErrAssignedToAnother), errors.Is(err, service.ErrInvalidTransition):
+			api.RespondWithError(w, err.Error(), http.StatusConflict)
+		default:
+			h.logger.Errorf("ошибка при провале задачи: %v", err)
+			api.RespondWithError(w, err.Error(), http.StatusInternalServerError)
+		}
 		return
 	}
 
 	api.RespondWithJSON(h.logger, w, task, http.StatusOK)
 }
+
+// AssignTask назначает или переназначает задачу сотруднику.
+func (h *TaskHandler) AssignTask(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		api.RespondWithError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	taskID, err := uuid.Parse(chi.URLParam(r, "taskID"))
+	if err != nil {
+		api.RespondWithError(w, "некорректный ID задачи", http.StatusBadRequest)
+		return
+	}
+
+	var req assignTaskRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.RespondWithError(w, "некорректное тело запроса", http.StatusBadRequest)
+		return
+	}
+	if err := h.validate.Struct(req); err != nil {
+		api.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	task, err := h.service.AssignTask(r.Context(), taskID, req.AssigneeID, userID)
+	if err != nil {
+		h.respondAssignmentError(w, err)
+		return
+	}
+
+	api.RespondWithJSON(h.logger, w, task, http.StatusOK)
+}
+
+// UnassignTask снимает исполнителя и возвращает задачу в пул.
+func (h *TaskHandler) UnassignTask(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		api.RespondWithError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	taskID, err := uuid.Parse(chi.URLParam(r, "taskID"))
+	if err != nil {
+		api.RespondWithError(w, "некорректный ID задачи", http.StatusBadRequest)
+		return
+	}
+
+	task, err := h.service.UnassignTask(r.Context(), taskID, userID)
+	if err != nil {
+		h.respondAssignmentError(w, err)
+		return
+	}
+
+	api.RespondWithJSON(h.logger, w, task, http.StatusOK)
+}
+
+// GetAssignmentHistory возвращает историю назначений задачи.
+func (h *TaskHandler) GetAssignmentHistory(w http.ResponseWriter, r *http.Request) {
+	taskID, err := uuid.Parse(chi.URLParam(r, "taskID"))
+	if err != nil {
+		api.RespondWithError(w, "некорректный ID задачи", http.StatusBadRequest)
+		return
+	}
+
+	history, err := h.service.GetAssignmentHistory(r.Context(), taskID)
+	if err != nil {
+		h.respondAssignmentError(w, err)
+		return
+	}
+
+	api.RespondWithJSON(h.logger, w, history, http.StatusOK)
+}
+
+// respondAssignmentError переводит ошибки назначения исполнителя в HTTP-статусы.
+// GetTimeline возвращает историю статусов задачи.
+func (h *TaskHandler) GetTimeline(w http.ResponseWriter, r *http.Request) {
+	taskID, err := uuid.Parse(chi.URLParam(r, "taskID"))
+	if err != nil {
+		api.RespondWithError(w, "некорректный ID задачи", http.StatusBadRequest)
+		return
+	}
+
+	timeline, err := h.service.GetTimeline(r.Context(), taskID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			api.RespondWithError(w, "задача не найдена", http.StatusNotFound)
+			return
+		}
+		h.logger.Errorf("ошибка при получении истории статусов задачи: %v", err)
+		api.RespondWithError(w, "could not retrieve task timeline", http.StatusInternalServerError)
+		return
+	}
+
+	api.RespondWithJSON(h.logger, w, timeline, http.StatusOK)
+}
+
+func (h *TaskHandler) respondAssignmentError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidAssignee):
+		api.RespondWithError(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, sql.ErrNoRows):
+		api.RespondWithError(w, "задача не найдена", http.StatusNotFound)
+	case errors.Is(err, service.ErrTaskClosed), errors.Is(err, service.ErrInvalidTransition):
+		api.RespondWithError(w, err.Error(), http.StatusConflict)
+	default:
+		h.logger.Errorf("ошибка при назначении задачи: %v", err)
+		api.RespondWithError(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func isMultipart(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mediaType == "multipart/form-data"
+}
+
+// parseCompletionForm читает поля завершения задачи и фото из multipart-формы.
+// cleanup закрывает файлы и удаляет временные файлы формы.
+func parseCompletionForm(r *http.Request, req *completeTaskRequest) ([]service.Photo, func(), error) {
+	if err := r.ParseMultipartForm(multipartMemoryBytes); err != nil {
+		return nil, nil, fmt.Errorf("некорректная форма: %v", err)
+	}
+	form := r.MultipartForm
+
+	var files []multipart.File
+	cleanup := func() {
+		for _, f := range files {
+			f.Close()
+		}
+		form.RemoveAll()
+	}
+
+	req.Note = r.FormValue("note")
+	if v := r.FormValue("harvest_weight_grams"); v != "" {
+		weight, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			cleanup()
+			return nil, nil, fmt.Errorf("некорректное поле harvest_weight_grams")
+		}
+		req.HarvestWeightGrams = weight
+	}
+	if v := r.FormValue("harvest_count"); v != "" {
+		count, err := strconv.Atoi(v)
+		if err != nil {
+			cleanup()
+			return nil, nil, fmt.Errorf("некорректное поле harvest_count")
+		}
+		req.HarvestCount = count
+	}
+
+	var photos []service.Photo
+	for _, fh := range form.File["photos"] {
+		if fh.Size > maxPhotoBytes {
+			cleanup()
+			return nil, nil, fmt.Errorf("фото '%s' больше %d МБ", fh.Filename, maxPhotoBytes>>20)
+		}
+		f, err := fh.Open()
+		if err != nil {
+			cleanup()
+			return nil, nil, fmt.Errorf("не удалось прочитать фото '%s'", fh.Filename)
+		}
+		files = append(files, f)
+		photos = append(photos, service.Photo{FileName: filepath.Base(fh.Filename), Content: f})
+	}
+	return photos, cleanup, nil
+}
@@ -5,17 +5,51 @@ import "github.com/go-chi/chi/v5"
 func (h *TaskHandler) Routes() chi.Router {
 	r := chi.NewRouter()
 
-	// GET /api/v1/admin/tasks/
+	// GET /api/v1/admin/tasks/?status=&priority=&overdue=&due_before=&assignee_id=&region_id=&structure_id=&action_type=&from=&to=&sort=&cursor=&limit=
 	r.Get("/", h.GetAllTasks)
 
+	// GET /api/v1/admin/tasks/worklist?assignee_id=&date= - лист работ сотрудника на день
+	r.Get("/worklist", h.GetWorkList)
+
+	// GET /api/v1/admin/tasks/worklist/print?assignee_id=&date= - тот же лист для печати (HTML)
+	r.Get("/worklist/print", h.PrintWorkList)
+
 	// POST /api/v1/admin/tasks/{taskID}/accept
 	r.Post("/{taskID}/accept", h.AcceptTask)
 
 	// POST /api/v1/admin/tasks/{taskID}/complete
 	r.Post("/{taskID}/complete", h.CompleteTask)
 
-	// POST /api/v1/admin/tasks/{taskID}/fail
+	// POST /api/v1/admin/tasks/{taskID}/fail - {"reason": "weather|out_of_stock|plot_issue", "comment": "..."}
 	r.Post("/{taskID}/fail", h.FailTask)
 
+	// POST /api/v1/admin/tasks/{taskID}/assign - назначить или переназначить исполнителя
+	r.Post("/{taskID}/assign", h.AssignTask)
+
+	// POST /api/v1/admin/tasks/{taskID}/unassign - снять исполнителя и вернуть задачу в пул
+	r.Post("/{taskID}/unassign", h.UnassignTask)
+
+	// GET /api/v1/admin/tasks/{taskID}/assignments - история назначений
+	r.Get("/{taskID}/assignments", h.GetAssignmentHistory)
+
+	// GET /api/v1/admin/tasks/{taskID}/timeline - история статусов (кто, когда, из какого статуса в какой)
+	r.Get("/{taskID}/timeline", h.GetTimeline)
+
+	return r
+}
+
+// StaffRoutes - роуты исполнителя задач.
+func (h *TaskHandler) StaffRoutes() chi.Router {
+	r := chi.NewRouter()
+
+	// GET /api/v1/tasks/mine - мои задачи (по умолчанию незакрытые, сначала срочные), те же фильтры
+	r.Get("/mine", h.GetMyTasks)
+
+	// GET /api/v1/tasks/mine/worklist?date= - мой лист работ на день, сгруппированный по участкам и строениям
+	r.Get("/mine/worklist", h.GetMyWorkList)
+
+	// GET /api/v1/tasks/mine/worklist/print?date= - тот же лист для печати (HTML)
+	r.Get("/mine/worklist/print", h.PrintMyWorkList)
+
 	return r
 }
@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"html/template"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rendley/vegshare/backend/internal/task/models"
+	"github.com/rendley/vegshare/backend/pkg/api"
+	"github.com/rendley/vegshare/backend/pkg/middleware"
+)
+
+// priorityLabels - подписи приоритетов в печатном листе работ.
+var priorityLabels = map[string]string{
+	models.PriorityUrgent: "срочно",
+	models.PriorityHigh:   "высокий",
+	models.PriorityNormal: "обычный",
+	models.PriorityLow:    "низкий",
+}
+
+// workListTemplate - печатная версия листа работ: одна страница A4 с чекбоксами у задач.
+var workListTemplate = template.Must(template.New("worklist").Funcs(template.FuncMap{
+	"priority": func(p string) string {
+		if label, ok := priorityLabels[p]; ok {
+			return label
+		}
+		return p
+	},
+	"due": func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.Local().Format("02.01 15:04")
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head>
+<meta charset="utf-8">
+<title>Лист работ на {{.Date}}</title>
+<style>
+  body { font-family: sans-serif; font-size: 12pt; margin: 1.5cm; }
+  h1 { font-size: 16pt; margin: 0 0 .3em; }
+  h2 { font-size: 14pt; margin: 1em 0 .3em; border-bottom: 1px solid #000; }
+  h3 { font-size: 12pt; margin: .8em 0 .2em; }
+  table { width: 100%; border-collapse: collapse; }
+  td, th { border: 1px solid #999; padding: .2em .4em; text-align: left; vertical-align: top; }
+  .check { width: 1.5em; }
+  .urgent { font-weight: bold; }
+  @media print { h2 { page-break-after: avoid; } tr { page-break-inside: avoid; } }
+</style>
+</head>
+<body>
+<h1>Лист работ на {{.Date}}</h1>
+<p>Задач: {{.TotalTasks}}{{if .EstimatedMinutes}}, примерно {{.EstimatedMinutes}} мин{{end}}</p>
+{{range .Parcels}}
+<h2>{{.Name}}{{if .RegionName}} ({{.RegionName}}){{end}}</h2>
+{{range .Structures}}
+<h3>{{.Name}}</h3>
+<table>
+<tr><th class="check"></th><th>Юнит</th><th>Задача</th><th>Приоритет</th><th>Срок</th></tr>
+{{range .Tasks}}
+<tr{{if eq .Priority "urgent"}} class="urgent"{{end}}>
+<td class="check">&#9744;</td>
+<td>{{.UnitName}}</td>
+<td>{{.Title}}{{if .Description}}<br><small>{{.Description}}</small>{{end}}</td>
+<td>{{priority .Priority}}</td>
+<td>{{due .DueAt}}</td>
+</tr>
+{{end}}
+</table>
+{{end}}
+{{else}}
+<p>На этот день задач нет.</p>
+{{end}}
+</body>
+</html>
+`))
+
+// parseWorkListDate разбирает query-параметр date (YYYY-MM-DD); по умолчанию - сегодня.
+func parseWorkListDate(r *http.Request) (time.Time, bool) {
+	v := r.URL.Query().Get("date")
+	if v == "" {
+		return time.Now(), true
+	}
+	day, err := time.ParseInLocation(dateLayout, v, time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return day, true
+}
+
+// GetMyWorkList возвращает лист работ текущего исполнителя на день.
+func (h *TaskHandler) GetMyWorkList(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		api.RespondWithError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	h.respondWorkList(w, r, userID, false)
+}
+
+// PrintMyWorkList отдает лист работ текущего исполнителя в виде HTML-страницы для печати.
+func (h *TaskHandler) PrintMyWorkList(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(uuid.UUID)
+	if !ok {
+		api.RespondWithError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	h.respondWorkList(w, r, userID, true)
+}
+
+// GetWorkList возвращает лист работ сотрудника assignee_id на день.
+func (h *TaskHandler) GetWorkList(w http.ResponseWriter, r *http.Request) {
+	assigneeID, err := uuid.Parse(r.URL.Query().Get("assignee_id"))
+	if err != nil {
+		api.RespondWithError(w, "invalid assignee_id query parameter", http.StatusBadRequest)
+		return
+	}
+	h.respondWorkList(w, r, assigneeID, false)
+}
+
+// PrintWorkList отдает лист работ сотрудника assignee_id в виде HTML-страницы для печати.
+func (h *TaskHandler) PrintWorkList(w http.ResponseWriter, r *http.Request) {
+	assigneeID, err := uuid.Parse(r.URL.Query().Get("assignee_id"))
+	if err != nil {
+		api.RespondWithError(w, "invalid assignee_id query parameter", http.StatusBadRequest)
+		return
+	}
+	h.respondWorkList(w, r, assigneeID, true)
+}
+
+func (h *TaskHandler) respondWorkList(w http.ResponseWriter, r *http.Request, assigneeID uuid.UUID, printable bool) {
+	day, ok := parseWorkListDate(r)
+	if !ok {
+		api.RespondWithError(w, "invalid date query parameter, expected YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+
+	list, err := h.service.GetWorkList(r.Context(), assigneeID, day)
+	if err != nil {
+		h.logger.Errorf("ошибка при составлении листа работ: %v", err)
+		api.RespondWithError(w, "could not build work list", http.StatusInternalServerError)
+		return
+	}
+
+	if !printable {
+		api.RespondWithJSON(h.logger, w, list, http.StatusOK)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	if err := workListTemplate.Execute(w, list); err != nil {
+		h.logger.Errorf("ошибка при выводе листа работ: %v", err)
+	}
+}
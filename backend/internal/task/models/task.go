@@ -1,7 +1,11 @@
 package models
 
 import (
+	"encoding/base64"
+	"errors"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"strings"
 	"time"
 )
 
@@ -12,6 +16,22 @@ const (
 	StatusInProgress TaskStatus = "in_progress"
 	StatusCompleted  TaskStatus = "completed"
 	StatusFailed     TaskStatus = "failed"
+	StatusCancelled  TaskStatus = "cancelled"
+)
+
+// Приоритеты задач, от низшего к высшему.
+const (
+	PriorityLow    = "low"
+	PriorityNormal = "normal"
+	PriorityHigh   = "high"
+	PriorityUrgent = "urgent"
+)
+
+// Причины невыполнения задачи.
+const (
+	FailureWeather    = "weather"      // погода не позволяет выполнить работу
+	FailureOutOfStock = "out_of_stock" // нет семян, удобрений или другого материала
+	FailurePlotIssue  = "plot_issue"   // проблема с грядкой или строением
 )
 
 type Task struct {
@@ -21,6 +41,230 @@ type Task struct {
 	Status      TaskStatus `json:"status" db:"status"`
 	Title       string     `json:"title" db:"title"`
 	Description *string    `json:"description" db:"description"`
+	// RequiredSkills - навыки исполнителя, которые задает обработчик типа действия.
+	RequiredSkills pq.StringArray `json:"required_skills" db:"required_skills"`
+	// EstimatedMinutes - оценка длительности выполнения; nil, если оценки нет.
+	EstimatedMinutes *int `json:"estimated_minutes" db:"estimated_minutes"`
+	// ClaimedAt - когда текущий исполнитель взял задачу в работу; nil, пока задача не в работе.
+	ClaimedAt *time.Time `json:"claimed_at" db:"claimed_at"`
+	Priority  string     `json:"priority" db:"priority"`
+	// DueAt - срок выполнения по SLA типа действия; nil, если срока нет.
+	DueAt *time.Time `json:"due_at" db:"due_at"`
+	// OverdueAt - когда SLA-монитор отметил задачу просроченной.
+	OverdueAt *time.Time `json:"overdue_at" db:"overdue_at"`
+	// CompletionNote - заметка исполнителя при завершении задачи.
+	CompletionNote *string `json:"completion_note" db:"completion_note"`
+	// FailureReason и FailureComment - почему задача не выполнена; заполняются при провале.
+	FailureReason  *string   `json:"failure_reason" db:"failure_reason"`
+	FailureComment *string   `json:"failure_comment" db:"failure_comment"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Причины изменения исполнителя в истории назначений.
+const (
+	AssignmentAccepted   = "accepted"   // исполнитель сам взял задачу в работу
+	AssignmentAssigned   = "assigned"   // руководитель назначил или переназначил задачу
+	AssignmentUnassigned = "unassigned" // руководитель вернул задачу в пул
+	AssignmentTimedOut   = "timed_out"  // задача вернулась в пул по таймауту
+)
+
+// TaskAssignment - запись истории назначений задачи.
+type TaskAssignment struct {
+	ID     uuid.UUID `json:"id" db:"id"`
+	TaskID uuid.UUID `json:"task_id" db:"task_id"`
+	// AssigneeID - новый исполнитель; nil, если задача вернулась в пул.
+	AssigneeID         *uuid.UUID `json:"assignee_id" db:"assignee_id"`
+	PreviousAssigneeID *uuid.UUID `json:"previous_assignee_id" db:"previous_assignee_id"`
+	// ChangedBy - кто сделал изменение; nil, если система.
+	ChangedBy *uuid.UUID `json:"changed_by" db:"changed_by"`
+	Reason    string     `json:"reason" db:"reason"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
+// TaskAttachment - фото, которое исполнитель приложил к выполненной задаче.
+type TaskAttachment struct {
+	ID     uuid.UUID `json:"id" db:"id"`
+	TaskID uuid.UUID `json:"task_id" db:"task_id"`
+	// StorageKey - ключ файла в хранилище; наружу не отдается.
+	StorageKey  string     `json:"-" db:"storage_key"`
+	FileName    string     `json:"file_name" db:"file_name"`
+	ContentType string     `json:"content_type" db:"content_type"`
+	SizeBytes   int64      `json:"size_bytes" db:"size_bytes"`
+	UploadedBy  *uuid.UUID `json:"uploaded_by" db:"uploaded_by"`
 	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Сортировки списка задач.
+const (
+	SortCreatedAt = "created_at" // сначала новые
+	SortDueAt     = "due_at"     // сначала с ближайшим сроком
+	SortPriority  = "priority"   // сначала срочные, внутри приоритета - по сроку
+)
+
+// PriorityRank - место приоритета в сортировке: 0 - самый срочный.
+func PriorityRank(priority string) int {
+	switch priority {
+	case PriorityUrgent:
+		return 0
+	case PriorityHigh:
+		return 1
+	case PriorityNormal:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// TaskFilter - фильтры, сортировка и страница списка задач. Пустые (nil) поля не участвуют в фильтрации.
+type TaskFilter struct {
+	Status TaskStatus
+	// Open - только незакрытые задачи (new и in_progress); вместе со Status не используется.
+	Open     bool
+	Priority string
+	// Overdue - только задачи с нарушенным SLA (true) или только без нарушения (false).
+	Overdue *bool
+	// DueBefore - только задачи со сроком раньше этого момента.
+	DueBefore   *time.Time
+	AssigneeID  *uuid.UUID
+	RegionID    *uuid.UUID
+	StructureID *uuid.UUID
+	ActionType  string
+	OperationID *uuid.UUID
+	// From и To - период создания задачи.
+	From *time.Time
+	To   *time.Time
+	Sort string
+	// Cursor - позиция, после которой начинается страница; nil - первая страница.
+	Cursor *TaskCursor
+	Limit  int
+}
+
+// TaskCursor - позиция в списке задач. Курсор действителен только для той сортировки, в которой получен.
+type TaskCursor struct {
+	Sort      string
+	Priority  string
+	DueAt     *time.Time
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// NewTaskCursor возвращает курсор, указывающий на задачу task в сортировке sort.
+func NewTaskCursor(sort string, task Task) TaskCursor {
+	if sort == "" {
+		sort = SortCreatedAt
+	}
+	return TaskCursor{Sort: sort, Priority: task.Priority, DueAt: task.DueAt, CreatedAt: task.CreatedAt, ID: task.ID}
+}
+
+// Encode кодирует курсор в непрозрачную строку для query-параметра cursor.
+func (c TaskCursor) Encode() string {
+	dueAt := ""
+	if c.DueAt != nil {
+		dueAt = c.DueAt.Format(time.RFC3339Nano)
+	}
+	raw := strings.Join([]string{c.Sort, c.Priority, dueAt, c.CreatedAt.Format(time.RFC3339Nano), c.ID.String()}, "|")
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeTaskCursor разбирает строку, полученную от Encode.
+func DecodeTaskCursor(s string) (*TaskCursor, error) {
+	errInvalid := errors.New("некорректный курсор")
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errInvalid
+	}
+	parts := strings.Split(string(raw), "|")
+	if len(parts) != 5 {
+		return nil, errInvalid
+	}
+
+	c := &TaskCursor{Sort: parts[0], Priority: parts[1]}
+	if parts[2] != "" {
+		dueAt, err := time.Parse(time.RFC3339Nano, parts[2])
+		if err != nil {
+			return nil, errInvalid
+		}
+		c.DueAt = &dueAt
+	}
+	if c.CreatedAt, err = time.Parse(time.RFC3339Nano, parts[3]); err != nil {
+		return nil, errInvalid
+	}
+	if c.ID, err = uuid.Parse(parts[4]); err != nil {
+		return nil, errInvalid
+	}
+	return c, nil
+}
+
+// TaskListItem - задача вместе с операцией и расположением юнита: что и где нужно сделать.
+type TaskListItem struct {
+	Task
+	ActionType   string     `json:"action_type" db:"action_type"`
+	UnitID       uuid.UUID  `json:"unit_id" db:"unit_id"`
+	UnitType     string     `json:"unit_type" db:"unit_type"`
+	UnitName     string     `json:"unit_name" db:"unit_name"`
+	StructureID  *uuid.UUID `json:"structure_id" db:"structure_id"`
+	LandParcelID *uuid.UUID `json:"land_parcel_id" db:"land_parcel_id"`
+	RegionID     *uuid.UUID `json:"region_id" db:"region_id"`
+	// Названия уровней иерархии фермы; nil, если юнит не привязан к строению.
+	StructureName  *string `json:"structure_name" db:"structure_name"`
+	LandParcelName *string `json:"land_parcel_name" db:"land_parcel_name"`
+	RegionName     *string `json:"region_name" db:"region_name"`
+	// LocationPath - путь к юниту в иерархии фермы: "Регион / Участок / Строение / Грядка".
+	LocationPath string `json:"location_path" db:"location_path"`
+}
+
+// TaskPage - страница списка задач. NextCursor пуст на последней странице.
+type TaskPage struct {
+	Items      []TaskListItem `json:"items"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+// WorkList - дневной лист работ исполнителя. Задачи сгруппированы по участкам и строениям
+// в порядке обхода: сначала места с самыми срочными задачами, чтобы не возвращаться в одно строение дважды.
+type WorkList struct {
+	AssigneeID uuid.UUID `json:"assignee_id"`
+	Date       string    `json:"date"`
+	TotalTasks int       `json:"total_tasks"`
+	// EstimatedMinutes - сумма оценок длительности задач, у которых оценка есть.
+	EstimatedMinutes int              `json:"estimated_minutes"`
+	Parcels          []WorkListParcel `json:"parcels"`
+}
+
+// WorkListParcel - земельный участок в листе работ. LandParcelID равен nil для задач,
+// юнит которых не привязан к строению.
+type WorkListParcel struct {
+	LandParcelID *uuid.UUID          `json:"land_parcel_id"`
+	Name         string              `json:"name"`
+	RegionName   string              `json:"region_name"`
+	Structures   []WorkListStructure `json:"structures"`
+}
+
+// WorkListStructure - строение в листе работ с задачами в порядке выполнения.
+type WorkListStructure struct {
+	StructureID *uuid.UUID     `json:"structure_id"`
+	Name        string         `json:"name"`
+	Tasks       []TaskListItem `json:"tasks"`
+}
+
+// События задач в очереди task_notifications.
+const (
+	EventTaskOverdue = "task.overdue" // задача не выполнена в срок
+	EventTaskFailed  = "task.failed"  // исполнитель не смог выполнить задачу; арендатору нужно сообщить причину
+)
+
+// TaskEvent - сообщение о событии задачи в очереди task_notifications для внешних потребителей (уведомлений и т.п.).
+type TaskEvent struct {
+	Type        string     `json:"type"`
+	TaskID      uuid.UUID  `json:"task_id"`
+	OperationID uuid.UUID  `json:"operation_id"`
+	AssigneeID  *uuid.UUID `json:"assignee_id,omitempty"`
+	Priority    string     `json:"priority"`
+	DueAt       *time.Time `json:"due_at,omitempty"`
+	// UserID - арендатор, которому принадлежит операция задачи.
+	UserID         *uuid.UUID `json:"user_id,omitempty"`
+	ActionType     string     `json:"action_type,omitempty"`
+	FailureReason  string     `json:"failure_reason,omitempty"`
+	FailureComment string     `json:"failure_comment,omitempty"`
+	OccurredAt     time.Time  `json:"occurred_at"`
 }
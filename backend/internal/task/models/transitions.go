@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// taskTransitions - конечный автомат статусов задачи: из какого статуса в какие можно перейти.
+// completed, failed и cancelled - конечные статусы.
+var taskTransitions = map[TaskStatus][]TaskStatus{
+	// Задачу берут в работу, отменяют вместе с операцией или проваливают, не начав (например, из-за погоды).
+	StatusNew: {StatusInProgress, StatusFailed, StatusCancelled},
+	// Задача в работе выполняется, проваливается или возвращается в пул (снятие исполнителя, таймаут).
+	StatusInProgress: {StatusCompleted, StatusFailed, StatusNew},
+}
+
+// CanTransitionTo сообщает, разрешен ли переход задачи из статуса s в статус to.
+func (s TaskStatus) CanTransitionTo(to TaskStatus) bool {
+	for _, allowed := range taskTransitions[s] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsClosed сообщает, что статус конечный и задача больше не меняется.
+func (s TaskStatus) IsClosed() bool {
+	return len(taskTransitions[s]) == 0
+}
+
+// TaskTransition - запись истории статусов задачи (таблица task_events).
+type TaskTransition struct {
+	ID     uuid.UUID `json:"id" db:"id"`
+	TaskID uuid.UUID `json:"task_id" db:"task_id"`
+	// ActorID - кто сменил статус; nil, если система.
+	ActorID *uuid.UUID `json:"actor_id" db:"actor_id"`
+	// FromStatus - nil для записи о создании задачи.
+	FromStatus *TaskStatus `json:"from_status" db:"from_status"`
+	ToStatus   TaskStatus  `json:"to_status" db:"to_status"`
+	Comment    *string     `json:"comment" db:"comment"`
+	CreatedAt  time.Time   `json:"created_at" db:"created_at"`
+}
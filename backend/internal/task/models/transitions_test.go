@@ -0,0 +1,41 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaskStatusTransitions(t *testing.T) {
+	t.Run("CanTransitionTo", func(t *testing.T) {
+		tests := []struct {
+			from, to TaskStatus
+			allowed  bool
+		}{
+			{StatusNew, StatusInProgress, true},
+			{StatusNew, StatusFailed, true},
+			{StatusNew, StatusCancelled, true},
+			{StatusNew, StatusCompleted, false},
+			{StatusInProgress, StatusCompleted, true},
+			{StatusInProgress, StatusFailed, true},
+			{StatusInProgress, StatusNew, true},
+			{StatusInProgress, StatusCancelled, false},
+			{StatusCompleted, StatusNew, false},
+			{StatusFailed, StatusInProgress, false},
+			{StatusCancelled, StatusNew, false},
+			{"unknown", StatusNew, false},
+		}
+		for _, tt := range tests {
+			assert.Equal(t, tt.allowed, tt.from.CanTransitionTo(tt.to), "%s -> %s", tt.from, tt.to)
+		}
+	})
+
+	t.Run("IsClosed", func(t *testing.T) {
+		for _, status := range []TaskStatus{StatusCompleted, StatusFailed, StatusCancelled} {
+			assert.True(t, status.IsClosed(), status)
+		}
+		for _, status := range []TaskStatus{StatusNew, StatusInProgress} {
+			assert.False(t, status.IsClosed(), status)
+		}
+	})
+}
@@ -0,0 +1,69 @@
+package releaser
+
+import (
+	"context"
+	"time"
+
+	"github.com/rendley/vegshare/backend/internal/task/service"
+	"github.com/rendley/vegshare/backend/pkg/config"
+	"github.com/sirupsen/logrus"
+)
+
+// Значения по умолчанию, если в конфиге секция tasks заполнена не полностью.
+const (
+	defaultPollInterval = time.Minute
+	defaultBatchSize    = 100
+)
+
+// Releaser периодически возвращает в пул задачи, которые в работе дольше tasks.claim_timeout.
+// Задачи выбираются с блокировкой строк, поэтому несколько экземпляров API
+// могут запускать его одновременно.
+type Releaser struct {
+	service service.Service
+	logger  *logrus.Logger
+	cfg     config.TasksConfig
+}
+
+// New - конструктор для Releaser.
+func New(s service.Service, logger *logrus.Logger, cfg config.TasksConfig) *Releaser {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = defaultPollInterval
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultBatchSize
+	}
+	return &Releaser{service: s, logger: logger, cfg: cfg}
+}
+
+// Run проверяет задачи до отмены контекста. Если таймаут не задан, сразу возвращается.
+func (r *Releaser) Run(ctx context.Context) {
+	if r.cfg.ClaimTimeout <= 0 {
+		r.logger.Info("Task claim timeout is disabled")
+		return
+	}
+
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			n, err := r.service.ReleaseExpiredClaims(ctx, r.cfg.ClaimTimeout, r.cfg.BatchSize)
+			if err != nil {
+				r.logger.Errorf("ошибка при возврате задач в пул: %v", err)
+				break
+			}
+			if n > 0 {
+				r.logger.Infof("Released %d tasks after claim timeout", n)
+			}
+			if n < r.cfg.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
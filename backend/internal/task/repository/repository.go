@@ -2,18 +2,50 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"github.com/google/uuid"
 	"github.com/rendley/vegshare/backend/internal/task/models"
 	"github.com/rendley/vegshare/backend/pkg/database"
+	"strings"
 	"time"
 )
 
+// ErrStatusChanged возвращается, если статус задачи в базе уже не тот, из которого ее обновляют:
+// задачу успел изменить параллельный запрос.
+var ErrStatusChanged = errors.New("статус задачи изменился")
+
 // Repository определяет интерфейс для взаимодействия с хранилищем задач.
 type Repository interface {
-	CreateTask(ctx context.Context, task *models.Task) error
+	// CreateTask создает задачу, если для операции ее еще нет, и записывает создание в историю статусов;
+	// created == false означает дубликат.
+	CreateTask(ctx context.Context, task *models.Task) (created bool, err error)
 	GetTaskByID(ctx context.Context, taskID uuid.UUID) (*models.Task, error)
-	GetAllTasks(ctx context.Context) ([]models.Task, error)
-	UpdateTask(ctx context.Context, task *models.Task) error
+	GetTaskByOperationID(ctx context.Context, operationID uuid.UUID) (*models.Task, error)
+	// GetTasks возвращает задачи с расположением юнитов по фильтру в порядке filter.Sort,
+	// не больше filter.Limit записей (0 - без ограничения) после filter.Cursor.
+	GetTasks(ctx context.Context, filter models.TaskFilter) ([]models.TaskListItem, error)
+	// UpdateTask сохраняет задачу, только если ее статус в базе по-прежнему from;
+	// иначе возвращает ErrStatusChanged.
+	UpdateTask(ctx context.Context, task *models.Task, from models.TaskStatus) error
+	// CancelTasksByOperationID отменяет еще не взятые в работу задачи операции и записывает отмену
+	// в историю статусов от имени cancelledBy.
+	CancelTasksByOperationID(ctx context.Context, operationID uuid.UUID, cancelledBy *uuid.UUID) error
+	// GetTaskForUpdate читает задачу с блокировкой строки до конца транзакции.
+	GetTaskForUpdate(ctx context.Context, taskID uuid.UUID) (*models.Task, error)
+	// FetchExpiredClaims блокирует и возвращает задачи в работе, взятые раньше claimedBefore.
+	// Строки, заблокированные другим экземпляром, пропускаются.
+	FetchExpiredClaims(ctx context.Context, claimedBefore time.Time, limit int) ([]models.Task, error)
+	CreateAssignment(ctx context.Context, assignment *models.TaskAssignment) error
+	// GetAssignmentsByTaskID возвращает историю назначений задачи от старых записей к новым.
+	GetAssignmentsByTaskID(ctx context.Context, taskID uuid.UUID) ([]models.TaskAssignment, error)
+	// FetchOverdue блокирует и возвращает открытые задачи со сроком раньше now, еще не отмеченные просроченными.
+	FetchOverdue(ctx context.Context, now time.Time, limit int) ([]models.Task, error)
+	MarkOverdue(ctx context.Context, taskID uuid.UUID, at time.Time) error
+	CreateAttachment(ctx context.Context, attachment *models.TaskAttachment) error
+	CreateTransition(ctx context.Context, transition *models.TaskTransition) error
+	// GetTransitionsByTaskID возвращает историю статусов задачи от старых записей к новым.
+	GetTransitionsByTaskID(ctx context.Context, taskID uuid.UUID) ([]models.TaskTransition, error)
 }
 
 // postgresRepository - реализация Repository для PostgreSQL.
@@ -26,11 +58,25 @@ func NewRepository(db database.DBTX) Repository {
 	return &repository{db: db}
 }
 
-func (r *repository) CreateTask(ctx context.Context, task *models.Task) error {
-	query := `INSERT INTO tasks (id, operation_id, status, title, description, created_at, updated_at) 
-			 VALUES ($1, $2, $3, $4, $5, $6, $7)`
-	_, err := r.db.ExecContext(ctx, query, task.ID, task.OperationID, task.Status, task.Title, task.Description, task.CreatedAt, task.UpdatedAt)
-	return err
+func (r *repository) CreateTask(ctx context.Context, task *models.Task) (bool, error) {
+	// Запись о создании вставляется только вместе с задачей: при дубликате CTE не возвращает строк.
+	query := `WITH created AS (
+			     INSERT INTO tasks (id, operation_id, status, title, description, required_skills, estimated_minutes, priority, due_at, created_at, updated_at) 
+			     VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			     ON CONFLICT (operation_id) DO NOTHING
+			     RETURNING id
+			 )
+			 INSERT INTO task_events (task_id, to_status, created_at)
+			 SELECT id, $3, $10 FROM created`
+	result, err := r.db.ExecContext(ctx, query, task.ID, task.OperationID, task.Status, task.Title, task.Description, task.RequiredSkills, task.EstimatedMinutes, task.Priority, task.DueAt, task.CreatedAt, task.UpdatedAt)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
 }
 
 func (r *repository) GetTaskByID(ctx context.Context, taskID uuid.UUID) (*models.Task, error) {
@@ -40,16 +86,258 @@ func (r *repository) GetTaskByID(ctx context.Context, taskID uuid.UUID) (*models
 	return &task, err
 }
 
-func (r *repository) GetAllTasks(ctx context.Context) ([]models.Task, error) {
-	var tasks []models.Task
-	query := `SELECT * FROM tasks ORDER BY created_at DESC`
-	err := r.db.SelectContext(ctx, &tasks, query)
-	return tasks, err
+func (r *repository) GetTaskByOperationID(ctx context.Context, operationID uuid.UUID) (*models.Task, error) {
+	var task models.Task
+	query := `SELECT * FROM tasks WHERE operation_id = $1`
+	err := r.db.GetContext(ctx, &task, query, operationID)
+	return &task, err
+}
+
+// priorityRank - SQL-выражение для models.PriorityRank.
+const priorityRank = `CASE t.priority WHEN 'urgent' THEN 0 WHEN 'high' THEN 1 WHEN 'normal' THEN 2 ELSE 3 END`
+
+// dueAtKey - срок для сортировки: задачи без срока идут последними.
+const dueAtKey = `COALESCE(t.due_at, 'infinity')`
+
+// taskOrder - сортировка списка задач и условие "после курсора" для keyset-пагинации.
+// Последний ключ сортировки - id, поэтому порядок однозначен.
+type taskOrder struct {
+	orderBy string
+	// after возвращает условие и значения курсора для подстановки в него.
+	after func(c *models.TaskCursor) (cond string, args []interface{})
+}
+
+var taskOrders = map[string]taskOrder{
+	models.SortCreatedAt: {
+		orderBy: "t.created_at DESC, t.id DESC",
+		after: func(c *models.TaskCursor) (string, []interface{}) {
+			return "(t.created_at, t.id) < ($%d, $%d)", []interface{}{c.CreatedAt, c.ID}
+		},
+	},
+	models.SortDueAt: {
+		orderBy: dueAtKey + " ASC, t.id ASC",
+		after: func(c *models.TaskCursor) (string, []interface{}) {
+			return "(" + dueAtKey + ", t.id) > ($%d, $%d)", []interface{}{cursorDueAt(c), c.ID}
+		},
+	},
+	models.SortPriority: {
+		orderBy: priorityRank + " ASC, " + dueAtKey + " ASC, t.id ASC",
+		after: func(c *models.TaskCursor) (string, []interface{}) {
+			return "(" + priorityRank + ", " + dueAtKey + ", t.id) > ($%d, $%d, $%d)",
+				[]interface{}{models.PriorityRank(c.Priority), cursorDueAt(c), c.ID}
+		},
+	},
+}
+
+// cursorDueAt - значение dueAtKey для задачи курсора.
+func cursorDueAt(c *models.TaskCursor) interface{} {
+	if c.DueAt == nil {
+		return "infinity"
+	}
+	return *c.DueAt
+}
+
+func (r *repository) GetTasks(ctx context.Context, filter models.TaskFilter) ([]models.TaskListItem, error) {
+	query := `
+        SELECT
+            t.*,
+            o.action_type,
+            o.unit_id,
+            o.unit_type,
+            COALESCE(p.name, c.name, '') AS unit_name,
+            s.id AS structure_id,
+            lp.id AS land_parcel_id,
+            lp.region_id AS region_id,
+            s.name AS structure_name,
+            lp.name AS land_parcel_name,
+            rg.name AS region_name,
+            concat_ws(' / ', rg.name, lp.name, s.name, COALESCE(p.name, c.name)) AS location_path
+        FROM
+            tasks t
+        JOIN
+            operation_log o ON o.id = t.operation_id
+        LEFT JOIN
+            plots p ON o.unit_type = 'plot' AND p.id = o.unit_id
+        LEFT JOIN
+            coops c ON o.unit_type = 'coop' AND c.id = o.unit_id
+        LEFT JOIN
+            structures s ON s.id = COALESCE(p.structure_id, c.structure_id)
+        LEFT JOIN
+            land_parcels lp ON lp.id = s.land_parcel_id
+        LEFT JOIN
+            regions rg ON rg.id = lp.region_id`
+
+	var conditions []string
+	var args []interface{}
+	addCondition := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(cond, len(args)))
+	}
+
+	if filter.Status != "" {
+		addCondition("t.status = $%d", filter.Status)
+	} else if filter.Open {
+		args = append(args, models.StatusNew, models.StatusInProgress)
+		conditions = append(conditions, fmt.Sprintf("t.status IN ($%d, $%d)", len(args)-1, len(args)))
+	}
+	if filter.Priority != "" {
+		addCondition("t.priority = $%d", filter.Priority)
+	}
+	if filter.Overdue != nil {
+		if *filter.Overdue {
+			conditions = append(conditions, "t.overdue_at IS NOT NULL")
+		} else {
+			conditions = append(conditions, "t.overdue_at IS NULL")
+		}
+	}
+	if filter.DueBefore != nil {
+		addCondition("t.due_at < $%d", *filter.DueBefore)
+	}
+	if filter.AssigneeID != nil {
+		addCondition("t.assignee_id = $%d", *filter.AssigneeID)
+	}
+	if filter.RegionID != nil {
+		addCondition("lp.region_id = $%d", *filter.RegionID)
+	}
+	if filter.StructureID != nil {
+		addCondition("s.id = $%d", *filter.StructureID)
+	}
+	if filter.ActionType != "" {
+		addCondition("o.action_type = $%d", filter.ActionType)
+	}
+	if filter.OperationID != nil {
+		addCondition("t.operation_id = $%d", *filter.OperationID)
+	}
+	if filter.From != nil {
+		addCondition("t.created_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		addCondition("t.created_at <= $%d", *filter.To)
+	}
+
+	order, ok := taskOrders[filter.Sort]
+	if !ok {
+		order = taskOrders[models.SortCreatedAt]
+	}
+	if filter.Cursor != nil {
+		cond, cursorArgs := order.after(filter.Cursor)
+		placeholders := make([]interface{}, len(cursorArgs))
+		for i := range cursorArgs {
+			placeholders[i] = len(args) + i + 1
+		}
+		args = append(args, cursorArgs...)
+		conditions = append(conditions, fmt.Sprintf(cond, placeholders...))
+	}
+
+	if len(conditions) > 0 {
+		query += "\n        WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += "\n        ORDER BY " + order.orderBy
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf("\n        LIMIT $%d", len(args))
+	}
+
+	tasks := []models.TaskListItem{}
+	if err := r.db.SelectContext(ctx, &tasks, query, args...); err != nil {
+		return nil, fmt.Errorf("не удалось получить список задач: %w", err)
+	}
+	return tasks, nil
 }
 
-func (r *repository) UpdateTask(ctx context.Context, task *models.Task) error {
+func (r *repository) UpdateTask(ctx context.Context, task *models.Task, from models.TaskStatus) error {
 	task.UpdatedAt = time.Now()
-	query := `UPDATE tasks SET status = :status, assignee_id = :assignee_id, updated_at = :updated_at WHERE id = :id`
-	_, err := r.db.NamedExecContext(ctx, query, task)
+	query := `UPDATE tasks SET status = $1, assignee_id = $2, claimed_at = $3, completion_note = $4, failure_reason = $5, failure_comment = $6, updated_at = $7
+	          WHERE id = $8 AND status = $9`
+	result, err := r.db.ExecContext(ctx, query, task.Status, task.AssigneeID, task.ClaimedAt, task.CompletionNote, task.FailureReason, task.FailureComment, task.UpdatedAt, task.ID, from)
+	if err != nil {
+		return fmt.Errorf("не удалось обновить задачу: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("не удалось проверить результат обновления задачи: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: задача %s не в статусе '%s'", ErrStatusChanged, task.ID, from)
+	}
+	return nil
+}
+func (r *repository) CancelTasksByOperationID(ctx context.Context, operationID uuid.UUID, cancelledBy *uuid.UUID) error {
+	query := `WITH cancelled AS (
+	              UPDATE tasks SET status = $1, updated_at = $2 WHERE operation_id = $3 AND status = $4
+	              RETURNING id
+	          )
+	          INSERT INTO task_events (task_id, actor_id, from_status, to_status, comment, created_at)
+	          SELECT id, $5, $4, $1, $6, $2 FROM cancelled`
+	_, err := r.db.ExecContext(ctx, query, models.StatusCancelled, time.Now(), operationID, models.StatusNew, cancelledBy, "операция отменена арендатором")
+	return err
+}
+func (r *repository) GetTaskForUpdate(ctx context.Context, taskID uuid.UUID) (*models.Task, error) {
+	var task models.Task
+	query := `SELECT * FROM tasks WHERE id = $1 FOR UPDATE`
+	err := r.db.GetContext(ctx, &task, query, taskID)
+	return &task, err
+}
+
+func (r *repository) FetchExpiredClaims(ctx context.Context, claimedBefore time.Time, limit int) ([]models.Task, error) {
+	tasks := []models.Task{}
+	query := `SELECT * FROM tasks
+	          WHERE status = $1 AND claimed_at < $2
+	          ORDER BY claimed_at
+	          LIMIT $3
+	          FOR UPDATE SKIP LOCKED`
+	err := r.db.SelectContext(ctx, &tasks, query, models.StatusInProgress, claimedBefore, limit)
+	return tasks, err
+}
+
+func (r *repository) CreateAssignment(ctx context.Context, assignment *models.TaskAssignment) error {
+	query := `INSERT INTO task_assignments (id, task_id, assignee_id, previous_assignee_id, changed_by, reason, created_at)
+	          VALUES (:id, :task_id, :assignee_id, :previous_assignee_id, :changed_by, :reason, :created_at)`
+	_, err := r.db.NamedExecContext(ctx, query, assignment)
+	return err
+}
+
+func (r *repository) GetAssignmentsByTaskID(ctx context.Context, taskID uuid.UUID) ([]models.TaskAssignment, error) {
+	assignments := []models.TaskAssignment{}
+	query := `SELECT * FROM task_assignments WHERE task_id = $1 ORDER BY created_at, id`
+	err := r.db.SelectContext(ctx, &assignments, query, taskID)
+	return assignments, err
+}
+
+func (r *repository) CreateTransition(ctx context.Context, transition *models.TaskTransition) error {
+	query := `INSERT INTO task_events (id, task_id, actor_id, from_status, to_status, comment, created_at)
+	          VALUES (:id, :task_id, :actor_id, :from_status, :to_status, :comment, :created_at)`
+	_, err := r.db.NamedExecContext(ctx, query, transition)
 	return err
-}
\ No newline at end of file
+}
+
+func (r *repository) GetTransitionsByTaskID(ctx context.Context, taskID uuid.UUID) ([]models.TaskTransition, error) {
+	transitions := []models.TaskTransition{}
+	query := `SELECT * FROM task_events WHERE task_id = $1 ORDER BY created_at, id`
+	err := r.db.SelectContext(ctx, &transitions, query, taskID)
+	return transitions, err
+}
+
+func (r *repository) CreateAttachment(ctx context.Context, attachment *models.TaskAttachment) error {
+	query := `INSERT INTO task_attachments (id, task_id, storage_key, file_name, content_type, size_bytes, uploaded_by, created_at)
+	          VALUES (:id, :task_id, :storage_key, :file_name, :content_type, :size_bytes, :uploaded_by, :created_at)`
+	_, err := r.db.NamedExecContext(ctx, query, attachment)
+	return err
+}
+
+func (r *repository) FetchOverdue(ctx context.Context, now time.Time, limit int) ([]models.Task, error) {
+	tasks := []models.Task{}
+	query := `SELECT * FROM tasks
+	          WHERE status IN ($1, $2) AND due_at < $3 AND overdue_at IS NULL
+	          ORDER BY due_at
+	          LIMIT $4
+	          FOR UPDATE SKIP LOCKED`
+	err := r.db.SelectContext(ctx, &tasks, query, models.StatusNew, models.StatusInProgress, now, limit)
+	return tasks, err
+}
+
+func (r *repository) MarkOverdue(ctx context.Context, taskID uuid.UUID, at time.Time) error {
+	query := `UPDATE tasks SET overdue_at = $1 WHERE id = $2`
+	_, err := r.db.ExecContext(ctx, query, at, taskID)
+	return err
+}
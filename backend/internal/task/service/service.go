@@ -1,109 +1,300 @@
 package service
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
-	catalogService "github.com/rendley/vegshare/backend/internal/catalog/service"
+	"github.com/rendley/vegshare/backend/internal/operations/actionhandlers"
+	operations_models "github.com/rendley/vegshare/backend/internal/operations/models"
 	operations_repository "github.com/rendley/vegshare/backend/internal/operations/repository"
-	plotService "github.com/rendley/vegshare/backend/internal/plot/service"
+	outbox_models "github.com/rendley/vegshare/backend/internal/outbox/models"
+	outbox_repository "github.com/rendley/vegshare/backend/internal/outbox/repository"
 	"github.com/rendley/vegshare/backend/internal/task/models"
 	"github.com/rendley/vegshare/backend/internal/task/repository"
-	unitcontent_service "github.com/rendley/vegshare/backend/internal/unitcontent/service"
+	user_repository "github.com/rendley/vegshare/backend/internal/user/repository"
+	"github.com/rendley/vegshare/backend/pkg/config"
+	"github.com/rendley/vegshare/backend/pkg/database"
+	"github.com/rendley/vegshare/backend/pkg/storage"
 	"time"
 )
 
+// ErrInvalidAssignee возвращается, если назначаемый исполнитель не найден или не является сотрудником.
+var ErrInvalidAssignee = errors.New("исполнитель должен быть сотрудником с ролью admin")
+
+// ErrTaskClosed возвращается при попытке назначить исполнителя завершенной или отмененной задаче.
+var ErrTaskClosed = errors.New("задача уже закрыта")
+
+// ErrAssignedToAnother возвращается, если задачу пытается взять или провалить не тот сотрудник, которому она назначена.
+var ErrAssignedToAnother = errors.New("задача назначена другому исполнителю")
+
+// ErrInvalidTransition возвращается, если конечный автомат задачи не разрешает переход из текущего статуса.
+var ErrInvalidTransition = errors.New("недопустимый переход статуса задачи")
+
+// ErrInvalidTaskFilter возвращается при неизвестном приоритете или сортировке в фильтре задач.
+var ErrInvalidTaskFilter = errors.New("некорректный фильтр задач")
+
+// ErrInvalidEvidence возвращается, если заметка или фото выполнения не проходят проверку.
+var ErrInvalidEvidence = errors.New("некорректное подтверждение выполнения")
+
+// ErrInvalidFailureReason возвращается, если причина невыполнения задачи не указана или неизвестна.
+var ErrInvalidFailureReason = errors.New("укажите причину невыполнения: weather, out_of_stock или plot_issue")
+
+// maxFailureCommentRune - максимальная длина комментария к причине невыполнения.
+const maxFailureCommentRune = 1000
+
+// Ограничения на подтверждение выполнения задачи.
+const (
+	maxCompletionPhotos   = 10
+	maxCompletionNoteRune = 2000
+)
+
+// photoExtensions - форматы фото, которые принимаются как подтверждение, и расширения файлов для них.
+var photoExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+}
+
+// Размер страницы списка задач.
+const (
+	defaultTaskLimit = 50
+	maxTaskLimit     = 200
+)
+
+// staffRole - роль пользователей, которые выполняют задачи.
+const staffRole = "admin"
+
 // Service определяет интерфейс для бизнес-логики управления задачами.
 type Service interface {
-	CreateTask(ctx context.Context, operationID uuid.UUID, title, description string) (*models.Task, error)
-	GetAllTasks(ctx context.Context) ([]models.Task, error)
+	// CreateTask идемпотентна: для операции, у которой уже есть задача, возвращается существующая.
+	CreateTask(ctx context.Context, operationID uuid.UUID, spec actionhandlers.TaskSpec) (*models.Task, error)
+	// GetTasks возвращает страницу задач по фильтру; по умолчанию сначала новые.
+	GetTasks(ctx context.Context, filter models.TaskFilter) (*models.TaskPage, error)
+	// GetMyTasks - очередь исполнителя: его задачи, по умолчанию только незакрытые и сначала срочные.
+	GetMyTasks(ctx context.Context, userID uuid.UUID, filter models.TaskFilter) (*models.TaskPage, error)
+	// GetWorkList - лист работ исполнителя на день day: незакрытые задачи без срока или со сроком
+	// до конца дня, сгруппированные по участкам и строениям в порядке обхода.
+	GetWorkList(ctx context.Context, assigneeID uuid.UUID, day time.Time) (*models.WorkList, error)
 	AcceptTask(ctx context.Context, taskID, userID uuid.UUID) (*models.Task, error)
-	CompleteTask(ctx context.Context, taskID, userID uuid.UUID) (*models.Task, error)
-	FailTask(ctx context.Context, taskID, userID uuid.UUID) (*models.Task, error)
+	// CompleteTask завершает задачу; фото из evidence сохраняются в хранилище, а заметка и фото
+	// становятся видны арендатору в истории операций.
+	CompleteTask(ctx context.Context, taskID, userID uuid.UUID, details CompletionDetails, evidence Evidence) (*models.Task, error)
+	// FailTask отмечает задачу и ее операцию проваленными с причиной failure и в той же транзакции
+	// ставит в outbox событие task.failed, по которому арендатору уходит уведомление.
+	FailTask(ctx context.Context, taskID, userID uuid.UUID, failure Failure) (*models.Task, error)
+	// CancelTasksForOperation отменяет задачи отмененной операции от имени арендатора cancelledBy.
+	CancelTasksForOperation(ctx context.Context, operationID, cancelledBy uuid.UUID) error
+	// AssignTask назначает или переназначает задачу сотруднику assigneeID от имени руководителя changedBy.
+	// Задача в работе остается в работе у нового исполнителя, новая ждет, пока он ее возьмет.
+	AssignTask(ctx context.Context, taskID, assigneeID, changedBy uuid.UUID) (*models.Task, error)
+	// UnassignTask снимает исполнителя и возвращает задачу в пул (статус new).
+	UnassignTask(ctx context.Context, taskID, changedBy uuid.UUID) (*models.Task, error)
+	GetAssignmentHistory(ctx context.Context, taskID uuid.UUID) ([]models.TaskAssignment, error)
+	// GetTimeline возвращает историю статусов задачи: кто, когда и из какого статуса в какой ее перевел.
+	GetTimeline(ctx context.Context, taskID uuid.UUID) ([]models.TaskTransition, error)
+	// ReleaseExpiredClaims возвращает в пул не больше limit задач, которые в работе дольше timeout.
+	ReleaseExpiredClaims(ctx context.Context, timeout time.Duration, limit int) (int, error)
+	// FlagOverdueTasks отмечает просроченными не больше limit открытых задач с истекшим сроком
+	// и в той же транзакции ставит для каждой событие task.overdue в outbox.
+	FlagOverdueTasks(ctx context.Context, limit int) (int, error)
 }
 
 // service - реализация Service.
 type service struct {
-	db                 *sqlx.DB
-	taskRepo           repository.Repository
-	operationRepo      operations_repository.Repository
-	unitContentService unitcontent_service.Service
-	plotService        plotService.Service
-	catalogService     catalogService.Service
+	db            *sqlx.DB
+	taskRepo      repository.Repository
+	operationRepo operations_repository.Repository
+	userRepo      user_repository.UserRepository
+	handlers      *actionhandlers.Registry
+	storage       storage.Storage
+	cfg           *config.Config
+
+	// Фабрики репозиториев поверх транзакции; в тестах подменяются моками.
+	newTaskRepo func(db database.DBTX) repository.Repository
+	newOpsRepo  func(db database.DBTX) operations_repository.Repository
+	newOutbox   func(db database.DBTX) outbox_repository.Repository
 }
 
 // NewService - конструктор для сервиса задач.
-func NewService(db *sqlx.DB, taskRepo repository.Repository, opRepo operations_repository.Repository, ucs unitcontent_service.Service, ps plotService.Service, cs catalogService.Service) Service {
+func NewService(db *sqlx.DB, taskRepo repository.Repository, opRepo operations_repository.Repository, userRepo user_repository.UserRepository, handlers *actionhandlers.Registry, store storage.Storage, cfg *config.Config) Service {
 	return &service{
-		db:                 db,
-		taskRepo:           taskRepo,
-		operationRepo:      opRepo,
-		unitContentService: ucs,
-		plotService:        ps,
-		catalogService:     cs,
+		db:            db,
+		taskRepo:      taskRepo,
+		operationRepo: opRepo,
+		userRepo:      userRepo,
+		handlers:      handlers,
+		storage:       store,
+		cfg:           cfg,
+
+		newTaskRepo: repository.NewRepository,
+		newOpsRepo:  operations_repository.NewRepository,
+		newOutbox:   outbox_repository.NewRepository,
 	}
 }
 
-func (s *service) CreateTask(ctx context.Context, operationID uuid.UUID, title, description string) (*models.Task, error) {
+func (s *service) CreateTask(ctx context.Context, operationID uuid.UUID, spec actionhandlers.TaskSpec) (*models.Task, error) {
 	now := time.Now()
-	descPtr := &description
-	if description == "" {
+	descPtr := &spec.Description
+	if spec.Description == "" {
 		descPtr = nil
 	}
+	var estimatedMinutes *int
+	if spec.EstimatedDuration > 0 {
+		minutes := int(spec.EstimatedDuration.Round(time.Minute) / time.Minute)
+		estimatedMinutes = &minutes
+	}
+	priority := spec.Priority
+	if priority == "" {
+		priority = models.PriorityNormal
+	}
+	var dueAt *time.Time
+	if !spec.DueAt.IsZero() {
+		dueAt = &spec.DueAt
+	}
 
 	task := &models.Task{
-		ID:          uuid.New(),
-		OperationID: operationID,
-		Status:      models.StatusNew,
-		Title:       title,
-		Description: descPtr,
-		CreatedAt:   now,
-		UpdatedAt:   now,
+		ID:               uuid.New(),
+		OperationID:      operationID,
+		Status:           models.StatusNew,
+		Title:            spec.Title,
+		Description:      descPtr,
+		RequiredSkills:   append([]string{}, spec.Skills...),
+		EstimatedMinutes: estimatedMinutes,
+		Priority:         priority,
+		DueAt:            dueAt,
+		CreatedAt:        now,
+		UpdatedAt:        now,
 	}
 
-	if err := s.taskRepo.CreateTask(ctx, task); err != nil {
+	created, err := s.taskRepo.CreateTask(ctx, task)
+	if err != nil {
 		return nil, fmt.Errorf("не удалось создать задачу в репозитории: %w", err)
 	}
+	if !created {
+		existing, err := s.taskRepo.GetTaskByOperationID(ctx, operationID)
+		if err != nil {
+			return nil, fmt.Errorf("не удалось получить существующую задачу операции %s: %w", operationID, err)
+		}
+		return existing, nil
+	}
 
 	return task, nil
 }
 
-func (s *service) GetAllTasks(ctx context.Context) ([]models.Task, error) {
-	return s.taskRepo.GetAllTasks(ctx)
+// CancelTasksForOperation отменяет задачи отмененной операции, которые еще не взяты в работу.
+func (s *service) CancelTasksForOperation(ctx context.Context, operationID, cancelledBy uuid.UUID) error {
+	if err := s.taskRepo.CancelTasksByOperationID(ctx, operationID, &cancelledBy); err != nil {
+		return fmt.Errorf("не удалось отменить задачи операции %s: %w", operationID, err)
+	}
+	return nil
 }
 
-func (s *service) AcceptTask(ctx context.Context, taskID, userID uuid.UUID) (*models.Task, error) {
-	task, err := s.taskRepo.GetTaskByID(ctx, taskID)
-	if err != nil {
-		return nil, fmt.Errorf("задача с ID %s не найдена: %w", taskID, err)
+func (s *service) GetTasks(ctx context.Context, filter models.TaskFilter) (*models.TaskPage, error) {
+	return s.listTasks(ctx, filter)
+}
+
+func (s *service) GetMyTasks(ctx context.Context, userID uuid.UUID, filter models.TaskFilter) (*models.TaskPage, error) {
+	filter.AssigneeID = &userID
+	if filter.Status == "" {
+		filter.Open = true
 	}
+	if filter.Sort == "" {
+		filter.Sort = models.SortPriority
+	}
+	return s.listTasks(ctx, filter)
+}
 
-	if task.Status != models.StatusNew {
-		return nil, fmt.Errorf("задачу можно взять в работу только из статуса 'new', текущий статус: '%s'", task.Status)
+// listTasks читает страницу задач. Запрашивается на одну запись больше лимита,
+// чтобы понять, есть ли следующая страница.
+func (s *service) listTasks(ctx context.Context, filter models.TaskFilter) (*models.TaskPage, error) {
+	switch filter.Priority {
+	case "", models.PriorityLow, models.PriorityNormal, models.PriorityHigh, models.PriorityUrgent:
+	default:
+		return nil, fmt.Errorf("%w: неизвестный приоритет '%s'", ErrInvalidTaskFilter, filter.Priority)
+	}
+	switch filter.Sort {
+	case "", models.SortCreatedAt, models.SortDueAt, models.SortPriority:
+	default:
+		return nil, fmt.Errorf("%w: неизвестная сортировка '%s'", ErrInvalidTaskFilter, filter.Sort)
+	}
+	if filter.Sort == "" {
+		filter.Sort = models.SortCreatedAt
+	}
+	if filter.Cursor != nil && filter.Cursor.Sort != filter.Sort {
+		return nil, fmt.Errorf("%w: курсор получен для другой сортировки", ErrInvalidTaskFilter)
 	}
 
-	task.Status = models.StatusInProgress
-	task.AssigneeID = &userID
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultTaskLimit
+	}
+	if limit > maxTaskLimit {
+		limit = maxTaskLimit
+	}
+	filter.Limit = limit + 1
 
+	items, err := s.taskRepo.GetTasks(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.TaskPage{Items: items}
+	if len(items) > limit {
+		page.Items = items[:limit]
+		page.NextCursor = models.NewTaskCursor(filter.Sort, page.Items[limit-1].Task).Encode()
+	}
+	return page, nil
+}
+
+func (s *service) AcceptTask(ctx context.Context, taskID, userID uuid.UUID) (*models.Task, error) {
 	tx, err := s.db.BeginTxx(ctx, nil)
 	if err != nil {
 		return nil, fmt.Errorf("не удалось начать транзакцию: %w", err)
 	}
 	defer tx.Rollback() // Откат, если что-то пойдет не так
 
-	taskRepoTx := repository.NewRepository(tx)
-	opRepoTx := operations_repository.NewRepository(tx)
+	taskRepoTx := s.newTaskRepo(tx)
+	opRepoTx := s.newOpsRepo(tx)
+
+	// Блокировка строки не дает двум сотрудникам взять задачу одновременно.
+	task, err := taskRepoTx.GetTaskForUpdate(ctx, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("задача с ID %s не найдена: %w", taskID, err)
+	}
+
+	if err := checkTransition(task, models.StatusInProgress); err != nil {
+		return nil, err
+	}
+	if task.AssigneeID != nil && *task.AssigneeID != userID {
+		return nil, ErrAssignedToAnother
+	}
 
-	if err := taskRepoTx.UpdateTask(ctx, task); err != nil {
-		return nil, fmt.Errorf("не удалось обновить задачу: %w", err)
+	previous := task.AssigneeID
+	now := time.Now()
+	task.AssigneeID = &userID
+	task.ClaimedAt = &now
+
+	if err := transition(ctx, taskRepoTx, task, models.StatusInProgress, &userID, ""); err != nil {
+		return nil, err
 	}
 
-	if err := opRepoTx.UpdateOperationLogStatus(ctx, task.OperationID, "in_progress"); err != nil {
+	if err := opRepoTx.UpdateOperationLogStatus(ctx, task.OperationID, operations_models.StatusInProgress); err != nil {
 		return nil, fmt.Errorf("не удалось обновить статус операции: %w", err)
 	}
 
+	if err := recordAssignment(ctx, taskRepoTx, task, previous, &userID, models.AssignmentAccepted); err != nil {
+		return nil, err
+	}
+
 	if err := tx.Commit(); err != nil {
 		return nil, fmt.Errorf("не удалось закоммитить транзакцию: %w", err)
 	}
@@ -111,31 +302,194 @@ func (s *service) AcceptTask(ctx context.Context, taskID, userID uuid.UUID) (*mo
 	return task, nil
 }
 
-// PlantActionParams - структура для парсинга параметров операции 'plant'.
-type PlantActionParams struct {
-	ItemID   uuid.UUID `json:"item_id"`
-	Quantity int       `json:"quantity"`
+// CompletionDetails - данные, которые исполнитель сообщает при завершении задачи.
+type CompletionDetails = actionhandlers.CompletionDetails
+
+// Evidence - подтверждение выполнения задачи: заметка и фото исполнителя.
+type Evidence struct {
+	Note   string
+	Photos []Photo
+}
+
+// Photo - загружаемое фото. Формат определяется по содержимому, а не по имени файла.
+type Photo struct {
+	FileName string
+	Content  io.Reader
+}
+
+// countingReader считает прочитанные байты, чтобы узнать размер сохраненного файла.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
 }
 
-func (s *service) CompleteTask(ctx context.Context, taskID, userID uuid.UUID) (*models.Task, error) {
-	task, err := s.taskRepo.GetTaskByID(ctx, taskID)
+func (s *service) CompleteTask(ctx context.Context, taskID, userID uuid.UUID, details CompletionDetails, evidence Evidence) (*models.Task, error) {
+	note := strings.TrimSpace(evidence.Note)
+	if utf8.RuneCountInString(note) > maxCompletionNoteRune {
+		return nil, fmt.Errorf("%w: заметка длиннее %d символов", ErrInvalidEvidence, maxCompletionNoteRune)
+	}
+	if len(evidence.Photos) > maxCompletionPhotos {
+		return nil, fmt.Errorf("%w: не больше %d фото", ErrInvalidEvidence, maxCompletionPhotos)
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось начать транзакцию: %w", err)
+	}
+	defer tx.Rollback()
+
+	taskRepoTx := s.newTaskRepo(tx)
+	opRepoTx := s.newOpsRepo(tx)
+
+	// Блокировка строки не дает одновременно переназначить задачу, вернуть ее в пул или закрыть ее еще раз.
+	task, err := taskRepoTx.GetTaskForUpdate(ctx, taskID)
 	if err != nil {
 		return nil, fmt.Errorf("задача с ID %s не найдена: %w", taskID, err)
 	}
 
-	if task.Status != models.StatusInProgress {
-		return nil, fmt.Errorf("завершить задачу можно только из статуса 'in_progress', текущий статус: '%s'", task.Status)
+	if err := checkTransition(task, models.StatusCompleted); err != nil {
+		return nil, err
 	}
 	if task.AssigneeID == nil || *task.AssigneeID != userID {
 		return nil, fmt.Errorf("завершить задачу может только назначенный исполнитель")
 	}
 
+	// Файлы сохраняются до записей о них; если транзакция не закоммитится, они удаляются.
+	attachments, err := s.storePhotos(ctx, task.ID, userID, evidence.Photos)
+	if err != nil {
+		return nil, err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			s.deletePhotos(ctx, attachments)
+		}
+	}()
+
 	operation, err := s.operationRepo.GetOperationLogByID(ctx, task.OperationID)
 	if err != nil {
 		return nil, fmt.Errorf("не удалось найти связанную операцию %s: %w", task.OperationID, err)
 	}
 
-	task.Status = models.StatusCompleted
+	if note != "" {
+		task.CompletionNote = &note
+	}
+
+	if err := transition(ctx, taskRepoTx, task, models.StatusCompleted, &userID, note); err != nil {
+		return nil, err
+	}
+
+	if err := opRepoTx.UpdateOperationLogStatus(ctx, task.OperationID, operations_models.StatusCompleted); err != nil {
+		return nil, err
+	}
+
+	for i := range attachments {
+		if err := taskRepoTx.CreateAttachment(ctx, &attachments[i]); err != nil {
+			return nil, fmt.Errorf("не удалось сохранить фото выполнения: %w", err)
+		}
+	}
+
+	// Последствия выполнения (посадка, сбор урожая и т.д.) определяет обработчик типа действия.
+	if err := s.handlers.Complete(ctx, tx, operation, details); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	committed = true
+
+	return task, nil
+}
+
+// storePhotos сохраняет фото в хранилище под ключами tasks/<taskID>/<attachmentID>.<ext>.
+// Если какое-то фото не удалось сохранить, уже сохраненные удаляются.
+func (s *service) storePhotos(ctx context.Context, taskID, userID uuid.UUID, photos []Photo) ([]models.TaskAttachment, error) {
+	attachments := make([]models.TaskAttachment, 0, len(photos))
+	for _, photo := range photos {
+		attachment, err := s.storePhoto(ctx, taskID, userID, photo)
+		if err != nil {
+			s.deletePhotos(ctx, attachments)
+			return nil, err
+		}
+		attachments = append(attachments, *attachment)
+	}
+	return attachments, nil
+}
+
+func (s *service) storePhoto(ctx context.Context, taskID, userID uuid.UUID, photo Photo) (*models.TaskAttachment, error) {
+	// Формат определяется по первым 512 байтам, как в http.DetectContentType.
+	head := make([]byte, 512)
+	n, err := io.ReadFull(photo.Content, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, fmt.Errorf("не удалось прочитать фото '%s': %w", photo.FileName, err)
+	}
+	contentType := http.DetectContentType(head[:n])
+	ext, ok := photoExtensions[contentType]
+	if n == 0 || !ok {
+		return nil, fmt.Errorf("%w: файл '%s' не является фото в формате JPEG, PNG или WebP", ErrInvalidEvidence, photo.FileName)
+	}
+
+	id := uuid.New()
+	key := fmt.Sprintf("tasks/%s/%s%s", taskID, id, ext)
+	content := &countingReader{r: io.MultiReader(bytes.NewReader(head[:n]), photo.Content)}
+	if err := s.storage.Put(ctx, key, content); err != nil {
+		return nil, fmt.Errorf("не удалось сохранить фото '%s': %w", photo.FileName, err)
+	}
+
+	fileName := photo.FileName
+	if fileName == "" {
+		fileName = id.String() + ext
+	}
+	return &models.TaskAttachment{
+		ID:          id,
+		TaskID:      taskID,
+		StorageKey:  key,
+		FileName:    fileName,
+		ContentType: contentType,
+		SizeBytes:   content.n,
+		UploadedBy:  &userID,
+		CreatedAt:   time.Now(),
+	}, nil
+}
+
+// deletePhotos удаляет файлы фото, записи о которых не попали в БД. Ошибки не критичны:
+// в худшем случае в хранилище останется файл без ссылок.
+func (s *service) deletePhotos(ctx context.Context, attachments []models.TaskAttachment) {
+	for _, a := range attachments {
+		_ = s.storage.Delete(ctx, a.StorageKey)
+	}
+}
+
+// Failure - причина, по которой исполнитель не смог выполнить задачу.
+type Failure struct {
+	// Reason - одна из констант models.Failure*.
+	Reason  string
+	Comment string
+}
+
+func (f Failure) validate() error {
+	switch f.Reason {
+	case models.FailureWeather, models.FailureOutOfStock, models.FailurePlotIssue:
+	default:
+		return ErrInvalidFailureReason
+	}
+	if utf8.RuneCountInString(f.Comment) > maxFailureCommentRune {
+		return fmt.Errorf("%w: комментарий длиннее %d символов", ErrInvalidFailureReason, maxFailureCommentRune)
+	}
+	return nil
+}
+
+func (s *service) FailTask(ctx context.Context, taskID, userID uuid.UUID, failure Failure) (*models.Task, error) {
+	if err := failure.validate(); err != nil {
+		return nil, err
+	}
 
 	tx, err := s.db.BeginTxx(ctx, nil)
 	if err != nil {
@@ -143,25 +497,64 @@ func (s *service) CompleteTask(ctx context.Context, taskID, userID uuid.UUID) (*
 	}
 	defer tx.Rollback()
 
-	taskRepoTx := repository.NewRepository(tx)
-	opRepoTx := operations_repository.NewRepository(tx)
+	taskRepoTx := s.newTaskRepo(tx)
+	opRepoTx := s.newOpsRepo(tx)
+
+	// Блокировка строки не дает одновременно переназначить задачу, вернуть ее в пул или закрыть ее еще раз.
+	task, err := taskRepoTx.GetTaskForUpdate(ctx, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("задача с ID %s не найдена: %w", taskID, err)
+	}
 
-	if err := taskRepoTx.UpdateTask(ctx, task); err != nil {
+	// Назначенную задачу, даже еще не взятую в работу, проваливает только ее исполнитель;
+	// задачу из пула может провалить любой сотрудник.
+	if task.AssigneeID != nil && *task.AssigneeID != userID {
+		return nil, ErrAssignedToAnother
+	}
+	if err := checkTransition(task, models.StatusFailed); err != nil {
 		return nil, err
 	}
 
-	if err := opRepoTx.UpdateOperationLogStatus(ctx, task.OperationID, "completed"); err != nil {
+	task.FailureReason = &failure.Reason
+	task.FailureComment = nil
+	if failure.Comment != "" {
+		task.FailureComment = &failure.Comment
+	}
+
+	comment := failure.Reason
+	if failure.Comment != "" {
+		comment += ": " + failure.Comment
+	}
+	if err := transition(ctx, taskRepoTx, task, models.StatusFailed, &userID, comment); err != nil {
 		return nil, err
 	}
 
-	if operation.ActionType == "plant" {
-		var params PlantActionParams
-		if err := json.Unmarshal(operation.Parameters, &params); err != nil {
-			return nil, fmt.Errorf("ошибка парсинга параметров для операции plant: %w", err)
-		}
-		if err := s.unitContentService.CreateOrUpdateContent(ctx, operation.UnitID, params.ItemID, operation.UnitType, params.Quantity); err != nil {
-			return nil, fmt.Errorf("не удалось обновить содержимое юнита: %w", err)
-		}
+	if err := opRepoTx.UpdateOperationLogStatus(ctx, task.OperationID, operations_models.StatusFailed); err != nil {
+		return nil, err
+	}
+
+	op, err := opRepoTx.GetOperationLogByID(ctx, task.OperationID)
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(models.TaskEvent{
+		Type:           models.EventTaskFailed,
+		TaskID:         task.ID,
+		OperationID:    task.OperationID,
+		AssigneeID:     task.AssigneeID,
+		Priority:       task.Priority,
+		DueAt:          task.DueAt,
+		UserID:         &op.UserID,
+		ActionType:     op.ActionType,
+		FailureReason:  failure.Reason,
+		FailureComment: failure.Comment,
+		OccurredAt:     time.Now(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal task event: %w", err)
+	}
+	if err := s.newOutbox(tx).Create(ctx, outbox_models.NewMessage(s.cfg.RabbitMQ.Queues["task_notifications"], string(body))); err != nil {
+		return nil, err
 	}
 
 	if err := tx.Commit(); err != nil {
@@ -171,38 +564,258 @@ func (s *service) CompleteTask(ctx context.Context, taskID, userID uuid.UUID) (*
 	return task, nil
 }
 
-func (s *service) FailTask(ctx context.Context, taskID, userID uuid.UUID) (*models.Task, error) {
-	task, err := s.taskRepo.GetTaskByID(ctx, taskID)
+func (s *service) AssignTask(ctx context.Context, taskID, assigneeID, changedBy uuid.UUID) (*models.Task, error) {
+	assignee, err := s.userRepo.GetUserByID(ctx, assigneeID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: пользователь %s не найден", ErrInvalidAssignee, assigneeID)
+	}
+	if assignee.Role != staffRole {
+		return nil, ErrInvalidAssignee
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось начать транзакцию: %w", err)
+	}
+	defer tx.Rollback()
+
+	taskRepoTx := s.newTaskRepo(tx)
+	task, err := taskRepoTx.GetTaskForUpdate(ctx, taskID)
 	if err != nil {
 		return nil, fmt.Errorf("задача с ID %s не найдена: %w", taskID, err)
 	}
+	if task.Status.IsClosed() {
+		return nil, fmt.Errorf("%w: статус '%s'", ErrTaskClosed, task.Status)
+	}
+	if task.AssigneeID != nil && *task.AssigneeID == assigneeID {
+		return task, nil
+	}
 
-	if task.AssigneeID != nil && *task.AssigneeID != userID && task.Status == models.StatusInProgress {
-		return nil, fmt.Errorf("отменить задачу может только назначенный исполнитель")
+	previous := task.AssigneeID
+	task.AssigneeID = &assigneeID
+	if task.Status == models.StatusInProgress {
+		// Таймаут нового исполнителя отсчитывается с момента переназначения.
+		now := time.Now()
+		task.ClaimedAt = &now
 	}
 
-	task.Status = models.StatusFailed
+	if err := updateTask(ctx, taskRepoTx, task, task.Status); err != nil {
+		return nil, err
+	}
+	if err := recordAssignment(ctx, taskRepoTx, task, previous, &changedBy, models.AssignmentAssigned); err != nil {
+		return nil, err
+	}
 
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("не удалось закоммитить транзакцию: %w", err)
+	}
+	return task, nil
+}
+
+func (s *service) UnassignTask(ctx context.Context, taskID, changedBy uuid.UUID) (*models.Task, error) {
 	tx, err := s.db.BeginTxx(ctx, nil)
 	if err != nil {
 		return nil, fmt.Errorf("не удалось начать транзакцию: %w", err)
 	}
 	defer tx.Rollback()
 
-	taskRepoTx := repository.NewRepository(tx)
-	opRepoTx := operations_repository.NewRepository(tx)
+	task, err := s.newTaskRepo(tx).GetTaskForUpdate(ctx, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("задача с ID %s не найдена: %w", taskID, err)
+	}
+	if task.Status.IsClosed() {
+		return nil, fmt.Errorf("%w: статус '%s'", ErrTaskClosed, task.Status)
+	}
+	if task.AssigneeID == nil {
+		return task, nil
+	}
 
-	if err := taskRepoTx.UpdateTask(ctx, task); err != nil {
+	if err := s.release(ctx, tx, task, &changedBy, models.AssignmentUnassigned); err != nil {
 		return nil, err
 	}
 
-	if err := opRepoTx.UpdateOperationLogStatus(ctx, task.OperationID, "failed"); err != nil {
-		return nil, err
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("не удалось закоммитить транзакцию: %w", err)
+	}
+	return task, nil
+}
+
+func (s *service) GetAssignmentHistory(ctx context.Context, taskID uuid.UUID) ([]models.TaskAssignment, error) {
+	if _, err := s.taskRepo.GetTaskByID(ctx, taskID); err != nil {
+		return nil, fmt.Errorf("задача с ID %s не найдена: %w", taskID, err)
+	}
+	return s.taskRepo.GetAssignmentsByTaskID(ctx, taskID)
+}
+
+func (s *service) GetTimeline(ctx context.Context, taskID uuid.UUID) ([]models.TaskTransition, error) {
+	if _, err := s.taskRepo.GetTaskByID(ctx, taskID); err != nil {
+		return nil, fmt.Errorf("задача с ID %s не найдена: %w", taskID, err)
+	}
+	return s.taskRepo.GetTransitionsByTaskID(ctx, taskID)
+}
+
+func (s *service) ReleaseExpiredClaims(ctx context.Context, timeout time.Duration, limit int) (int, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("не удалось начать транзакцию: %w", err)
+	}
+	defer tx.Rollback()
+
+	tasks, err := s.newTaskRepo(tx).FetchExpiredClaims(ctx, time.Now().Add(-timeout), limit)
+	if err != nil {
+		return 0, fmt.Errorf("не удалось получить просроченные задачи: %w", err)
+	}
+	for i := range tasks {
+		if err := s.release(ctx, tx, &tasks[i], nil, models.AssignmentTimedOut); err != nil {
+			return 0, err
+		}
 	}
 
 	if err := tx.Commit(); err != nil {
-		return nil, err
+		return 0, fmt.Errorf("не удалось закоммитить транзакцию: %w", err)
 	}
+	return len(tasks), nil
+}
 
-	return task, nil
-}
\ No newline at end of file
+// release возвращает задачу в пул в рамках транзакции tx. Если задача была в работе,
+// операция возвращается в processing: пока задачу никто не взял, арендатор может ее отменить.
+func (s *service) release(ctx context.Context, tx *sqlx.Tx, task *models.Task, changedBy *uuid.UUID, reason string) error {
+	taskRepoTx := s.newTaskRepo(tx)
+	previous := task.AssigneeID
+	wasInProgress := task.Status == models.StatusInProgress
+
+	task.AssigneeID = nil
+	task.ClaimedAt = nil
+	if !wasInProgress {
+		// Задача уже в пуле, статус не меняется - снимается только исполнитель.
+		if err := updateTask(ctx, taskRepoTx, task, task.Status); err != nil {
+			return err
+		}
+	} else if err := transition(ctx, taskRepoTx, task, models.StatusNew, changedBy, releaseComments[reason]); err != nil {
+		return err
+	}
+
+	if wasInProgress {
+		_, err := s.newOpsRepo(tx).TransitionOperationLogStatus(ctx, task.OperationID, operations_models.StatusInProgress, operations_models.StatusProcessing)
+		if err != nil {
+			return err
+		}
+	}
+
+	return recordAssignment(ctx, taskRepoTx, task, previous, changedBy, reason)
+}
+
+// releaseComments - комментарии в истории статусов для возврата задачи в пул по причине из истории назначений.
+var releaseComments = map[string]string{
+	models.AssignmentUnassigned: "руководитель вернул задачу в пул",
+	models.AssignmentTimedOut:   "задача вернулась в пул по таймауту",
+}
+
+// checkTransition возвращает ErrInvalidTransition, если задачу нельзя перевести в статус to.
+func checkTransition(task *models.Task, to models.TaskStatus) error {
+	if !task.Status.CanTransitionTo(to) {
+		return fmt.Errorf("%w: '%s' -> '%s'", ErrInvalidTransition, task.Status, to)
+	}
+	return nil
+}
+
+// transition переводит задачу в статус to по правилам конечного автомата, сохраняет ее
+// и записывает переход в историю статусов через taskRepo (в той же транзакции).
+// actorID равен nil для переходов, которые делает система.
+func transition(ctx context.Context, taskRepo repository.Repository, task *models.Task, to models.TaskStatus, actorID *uuid.UUID, comment string) error {
+	if err := checkTransition(task, to); err != nil {
+		return err
+	}
+	from := task.Status
+	task.Status = to
+	if err := updateTask(ctx, taskRepo, task, from); err != nil {
+		return err
+	}
+
+	record := &models.TaskTransition{
+		ID:         uuid.New(),
+		TaskID:     task.ID,
+		ActorID:    actorID,
+		FromStatus: &from,
+		ToStatus:   to,
+		CreatedAt:  time.Now(),
+	}
+	if comment != "" {
+		record.Comment = &comment
+	}
+	if err := taskRepo.CreateTransition(ctx, record); err != nil {
+		return fmt.Errorf("не удалось записать историю статусов: %w", err)
+	}
+	return nil
+}
+
+// updateTask сохраняет задачу, если ее статус в базе все еще from. Если задачу успел изменить
+// параллельный запрос, возвращает ErrInvalidTransition, как и недопустимый переход.
+func updateTask(ctx context.Context, taskRepo repository.Repository, task *models.Task, from models.TaskStatus) error {
+	if err := taskRepo.UpdateTask(ctx, task, from); err != nil {
+		if errors.Is(err, repository.ErrStatusChanged) {
+			return fmt.Errorf("%w: %w", ErrInvalidTransition, err)
+		}
+		return fmt.Errorf("не удалось обновить задачу: %w", err)
+	}
+	return nil
+}
+
+// recordAssignment добавляет запись в историю назначений задачи.
+func recordAssignment(ctx context.Context, taskRepo repository.Repository, task *models.Task, previous, changedBy *uuid.UUID, reason string) error {
+	assignment := &models.TaskAssignment{
+		ID:                 uuid.New(),
+		TaskID:             task.ID,
+		AssigneeID:         task.AssigneeID,
+		PreviousAssigneeID: previous,
+		ChangedBy:          changedBy,
+		Reason:             reason,
+		CreatedAt:          time.Now(),
+	}
+	if err := taskRepo.CreateAssignment(ctx, assignment); err != nil {
+		return fmt.Errorf("не удалось записать историю назначений: %w", err)
+	}
+	return nil
+}
+
+func (s *service) FlagOverdueTasks(ctx context.Context, limit int) (int, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("не удалось начать транзакцию: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	taskRepoTx := s.newTaskRepo(tx)
+	tasks, err := taskRepoTx.FetchOverdue(ctx, now, limit)
+	if err != nil {
+		return 0, fmt.Errorf("не удалось получить просроченные задачи: %w", err)
+	}
+
+	outboxRepoTx := s.newOutbox(tx)
+	for _, task := range tasks {
+		if err := taskRepoTx.MarkOverdue(ctx, task.ID, now); err != nil {
+			return 0, fmt.Errorf("не удалось отметить задачу %s просроченной: %w", task.ID, err)
+		}
+		body, err := json.Marshal(models.TaskEvent{
+			Type:        models.EventTaskOverdue,
+			TaskID:      task.ID,
+			OperationID: task.OperationID,
+			AssigneeID:  task.AssigneeID,
+			Priority:    task.Priority,
+			DueAt:       task.DueAt,
+			OccurredAt:  now,
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to marshal task event: %w", err)
+		}
+		if err := outboxRepoTx.Create(ctx, outbox_models.NewMessage(s.cfg.RabbitMQ.Queues["task_notifications"], string(body))); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("не удалось закоммитить транзакцию: %w", err)
+	}
+	return len(tasks), nil
+}
@@ -0,0 +1,750 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rendley/vegshare/backend/internal/operations/actionhandlers"
+	operationsModels "github.com/rendley/vegshare/backend/internal/operations/models"
+	operationsRepository "github.com/rendley/vegshare/backend/internal/operations/repository"
+	outboxModels "github.com/rendley/vegshare/backend/internal/outbox/models"
+	outboxRepository "github.com/rendley/vegshare/backend/internal/outbox/repository"
+	outboxMocks "github.com/rendley/vegshare/backend/internal/outbox/repository/mocks"
+	"github.com/rendley/vegshare/backend/internal/task/models"
+	"github.com/rendley/vegshare/backend/internal/task/repository"
+	userModels "github.com/rendley/vegshare/backend/internal/user/models"
+	userRepository "github.com/rendley/vegshare/backend/internal/user/repository"
+	"github.com/rendley/vegshare/backend/pkg/config"
+	"github.com/rendley/vegshare/backend/pkg/database"
+	"github.com/rendley/vegshare/backend/pkg/database/dbtest"
+	"github.com/rendley/vegshare/backend/pkg/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// --- Mocks ---
+
+type MockTaskRepository struct {
+	mock.Mock
+}
+
+var _ repository.Repository = &MockTaskRepository{}
+
+func (m *MockTaskRepository) CreateTask(ctx context.Context, task *models.Task) (bool, error) {
+	args := m.Called(ctx, task)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTaskRepository) GetTaskByID(ctx context.Context, taskID uuid.UUID) (*models.Task, error) {
+	args := m.Called(ctx, taskID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Task), args.Error(1)
+}
+
+func (m *MockTaskRepository) GetTaskByOperationID(ctx context.Context, operationID uuid.UUID) (*models.Task, error) {
+	args := m.Called(ctx, operationID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Task), args.Error(1)
+}
+
+func (m *MockTaskRepository) GetAssignmentsByTaskID(ctx context.Context, taskID uuid.UUID) ([]models.TaskAssignment, error) {
+	args := m.Called(ctx, taskID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.TaskAssignment), args.Error(1)
+}
+
+func (m *MockTaskRepository) GetTransitionsByTaskID(ctx context.Context, taskID uuid.UUID) ([]models.TaskTransition, error) {
+	args := m.Called(ctx, taskID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.TaskTransition), args.Error(1)
+}
+
+func (m *MockTaskRepository) GetTasks(ctx context.Context, filter models.TaskFilter) ([]models.TaskListItem, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.TaskListItem), args.Error(1)
+}
+
+func (m *MockTaskRepository) UpdateTask(ctx context.Context, task *models.Task, from models.TaskStatus) error {
+	return m.Called(ctx, task, from).Error(0)
+}
+
+func (m *MockTaskRepository) GetTaskForUpdate(ctx context.Context, taskID uuid.UUID) (*models.Task, error) {
+	args := m.Called(ctx, taskID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Task), args.Error(1)
+}
+
+func (m *MockTaskRepository) FetchExpiredClaims(ctx context.Context, claimedBefore time.Time, limit int) ([]models.Task, error) {
+	args := m.Called(ctx, claimedBefore, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Task), args.Error(1)
+}
+
+func (m *MockTaskRepository) CreateAssignment(ctx context.Context, assignment *models.TaskAssignment) error {
+	return m.Called(ctx, assignment).Error(0)
+}
+
+func (m *MockTaskRepository) CreateTransition(ctx context.Context, transition *models.TaskTransition) error {
+	return m.Called(ctx, transition).Error(0)
+}
+
+func (m *MockTaskRepository) CancelTasksByOperationID(ctx context.Context, operationID uuid.UUID, cancelledBy *uuid.UUID) error {
+	return nil
+}
+func (m *MockTaskRepository) CreateAttachment(ctx context.Context, attachment *models.TaskAttachment) error {
+	return nil
+}
+func (m *MockTaskRepository) FetchOverdue(ctx context.Context, now time.Time, limit int) ([]models.Task, error) {
+	return nil, nil
+}
+func (m *MockTaskRepository) MarkOverdue(ctx context.Context, taskID uuid.UUID, at time.Time) error {
+	return nil
+}
+
+// MockOperationsRepository мокает только те методы репозитория операций, которые вызывает сервис задач.
+type MockOperationsRepository struct {
+	operationsRepository.Repository
+	mock.Mock
+}
+
+func (m *MockOperationsRepository) GetOperationLogByID(ctx context.Context, logID uuid.UUID) (*operationsModels.OperationLog, error) {
+	args := m.Called(ctx, logID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*operationsModels.OperationLog), args.Error(1)
+}
+
+func (m *MockOperationsRepository) UpdateOperationLogStatus(ctx context.Context, logID uuid.UUID, status string) error {
+	return m.Called(ctx, logID, status).Error(0)
+}
+
+func (m *MockOperationsRepository) TransitionOperationLogStatus(ctx context.Context, logID uuid.UUID, from, to string) (bool, error) {
+	args := m.Called(ctx, logID, from, to)
+	return args.Bool(0), args.Error(1)
+}
+
+type MockUserRepository struct {
+	mock.Mock
+}
+
+var _ userRepository.UserRepository = &MockUserRepository{}
+
+func (m *MockUserRepository) GetUserByID(ctx context.Context, id uuid.UUID) (*userModels.UserProfile, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*userModels.UserProfile), args.Error(1)
+}
+
+func (m *MockUserRepository) GetAllUsers(ctx context.Context) ([]userModels.UserProfile, error) {
+	return nil, nil
+}
+func (m *MockUserRepository) UpdateUser(ctx context.Context, user *userModels.UserProfile) error {
+	return nil
+}
+func (m *MockUserRepository) UpdateUserRole(ctx context.Context, id uuid.UUID, role string) error {
+	return nil
+}
+func (m *MockUserRepository) DeleteUser(ctx context.Context, id uuid.UUID) error { return nil }
+
+// --- Tests ---
+
+func TestTaskService(t *testing.T) {
+	ctx := context.Background()
+	mockTaskRepo := new(MockTaskRepository)
+	mockOpsRepo := new(MockOperationsRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockOutbox := new(outboxMocks.Repository)
+	storageDir := t.TempDir()
+	store, err := storage.NewLocal(storageDir)
+	require.NoError(t, err)
+	cfg := &config.Config{RabbitMQ: config.RabbitMQConfig{Queues: map[string]string{"task_notifications": "task_notifications_queue_test"}}}
+	db, stats := dbtest.New()
+	svc := NewService(db, mockTaskRepo, mockOpsRepo, mockUserRepo, nil, store, cfg)
+	svc.(*service).newTaskRepo = func(database.DBTX) repository.Repository { return mockTaskRepo }
+	svc.(*service).newOpsRepo = func(database.DBTX) operationsRepository.Repository { return mockOpsRepo }
+	svc.(*service).newOutbox = func(database.DBTX) outboxRepository.Repository { return mockOutbox }
+
+	// sameTask сопоставляет аргумент-задачу по ID: мок общий для всех подтестов.
+	sameTask := func(id uuid.UUID) interface{} {
+		return mock.MatchedBy(func(task *models.Task) bool { return task.ID == id })
+	}
+
+	t.Run("CreateTask returns the existing task for a duplicate operation", func(t *testing.T) {
+		// Arrange
+		operationID := uuid.New()
+		existing := &models.Task{ID: uuid.New(), OperationID: operationID, Status: models.StatusInProgress}
+		mockTaskRepo.On("CreateTask", ctx, mock.AnythingOfType("*models.Task")).Return(false, nil).Once()
+		mockTaskRepo.On("GetTaskByOperationID", ctx, operationID).Return(existing, nil).Once()
+
+		// Act
+		task, err := svc.CreateTask(ctx, operationID, actionhandlers.TaskSpec{Title: "Полить грядку"})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, existing, task)
+	})
+
+	t.Run("CreateTask applies the spec priority and due date", func(t *testing.T) {
+		// Arrange
+		operationID := uuid.New()
+		dueAt := time.Now().Add(4 * time.Hour)
+		spec := actionhandlers.TaskSpec{Title: "Полить грядку", Priority: models.PriorityHigh, DueAt: dueAt}
+		mockTaskRepo.On("CreateTask", ctx, mock.MatchedBy(func(task *models.Task) bool {
+			return task.OperationID == operationID && task.Priority == models.PriorityHigh &&
+				task.DueAt != nil && task.DueAt.Equal(dueAt)
+		})).Return(true, nil).Once()
+
+		// Act
+		task, err := svc.CreateTask(ctx, operationID, spec)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, models.PriorityHigh, task.Priority)
+	})
+
+	t.Run("CreateTask defaults to normal priority without a due date", func(t *testing.T) {
+		// Arrange
+		operationID := uuid.New()
+		mockTaskRepo.On("CreateTask", ctx, mock.MatchedBy(func(task *models.Task) bool {
+			return task.OperationID == operationID
+		})).Return(true, nil).Once()
+
+		// Act
+		task, err := svc.CreateTask(ctx, operationID, actionhandlers.TaskSpec{Title: "Сделать фото"})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, models.PriorityNormal, task.Priority)
+		assert.Nil(t, task.DueAt)
+	})
+
+	t.Run("AcceptTask - Task changed by a concurrent request is a conflict", func(t *testing.T) {
+		// Arrange
+		task := &models.Task{ID: uuid.New(), OperationID: uuid.New(), Status: models.StatusNew}
+		mockTaskRepo.On("GetTaskForUpdate", ctx, task.ID).Return(task, nil).Once()
+		mockTaskRepo.On("UpdateTask", ctx, sameTask(task.ID), models.StatusNew).Return(repository.ErrStatusChanged).Once()
+		committed := stats.Committed()
+
+		// Act
+		result, err := svc.AcceptTask(ctx, task.ID, uuid.New())
+
+		// Assert
+		assert.ErrorIs(t, err, ErrInvalidTransition)
+		assert.ErrorIs(t, err, repository.ErrStatusChanged)
+		assert.Nil(t, result)
+		assert.Equal(t, committed, stats.Committed())
+		mockTaskRepo.AssertNotCalled(t, "CreateTransition", mock.Anything, mock.MatchedBy(func(tr *models.TaskTransition) bool {
+			return tr.TaskID == task.ID
+		}))
+	})
+
+	t.Run("AcceptTask - Task assigned to another staff member", func(t *testing.T) {
+		// Arrange
+		assigneeID := uuid.New()
+		task := &models.Task{ID: uuid.New(), OperationID: uuid.New(), Status: models.StatusNew, AssigneeID: &assigneeID}
+		mockTaskRepo.On("GetTaskForUpdate", ctx, task.ID).Return(task, nil).Once()
+		committed := stats.Committed()
+
+		// Act
+		result, err := svc.AcceptTask(ctx, task.ID, uuid.New())
+
+		// Assert
+		assert.ErrorIs(t, err, ErrAssignedToAnother)
+		assert.Nil(t, result)
+		assert.Equal(t, assigneeID, *task.AssigneeID)
+		assert.Equal(t, committed, stats.Committed())
+		mockTaskRepo.AssertNotCalled(t, "UpdateTask", mock.Anything, sameTask(task.ID), mock.Anything)
+	})
+
+	t.Run("CompleteTask", func(t *testing.T) {
+		assigneeID := uuid.New()
+		inProgressTask := func() *models.Task {
+			return &models.Task{ID: uuid.New(), OperationID: uuid.New(), Status: models.StatusInProgress, AssigneeID: &assigneeID}
+		}
+
+		t.Run("Too many photos", func(t *testing.T) {
+			// Arrange
+			task := inProgressTask()
+			photos := make([]Photo, maxCompletionPhotos+1)
+
+			// Act
+			_, err := svc.CompleteTask(ctx, task.ID, assigneeID, CompletionDetails{}, Evidence{Photos: photos})
+
+			// Assert
+			assert.ErrorIs(t, err, ErrInvalidEvidence)
+			mockTaskRepo.AssertNotCalled(t, "GetTaskForUpdate", mock.Anything, task.ID)
+		})
+
+		t.Run("Note is too long", func(t *testing.T) {
+			// Arrange
+			task := inProgressTask()
+
+			// Act
+			_, err := svc.CompleteTask(ctx, task.ID, assigneeID, CompletionDetails{}, Evidence{Note: strings.Repeat("я", maxCompletionNoteRune+1)})
+
+			// Assert
+			assert.ErrorIs(t, err, ErrInvalidEvidence)
+		})
+
+		t.Run("Non-image file is rejected and stored photos are removed", func(t *testing.T) {
+			// Arrange
+			task := inProgressTask()
+			mockTaskRepo.On("GetTaskForUpdate", ctx, task.ID).Return(task, nil).Once()
+			photos := []Photo{
+				{FileName: "beans.png", Content: strings.NewReader("\x89PNG\r\n\x1a\n" + strings.Repeat("\x00", 32))},
+				{FileName: "report.txt", Content: strings.NewReader("посадили фасоль")},
+			}
+
+			// Act
+			_, err := svc.CompleteTask(ctx, task.ID, assigneeID, CompletionDetails{}, Evidence{Photos: photos})
+
+			// Assert
+			assert.ErrorIs(t, err, ErrInvalidEvidence)
+			entries, err := os.ReadDir(filepath.Join(storageDir, "tasks", task.ID.String()))
+			require.NoError(t, err)
+			assert.Empty(t, entries)
+		})
+	})
+
+	t.Run("FailTask", func(t *testing.T) {
+		t.Run("Requires a known reason", func(t *testing.T) {
+			for _, reason := range []string{"", "lazy"} {
+				// Act
+				_, err := svc.FailTask(ctx, uuid.New(), uuid.New(), Failure{Reason: reason})
+
+				// Assert
+				assert.ErrorIs(t, err, ErrInvalidFailureReason, reason)
+			}
+		})
+
+		t.Run("Closed task cannot fail", func(t *testing.T) {
+			// Arrange
+			task := &models.Task{ID: uuid.New(), Status: models.StatusCompleted}
+			mockTaskRepo.On("GetTaskForUpdate", ctx, task.ID).Return(task, nil).Once()
+
+			// Act
+			_, err := svc.FailTask(ctx, task.ID, uuid.New(), Failure{Reason: models.FailureWeather})
+
+			// Assert
+			assert.ErrorIs(t, err, ErrInvalidTransition)
+			assert.Equal(t, models.StatusCompleted, task.Status)
+		})
+
+		t.Run("Task assigned to another staff member cannot fail even before it is taken", func(t *testing.T) {
+			// Arrange
+			assigneeID := uuid.New()
+			task := &models.Task{ID: uuid.New(), OperationID: uuid.New(), Status: models.StatusNew, AssigneeID: &assigneeID}
+			mockTaskRepo.On("GetTaskForUpdate", ctx, task.ID).Return(task, nil).Once()
+
+			// Act
+			_, err := svc.FailTask(ctx, task.ID, uuid.New(), Failure{Reason: models.FailureWeather})
+
+			// Assert
+			assert.ErrorIs(t, err, ErrAssignedToAnother)
+			assert.Equal(t, models.StatusNew, task.Status)
+			mockTaskRepo.AssertNotCalled(t, "UpdateTask", mock.Anything, sameTask(task.ID), mock.Anything)
+		})
+
+		t.Run("Success", func(t *testing.T) {
+			// Arrange
+			assigneeID, lesseeID := uuid.New(), uuid.New()
+			task := &models.Task{ID: uuid.New(), OperationID: uuid.New(), Status: models.StatusInProgress, AssigneeID: &assigneeID, Priority: models.PriorityHigh}
+			failure := Failure{Reason: models.FailureOutOfStock, Comment: "Закончились семена"}
+			mockTaskRepo.On("GetTaskForUpdate", ctx, task.ID).Return(task, nil).Once()
+			mockTaskRepo.On("UpdateTask", ctx, sameTask(task.ID), models.StatusInProgress).Return(nil).Once()
+			mockTaskRepo.On("CreateTransition", ctx, mock.MatchedBy(func(tr *models.TaskTransition) bool {
+				return tr.TaskID == task.ID && tr.ToStatus == models.StatusFailed && *tr.ActorID == assigneeID &&
+					*tr.Comment == "out_of_stock: Закончились семена"
+			})).Return(nil).Once()
+			mockOpsRepo.On("UpdateOperationLogStatus", ctx, task.OperationID, operationsModels.StatusFailed).Return(nil).Once()
+			mockOpsRepo.On("GetOperationLogByID", ctx, task.OperationID).
+				Return(&operationsModels.OperationLog{ID: task.OperationID, UserID: lesseeID, ActionType: "plant"}, nil).Once()
+			var event models.TaskEvent
+			mockOutbox.On("Create", ctx, mock.MatchedBy(func(msg *outboxModels.Message) bool {
+				return msg.Queue == "task_notifications_queue_test" && json.Unmarshal([]byte(msg.Payload), &event) == nil && event.TaskID == task.ID
+			})).Return(nil).Once()
+			committed := stats.Committed()
+
+			// Act
+			result, err := svc.FailTask(ctx, task.ID, assigneeID, failure)
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, models.StatusFailed, result.Status)
+			assert.Equal(t, models.FailureOutOfStock, *result.FailureReason)
+			assert.Equal(t, "Закончились семена", *result.FailureComment)
+			assert.Equal(t, committed+1, stats.Committed())
+
+			assert.Equal(t, models.EventTaskFailed, event.Type)
+			assert.Equal(t, task.OperationID, event.OperationID)
+			assert.Equal(t, assigneeID, *event.AssigneeID)
+			assert.Equal(t, lesseeID, *event.UserID)
+			assert.Equal(t, "plant", event.ActionType)
+			assert.Equal(t, models.FailureOutOfStock, event.FailureReason)
+			assert.Equal(t, "Закончились семена", event.FailureComment)
+			mockTaskRepo.AssertExpectations(t)
+			mockOpsRepo.AssertExpectations(t)
+			mockOutbox.AssertExpectations(t)
+		})
+	})
+
+	t.Run("Task in the pool cannot be completed", func(t *testing.T) {
+		// Arrange
+		assigneeID := uuid.New()
+		task := &models.Task{ID: uuid.New(), Status: models.StatusNew, AssigneeID: &assigneeID}
+		mockTaskRepo.On("GetTaskForUpdate", ctx, task.ID).Return(task, nil).Once()
+
+		// Act
+		_, err := svc.CompleteTask(ctx, task.ID, assigneeID, CompletionDetails{}, Evidence{})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrInvalidTransition)
+	})
+
+	t.Run("GetTimeline", func(t *testing.T) {
+		t.Run("Unknown task", func(t *testing.T) {
+			// Arrange
+			taskID := uuid.New()
+			mockTaskRepo.On("GetTaskByID", ctx, taskID).Return(nil, sql.ErrNoRows).Once()
+
+			// Act
+			timeline, err := svc.GetTimeline(ctx, taskID)
+
+			// Assert
+			assert.ErrorIs(t, err, sql.ErrNoRows)
+			assert.Nil(t, timeline)
+		})
+
+		t.Run("Returns transitions of the task", func(t *testing.T) {
+			// Arrange
+			taskID := uuid.New()
+			from := models.StatusNew
+			expected := []models.TaskTransition{
+				{ID: uuid.New(), TaskID: taskID, ToStatus: models.StatusNew},
+				{ID: uuid.New(), TaskID: taskID, FromStatus: &from, ToStatus: models.StatusInProgress},
+			}
+			mockTaskRepo.On("GetTaskByID", ctx, taskID).Return(&models.Task{ID: taskID}, nil).Once()
+			mockTaskRepo.On("GetTransitionsByTaskID", ctx, taskID).Return(expected, nil).Once()
+
+			// Act
+			timeline, err := svc.GetTimeline(ctx, taskID)
+
+			// Assert
+			assert.NoError(t, err)
+			assert.Equal(t, expected, timeline)
+		})
+	})
+
+	t.Run("GetMyTasks shows open tasks of the assignee, most urgent first", func(t *testing.T) {
+		// Arrange
+		userID := uuid.New()
+		mockTaskRepo.On("GetTasks", ctx, mock.MatchedBy(func(f models.TaskFilter) bool {
+			return f.AssigneeID != nil && *f.AssigneeID == userID && f.Open && f.Sort == models.SortPriority
+		})).Return([]models.TaskListItem{}, nil).Once()
+
+		// Act
+		page, err := svc.GetMyTasks(ctx, userID, models.TaskFilter{})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Empty(t, page.Items)
+		mockTaskRepo.AssertExpectations(t)
+	})
+
+	t.Run("GetWorkList groups the day's tasks by parcel and structure", func(t *testing.T) {
+		// Arrange
+		userID := uuid.New()
+		day := time.Date(2026, 10, 19, 15, 0, 0, 0, time.UTC)
+		ptr := func(s string) *string { return &s }
+		at := func(hour int) *time.Time { t := time.Date(2026, 10, 19, hour, 0, 0, 0, time.UTC); return &t }
+		minutes := func(m int) *int { return &m }
+		northID, southID := uuid.New(), uuid.New()
+		greenhouseID, coopID, southGreenhouseID := uuid.New(), uuid.New(), uuid.New()
+		item := func(title, priority string, dueAt *time.Time, parcelID *uuid.UUID, parcel string, structureID *uuid.UUID, structure string) models.TaskListItem {
+			return models.TaskListItem{
+				Task:           models.Task{ID: uuid.New(), Title: title, Priority: priority, DueAt: dueAt, EstimatedMinutes: minutes(10)},
+				LandParcelID:   parcelID,
+				LandParcelName: ptr(parcel),
+				RegionName:     ptr("Подмосковье"),
+				StructureID:    structureID,
+				StructureName:  ptr(structure),
+			}
+		}
+		tomorrow := time.Date(2026, 10, 20, 9, 0, 0, 0, time.UTC)
+		items := []models.TaskListItem{
+			item("Полить", models.PriorityHigh, at(12), &northID, "Северный", &greenhouseID, "Теплица 1"),
+			item("Собрать яйца", models.PriorityNormal, nil, &northID, "Северный", &coopID, "Курятник"),
+			item("Доставка", models.PriorityUrgent, at(10), &southID, "Южный", &southGreenhouseID, "Теплица 2"),
+			item("Прополоть", models.PriorityLow, &tomorrow, &northID, "Северный", &greenhouseID, "Теплица 1"),
+			item("Полить срочно", models.PriorityUrgent, at(9), &northID, "Северный", &greenhouseID, "Теплица 1"),
+			{Task: models.Task{ID: uuid.New(), Title: "Без грядки", Priority: models.PriorityUrgent}},
+		}
+		mockTaskRepo.On("GetTasks", ctx, mock.MatchedBy(func(f models.TaskFilter) bool {
+			return f.AssigneeID != nil && *f.AssigneeID == userID && f.Open && f.Limit == 0
+		})).Return(items, nil).Once()
+
+		// Act
+		list, err := svc.GetWorkList(ctx, userID, day)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, "2026-10-19", list.Date)
+		assert.Equal(t, 5, list.TotalTasks, "задача со сроком завтра не попадает в лист")
+		assert.Equal(t, 40, list.EstimatedMinutes)
+		var route []string
+		for _, parcel := range list.Parcels {
+			for _, structure := range parcel.Structures {
+				for _, task := range structure.Tasks {
+					route = append(route, parcel.Name+"/"+structure.Name+"/"+task.Title)
+				}
+			}
+		}
+		assert.Equal(t, []string{
+			"Северный/Теплица 1/Полить срочно",
+			"Северный/Теплица 1/Полить",
+			"Северный/Курятник/Собрать яйца",
+			"Южный/Теплица 2/Доставка",
+			"Без расположения/Без расположения/Без грядки",
+		}, route)
+	})
+
+	t.Run("GetTasks", func(t *testing.T) {
+		t.Run("Passes a valid filter to the repository", func(t *testing.T) {
+			// Arrange
+			filter := models.TaskFilter{Status: models.StatusNew, Priority: models.PriorityUrgent, Sort: models.SortDueAt}
+			expected := []models.TaskListItem{{Task: models.Task{ID: uuid.New(), Priority: models.PriorityUrgent}}}
+			// На одну запись больше лимита - чтобы узнать, есть ли следующая страница.
+			repoFilter := filter
+			repoFilter.Limit = defaultTaskLimit + 1
+			mockTaskRepo.On("GetTasks", ctx, repoFilter).Return(expected, nil).Once()
+
+			// Act
+			page, err := svc.GetTasks(ctx, filter)
+
+			// Assert
+			assert.NoError(t, err)
+			assert.Equal(t, expected, page.Items)
+			assert.Empty(t, page.NextCursor)
+		})
+
+		t.Run("Full page returns a cursor for its sort", func(t *testing.T) {
+			// Arrange
+			dueAt := time.Now().Add(time.Hour)
+			items := []models.TaskListItem{
+				{Task: models.Task{ID: uuid.New(), Priority: models.PriorityUrgent}},
+				{Task: models.Task{ID: uuid.New(), Priority: models.PriorityHigh, DueAt: &dueAt}},
+				{Task: models.Task{ID: uuid.New(), Priority: models.PriorityLow}},
+			}
+			mockTaskRepo.On("GetTasks", ctx, mock.MatchedBy(func(f models.TaskFilter) bool {
+				return f.Sort == models.SortPriority && f.Limit == 3
+			})).Return(items, nil).Once()
+
+			// Act
+			page, err := svc.GetTasks(ctx, models.TaskFilter{Sort: models.SortPriority, Limit: 2})
+
+			// Assert
+			require.NoError(t, err)
+			assert.Len(t, page.Items, 2)
+			cursor, err := models.DecodeTaskCursor(page.NextCursor)
+			require.NoError(t, err)
+			assert.Equal(t, models.SortPriority, cursor.Sort)
+			assert.Equal(t, items[1].ID, cursor.ID)
+			assert.Equal(t, models.PriorityHigh, cursor.Priority)
+			assert.True(t, dueAt.Equal(*cursor.DueAt))
+		})
+
+		t.Run("Cursor from another sort", func(t *testing.T) {
+			cursor := models.NewTaskCursor(models.SortDueAt, models.Task{ID: uuid.New()})
+			_, err := svc.GetTasks(ctx, models.TaskFilter{Cursor: &cursor})
+			assert.ErrorIs(t, err, ErrInvalidTaskFilter)
+		})
+
+		t.Run("Unknown priority", func(t *testing.T) {
+			_, err := svc.GetTasks(ctx, models.TaskFilter{Priority: "critical"})
+			assert.ErrorIs(t, err, ErrInvalidTaskFilter)
+		})
+
+		t.Run("Unknown sort", func(t *testing.T) {
+			_, err := svc.GetTasks(ctx, models.TaskFilter{Sort: "title"})
+			assert.ErrorIs(t, err, ErrInvalidTaskFilter)
+		})
+	})
+
+	t.Run("AssignTask", func(t *testing.T) {
+		t.Run("Unknown assignee", func(t *testing.T) {
+			// Arrange
+			assigneeID := uuid.New()
+			mockUserRepo.On("GetUserByID", ctx, assigneeID).Return(nil, sql.ErrNoRows).Once()
+
+			// Act
+			task, err := svc.AssignTask(ctx, uuid.New(), assigneeID, uuid.New())
+
+			// Assert
+			assert.ErrorIs(t, err, ErrInvalidAssignee)
+			assert.Nil(t, task)
+		})
+
+		t.Run("Assignee is not staff", func(t *testing.T) {
+			// Arrange
+			assigneeID := uuid.New()
+			mockUserRepo.On("GetUserByID", ctx, assigneeID).Return(&userModels.UserProfile{ID: assigneeID, Role: "user"}, nil).Once()
+
+			// Act
+			task, err := svc.AssignTask(ctx, uuid.New(), assigneeID, uuid.New())
+
+			// Assert
+			assert.ErrorIs(t, err, ErrInvalidAssignee)
+			assert.Nil(t, task)
+		})
+
+		t.Run("Success", func(t *testing.T) {
+			// Arrange
+			assigneeID, managerID := uuid.New(), uuid.New()
+			task := &models.Task{ID: uuid.New(), OperationID: uuid.New(), Status: models.StatusNew}
+			mockUserRepo.On("GetUserByID", ctx, assigneeID).Return(&userModels.UserProfile{ID: assigneeID, Role: staffRole}, nil).Once()
+			mockTaskRepo.On("GetTaskForUpdate", ctx, task.ID).Return(task, nil).Once()
+			mockTaskRepo.On("UpdateTask", ctx, sameTask(task.ID), models.StatusNew).Return(nil).Once()
+			mockTaskRepo.On("CreateAssignment", ctx, mock.MatchedBy(func(a *models.TaskAssignment) bool {
+				return a.TaskID == task.ID && *a.AssigneeID == assigneeID && a.PreviousAssigneeID == nil &&
+					*a.ChangedBy == managerID && a.Reason == models.AssignmentAssigned
+			})).Return(nil).Once()
+			committed := stats.Committed()
+
+			// Act
+			result, err := svc.AssignTask(ctx, task.ID, assigneeID, managerID)
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, assigneeID, *result.AssigneeID)
+			// Новая задача ждет, пока исполнитель ее возьмет.
+			assert.Equal(t, models.StatusNew, result.Status)
+			assert.Nil(t, result.ClaimedAt)
+			assert.Equal(t, committed+1, stats.Committed())
+			mockTaskRepo.AssertExpectations(t)
+		})
+	})
+
+	t.Run("UnassignTask - Task in progress returns to the pool", func(t *testing.T) {
+		// Arrange
+		assigneeID, managerID := uuid.New(), uuid.New()
+		claimedAt := time.Now().Add(-time.Hour)
+		task := &models.Task{ID: uuid.New(), OperationID: uuid.New(), Status: models.StatusInProgress, AssigneeID: &assigneeID, ClaimedAt: &claimedAt}
+		mockTaskRepo.On("GetTaskForUpdate", ctx, task.ID).Return(task, nil).Once()
+		mockTaskRepo.On("UpdateTask", ctx, sameTask(task.ID), models.StatusInProgress).Return(nil).Once()
+		mockTaskRepo.On("CreateTransition", ctx, mock.MatchedBy(func(tr *models.TaskTransition) bool {
+			return tr.TaskID == task.ID && *tr.FromStatus == models.StatusInProgress && tr.ToStatus == models.StatusNew && *tr.ActorID == managerID
+		})).Return(nil).Once()
+		mockOpsRepo.On("TransitionOperationLogStatus", ctx, task.OperationID, operationsModels.StatusInProgress, operationsModels.StatusProcessing).Return(true, nil).Once()
+		mockTaskRepo.On("CreateAssignment", ctx, mock.MatchedBy(func(a *models.TaskAssignment) bool {
+			return a.TaskID == task.ID && a.AssigneeID == nil && *a.PreviousAssigneeID == assigneeID && a.Reason == models.AssignmentUnassigned
+		})).Return(nil).Once()
+
+		// Act
+		result, err := svc.UnassignTask(ctx, task.ID, managerID)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, models.StatusNew, result.Status)
+		assert.Nil(t, result.AssigneeID)
+		assert.Nil(t, result.ClaimedAt)
+		mockTaskRepo.AssertExpectations(t)
+		mockOpsRepo.AssertExpectations(t)
+	})
+
+	t.Run("ReleaseExpiredClaims returns timed out tasks to the pool", func(t *testing.T) {
+		// Arrange
+		first, second := uuid.New(), uuid.New()
+		claimedAt := time.Now().Add(-3 * time.Hour)
+		tasks := []models.Task{
+			{ID: uuid.New(), OperationID: uuid.New(), Status: models.StatusInProgress, AssigneeID: &first, ClaimedAt: &claimedAt},
+			{ID: uuid.New(), OperationID: uuid.New(), Status: models.StatusInProgress, AssigneeID: &second, ClaimedAt: &claimedAt},
+		}
+		mockTaskRepo.On("FetchExpiredClaims", ctx, mock.MatchedBy(func(before time.Time) bool {
+			return before.Before(time.Now().Add(-time.Hour)) && before.After(time.Now().Add(-2*time.Hour))
+		}), 10).Return(tasks, nil).Once()
+		for _, task := range tasks {
+			mockTaskRepo.On("UpdateTask", ctx, sameTask(task.ID), models.StatusInProgress).Return(nil).Once()
+			mockTaskRepo.On("CreateTransition", ctx, mock.MatchedBy(func(tr *models.TaskTransition) bool {
+				return tr.TaskID == task.ID && tr.ToStatus == models.StatusNew && tr.ActorID == nil
+			})).Return(nil).Once()
+			mockOpsRepo.On("TransitionOperationLogStatus", ctx, task.OperationID, operationsModels.StatusInProgress, operationsModels.StatusProcessing).Return(true, nil).Once()
+			mockTaskRepo.On("CreateAssignment", ctx, mock.MatchedBy(func(a *models.TaskAssignment) bool {
+				return a.TaskID == task.ID && a.ChangedBy == nil && a.Reason == models.AssignmentTimedOut
+			})).Return(nil).Once()
+		}
+		committed := stats.Committed()
+
+		// Act
+		released, err := svc.ReleaseExpiredClaims(ctx, 90*time.Minute, 10)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, 2, released)
+		assert.Equal(t, committed+1, stats.Committed())
+		mockTaskRepo.AssertExpectations(t)
+		mockOpsRepo.AssertExpectations(t)
+	})
+
+	t.Run("GetAssignmentHistory", func(t *testing.T) {
+		t.Run("Unknown task", func(t *testing.T) {
+			// Arrange
+			taskID := uuid.New()
+			mockTaskRepo.On("GetTaskByID", ctx, taskID).Return(nil, sql.ErrNoRows).Once()
+
+			// Act
+			history, err := svc.GetAssignmentHistory(ctx, taskID)
+
+			// Assert
+			assert.ErrorIs(t, err, sql.ErrNoRows)
+			assert.Nil(t, history)
+			mockTaskRepo.AssertNotCalled(t, "GetAssignmentsByTaskID", ctx, taskID)
+		})
+
+		t.Run("Success", func(t *testing.T) {
+			// Arrange
+			taskID := uuid.New()
+			first, second := uuid.New(), uuid.New()
+			history := []models.TaskAssignment{
+				{TaskID: taskID, AssigneeID: &first, Reason: models.AssignmentAccepted},
+				{TaskID: taskID, AssigneeID: &second, PreviousAssigneeID: &first, Reason: models.AssignmentAssigned},
+				{TaskID: taskID, PreviousAssigneeID: &second, Reason: models.AssignmentTimedOut},
+			}
+			mockTaskRepo.On("GetTaskByID", ctx, taskID).Return(&models.Task{ID: taskID}, nil).Once()
+			mockTaskRepo.On("GetAssignmentsByTaskID", ctx, taskID).Return(history, nil).Once()
+
+			// Act
+			result, err := svc.GetAssignmentHistory(ctx, taskID)
+
+			// Assert
+			assert.NoError(t, err)
+			assert.Equal(t, history, result)
+			mockTaskRepo.AssertExpectations(t)
+		})
+	})
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rendley/vegshare/backend/internal/task/models"
+)
+
+// workListDateLayout - формат даты листа работ.
+const workListDateLayout = "2006-01-02"
+
+// unplacedName - название группы для задач, юнит которых не привязан к строению.
+const unplacedName = "Без расположения"
+
+func (s *service) GetWorkList(ctx context.Context, assigneeID uuid.UUID, day time.Time) (*models.WorkList, error) {
+	dayStart := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	dayEnd := dayStart.AddDate(0, 0, 1)
+
+	items, err := s.taskRepo.GetTasks(ctx, models.TaskFilter{
+		AssigneeID: &assigneeID,
+		Open:       true,
+		Sort:       models.SortPriority,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// В лист дня попадают задачи без срока и задачи со сроком до конца дня, включая просроченные.
+	var due []models.TaskListItem
+	for _, item := range items {
+		if item.DueAt == nil || item.DueAt.Before(dayEnd) {
+			due = append(due, item)
+		}
+	}
+
+	list := buildWorkList(due)
+	list.AssigneeID = assigneeID
+	list.Date = dayStart.Format(workListDateLayout)
+	return list, nil
+}
+
+// buildWorkList группирует задачи по участкам и строениям и упорядочивает маршрут обхода.
+// Участки и строения идут по самой срочной задаче в них, при равенстве - по названию,
+// чтобы соседние строения одного участка шли подряд. Внутри строения задачи идут
+// по приоритету, сроку и названию юнита. Задачи без расположения идут последними.
+func buildWorkList(items []models.TaskListItem) *models.WorkList {
+	type structureGroup struct {
+		structure models.WorkListStructure
+		rank      int
+	}
+	type parcelGroup struct {
+		parcel     models.WorkListParcel
+		rank       int
+		structures map[uuid.UUID]*structureGroup
+		order      []uuid.UUID
+	}
+
+	list := &models.WorkList{Parcels: []models.WorkListParcel{}}
+	parcels := map[uuid.UUID]*parcelGroup{}
+	var parcelOrder []uuid.UUID
+
+	for _, item := range items {
+		list.TotalTasks++
+		if item.EstimatedMinutes != nil {
+			list.EstimatedMinutes += *item.EstimatedMinutes
+		}
+
+		// uuid.Nil - ключ группы без расположения.
+		parcelKey, structureKey := uuid.Nil, uuid.Nil
+		if item.LandParcelID != nil && item.StructureID != nil {
+			parcelKey, structureKey = *item.LandParcelID, *item.StructureID
+		}
+
+		pg, ok := parcels[parcelKey]
+		if !ok {
+			pg = &parcelGroup{rank: models.PriorityRank(item.Priority), structures: map[uuid.UUID]*structureGroup{}}
+			if parcelKey == uuid.Nil {
+				pg.parcel.Name = unplacedName
+			} else {
+				pg.parcel.LandParcelID = item.LandParcelID
+				pg.parcel.Name = stringValue(item.LandParcelName)
+				pg.parcel.RegionName = stringValue(item.RegionName)
+			}
+			parcels[parcelKey] = pg
+			parcelOrder = append(parcelOrder, parcelKey)
+		}
+
+		sg, ok := pg.structures[structureKey]
+		if !ok {
+			sg = &structureGroup{rank: models.PriorityRank(item.Priority)}
+			if structureKey == uuid.Nil {
+				sg.structure.Name = unplacedName
+			} else {
+				sg.structure.StructureID = item.StructureID
+				sg.structure.Name = stringValue(item.StructureName)
+			}
+			pg.structures[structureKey] = sg
+			pg.order = append(pg.order, structureKey)
+		}
+
+		rank := models.PriorityRank(item.Priority)
+		if rank < pg.rank {
+			pg.rank = rank
+		}
+		if rank < sg.rank {
+			sg.rank = rank
+		}
+		sg.structure.Tasks = append(sg.structure.Tasks, item)
+	}
+
+	sort.SliceStable(parcelOrder, func(i, j int) bool {
+		a, b := parcels[parcelOrder[i]], parcels[parcelOrder[j]]
+		if (parcelOrder[i] == uuid.Nil) != (parcelOrder[j] == uuid.Nil) {
+			return parcelOrder[j] == uuid.Nil
+		}
+		if a.rank != b.rank {
+			return a.rank < b.rank
+		}
+		if a.parcel.RegionName != b.parcel.RegionName {
+			return a.parcel.RegionName < b.parcel.RegionName
+		}
+		return a.parcel.Name < b.parcel.Name
+	})
+
+	for _, parcelKey := range parcelOrder {
+		pg := parcels[parcelKey]
+		sort.SliceStable(pg.order, func(i, j int) bool {
+			a, b := pg.structures[pg.order[i]], pg.structures[pg.order[j]]
+			if a.rank != b.rank {
+				return a.rank < b.rank
+			}
+			return a.structure.Name < b.structure.Name
+		})
+
+		pg.parcel.Structures = make([]models.WorkListStructure, 0, len(pg.order))
+		for _, structureKey := range pg.order {
+			structure := pg.structures[structureKey].structure
+			sortWorkListTasks(structure.Tasks)
+			pg.parcel.Structures = append(pg.parcel.Structures, structure)
+		}
+		list.Parcels = append(list.Parcels, pg.parcel)
+	}
+	return list
+}
+
+// sortWorkListTasks упорядочивает задачи одного строения: приоритет, срок (задачи без срока - в конце), юнит.
+func sortWorkListTasks(tasks []models.TaskListItem) {
+	sort.SliceStable(tasks, func(i, j int) bool {
+		a, b := tasks[i], tasks[j]
+		if ra, rb := models.PriorityRank(a.Priority), models.PriorityRank(b.Priority); ra != rb {
+			return ra < rb
+		}
+		if (a.DueAt == nil) != (b.DueAt == nil) {
+			return b.DueAt == nil
+		}
+		if a.DueAt != nil && !a.DueAt.Equal(*b.DueAt) {
+			return a.DueAt.Before(*b.DueAt)
+		}
+		return a.UnitName < b.UnitName
+	})
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,64 @@
+package sla
+
+import (
+	"context"
+	"time"
+
+	"github.com/rendley/vegshare/backend/internal/task/service"
+	"github.com/rendley/vegshare/backend/pkg/config"
+	"github.com/sirupsen/logrus"
+)
+
+// Значения по умолчанию, если в конфиге секция tasks заполнена не полностью.
+const (
+	defaultPollInterval = time.Minute
+	defaultBatchSize    = 100
+)
+
+// Monitor периодически отмечает просроченными открытые задачи с истекшим сроком
+// и публикует для них событие task.overdue. Каждая задача отмечается один раз,
+// а выборка идет с блокировкой строк, поэтому экземпляров API может быть несколько.
+type Monitor struct {
+	service service.Service
+	logger  *logrus.Logger
+	cfg     config.TasksConfig
+}
+
+// New - конструктор для Monitor.
+func New(s service.Service, logger *logrus.Logger, cfg config.TasksConfig) *Monitor {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = defaultPollInterval
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultBatchSize
+	}
+	return &Monitor{service: s, logger: logger, cfg: cfg}
+}
+
+// Run проверяет сроки задач до отмены контекста.
+func (m *Monitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			n, err := m.service.FlagOverdueTasks(ctx, m.cfg.BatchSize)
+			if err != nil {
+				m.logger.Errorf("ошибка при проверке сроков задач: %v", err)
+				break
+			}
+			if n > 0 {
+				m.logger.Warnf("Flagged %d overdue tasks", n)
+			}
+			if n < m.cfg.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
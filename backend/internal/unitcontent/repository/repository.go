@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"github.com/google/uuid"
 	"github.com/rendley/vegshare/backend/internal/unitcontent/models"
 	"github.com/rendley/vegshare/backend/pkg/database"
 )
@@ -10,6 +11,12 @@ import (
 type Repository interface {
 	// CreateOrUpdate создает новую запись или обновляет существующую, если контент для юнита уже есть.
 	CreateOrUpdate(ctx context.Context, content *models.UnitContent) error
+	// GetByUnit возвращает текущее содержимое юнита.
+	GetByUnit(ctx context.Context, unitID uuid.UUID, unitType string) (*models.UnitContent, error)
+	// UpdateQuantity устанавливает новое количество для содержимого юнита.
+	UpdateQuantity(ctx context.Context, unitID uuid.UUID, unitType string, quantity int) error
+	// Delete удаляет содержимое юнита.
+	Delete(ctx context.Context, unitID uuid.UUID, unitType string) error
 }
 
 // repository - реализация Repository для PostgreSQL.
@@ -34,4 +41,23 @@ func (r *repository) CreateOrUpdate(ctx context.Context, content *models.UnitCon
 
 	_, err := r.db.NamedExecContext(ctx, query, content)
 	return err
-}
\ No newline at end of file
+}
+
+func (r *repository) GetByUnit(ctx context.Context, unitID uuid.UUID, unitType string) (*models.UnitContent, error) {
+	var content models.UnitContent
+	query := `SELECT * FROM unit_contents WHERE unit_id = $1 AND unit_type = $2`
+	err := r.db.GetContext(ctx, &content, query, unitID, unitType)
+	return &content, err
+}
+
+func (r *repository) UpdateQuantity(ctx context.Context, unitID uuid.UUID, unitType string, quantity int) error {
+	query := `UPDATE unit_contents SET quantity = $1, updated_at = NOW() WHERE unit_id = $2 AND unit_type = $3`
+	_, err := r.db.ExecContext(ctx, query, quantity, unitID, unitType)
+	return err
+}
+
+func (r *repository) Delete(ctx context.Context, unitID uuid.UUID, unitType string) error {
+	query := `DELETE FROM unit_contents WHERE unit_id = $1 AND unit_type = $2`
+	_, err := r.db.ExecContext(ctx, query, unitID, unitType)
+	return err
+}
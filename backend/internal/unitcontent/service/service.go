@@ -2,15 +2,25 @@ package service
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
 	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
 	"github.com/rendley/vegshare/backend/internal/unitcontent/models"
 	"github.com/rendley/vegshare/backend/internal/unitcontent/repository"
 	"time"
 )
 
+// ErrNoContent возвращается, когда у юнита нет содержимого.
+var ErrNoContent = errors.New("у юнита нет содержимого")
+
 // Service определяет интерфейс для бизнес-логики управления содержимым юнитов.
 type Service interface {
 	CreateOrUpdateContent(ctx context.Context, unitID, itemID uuid.UUID, unitType string, quantity int) error
+	GetContent(ctx context.Context, unitID uuid.UUID, unitType string) (*models.UnitContent, error)
+	RemoveContent(ctx context.Context, unitID uuid.UUID, unitType string, quantity int) error
+	WithTx(tx *sqlx.Tx) Service
 }
 
 type service struct {
@@ -22,6 +32,11 @@ func NewService(repo repository.Repository) Service {
 	return &service{repo: repo}
 }
 
+// WithTx создает экземпляр сервиса, работающий в контексте транзакции.
+func (s *service) WithTx(tx *sqlx.Tx) Service {
+	return &service{repo: repository.NewRepository(tx)}
+}
+
 // CreateOrUpdateContent - основной метод, который создает или обновляет содержимое юнита.
 // Он не знает о существовании "операций" или "задач", что делает модуль независимым.
 func (s *service) CreateOrUpdateContent(ctx context.Context, unitID, itemID uuid.UUID, unitType string, quantity int) error {
@@ -36,3 +51,32 @@ func (s *service) CreateOrUpdateContent(ctx context.Context, unitID, itemID uuid
 
 	return s.repo.CreateOrUpdate(ctx, content)
 }
+
+// GetContent возвращает текущее содержимое юнита или ErrNoContent, если юнит пуст.
+func (s *service) GetContent(ctx context.Context, unitID uuid.UUID, unitType string) (*models.UnitContent, error) {
+	content, err := s.repo.GetByUnit(ctx, unitID, unitType)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNoContent
+	}
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить содержимое юнита: %w", err)
+	}
+	return content, nil
+}
+
+// RemoveContent уменьшает количество содержимого юнита на quantity.
+// Если остаток становится нулевым, запись о содержимом удаляется полностью.
+func (s *service) RemoveContent(ctx context.Context, unitID uuid.UUID, unitType string, quantity int) error {
+	content, err := s.GetContent(ctx, unitID, unitType)
+	if err != nil {
+		return err
+	}
+	if quantity > content.Quantity {
+		return fmt.Errorf("нельзя убрать %d ед., в юните только %d", quantity, content.Quantity)
+	}
+
+	if remaining := content.Quantity - quantity; remaining > 0 {
+		return s.repo.UpdateQuantity(ctx, unitID, unitType, remaining)
+	}
+	return s.repo.Delete(ctx, unitID, unitType)
+}
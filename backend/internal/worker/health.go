@@ -0,0 +1,38 @@
+package worker
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// HealthHandler возвращает обработчик проверок для оркестратора контейнеров:
+//   - GET /healthz - процесс жив;
+//   - GET /readyz - воркер подписан на очереди, не останавливается и БД доступна.
+func (w *Worker) HealthHandler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/healthz", func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+		rw.Write([]byte("OK"))
+	})
+
+	mux.HandleFunc("/readyz", func(rw http.ResponseWriter, r *http.Request) {
+		if !w.ready.Load() {
+			http.Error(rw, "not consuming", http.StatusServiceUnavailable)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+		defer cancel()
+		if err := w.db.PingContext(ctx); err != nil {
+			http.Error(rw, "database unavailable", http.StatusServiceUnavailable)
+			return
+		}
+
+		rw.WriteHeader(http.StatusOK)
+		rw.Write([]byte("OK"))
+	})
+
+	return mux
+}
@@ -0,0 +1,384 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	catalogRepository "github.com/rendley/vegshare/backend/internal/catalog/repository"
+	catalogService "github.com/rendley/vegshare/backend/internal/catalog/service"
+	coopRepository "github.com/rendley/vegshare/backend/internal/coop/repository"
+	coopService "github.com/rendley/vegshare/backend/internal/coop/service"
+	deadletterRepository "github.com/rendley/vegshare/backend/internal/deadletter/repository"
+	deadletterService "github.com/rendley/vegshare/backend/internal/deadletter/service"
+	"github.com/rendley/vegshare/backend/internal/device/adapter"
+	deviceRepository "github.com/rendley/vegshare/backend/internal/device/repository"
+	deviceService "github.com/rendley/vegshare/backend/internal/device/service"
+	farmRepository "github.com/rendley/vegshare/backend/internal/farm/repository"
+	farmService "github.com/rendley/vegshare/backend/internal/farm/service"
+	harvestRepository "github.com/rendley/vegshare/backend/internal/harvest/repository"
+	harvestService "github.com/rendley/vegshare/backend/internal/harvest/service"
+	notificationRepository "github.com/rendley/vegshare/backend/internal/notification/repository"
+	notificationService "github.com/rendley/vegshare/backend/internal/notification/service"
+	"github.com/rendley/vegshare/backend/internal/operations/actionhandlers"
+	"github.com/rendley/vegshare/backend/internal/operations/actions"
+	operationsModels "github.com/rendley/vegshare/backend/internal/operations/models"
+	"github.com/rendley/vegshare/backend/internal/operations/processor"
+	operationsRepository "github.com/rendley/vegshare/backend/internal/operations/repository"
+	plotRepository "github.com/rendley/vegshare/backend/internal/plot/repository"
+	plotService "github.com/rendley/vegshare/backend/internal/plot/service"
+	taskModels "github.com/rendley/vegshare/backend/internal/task/models"
+	taskRepository "github.com/rendley/vegshare/backend/internal/task/repository"
+	taskService "github.com/rendley/vegshare/backend/internal/task/service"
+	unitcontentRepository "github.com/rendley/vegshare/backend/internal/unitcontent/repository"
+	unitcontentService "github.com/rendley/vegshare/backend/internal/unitcontent/service"
+	userRepository "github.com/rendley/vegshare/backend/internal/user/repository"
+	"github.com/rendley/vegshare/backend/pkg/config"
+	"github.com/rendley/vegshare/backend/pkg/rabbitmq"
+	"github.com/rendley/vegshare/backend/pkg/telegram"
+	"github.com/sirupsen/logrus"
+	"github.com/streadway/amqp"
+)
+
+// Значения по умолчанию, если секция worker в конфиге не заполнена.
+const (
+	defaultConcurrency     = 1
+	defaultMessageTimeout  = 30 * time.Second
+	defaultShutdownTimeout = 30 * time.Second
+	defaultSendTimeout     = 10 * time.Second
+	// deadLetterRetryDelay - пауза перед возвратом сообщения в DLQ, если его не удалось сохранить
+	// из-за временной ошибки (например, недоступна БД), чтобы не крутить его в цикле.
+	deadLetterRetryDelay = 5 * time.Second
+)
+
+// Worker - оркестратор, который превращает операции из очереди в задачи для персонала.
+// Запускается отдельным процессом (cmd/worker) или, с драйвером memory, внутри API.
+type Worker struct {
+	db            *sqlx.DB
+	cfg           config.WorkerConfig
+	client        rabbitmq.ClientInterface
+	proc          *processor.Processor
+	taskSvc       taskService.Service
+	deadletterSvc deadletterService.Service
+	// notifier присылает персоналу новые задачи в Telegram; nil, если бот отключен.
+	notifier notificationService.Service
+	// sendTimeout ограничивает уведомление о новой задаче, которое отправляется до подтверждения сообщения.
+	sendTimeout time.Duration
+	// deadLetterRetryDelay - пауза перед возвратом в DLQ сообщения, которое не удалось сохранить.
+	deadLetterRetryDelay time.Duration
+	// prefetch - сколько неподтвержденных сообщений брокер отдает консьюмеру очереди.
+	prefetch int
+	logger   *logrus.Logger
+
+	actions       rabbitmq.Topology
+	cancellations rabbitmq.Topology
+	taskEvents    rabbitmq.Topology
+
+	// ready - воркер подписан на очереди и не останавливается; используется в /readyz.
+	ready atomic.Bool
+}
+
+// New собирает воркер и все сервисы, которые ему нужны.
+func New(db *sqlx.DB, client rabbitmq.ClientInterface, logger *logrus.Logger, cfg *config.Config) *Worker {
+	opsRepo := operationsRepository.NewRepository(db)
+	taskRepo := taskRepository.NewRepository(db)
+	unitContentRepo := unitcontentRepository.NewRepository(db)
+	plotRepo := plotRepository.NewRepository(db)
+	coopRepo := coopRepository.NewRepository(db)
+	farmRepo := farmRepository.NewRepository(db)
+	catalogRepo := catalogRepository.NewRepository(db)
+	harvestRepo := harvestRepository.NewRepository(db)
+
+	unitContentSvc := unitcontentService.NewService(unitContentRepo)
+	farmSvc := farmService.NewFarmService(farmRepo)
+	plotSvc := plotService.NewService(plotRepo, farmSvc)
+	coopSvc := coopService.NewService(coopRepo, farmSvc)
+	catalogSvc := catalogService.NewService(catalogRepo)
+	harvestSvc := harvestService.NewService(harvestRepo, unitContentSvc)
+	handlers := actionhandlers.NewDefaultRegistry(actionhandlers.Deps{
+		Plots:       plotSvc,
+		Coops:       coopSvc,
+		Catalog:     catalogSvc,
+		UnitContent: unitContentSvc,
+		Harvest:     harvestSvc,
+	})
+	// Воркер задачи не завершает, поэтому хранилище фото выполнения ему не нужно.
+	taskSvc := taskService.NewService(db, taskRepo, opsRepo, userRepository.NewUserRepository(db), handlers, nil, cfg)
+
+	adapters := adapter.NewDefaultRegistry(cfg.Devices.HTTPTimeout, cfg.Devices.SimulatorDelay)
+	deviceSvc := deviceService.NewService(db, deviceRepository.NewRepository(db), adapters, actions.NewDefaultRegistry(), handlers, logger)
+
+	proc := processor.New(opsRepo, taskSvc, handlers, deviceSvc, logger)
+
+	var notifier notificationService.Service
+	sendTimeout := cfg.Telegram.SendTimeout
+	if sendTimeout <= 0 {
+		sendTimeout = defaultSendTimeout
+	}
+	if cfg.Telegram.Enabled {
+		// Без таймаута зависший Bot API держал бы сообщение неподтвержденным до MessageTimeout.
+		client := telegram.NewClient(cfg.Telegram.APIURL, cfg.Telegram.BotToken, &http.Client{Timeout: sendTimeout})
+		notifier = notificationService.NewService(db, notificationRepository.NewRepository(db), taskSvc, client, cfg.Telegram, logger)
+	}
+
+	prefetch := cfg.RabbitMQ.Prefetch
+	if prefetch <= 0 {
+		prefetch = rabbitmq.DefaultPrefetch
+	}
+
+	workerCfg := cfg.Worker
+	if workerCfg.Concurrency <= 0 {
+		workerCfg.Concurrency = defaultConcurrency
+	}
+	if workerCfg.MessageTimeout <= 0 {
+		workerCfg.MessageTimeout = defaultMessageTimeout
+	}
+	if workerCfg.ShutdownTimeout <= 0 {
+		workerCfg.ShutdownTimeout = defaultShutdownTimeout
+	}
+
+	return &Worker{
+		db:            db,
+		cfg:           workerCfg,
+		client:        client,
+		proc:          proc,
+		taskSvc:       taskSvc,
+		deadletterSvc: deadletterService.NewService(db, deadletterRepository.NewRepository(db), opsRepo),
+		notifier:      notifier,
+		sendTimeout:   sendTimeout,
+		logger:        logger,
+		actions:       rabbitmq.NewTopology(cfg.RabbitMQ.Queues["actions"], cfg.RabbitMQ.Retry),
+		cancellations: rabbitmq.NewTopology(cfg.RabbitMQ.Queues["cancellations"], cfg.RabbitMQ.Retry),
+		taskEvents:    rabbitmq.NewTopology(cfg.RabbitMQ.Queues["task_notifications"], cfg.RabbitMQ.Retry),
+
+		deadLetterRetryDelay: deadLetterRetryDelay,
+		prefetch:             prefetch,
+	}
+}
+
+// Run объявляет очереди, подписывается на них и обрабатывает сообщения, пока не отменен ctx.
+// После отмены ctx воркер перестает брать новые сообщения и ждет завершения уже взятых
+// не дольше ShutdownTimeout. Неподтвержденные сообщения брокер вернет в очередь после Close клиента.
+func (w *Worker) Run(ctx context.Context) error {
+	// Брокер не отдаст консьюмеру больше prefetch сообщений, и часть обработчиков простаивала бы.
+	if w.prefetch < w.cfg.Concurrency {
+		return fmt.Errorf("rabbitmq.prefetch (%d) must be at least worker.concurrency (%d)", w.prefetch, w.cfg.Concurrency)
+	}
+
+	// --- Объявление очередей с повторами и DLQ ---
+	for _, t := range []rabbitmq.Topology{w.actions, w.cancellations, w.taskEvents} {
+		if err := w.client.DeclareTopology(t); err != nil {
+			return fmt.Errorf("failed to declare topology for queue '%s': %w", t.Queue, err)
+		}
+	}
+
+	var inFlight sync.WaitGroup
+
+	// --- Запуск консьюмера ---
+	if err := w.consume(ctx, &inFlight, w.actions.Queue, w.handleAction); err != nil {
+		return err
+	}
+
+	// --- Консьюмер событий отмены ---
+	// Задачи отменяются вместе с операцией в API, но если воркер создавал задачу в момент отмены,
+	// она могла появиться уже после этого. Событие отмены закрывает такую гонку.
+	if err := w.consume(ctx, &inFlight, w.cancellations.Queue, w.handleCancellation); err != nil {
+		return err
+	}
+
+	// --- Консьюмер событий задач ---
+	// События о просроченных задачах превращаются в уведомления в Telegram.
+	if err := w.consume(ctx, &inFlight, w.taskEvents.Queue, w.handleTaskEvent); err != nil {
+		return err
+	}
+
+	// --- Консьюмеры DLQ ---
+	// Сообщения, исчерпавшие повторы, сохраняются в БД для разбора администратором.
+	for _, t := range []rabbitmq.Topology{w.actions, w.cancellations, w.taskEvents} {
+		handle := func(ctx context.Context, d amqp.Delivery) { w.handleDeadLetter(ctx, t, d) }
+		if err := w.consume(ctx, &inFlight, t.DeadLetterQueue(), handle); err != nil {
+			return err
+		}
+	}
+
+	w.ready.Store(true)
+	w.logger.Printf(" [*] Waiting for messages with %d handler(s) per queue. To exit press CTRL+C", w.cfg.Concurrency)
+	<-ctx.Done()
+	w.ready.Store(false)
+
+	w.logger.Info("Shutting down: waiting for in-flight messages...")
+	drained := make(chan struct{})
+	go func() {
+		inFlight.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		w.logger.Info("All in-flight messages processed")
+		return nil
+	case <-time.After(w.cfg.ShutdownTimeout):
+		return fmt.Errorf("shutdown timed out after %s with messages still in flight", w.cfg.ShutdownTimeout)
+	}
+}
+
+// consume подписывается на очередь и запускает Concurrency обработчиков.
+// Каждое сообщение обрабатывается с собственным таймаутом, не зависящим от ctx,
+// чтобы остановка воркера не прерывала уже начатую обработку.
+func (w *Worker) consume(ctx context.Context, inFlight *sync.WaitGroup, queue string, handle func(context.Context, amqp.Delivery)) error {
+	msgs, err := w.client.Consume(queue)
+	if err != nil {
+		return fmt.Errorf("failed to register a consumer for queue '%s': %w", queue, err)
+	}
+
+	for i := 0; i < w.cfg.Concurrency; i++ {
+		inFlight.Add(1)
+		go func() {
+			defer inFlight.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case d, ok := <-msgs:
+					if !ok {
+						return
+					}
+					if ctx.Err() != nil {
+						// Сообщение получено уже после сигнала остановки - возвращаем его в очередь.
+						d.Nack(false, true)
+						return
+					}
+
+					msgCtx, cancel := context.WithTimeout(context.Background(), w.cfg.MessageTimeout)
+					handle(msgCtx, d)
+					cancel()
+				}
+			}
+		}()
+	}
+	return nil
+}
+
+func (w *Worker) handleAction(ctx context.Context, d amqp.Delivery) {
+	w.logger.Infof("Received a message: %s", d.Body)
+
+	if err := w.proc.Handle(ctx, d.Body); err != nil {
+		w.logger.Errorf("Error processing message: %s", err)
+		// Битые сообщения сразу уходят в DLQ, остальные повторяются с задержкой -
+		// повторная обработка безопасна благодаря идемпотентности Processor.
+		w.reject(w.actions, d, err, errors.Is(err, processor.ErrInvalidMessage))
+		return
+	}
+
+	w.notifyTaskCreated(ctx, d.Body)
+
+	d.Ack(false)
+}
+
+// notifyTaskCreated присылает созданную задачу персоналу в Telegram. Уведомление не критично:
+// ошибка только логируется, а операция считается обработанной.
+func (w *Worker) notifyTaskCreated(ctx context.Context, body []byte) {
+	if w.notifier == nil {
+		return
+	}
+	var opLog operationsModels.OperationLog
+	if err := json.Unmarshal(body, &opLog); err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, w.sendTimeout)
+	defer cancel()
+	if err := w.notifier.NotifyTaskCreated(ctx, opLog.ID); err != nil {
+		w.logger.Errorf("Error sending Telegram notification for operation %s: %s", opLog.ID, err)
+	}
+}
+
+func (w *Worker) handleCancellation(ctx context.Context, d amqp.Delivery) {
+	var opLog operationsModels.OperationLog
+	if err := json.Unmarshal(d.Body, &opLog); err != nil {
+		w.logger.Errorf("Error unmarshalling cancellation: %s", err)
+		w.reject(w.cancellations, d, err, true)
+		return
+	}
+
+	if err := w.taskSvc.CancelTasksForOperation(ctx, opLog.ID, opLog.UserID); err != nil {
+		w.logger.Errorf("Error cancelling tasks for operation %s: %s", opLog.ID, err)
+		w.reject(w.cancellations, d, err, false)
+		return
+	}
+
+	w.logger.Infof("Cancelled tasks for operation %s.", opLog.ID)
+	d.Ack(false)
+}
+
+func (w *Worker) handleTaskEvent(ctx context.Context, d amqp.Delivery) {
+	var event taskModels.TaskEvent
+	if err := json.Unmarshal(d.Body, &event); err != nil {
+		w.logger.Errorf("Error unmarshalling task event: %s", err)
+		w.reject(w.taskEvents, d, err, true)
+		return
+	}
+
+	if w.notifier == nil {
+		// Других потребителей у событий задач нет: без бота событие просто подтверждается.
+		d.Ack(false)
+		return
+	}
+	if err := w.notifier.NotifyTaskEvent(ctx, event); err != nil {
+		w.logger.Errorf("Error sending Telegram notification for '%s' of task %s: %s", event.Type, event.TaskID, err)
+		w.reject(w.taskEvents, d, err, false)
+		return
+	}
+
+	d.Ack(false)
+}
+
+func (w *Worker) handleDeadLetter(ctx context.Context, t rabbitmq.Topology, d amqp.Delivery) {
+	queue, attempts, lastError := rabbitmq.DeadLetterInfo(d)
+	if queue == "" {
+		queue = t.Queue
+	}
+	dl, err := w.deadletterSvc.Record(ctx, queue, string(d.Body), lastError, attempts)
+	if errors.Is(err, deadletterService.ErrUnrecordable) {
+		// Возврат в DLQ зациклил бы сообщение, которое не записывается из-за него самого,
+		// поэтому оно подтверждается, а тело остается в логе для ручного разбора.
+		w.logger.Errorf("Error recording dead letter from '%s', message dropped: %s; body: %q", t.DeadLetterQueue(), err, d.Body)
+		d.Ack(false)
+		return
+	}
+	if err != nil {
+		// Временная ошибка: сообщение возвращается в DLQ после паузы, чтобы не потерять его.
+		w.logger.Errorf("Error recording dead letter from '%s', requeueing in %s: %s", t.DeadLetterQueue(), w.deadLetterRetryDelay, err)
+		select {
+		case <-time.After(w.deadLetterRetryDelay):
+		case <-ctx.Done():
+		}
+		d.Nack(false, true)
+		return
+	}
+
+	w.logger.Warnf("Message from queue '%s' dead-lettered after %d attempt(s) as %s: %s", queue, attempts, dl.ID, lastError)
+	d.Ack(false)
+}
+
+// reject отправляет необработанное сообщение на повтор (или сразу в DLQ, если invalid) и подтверждает исходное.
+// Если переотправить не удалось, сообщение возвращается в рабочую очередь, чтобы не потерять его.
+func (w *Worker) reject(t rabbitmq.Topology, d amqp.Delivery, cause error, invalid bool) {
+	var err error
+	if invalid {
+		err = w.client.DeadLetter(t, d, cause)
+	} else {
+		err = w.client.Retry(t, d, cause)
+	}
+	if err != nil {
+		w.logger.Errorf("Error republishing message from '%s': %s", t.Queue, err)
+		d.Nack(false, true)
+		return
+	}
+	d.Ack(false)
+}
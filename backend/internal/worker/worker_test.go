@@ -0,0 +1,258 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	deadletterModels "github.com/rendley/vegshare/backend/internal/deadletter/models"
+	deadletterService "github.com/rendley/vegshare/backend/internal/deadletter/service"
+	notificationService "github.com/rendley/vegshare/backend/internal/notification/service"
+	operationsModels "github.com/rendley/vegshare/backend/internal/operations/models"
+	"github.com/rendley/vegshare/backend/pkg/config"
+	"github.com/rendley/vegshare/backend/pkg/rabbitmq"
+	"github.com/sirupsen/logrus"
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestWorker(client rabbitmq.ClientInterface, concurrency int) *Worker {
+	return &Worker{
+		cfg: config.WorkerConfig{
+			Concurrency:     concurrency,
+			MessageTimeout:  time.Second,
+			ShutdownTimeout: time.Second,
+		},
+		client:   client,
+		logger:   logrus.New(),
+		prefetch: concurrency,
+	}
+}
+
+// blockingNotifier ждет, пока не истечет контекст уведомления, как зависший Bot API.
+type blockingNotifier struct {
+	notificationService.Service
+	done chan error
+}
+
+func (n *blockingNotifier) NotifyTaskCreated(ctx context.Context, operationID uuid.UUID) error {
+	<-ctx.Done()
+	n.done <- ctx.Err()
+	return ctx.Err()
+}
+
+func TestWorkerNotifyTaskCreated(t *testing.T) {
+	t.Run("Notification is cut off after sendTimeout", func(t *testing.T) {
+		w := newTestWorker(nil, 1)
+		notifier := &blockingNotifier{done: make(chan error, 1)}
+		w.notifier = notifier
+		w.sendTimeout = 10 * time.Millisecond
+		body, err := json.Marshal(operationsModels.OperationLog{ID: uuid.New()})
+		require.NoError(t, err)
+
+		returned := make(chan struct{})
+		go func() {
+			w.notifyTaskCreated(context.Background(), body)
+			close(returned)
+		}()
+
+		select {
+		case <-returned:
+			assert.ErrorIs(t, <-notifier.done, context.DeadlineExceeded)
+		case <-time.After(time.Second):
+			t.Fatal("уведомление задержало подтверждение сообщения")
+		}
+	})
+}
+
+func TestWorkerRun(t *testing.T) {
+	t.Run("Prefetch below Concurrency fails at startup", func(t *testing.T) {
+		client := rabbitmq.NewMemoryClient(2)
+		defer client.Close()
+		w := newTestWorker(client, 4)
+		w.prefetch = 2
+
+		err := w.Run(context.Background())
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "rabbitmq.prefetch (2) must be at least worker.concurrency (4)")
+		assert.False(t, w.ready.Load())
+	})
+}
+
+func TestWorkerConsume(t *testing.T) {
+	t.Run("Messages are handled in parallel up to Concurrency", func(t *testing.T) {
+		client := rabbitmq.NewMemoryClient(10)
+		defer client.Close()
+		w := newTestWorker(client, 3)
+
+		var active, peak int32
+		release := make(chan struct{})
+		var handled sync.WaitGroup
+		handled.Add(5)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		var inFlight sync.WaitGroup
+		require.NoError(t, w.consume(ctx, &inFlight, "q", func(ctx context.Context, d amqp.Delivery) {
+			n := atomic.AddInt32(&active, 1)
+			for {
+				p := atomic.LoadInt32(&peak)
+				if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+					break
+				}
+			}
+			<-release
+			atomic.AddInt32(&active, -1)
+			d.Ack(false)
+			handled.Done()
+		}))
+
+		for i := 0; i < 5; i++ {
+			require.NoError(t, client.Publish("q", "msg"))
+		}
+		require.Eventually(t, func() bool { return atomic.LoadInt32(&active) == 3 }, time.Second, 5*time.Millisecond)
+		close(release)
+		handled.Wait()
+
+		assert.Equal(t, int32(3), atomic.LoadInt32(&peak))
+	})
+
+	t.Run("Shutdown waits for in-flight message and stops taking new ones", func(t *testing.T) {
+		client := rabbitmq.NewMemoryClient(10)
+		defer client.Close()
+		w := newTestWorker(client, 1)
+
+		started := make(chan struct{})
+		var handled int32
+		ctx, cancel := context.WithCancel(context.Background())
+		var inFlight sync.WaitGroup
+		require.NoError(t, w.consume(ctx, &inFlight, "q", func(msgCtx context.Context, d amqp.Delivery) {
+			if atomic.AddInt32(&handled, 1) == 1 {
+				close(started)
+				// Остановка воркера не должна отменять контекст сообщения.
+				time.Sleep(50 * time.Millisecond)
+				assert.NoError(t, msgCtx.Err())
+			}
+			d.Ack(false)
+		}))
+
+		require.NoError(t, client.Publish("q", "first"))
+		<-started
+		cancel()
+		require.NoError(t, client.Publish("q", "second"))
+		inFlight.Wait()
+
+		assert.Equal(t, int32(1), atomic.LoadInt32(&handled))
+	})
+
+	t.Run("Message context expires after MessageTimeout", func(t *testing.T) {
+		client := rabbitmq.NewMemoryClient(10)
+		defer client.Close()
+		w := newTestWorker(client, 1)
+		w.cfg.MessageTimeout = 10 * time.Millisecond
+
+		done := make(chan error, 1)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		var inFlight sync.WaitGroup
+		require.NoError(t, w.consume(ctx, &inFlight, "q", func(msgCtx context.Context, d amqp.Delivery) {
+			<-msgCtx.Done()
+			done <- msgCtx.Err()
+			d.Ack(false)
+		}))
+
+		require.NoError(t, client.Publish("q", "slow"))
+		select {
+		case err := <-done:
+			assert.ErrorIs(t, err, context.DeadlineExceeded)
+		case <-time.After(time.Second):
+			t.Fatal("таймаут сообщения не сработал")
+		}
+	})
+}
+
+// failingDeadLetters не может сохранить ни одно сообщение и возвращает err.
+type failingDeadLetters struct {
+	deadletterService.Service
+	err error
+}
+
+func (s *failingDeadLetters) Record(ctx context.Context, queue, payload, lastError string, attempts int) (*deadletterModels.DeadLetter, error) {
+	return nil, s.err
+}
+
+// recordingAcknowledger запоминает, как было подтверждено сообщение.
+type recordingAcknowledger struct {
+	acked   bool
+	requeue *bool
+}
+
+func (a *recordingAcknowledger) Ack(tag uint64, multiple bool) error {
+	a.acked = true
+	return nil
+}
+
+func (a *recordingAcknowledger) Nack(tag uint64, multiple, requeue bool) error {
+	a.requeue = &requeue
+	return nil
+}
+
+func (a *recordingAcknowledger) Reject(tag uint64, requeue bool) error {
+	a.requeue = &requeue
+	return nil
+}
+
+func TestWorkerHandleDeadLetter(t *testing.T) {
+	topology := rabbitmq.NewTopology("actions_queue_test", config.RetryConfig{})
+
+	t.Run("Transient failure requeues the message after a delay", func(t *testing.T) {
+		w := newTestWorker(nil, 1)
+		w.deadletterSvc = &failingDeadLetters{err: errors.New("connection refused")}
+		w.deadLetterRetryDelay = 20 * time.Millisecond
+		ack := &recordingAcknowledger{}
+
+		started := time.Now()
+		w.handleDeadLetter(context.Background(), topology, amqp.Delivery{Acknowledger: ack, Body: []byte(`{}`)})
+
+		assert.GreaterOrEqual(t, time.Since(started), w.deadLetterRetryDelay)
+		assert.False(t, ack.acked)
+		if assert.NotNil(t, ack.requeue) {
+			assert.True(t, *ack.requeue)
+		}
+	})
+
+	t.Run("Shutdown cuts the delay short", func(t *testing.T) {
+		w := newTestWorker(nil, 1)
+		w.deadletterSvc = &failingDeadLetters{err: errors.New("connection refused")}
+		w.deadLetterRetryDelay = time.Hour
+		ack := &recordingAcknowledger{}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		w.handleDeadLetter(ctx, topology, amqp.Delivery{Acknowledger: ack, Body: []byte(`{}`)})
+
+		if assert.NotNil(t, ack.requeue) {
+			assert.True(t, *ack.requeue)
+		}
+	})
+
+	t.Run("Unrecordable message is dropped", func(t *testing.T) {
+		w := newTestWorker(nil, 1)
+		w.deadletterSvc = &failingDeadLetters{err: fmt.Errorf("%w: текст не является корректной строкой UTF-8", deadletterService.ErrUnrecordable)}
+		w.deadLetterRetryDelay = time.Hour
+		ack := &recordingAcknowledger{}
+
+		w.handleDeadLetter(context.Background(), topology, amqp.Delivery{Acknowledger: ack, Body: []byte("\xff")})
+
+		assert.True(t, ack.acked)
+		assert.Nil(t, ack.requeue)
+	})
+}
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS coops;
\ No newline at end of file
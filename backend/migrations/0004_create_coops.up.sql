@@ -0,0 +1,12 @@
+-- Загоны для животных (второй тип арендуемых юнитов после грядок)
+CREATE TABLE coops (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    structure_id UUID NOT NULL REFERENCES structures(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    capacity INT NOT NULL DEFAULT 1,
+    animal_type VARCHAR(100) NOT NULL,
+    status VARCHAR(50) NOT NULL DEFAULT 'available',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX ON coops (structure_id);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS leases_status_idx;
+DROP INDEX IF EXISTS leases_one_active_per_unit;
+ALTER TABLE leases ADD CONSTRAINT leases_unit_id_user_id_status_key UNIQUE (unit_id, user_id, status);
@@ -0,0 +1,5 @@
+-- Ограничение UNIQUE(unit_id, user_id, status) не позволяло повторно арендовать юнит тем же
+-- пользователем после завершения аренды. Вместо него гарантируем не более одной активной аренды на юнит.
+ALTER TABLE leases DROP CONSTRAINT IF EXISTS leases_unit_id_user_id_status_key;
+CREATE UNIQUE INDEX leases_one_active_per_unit ON leases (unit_id, unit_type) WHERE status = 'active';
+CREATE INDEX ON leases (status);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS harvests;
\ No newline at end of file
@@ -0,0 +1,15 @@
+-- Журнал собранного урожая
+CREATE TABLE harvests (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    operation_id UUID NOT NULL REFERENCES operation_log(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    unit_id UUID NOT NULL,
+    unit_type VARCHAR(50) NOT NULL,
+    item_id UUID NOT NULL REFERENCES catalog_items(id) ON DELETE RESTRICT,
+    count INT NOT NULL DEFAULT 0,
+    weight_grams NUMERIC(10, 2) NOT NULL DEFAULT 0,
+    harvested_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE(operation_id)
+);
+CREATE INDEX ON harvests (user_id);
+CREATE INDEX ON harvests (unit_id, unit_type);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS delivery_bookings;
+DROP TABLE IF EXISTS delivery_slots;
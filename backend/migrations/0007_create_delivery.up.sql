@@ -0,0 +1,30 @@
+-- Слоты выдачи урожая: самовывоз с земельного участка или курьерская доставка с него
+CREATE TABLE delivery_slots (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    land_parcel_id UUID NOT NULL REFERENCES land_parcels(id) ON DELETE CASCADE,
+    method VARCHAR(50) NOT NULL,
+    starts_at TIMESTAMPTZ NOT NULL,
+    ends_at TIMESTAMPTZ NOT NULL,
+    capacity INT NOT NULL CHECK (capacity > 0),
+    booked INT NOT NULL DEFAULT 0 CHECK (booked >= 0 AND booked <= capacity),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (ends_at > starts_at)
+);
+CREATE INDEX ON delivery_slots (land_parcel_id, starts_at);
+
+-- Бронирования слотов пользователями под конкретную запись урожая
+CREATE TABLE delivery_bookings (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    slot_id UUID NOT NULL REFERENCES delivery_slots(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    harvest_id UUID NOT NULL REFERENCES harvests(id) ON DELETE CASCADE,
+    address TEXT,
+    status VARCHAR(50) NOT NULL,
+    operation_id UUID REFERENCES operation_log(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX ON delivery_bookings (user_id);
+-- Одну запись урожая можно забронировать только в одном активном слоте
+CREATE UNIQUE INDEX delivery_bookings_single_active_per_harvest ON delivery_bookings (harvest_id) WHERE status = 'booked';
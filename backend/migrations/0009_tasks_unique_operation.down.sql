@@ -0,0 +1 @@
+DROP INDEX IF EXISTS tasks_operation_id_key;
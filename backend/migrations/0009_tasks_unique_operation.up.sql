@@ -0,0 +1,8 @@
+-- Одна задача на операцию: повторная доставка сообщения воркеру не должна создавать дубликаты.
+-- Перед созданием индекса оставляем только самую раннюю задачу для каждой операции.
+DELETE FROM tasks t
+USING tasks older
+WHERE t.operation_id = older.operation_id
+  AND (t.created_at, t.id) > (older.created_at, older.id);
+
+CREATE UNIQUE INDEX tasks_operation_id_key ON tasks (operation_id);
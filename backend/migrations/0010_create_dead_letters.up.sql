@@ -0,0 +1,13 @@
+-- Сообщения, которые воркер не смог обработать после всех повторов (содержимое DLQ)
+CREATE TABLE dead_letters (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    queue VARCHAR(255) NOT NULL,
+    operation_id UUID REFERENCES operation_log(id) ON DELETE SET NULL,
+    payload TEXT NOT NULL,
+    last_error TEXT NOT NULL DEFAULT '',
+    attempts INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    redriven_at TIMESTAMPTZ
+);
+CREATE INDEX ON dead_letters (created_at DESC);
+CREATE INDEX ON dead_letters (operation_id);
@@ -0,0 +1,3 @@
+ALTER TABLE tasks
+    DROP COLUMN IF EXISTS estimated_minutes,
+    DROP COLUMN IF EXISTS required_skills;
@@ -0,0 +1,4 @@
+-- Навыки исполнителя и оценка длительности, которые задает обработчик типа действия.
+ALTER TABLE tasks
+    ADD COLUMN required_skills TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN estimated_minutes INT;
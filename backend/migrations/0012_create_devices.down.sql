@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS device_commands;
+DROP TABLE IF EXISTS devices;
@@ -0,0 +1,27 @@
+-- Устройства (контроллеры полива и т.п.), которые выполняют действия над юнитом без участия персонала.
+CREATE TABLE devices (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(255) NOT NULL,
+    unit_id UUID NOT NULL,
+    unit_type VARCHAR(50) NOT NULL,
+    adapter VARCHAR(50) NOT NULL,            -- 'http', 'simulator'
+    endpoint VARCHAR(1024) NOT NULL DEFAULT '',
+    actions TEXT[] NOT NULL DEFAULT '{}',     -- типы действий, которые устройство выполняет
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX ON devices (unit_id, unit_type);
+
+-- Команды устройствам. Одна команда на операцию: повторная доставка сообщения
+-- не должна второй раз поливать грядку.
+CREATE TABLE device_commands (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    operation_id UUID NOT NULL UNIQUE REFERENCES operation_log(id) ON DELETE CASCADE,
+    device_id UUID NOT NULL REFERENCES devices(id) ON DELETE CASCADE,
+    status VARCHAR(50) NOT NULL DEFAULT 'sent', -- 'sent', 'completed', 'failed'
+    message TEXT NOT NULL DEFAULT '',
+    sent_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMPTZ
+);
+CREATE INDEX ON device_commands (device_id);
@@ -0,0 +1,2 @@
+ALTER TABLE operation_log DROP COLUMN IF EXISTS schedule_id;
+DROP TABLE IF EXISTS operation_schedules;
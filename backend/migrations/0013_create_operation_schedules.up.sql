@@ -0,0 +1,27 @@
+-- Расписания повторяющихся действий арендатора (например, полив каждые 2 дня).
+-- Планировщик превращает наступившие срабатывания в записи operation_log.
+CREATE TABLE operation_schedules (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    unit_id UUID NOT NULL,
+    unit_type VARCHAR(50) NOT NULL,
+    action_type VARCHAR(50) NOT NULL,
+    parameters JSONB,
+    cron VARCHAR(255),                        -- выражение crontab из 5 полей
+    interval_days INT CHECK (interval_days > 0), -- или "каждые N дней" начиная со starts_at
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    starts_at TIMESTAMPTZ NOT NULL,
+    ends_at TIMESTAMPTZ,
+    status VARCHAR(20) NOT NULL DEFAULT 'active', -- 'active', 'paused', 'finished'
+    next_run_at TIMESTAMPTZ,                  -- NULL, если срабатываний больше не будет
+    last_run_at TIMESTAMPTZ,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK ((cron IS NULL) <> (interval_days IS NULL))
+);
+CREATE INDEX ON operation_schedules (user_id);
+CREATE INDEX ON operation_schedules (next_run_at) WHERE status = 'active';
+
+ALTER TABLE operation_log
+    ADD COLUMN schedule_id UUID REFERENCES operation_schedules(id) ON DELETE SET NULL;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS operation_log_unit_history_idx;
+DROP INDEX IF EXISTS operation_log_user_history_idx;
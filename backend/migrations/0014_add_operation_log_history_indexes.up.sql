@@ -0,0 +1,3 @@
+-- Индексы для истории операций с курсорной пагинацией по (created_at, id).
+CREATE INDEX operation_log_user_history_idx ON operation_log (user_id, created_at DESC, id DESC);
+CREATE INDEX operation_log_unit_history_idx ON operation_log (unit_id, created_at DESC, id DESC);
@@ -0,0 +1 @@
+ALTER TABLE leases DROP COLUMN IF EXISTS tariff;
@@ -0,0 +1,2 @@
+-- Тариф аренды. От него зависят лимиты операций (quotas в конфиге).
+ALTER TABLE leases ADD COLUMN tariff VARCHAR(50) NOT NULL DEFAULT 'standard';
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS task_assignments;
+DROP INDEX IF EXISTS tasks_claimed_at_idx;
+ALTER TABLE tasks DROP COLUMN IF EXISTS claimed_at;
@@ -0,0 +1,16 @@
+-- Когда текущий исполнитель взял задачу в работу; по нему задача возвращается в пул по таймауту.
+ALTER TABLE tasks ADD COLUMN claimed_at TIMESTAMPTZ;
+UPDATE tasks SET claimed_at = updated_at WHERE status = 'in_progress';
+CREATE INDEX tasks_claimed_at_idx ON tasks (claimed_at) WHERE status = 'in_progress';
+
+-- История назначений задачи: кто взял, кто назначил или снял исполнителя, возвраты в пул по таймауту.
+CREATE TABLE task_assignments (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    assignee_id UUID REFERENCES users(id) ON DELETE SET NULL,          -- NULL - задача вернулась в пул
+    previous_assignee_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    changed_by UUID REFERENCES users(id) ON DELETE SET NULL,           -- NULL - изменение сделала система
+    reason VARCHAR(50) NOT NULL,                                       -- 'accepted', 'assigned', 'unassigned', 'timed_out'
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX ON task_assignments (task_id, created_at);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS tasks_sla_idx;
+ALTER TABLE tasks
+    DROP COLUMN IF EXISTS overdue_at,
+    DROP COLUMN IF EXISTS due_at,
+    DROP COLUMN IF EXISTS priority;
@@ -0,0 +1,8 @@
+-- Приоритет и срок выполнения задачи по SLA типа действия.
+ALTER TABLE tasks
+    ADD COLUMN priority VARCHAR(20) NOT NULL DEFAULT 'normal', -- 'low', 'normal', 'high', 'urgent'
+    ADD COLUMN due_at TIMESTAMPTZ,
+    ADD COLUMN overdue_at TIMESTAMPTZ;                       -- когда SLA-монитор отметил задачу просроченной
+
+-- SLA-монитор ищет открытые задачи с истекшим сроком, еще не отмеченные просроченными.
+CREATE INDEX tasks_sla_idx ON tasks (due_at) WHERE status IN ('new', 'in_progress') AND overdue_at IS NULL;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS task_attachments;
+ALTER TABLE tasks DROP COLUMN IF EXISTS completion_note;
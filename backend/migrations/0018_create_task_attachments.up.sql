@@ -0,0 +1,15 @@
+-- Заметка исполнителя о выполнении задачи.
+ALTER TABLE tasks ADD COLUMN completion_note TEXT;
+
+-- Фото, подтверждающие выполнение задачи. Сами файлы лежат в хранилище (storage) по storage_key.
+CREATE TABLE task_attachments (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    storage_key VARCHAR(255) NOT NULL,
+    file_name VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    uploaded_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX ON task_attachments (task_id, created_at);
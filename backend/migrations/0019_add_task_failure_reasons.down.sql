@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS operation_log_retry_of_key;
+ALTER TABLE operation_log DROP COLUMN IF EXISTS retry_of;
+ALTER TABLE tasks DROP COLUMN IF EXISTS failure_comment;
+ALTER TABLE tasks DROP COLUMN IF EXISTS failure_reason;
@@ -0,0 +1,9 @@
+-- Причина невыполнения задачи (weather, out_of_stock, plot_issue) и комментарий исполнителя.
+ALTER TABLE tasks
+    ADD COLUMN failure_reason VARCHAR(50),
+    ADD COLUMN failure_comment TEXT;
+
+-- Повтор проваленной операции ссылается на исходную. У операции может быть только один повтор.
+ALTER TABLE operation_log
+    ADD COLUMN retry_of UUID REFERENCES operation_log(id) ON DELETE SET NULL;
+CREATE UNIQUE INDEX operation_log_retry_of_key ON operation_log (retry_of) WHERE retry_of IS NOT NULL;
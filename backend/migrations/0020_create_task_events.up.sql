@@ -0,0 +1,15 @@
+-- История статусов задачи: кто, когда и из какого статуса в какой перевел задачу.
+CREATE TABLE task_events (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    actor_id UUID REFERENCES users(id) ON DELETE SET NULL, -- NULL - переход сделала система
+    from_status VARCHAR(50),                               -- NULL - задача создана
+    to_status VARCHAR(50) NOT NULL,
+    comment TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX ON task_events (task_id, created_at);
+
+-- У существующих задач история начинается с записи о создании.
+INSERT INTO task_events (task_id, to_status, created_at)
+SELECT id, 'new', created_at FROM tasks;
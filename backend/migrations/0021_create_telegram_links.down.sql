@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS telegram_task_messages;
+DROP TABLE IF EXISTS telegram_links;
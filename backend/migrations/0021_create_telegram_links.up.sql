@@ -0,0 +1,22 @@
+-- Привязка сотрудников к чатам Telegram-бота. Пока чат не привязан, chat_id пуст,
+-- а link_code - одноразовый код, который сотрудник отправляет боту командой /start.
+-- telegram_user_id - пользователь Telegram, отправивший код: нажатия кнопок проверяются по нему.
+CREATE TABLE telegram_links (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    chat_id BIGINT UNIQUE,
+    telegram_user_id BIGINT UNIQUE,
+    link_code VARCHAR(64) UNIQUE,
+    link_code_expires_at TIMESTAMPTZ,
+    linked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Отправленные уведомления о задачах: защищают от повторной отправки при повторной доставке
+-- сообщения воркеру и позволяют поменять кнопки под сообщением после нажатия.
+CREATE TABLE telegram_task_messages (
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    chat_id BIGINT NOT NULL,
+    message_id BIGINT, -- NULL - отправка еще не завершена
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (task_id, chat_id)
+);
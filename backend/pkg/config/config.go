@@ -12,12 +12,20 @@ import (
 
 // Config - структура для хранение всех конфигов.
 type Config struct {
-	HTTP     HTTPConfig     `yaml:"http"`
-	Database DatabaseConfig `yaml:"database"`
-	JWT      JWTConfig      `yaml:"jwt"`
-	Redis    RedisConfig    `yaml:"redis"`
-	RabbitMQ RabbitMQConfig `yaml:"rabbitmq"`
-	MediaMTX MediaMTXConfig `yaml:"mediamtx"`
+	HTTP      HTTPConfig      `yaml:"http"`
+	Database  DatabaseConfig  `yaml:"database"`
+	JWT       JWTConfig       `yaml:"jwt"`
+	Redis     RedisConfig     `yaml:"redis"`
+	RabbitMQ  RabbitMQConfig  `yaml:"rabbitmq"`
+	MediaMTX  MediaMTXConfig  `yaml:"mediamtx"`
+	Outbox    OutboxConfig    `yaml:"outbox"`
+	Worker    WorkerConfig    `yaml:"worker"`
+	Devices   DevicesConfig   `yaml:"devices"`
+	Scheduler SchedulerConfig `yaml:"scheduler"`
+	Quotas    QuotasConfig    `yaml:"quotas"`
+	Tasks     TasksConfig     `yaml:"tasks"`
+	Storage   StorageConfig   `yaml:"storage"`
+	Telegram  TelegramConfig  `yaml:"telegram"`
 }
 
 type HTTPConfig struct {
@@ -46,8 +54,112 @@ type RedisConfig struct {
 }
 
 type RabbitMQConfig struct {
+	// Driver - "amqp" (по умолчанию) или "memory": шина в памяти, API, relay и воркер в одном процессе.
+	Driver string            `yaml:"driver"`
 	URL    string            `yaml:"url"`
 	Queues map[string]string `yaml:"queues"`
+	Retry  RetryConfig       `yaml:"retry"`
+	// ManagementURL - адрес management API с логином и паролем; через него рабочим очередям
+	// ставится политика DLX. Пусто - политика не ставится.
+	ManagementURL string `yaml:"management_url"`
+	// PoolSize - число каналов для публикации, т.е. максимум одновременных Publish.
+	PoolSize int `yaml:"pool_size"`
+	// Prefetch - сколько неподтвержденных сообщений брокер отдает одному консьюмеру.
+	Prefetch int `yaml:"prefetch"`
+	// ConfirmTimeout - сколько Publish ждет подтверждения от брокера.
+	ConfirmTimeout     time.Duration `yaml:"confirm_timeout"`
+	ReconnectBaseDelay time.Duration `yaml:"reconnect_base_delay"`
+	ReconnectMaxDelay  time.Duration `yaml:"reconnect_max_delay"`
+}
+
+// RetryConfig - настройки отложенных повторов обработки сообщений и перевода в DLQ.
+type RetryConfig struct {
+	MaxAttempts int           `yaml:"max_attempts"`
+	BaseDelay   time.Duration `yaml:"base_delay"`
+	MaxDelay    time.Duration `yaml:"max_delay"`
+}
+
+// OutboxConfig - настройки relay, публикующего сообщения из таблицы outbox в RabbitMQ.
+type OutboxConfig struct {
+	PollInterval   time.Duration `yaml:"poll_interval"`
+	BatchSize      int           `yaml:"batch_size"`
+	RetryBaseDelay time.Duration `yaml:"retry_base_delay"`
+	RetryMaxDelay  time.Duration `yaml:"retry_max_delay"`
+}
+
+// WorkerConfig - настройки параллельной обработки и остановки воркера.
+type WorkerConfig struct {
+	// Concurrency - число параллельных обработчиков на очередь; при rabbitmq.prefetch меньше него воркер не запустится.
+	Concurrency int `yaml:"concurrency"`
+	// MessageTimeout - сколько может обрабатываться одно сообщение.
+	MessageTimeout time.Duration `yaml:"message_timeout"`
+	// ShutdownTimeout - сколько при остановке ждать завершения сообщений, взятых в обработку.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	// HealthAddr - адрес HTTP-сервера с /healthz и /readyz; пустой - сервер не запускается.
+	HealthAddr string `yaml:"health_addr"`
+}
+
+// SchedulerConfig - настройки планировщика, создающего операции по расписаниям.
+type SchedulerConfig struct {
+	PollInterval time.Duration `yaml:"poll_interval"`
+	BatchSize    int           `yaml:"batch_size"`
+}
+
+// TasksConfig - настройки возврата в пул задач, которые слишком долго в работе.
+type TasksConfig struct {
+	// ClaimTimeout - сколько задача может быть в работе у исполнителя; 0 - задачи не возвращаются.
+	ClaimTimeout time.Duration `yaml:"claim_timeout"`
+	PollInterval time.Duration `yaml:"poll_interval"`
+	BatchSize    int           `yaml:"batch_size"`
+}
+
+// StorageConfig - хранилище файлов (фото выполнения задач).
+type StorageConfig struct {
+	// Driver - "local" (по умолчанию): файлы в каталоге LocalDir.
+	Driver   string `yaml:"driver"`
+	LocalDir string `yaml:"local_dir"`
+}
+
+// TelegramConfig - бот, который присылает персоналу новые задачи.
+type TelegramConfig struct {
+	Enabled  bool   `yaml:"enabled"`
+	BotToken string `yaml:"bot_token"`
+	// BotUsername - имя бота без @, из него строится ссылка для привязки чата.
+	BotUsername string `yaml:"bot_username"`
+	// APIURL - адрес Bot API; пустой - https://api.telegram.org.
+	APIURL string `yaml:"api_url"`
+	// PollUpdates - получать нажатия кнопок в этом процессе. Bot API отдает обновления
+	// только одному получателю, поэтому включается ровно в одном экземпляре API.
+	PollUpdates bool          `yaml:"poll_updates"`
+	PollTimeout time.Duration `yaml:"poll_timeout"`
+	// LinkCodeTTL - сколько действует одноразовый код привязки чата.
+	LinkCodeTTL time.Duration `yaml:"link_code_ttl"`
+	// SendTimeout - сколько воркер ждет отправки уведомления о новой задаче; 0 - 10 секунд.
+	SendTimeout time.Duration `yaml:"send_timeout"`
+}
+
+// QuotasConfig - лимиты операций по тарифам аренды.
+type QuotasConfig struct {
+	// Timezone - часовой пояс, в котором считаются границы дня, недели и месяца.
+	Timezone string `yaml:"timezone"`
+	// DefaultTariff - лимиты для аренд с тарифом, которого нет в Tariffs.
+	DefaultTariff string `yaml:"default_tariff"`
+	// Tariffs - тариф -> тип действия -> лимит. Действия без лимита не ограничены.
+	Tariffs map[string]map[string]QuotaRule `yaml:"tariffs"`
+}
+
+// QuotaRule - не больше Limit операций за Period: day, week, month или season (срок аренды).
+type QuotaRule struct {
+	Limit  int    `yaml:"limit"`
+	Period string `yaml:"period"`
+}
+
+// DevicesConfig - настройки адаптеров IoT-устройств.
+type DevicesConfig struct {
+	// HTTPTimeout - сколько ждать ответа устройства; должен быть меньше worker.message_timeout.
+	HTTPTimeout time.Duration `yaml:"http_timeout"`
+	// SimulatorDelay - время "выполнения" команды адаптером simulator.
+	SimulatorDelay time.Duration `yaml:"simulator_delay"`
 }
 
 type MediaMTXConfig struct {
@@ -0,0 +1,59 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	valid := []string{"* * * * *", "0 7 * * *", "*/15 6-20 * * 1-5", "0 7,19 1,15 * *", "30 8 * * 7", "5/20 * * * *"}
+	for _, expr := range valid {
+		_, err := Parse(expr)
+		assert.NoError(t, err, expr)
+	}
+
+	invalid := []string{"", "* * * *", "60 * * * *", "0 24 * * *", "0 0 0 * *", "0 0 * 13 *", "0 0 * * 8", "*/0 * * * *", "5-1 * * * *", "a * * * *"}
+	for _, expr := range invalid {
+		_, err := Parse(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestNext(t *testing.T) {
+	msk := time.FixedZone("MSK", 3*60*60)
+	// Понедельник.
+	base := time.Date(2025, 9, 1, 10, 30, 15, 0, msk)
+
+	cases := []struct {
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{"* * * * *", base, time.Date(2025, 9, 1, 10, 31, 0, 0, msk)},
+		{"0 7 * * *", base, time.Date(2025, 9, 2, 7, 0, 0, 0, msk)},
+		{"0 7 * * *", time.Date(2025, 9, 1, 6, 59, 59, 0, msk), time.Date(2025, 9, 1, 7, 0, 0, 0, msk)},
+		// Строго после: ровно в момент срабатывания возвращается следующее.
+		{"0 7 * * *", time.Date(2025, 9, 1, 7, 0, 0, 0, msk), time.Date(2025, 9, 2, 7, 0, 0, 0, msk)},
+		{"*/20 * * * *", base, time.Date(2025, 9, 1, 10, 40, 0, 0, msk)},
+		{"0 8 * * 0", base, time.Date(2025, 9, 7, 8, 0, 0, 0, msk)},
+		{"0 8 * * 7", base, time.Date(2025, 9, 7, 8, 0, 0, 0, msk)},
+		{"0 0 1 1 *", base, time.Date(2026, 1, 1, 0, 0, 0, 0, msk)},
+		{"0 0 29 2 *", base, time.Date(2028, 2, 29, 0, 0, 0, 0, msk)},
+		// День месяца или день недели: 15-е число (понедельник 15.09 не важен) или ближайшая пятница.
+		{"0 9 15 * 5", base, time.Date(2025, 9, 5, 9, 0, 0, 0, msk)},
+	}
+	for _, c := range cases {
+		s, err := Parse(c.expr)
+		require.NoError(t, err, c.expr)
+		assert.Equal(t, c.want, s.Next(c.from), c.expr)
+	}
+
+	t.Run("Impossible date never fires", func(t *testing.T) {
+		s, err := Parse("0 0 31 2 *")
+		require.NoError(t, err)
+		assert.True(t, s.Next(base).IsZero())
+	})
+}
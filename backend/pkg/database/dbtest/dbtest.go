@@ -0,0 +1,151 @@
+// Package dbtest содержит поддельное подключение к БД для unit-тестов сервисов.
+//
+// Подключение поддерживает только транзакции: BeginTxx, Commit, Rollback и точки сохранения
+// (SAVEPOINT, ROLLBACK TO SAVEPOINT, RELEASE SAVEPOINT) проходят, а любой другой запрос
+// через него завершается ошибкой. Данные в тестах отдают моки репозиториев,
+// подменяющие репозитории, которые сервис создает поверх транзакции.
+package dbtest
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrUnexpectedQuery возвращается на любой запрос к поддельной БД.
+var ErrUnexpectedQuery = errors.New("dbtest: запросы к БД в тесте не ожидаются")
+
+// Stats считает транзакции, открытые через поддельную БД.
+type Stats struct {
+	mu         sync.Mutex
+	begun      int
+	committed  int
+	rolledBack int
+
+	savepoints           int
+	rolledBackSavepoints int
+}
+
+// Begun возвращает число открытых транзакций.
+func (s *Stats) Begun() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.begun
+}
+
+// Committed возвращает число закоммиченных транзакций.
+func (s *Stats) Committed() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.committed
+}
+
+// RolledBack возвращает число откаченных транзакций.
+func (s *Stats) RolledBack() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rolledBack
+}
+
+// Savepoints возвращает число созданных точек сохранения.
+func (s *Stats) Savepoints() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.savepoints
+}
+
+// RolledBackToSavepoint возвращает число откатов к точке сохранения.
+func (s *Stats) RolledBackToSavepoint() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rolledBackSavepoints
+}
+
+// New создает поддельную БД и счетчик ее транзакций.
+func New() (*sqlx.DB, *Stats) {
+	stats := &Stats{}
+	return sqlx.NewDb(sql.OpenDB(connector{stats: stats}), "postgres"), stats
+}
+
+type connector struct {
+	stats *Stats
+}
+
+func (c connector) Connect(context.Context) (driver.Conn, error) {
+	return &conn{stats: c.stats}, nil
+}
+
+func (c connector) Driver() driver.Driver {
+	return fakeDriver{stats: c.stats}
+}
+
+type fakeDriver struct {
+	stats *Stats
+}
+
+func (d fakeDriver) Open(string) (driver.Conn, error) {
+	return &conn{stats: d.stats}, nil
+}
+
+type conn struct {
+	stats *Stats
+}
+
+func (c *conn) Prepare(string) (driver.Stmt, error) {
+	return nil, ErrUnexpectedQuery
+}
+
+// ExecContext выполняет только команды точек сохранения.
+func (c *conn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	statement := strings.ToUpper(strings.TrimSpace(query))
+	if len(args) > 0 {
+		return nil, ErrUnexpectedQuery
+	}
+
+	c.stats.mu.Lock()
+	defer c.stats.mu.Unlock()
+	switch {
+	case strings.HasPrefix(statement, "SAVEPOINT "):
+		c.stats.savepoints++
+	case strings.HasPrefix(statement, "ROLLBACK TO SAVEPOINT "):
+		c.stats.rolledBackSavepoints++
+	case strings.HasPrefix(statement, "RELEASE SAVEPOINT "):
+	default:
+		return nil, ErrUnexpectedQuery
+	}
+	return driver.ResultNoRows, nil
+}
+
+func (c *conn) Close() error {
+	return nil
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	c.stats.mu.Lock()
+	defer c.stats.mu.Unlock()
+	c.stats.begun++
+	return &tx{stats: c.stats}, nil
+}
+
+type tx struct {
+	stats *Stats
+}
+
+func (t *tx) Commit() error {
+	t.stats.mu.Lock()
+	defer t.stats.mu.Unlock()
+	t.stats.committed++
+	return nil
+}
+
+func (t *tx) Rollback() error {
+	t.stats.mu.Lock()
+	defer t.stats.mu.Unlock()
+	t.stats.rolledBack++
+	return nil
+}
@@ -1,97 +1,492 @@
-
 package rabbitmq
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"sync"
+	"time"
 
+	"github.com/rendley/vegshare/backend/pkg/config"
+	"github.com/sirupsen/logrus"
 	"github.com/streadway/amqp"
 )
 
+// Значения по умолчанию для настроек соединения, если они не заданы в конфиге.
+const (
+	defaultPoolSize           = 8
+	defaultConfirmTimeout     = 5 * time.Second
+	defaultReconnectBaseDelay = time.Second
+	defaultReconnectMaxDelay  = 30 * time.Second
+)
+
+// DefaultPrefetch - сколько неподтвержденных сообщений получает консьюмер, если rabbitmq.prefetch не задан.
+const DefaultPrefetch = 10
+
+// ErrClosed возвращается при обращении к закрытому клиенту.
+var ErrClosed = errors.New("rabbitmq client is closed")
+
 // Client is a RabbitMQ client.
+//
+// Клиент переживает перезапуск брокера: при разрыве соединения он переподключается
+// с экспоненциальной задержкой, заново объявляет топологии и возобновляет консьюмеры.
+// Публикация идет через пул каналов в режиме publisher confirms, поэтому Publish
+// безопасен для конкурентного вызова и возвращается только после подтверждения брокера.
 type Client struct {
-	conn *amqp.Connection
-	ch   *amqp.Channel
+	url        string
+	cfg        config.RabbitMQConfig
+	logger     *logrus.Logger
+	httpClient *http.Client // для management API
+
+	mu         sync.Mutex
+	conn       *amqp.Connection
+	declared   map[string]bool // очереди, уже объявленные через DeclareTopology, Publish или Consume
+	topologies []Topology      // объявляются заново после переподключения
+	consumers  []*consumer     // подписываются заново после переподключения
+
+	// pool ограничивает число одновременных публикаций. Пустой слот (nil) означает,
+	// что канал будет открыт при первом использовании.
+	pool chan *confirmChannel
+
+	forwarders sync.WaitGroup
+	done       chan struct{}
+	closeOnce  sync.Once
+}
+
+// confirmChannel - канал в режиме publisher confirms вместе с его подписками.
+type confirmChannel struct {
+	conn     *amqp.Connection // соединение, на котором открыт канал
+	ch       *amqp.Channel
+	confirms chan amqp.Confirmation
+	closed   chan *amqp.Error
+}
+
+// consumer - подписка на очередь. Канал out живет дольше соединения:
+// после переподключения в него продолжают поступать сообщения.
+type consumer struct {
+	queue string
+	out   chan amqp.Delivery
 }
 
 // New creates a new RabbitMQ client.
-func New(url string) (*Client, error) {
-	conn, err := amqp.Dial(url)
+// Первое подключение должно быть успешным, дальнейшие разрывы клиент обрабатывает сам.
+func New(cfg config.RabbitMQConfig, logger *logrus.Logger) (*Client, error) {
+	if cfg.PoolSize <= 0 {
+		cfg.PoolSize = defaultPoolSize
+	}
+	if cfg.Prefetch <= 0 {
+		cfg.Prefetch = DefaultPrefetch
+	}
+	if cfg.ConfirmTimeout <= 0 {
+		cfg.ConfirmTimeout = defaultConfirmTimeout
+	}
+	if cfg.ReconnectBaseDelay <= 0 {
+		cfg.ReconnectBaseDelay = defaultReconnectBaseDelay
+	}
+	if cfg.ReconnectMaxDelay <= 0 {
+		cfg.ReconnectMaxDelay = defaultReconnectMaxDelay
+	}
+
+	conn, err := amqp.Dial(cfg.URL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to RabbitMQ: %w", err)
 	}
 
-	ch, err := conn.Channel()
-	if err != nil {
-		return nil, fmt.Errorf("failed to open a channel: %w", err)
+	c := &Client{
+		url:        cfg.URL,
+		cfg:        cfg,
+		logger:     logger,
+		httpClient: &http.Client{Timeout: policyTimeout},
+		conn:       conn,
+		declared:   make(map[string]bool),
+		pool:       make(chan *confirmChannel, cfg.PoolSize),
+		done:       make(chan struct{}),
+	}
+	for i := 0; i < cfg.PoolSize; i++ {
+		c.pool <- nil
 	}
 
-	return &Client{conn: conn, ch: ch}, nil
+	go c.watch(conn)
+	return c, nil
 }
 
-// Close closes the RabbitMQ connection and channel.
+// Close closes the RabbitMQ connection and channels.
+// Каналы, полученные из Consume, закрываются после остановки всех пересылок.
 func (c *Client) Close() {
-	c.ch.Close()
-	c.conn.Close()
+	c.closeOnce.Do(func() {
+		close(c.done)
+
+		c.mu.Lock()
+		c.conn.Close()
+		consumers := c.consumers
+		c.mu.Unlock()
+
+		c.forwarders.Wait()
+		for _, cons := range consumers {
+			close(cons.out)
+		}
+	})
 }
 
 // Publish publishes a message to a queue.
 func (c *Client) Publish(queueName, body string) error {
-	q, err := c.ch.QueueDeclare(
-		queueName, // name
-		true,      // durable
-		false,     // delete when unused
-		false,     // exclusive
-		false,     // no-wait
-		nil,       // arguments
-	)
+	return c.publish(queueName, amqp.Publishing{
+		DeliveryMode: amqp.Persistent,
+		ContentType:  "text/plain",
+		Body:         []byte(body),
+	})
+}
+
+// DeclareTopology объявляет рабочую очередь вместе с очередями повторов и DLQ.
+// Должна вызываться до Publish/Consume в каждом процессе, работающем с очередью.
+func (c *Client) DeclareTopology(t Topology) error {
+	// Политика хранится на брокере, поэтому после переподключения ее не нужно ставить заново.
+	if err := c.applyPolicy(t.deadLetterPolicy()); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.declareTopology(c.conn, t); err != nil {
+		return err
+	}
+	c.topologies = append(c.topologies, t)
+	return nil
+}
+
+// applyPolicy ставит политику p через management API. Без management_url политика не ставится:
+// сообщения, отклоненные без requeue, тогда не попадут в DLQ (воркер сам так не делает).
+func (c *Client) applyPolicy(p policy) error {
+	if c.cfg.ManagementURL == "" {
+		c.logger.Warnf("RabbitMQ management_url is not set, policy '%s' is not applied", p.Name)
+		return nil
+	}
+	uri, err := amqp.ParseURI(c.url)
 	if err != nil {
-		return fmt.Errorf("failed to declare a queue: %w", err)
+		return fmt.Errorf("failed to parse RabbitMQ URL: %w", err)
 	}
 
-	err = c.ch.Publish(
-		"",     // exchange
-		q.Name, // routing key
-		false,  // mandatory
-		false,  // immediate
-		amqp.Publishing{
-			DeliveryMode: amqp.Persistent,
-			ContentType:  "text/plain",
-			Body:         []byte(body),
-		},
-	)
+	ctx, cancel := context.WithTimeout(context.Background(), policyTimeout)
+	defer cancel()
+	return putPolicy(ctx, c.httpClient, c.cfg.ManagementURL, uri.Vhost, p)
+}
+
+// declareTopology объявляет очереди топологии на отдельном канале соединения conn.
+// Вызывается под c.mu.
+func (c *Client) declareTopology(conn *amqp.Connection, t Topology) error {
+	ch, err := conn.Channel()
 	if err != nil {
-		return fmt.Errorf("failed to publish a message: %w", err)
+		return fmt.Errorf("failed to open a channel: %w", err)
 	}
+	defer ch.Close()
 
+	for name, args := range t.queues() {
+		if _, err := ch.QueueDeclare(name, true, false, false, false, args); err != nil {
+			return fmt.Errorf("failed to declare queue '%s': %w", name, err)
+		}
+		c.declared[name] = true
+	}
 	return nil
 }
 
-// Consume consumes messages from a queue.
-func (c *Client) Consume(queueName string) (<-chan amqp.Delivery, error) {
-	q, err := c.ch.QueueDeclare(
+// Retry переотправляет сообщение в очередь задержки для следующей попытки, а после
+// MaxAttempts - в DLQ. Исходное сообщение вызывающий должен подтвердить (Ack) сам.
+func (c *Client) Retry(t Topology, d amqp.Delivery, cause error) error {
+	return retry(c.publish, t, d, cause)
+}
+
+// DeadLetter отправляет сообщение в DLQ без дальнейших повторов.
+func (c *Client) DeadLetter(t Topology, d amqp.Delivery, cause error) error {
+	return deadLetter(c.publish, t, d, cause)
+}
+
+// publish публикует сообщение через канал из пула и ждет подтверждения брокера.
+func (c *Client) publish(queueName string, msg amqp.Publishing) error {
+	cc, err := c.acquire()
+	if err != nil {
+		return err
+	}
+	healthy := false
+	defer func() { c.release(cc, healthy) }()
+
+	c.mu.Lock()
+	err = c.ensureQueue(cc.ch, queueName)
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := cc.ch.Publish(
+		"",        // exchange
+		queueName, // routing key
+		false,     // mandatory
+		false,     // immediate
+		msg,
+	); err != nil {
+		return fmt.Errorf("failed to publish a message: %w", err)
+	}
+
+	timer := time.NewTimer(c.cfg.ConfirmTimeout)
+	defer timer.Stop()
+
+	select {
+	case confirm, ok := <-cc.confirms:
+		if !ok {
+			return fmt.Errorf("channel closed before the broker confirmed the message")
+		}
+		healthy = true
+		if !confirm.Ack {
+			return fmt.Errorf("broker rejected the message for queue '%s'", queueName)
+		}
+		return nil
+	case <-timer.C:
+		// Подтверждение может прийти позже и перепутаться со следующей публикацией,
+		// поэтому канал после таймаута не возвращается в пул.
+		return fmt.Errorf("timed out waiting for broker confirmation for queue '%s'", queueName)
+	case <-c.done:
+		return ErrClosed
+	}
+}
+
+// acquire берет канал из пула, открывая новый, если слот пуст или канал устарел.
+func (c *Client) acquire() (*confirmChannel, error) {
+	// select ниже выбирает случайно, если готовы оба случая, поэтому закрытие проверяется заранее.
+	select {
+	case <-c.done:
+		return nil, ErrClosed
+	default:
+	}
+
+	var cc *confirmChannel
+	select {
+	case cc = <-c.pool:
+	case <-c.done:
+		return nil, ErrClosed
+	}
+
+	c.mu.Lock()
+	conn := c.conn
+	c.mu.Unlock()
+
+	if cc != nil && cc.conn == conn && !cc.isClosed() {
+		return cc, nil
+	}
+	if cc != nil {
+		cc.ch.Close()
+	}
+
+	cc, err := openConfirmChannel(conn)
+	if err != nil {
+		c.pool <- nil
+		return nil, err
+	}
+	return cc, nil
+}
+
+// release возвращает канал в пул; сломанный канал закрывается, а слот освобождается.
+func (c *Client) release(cc *confirmChannel, healthy bool) {
+	if !healthy {
+		cc.ch.Close()
+		cc = nil
+	}
+	c.pool <- cc
+}
+
+func openConfirmChannel(conn *amqp.Connection) (*confirmChannel, error) {
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open a channel: %w", err)
+	}
+	if err := ch.Confirm(false); err != nil {
+		ch.Close()
+		return nil, fmt.Errorf("failed to enable publisher confirms: %w", err)
+	}
+
+	return &confirmChannel{
+		conn:     conn,
+		ch:       ch,
+		confirms: ch.NotifyPublish(make(chan amqp.Confirmation, 1)),
+		closed:   ch.NotifyClose(make(chan *amqp.Error, 1)),
+	}, nil
+}
+
+func (cc *confirmChannel) isClosed() bool {
+	select {
+	case <-cc.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// ensureQueue объявляет простую durable-очередь, если она не была объявлена ранее.
+// Очереди из топологии уже объявлены с аргументами DLX, и повторное объявление без них вызвало бы ошибку.
+// Вызывается под c.mu.
+func (c *Client) ensureQueue(ch *amqp.Channel, queueName string) error {
+	if c.declared[queueName] {
+		return nil
+	}
+	if _, err := ch.QueueDeclare(
 		queueName, // name
 		true,      // durable
 		false,     // delete when unused
 		false,     // exclusive
 		false,     // no-wait
 		nil,       // arguments
-	)
+	); err != nil {
+		return fmt.Errorf("failed to declare a queue: %w", err)
+	}
+	c.declared[queueName] = true
+	return nil
+}
+
+// Consume consumes messages from a queue.
+// Возвращаемый канал не закрывается при разрыве соединения: после переподключения
+// подписка восстанавливается. Сообщения, не подтвержденные до разрыва, брокер доставит повторно.
+func (c *Client) Consume(queueName string) (<-chan amqp.Delivery, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cons := &consumer{queue: queueName, out: make(chan amqp.Delivery)}
+	if err := c.subscribe(c.conn, cons); err != nil {
+		return nil, err
+	}
+	c.consumers = append(c.consumers, cons)
+	return cons.out, nil
+}
+
+// subscribe открывает для консьюмера канал с prefetch и пересылает из него сообщения в cons.out.
+// Вызывается под c.mu.
+func (c *Client) subscribe(conn *amqp.Connection, cons *consumer) error {
+	ch, err := conn.Channel()
 	if err != nil {
-		return nil, fmt.Errorf("failed to declare a queue: %w", err)
+		return fmt.Errorf("failed to open a channel: %w", err)
+	}
+	if err := ch.Qos(c.cfg.Prefetch, 0, false); err != nil {
+		ch.Close()
+		return fmt.Errorf("failed to set QoS: %w", err)
+	}
+	if err := c.ensureQueue(ch, cons.queue); err != nil {
+		ch.Close()
+		return err
 	}
 
-	msgs, err := c.ch.Consume(
-		q.Name, // queue
-		"",     // consumer
-		false,  // auto-ack
-		false,  // exclusive
-		false,  // no-local
-		false,  // no-wait
-		nil,    // args
+	msgs, err := ch.Consume(
+		cons.queue, // queue
+		"",         // consumer
+		false,      // auto-ack
+		false,      // exclusive
+		false,      // no-local
+		false,      // no-wait
+		nil,        // args
 	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to register a consumer: %w", err)
+		ch.Close()
+		return fmt.Errorf("failed to register a consumer: %w", err)
+	}
+
+	c.forwarders.Add(1)
+	go func() {
+		defer c.forwarders.Done()
+		for d := range msgs {
+			select {
+			case cons.out <- d:
+			case <-c.done:
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// watch ждет разрыва соединения и переподключается, пока клиент не закрыт.
+func (c *Client) watch(conn *amqp.Connection) {
+	for {
+		closed := conn.NotifyClose(make(chan *amqp.Error, 1))
+		select {
+		case <-c.done:
+			return
+		case err := <-closed:
+			select {
+			case <-c.done:
+				return
+			default:
+			}
+			c.logger.Warnf("RabbitMQ connection lost: %v. Reconnecting...", err)
+		}
+
+		var ok bool
+		if conn, ok = c.reconnect(); !ok {
+			return
+		}
 	}
+}
 
-	return msgs, nil
+// reconnect подключается заново с экспоненциальной задержкой и восстанавливает
+// топологии и консьюмеров. Возвращает false, если клиент закрыли во время ожидания.
+func (c *Client) reconnect() (*amqp.Connection, bool) {
+	for attempt := 1; ; attempt++ {
+		select {
+		case <-c.done:
+			return nil, false
+		case <-time.After(reconnectDelay(c.cfg.ReconnectBaseDelay, c.cfg.ReconnectMaxDelay, attempt)):
+		}
+
+		conn, err := amqp.Dial(c.url)
+		if err != nil {
+			c.logger.Warnf("RabbitMQ reconnect attempt %d failed: %v", attempt, err)
+			continue
+		}
+
+		if err := c.restore(conn); err != nil {
+			c.logger.Warnf("RabbitMQ reconnect attempt %d failed to restore state: %v", attempt, err)
+			conn.Close()
+			continue
+		}
+
+		c.logger.Infof("RabbitMQ reconnected after %d attempt(s)", attempt)
+		return conn, true
+	}
+}
+
+// restore переключает клиент на новое соединение: объявляет топологии и подписывает консьюмеров.
+// Каналы публикации из пула откроются заново при следующем acquire.
+func (c *Client) restore(conn *amqp.Connection) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	select {
+	case <-c.done:
+		return ErrClosed
+	default:
+	}
+
+	c.declared = make(map[string]bool)
+	for _, t := range c.topologies {
+		if err := c.declareTopology(conn, t); err != nil {
+			return err
+		}
+	}
+	for _, cons := range c.consumers {
+		if err := c.subscribe(conn, cons); err != nil {
+			return err
+		}
+	}
+
+	c.conn = conn
+	return nil
+}
+
+// reconnectDelay возвращает задержку перед попыткой attempt: base * 2^(attempt-1), но не больше max.
+func reconnectDelay(base, max time.Duration, attempt int) time.Duration {
+	delay := base
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= max {
+			return max
+		}
+	}
+	return delay
 }
@@ -0,0 +1,414 @@
+package rabbitmq
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rendley/vegshare/backend/pkg/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Режимы ответа fakeBroker на публикацию в канале с publisher confirms.
+const (
+	confirmAck = iota
+	confirmNack
+	confirmNone
+)
+
+// fakeBroker - минимальный AMQP 0-9-1 сервер: ровно то, что нужно Client для подключения,
+// объявления очередей, публикации с подтверждениями и подписки.
+type fakeBroker struct {
+	t  *testing.T
+	ln net.Listener
+
+	mu           sync.Mutex
+	conns        []*fakeConn
+	channels     int // сколько раз открывались каналы
+	confirmMode  int
+	consumers    map[string]fakeConsumer // последняя подписка на очередь
+	consumeCalls chan string
+}
+
+type fakeConn struct {
+	net.Conn
+	mu sync.Mutex
+}
+
+type fakeConsumer struct {
+	conn    *fakeConn
+	channel uint16
+	tag     string
+}
+
+// fakePublish собирает из кадров метода, заголовка и тела публикацию в канал.
+type fakePublish struct {
+	size int
+	body []byte
+}
+
+func newFakeBroker(t *testing.T) *fakeBroker {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	b := &fakeBroker{t: t, ln: ln, consumers: make(map[string]fakeConsumer), consumeCalls: make(chan string, 10)}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			fc := &fakeConn{Conn: conn}
+			b.mu.Lock()
+			b.conns = append(b.conns, fc)
+			b.mu.Unlock()
+			go b.serve(fc)
+		}
+	}()
+	t.Cleanup(func() {
+		ln.Close()
+		b.dropConnections()
+	})
+	return b
+}
+
+func (b *fakeBroker) url() string {
+	return "amqp://guest:guest@" + b.ln.Addr().String() + "/"
+}
+
+func (b *fakeBroker) setConfirmMode(mode int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.confirmMode = mode
+}
+
+func (b *fakeBroker) openedChannels() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.channels
+}
+
+// dropConnections рвет все соединения, как при перезапуске брокера.
+func (b *fakeBroker) dropConnections() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, c := range b.conns {
+		c.Close()
+	}
+	b.conns = nil
+}
+
+// deliver отправляет сообщение последнему подписчику очереди.
+func (b *fakeBroker) deliver(queue, body string, tag uint64) {
+	b.mu.Lock()
+	cons, ok := b.consumers[queue]
+	b.mu.Unlock()
+	require.True(b.t, ok, "на очередь '%s' никто не подписан", queue)
+
+	var args bytes.Buffer
+	writeShortstr(&args, cons.tag)
+	binary.Write(&args, binary.BigEndian, tag)
+	args.WriteByte(0) // redelivered
+	writeShortstr(&args, "")
+	writeShortstr(&args, queue)
+
+	var header bytes.Buffer
+	binary.Write(&header, binary.BigEndian, uint16(60)) // basic
+	binary.Write(&header, binary.BigEndian, uint16(0))  // weight
+	binary.Write(&header, binary.BigEndian, uint64(len(body)))
+	binary.Write(&header, binary.BigEndian, uint16(0)) // без свойств
+
+	cons.conn.mu.Lock()
+	defer cons.conn.mu.Unlock()
+	cons.conn.writeFrame(1, cons.channel, methodPayload(60, 60, args.Bytes()))
+	cons.conn.writeFrame(2, cons.channel, header.Bytes())
+	cons.conn.writeFrame(3, cons.channel, []byte(body))
+}
+
+func (b *fakeBroker) serve(c *fakeConn) {
+	defer c.Close()
+
+	protocol := make([]byte, 8)
+	if _, err := io.ReadFull(c, protocol); err != nil {
+		return
+	}
+
+	var start bytes.Buffer
+	start.Write([]byte{0, 9})
+	binary.Write(&start, binary.BigEndian, uint32(0)) // server-properties
+	writeLongstr(&start, "PLAIN")
+	writeLongstr(&start, "en_US")
+	c.send(0, 10, 10, start.Bytes())
+
+	publishes := make(map[uint16]*fakePublish)
+	confirmed := make(map[uint16]uint64)
+	for {
+		typ, channel, payload, err := readFrame(c)
+		if err != nil {
+			return
+		}
+
+		switch typ {
+		case 1:
+			class, method := binary.BigEndian.Uint16(payload), binary.BigEndian.Uint16(payload[2:])
+			args := payload[4:]
+			switch {
+			case class == 10 && method == 11: // connection.start-ok
+				var tune bytes.Buffer
+				binary.Write(&tune, binary.BigEndian, uint16(0))      // channel-max
+				binary.Write(&tune, binary.BigEndian, uint32(131072)) // frame-max
+				binary.Write(&tune, binary.BigEndian, uint16(0))      // heartbeat
+				c.send(0, 10, 30, tune.Bytes())
+			case class == 10 && method == 40: // connection.open
+				c.send(0, 10, 41, shortstr(""))
+			case class == 10 && method == 50: // connection.close
+				c.send(0, 10, 51, nil)
+				return
+			case class == 20 && method == 10: // channel.open
+				b.mu.Lock()
+				b.channels++
+				b.mu.Unlock()
+				// Клиент может переиспользовать номер закрытого канала; нумерация подтверждений начинается заново.
+				delete(confirmed, channel)
+				c.send(channel, 20, 11, []byte{0, 0, 0, 0})
+			case class == 20 && method == 40: // channel.close
+				c.send(channel, 20, 41, nil)
+			case class == 85 && method == 10: // confirm.select
+				c.send(channel, 85, 11, nil)
+			case class == 50 && method == 10: // queue.declare
+				queue := readShortstr(args[2:])
+				var ok bytes.Buffer
+				writeShortstr(&ok, queue)
+				binary.Write(&ok, binary.BigEndian, uint32(0))
+				binary.Write(&ok, binary.BigEndian, uint32(0))
+				c.send(channel, 50, 11, ok.Bytes())
+			case class == 60 && method == 10: // basic.qos
+				c.send(channel, 60, 11, nil)
+			case class == 60 && method == 20: // basic.consume
+				queue := readShortstr(args[2:])
+				tag := readShortstr(args[3+len(queue):])
+				b.mu.Lock()
+				b.consumers[queue] = fakeConsumer{conn: c, channel: channel, tag: tag}
+				b.mu.Unlock()
+				c.send(channel, 60, 21, shortstr(tag))
+				b.consumeCalls <- queue
+			case class == 60 && method == 40: // basic.publish
+				publishes[channel] = &fakePublish{}
+			}
+		case 2: // заголовок содержимого
+			pub := publishes[channel]
+			pub.size = int(binary.BigEndian.Uint64(payload[4:]))
+			if pub.size == 0 {
+				b.confirm(c, channel, confirmed)
+			}
+		case 3: // тело
+			pub := publishes[channel]
+			pub.body = append(pub.body, payload...)
+			if len(pub.body) >= pub.size {
+				b.confirm(c, channel, confirmed)
+			}
+		}
+	}
+}
+
+// confirm отвечает на публикацию в канале channel в текущем режиме брокера.
+func (b *fakeBroker) confirm(c *fakeConn, channel uint16, confirmed map[uint16]uint64) {
+	confirmed[channel]++
+	var args bytes.Buffer
+	binary.Write(&args, binary.BigEndian, confirmed[channel])
+	args.WriteByte(0)
+
+	b.mu.Lock()
+	mode := b.confirmMode
+	b.mu.Unlock()
+	switch mode {
+	case confirmAck:
+		c.send(channel, 60, 80, args.Bytes())
+	case confirmNack:
+		c.send(channel, 60, 120, args.Bytes())
+	}
+}
+
+func (c *fakeConn) send(channel, class, method uint16, args []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.writeFrame(1, channel, methodPayload(class, method, args))
+}
+
+// writeFrame пишет кадр AMQP; вызывается под c.mu.
+func (c *fakeConn) writeFrame(typ byte, channel uint16, payload []byte) {
+	var frame bytes.Buffer
+	frame.WriteByte(typ)
+	binary.Write(&frame, binary.BigEndian, channel)
+	binary.Write(&frame, binary.BigEndian, uint32(len(payload)))
+	frame.Write(payload)
+	frame.WriteByte(0xCE)
+	c.Write(frame.Bytes())
+}
+
+func readFrame(r io.Reader) (typ byte, channel uint16, payload []byte, err error) {
+	header := make([]byte, 7)
+	if _, err = io.ReadFull(r, header); err != nil {
+		return
+	}
+	payload = make([]byte, binary.BigEndian.Uint32(header[3:])+1)
+	if _, err = io.ReadFull(r, payload); err != nil {
+		return
+	}
+	return header[0], binary.BigEndian.Uint16(header[1:]), payload[:len(payload)-1], nil
+}
+
+func methodPayload(class, method uint16, args []byte) []byte {
+	payload := make([]byte, 4, 4+len(args))
+	binary.BigEndian.PutUint16(payload, class)
+	binary.BigEndian.PutUint16(payload[2:], method)
+	return append(payload, args...)
+}
+
+func shortstr(s string) []byte {
+	var buf bytes.Buffer
+	writeShortstr(&buf, s)
+	return buf.Bytes()
+}
+
+func writeShortstr(buf *bytes.Buffer, s string) {
+	buf.WriteByte(byte(len(s)))
+	buf.WriteString(s)
+}
+
+func writeLongstr(buf *bytes.Buffer, s string) {
+	binary.Write(buf, binary.BigEndian, uint32(len(s)))
+	buf.WriteString(s)
+}
+
+func readShortstr(b []byte) string {
+	return string(b[1 : 1+int(b[0])])
+}
+
+func newTestClient(t *testing.T, b *fakeBroker) *Client {
+	c, err := New(config.RabbitMQConfig{
+		URL:                b.url(),
+		PoolSize:           1,
+		ConfirmTimeout:     100 * time.Millisecond,
+		ReconnectBaseDelay: 10 * time.Millisecond,
+		ReconnectMaxDelay:  50 * time.Millisecond,
+	}, logrus.New())
+	require.NoError(t, err)
+	t.Cleanup(c.Close)
+	return c
+}
+
+func TestClientPublish(t *testing.T) {
+	t.Run("Confirmed publish succeeds", func(t *testing.T) {
+		b := newFakeBroker(t)
+		c := newTestClient(t, b)
+
+		assert.NoError(t, c.Publish("q", "hello"))
+	})
+
+	t.Run("Publish fails when the broker rejects the message", func(t *testing.T) {
+		b := newFakeBroker(t)
+		b.setConfirmMode(confirmNack)
+		c := newTestClient(t, b)
+
+		err := c.Publish("q", "hello")
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "broker rejected")
+	})
+
+	t.Run("Publish fails when the broker never confirms", func(t *testing.T) {
+		b := newFakeBroker(t)
+		b.setConfirmMode(confirmNone)
+		c := newTestClient(t, b)
+
+		err := c.Publish("q", "hello")
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "timed out")
+	})
+
+	t.Run("Publish after Close fails", func(t *testing.T) {
+		b := newFakeBroker(t)
+		c := newTestClient(t, b)
+		c.Close()
+
+		assert.ErrorIs(t, c.Publish("q", "hello"), ErrClosed)
+	})
+}
+
+func TestClientPool(t *testing.T) {
+	t.Run("Channel goes back to the pool after a rejected publish", func(t *testing.T) {
+		b := newFakeBroker(t)
+		b.setConfirmMode(confirmNack)
+		c := newTestClient(t, b)
+
+		require.Error(t, c.Publish("q", "rejected"))
+		b.setConfirmMode(confirmAck)
+		require.NoError(t, c.Publish("q", "accepted"))
+
+		// Брокер ответил на публикацию, поэтому канал исправен и используется повторно.
+		assert.Equal(t, 1, b.openedChannels())
+	})
+
+	t.Run("Channel is replaced after a confirmation timeout", func(t *testing.T) {
+		b := newFakeBroker(t)
+		b.setConfirmMode(confirmNone)
+		c := newTestClient(t, b)
+
+		require.Error(t, c.Publish("q", "lost"))
+		b.setConfirmMode(confirmAck)
+		require.NoError(t, c.Publish("q", "accepted"))
+
+		// Опоздавшее подтверждение перепуталось бы со следующей публикацией, поэтому канал новый.
+		assert.Equal(t, 2, b.openedChannels())
+	})
+
+	t.Run("Channel is reopened after the connection drops", func(t *testing.T) {
+		b := newFakeBroker(t)
+		c := newTestClient(t, b)
+		require.NoError(t, c.Publish("q", "before"))
+
+		b.dropConnections()
+
+		assert.Eventually(t, func() bool { return c.Publish("q", "after") == nil }, time.Second, 20*time.Millisecond)
+	})
+}
+
+func TestClientConsume(t *testing.T) {
+	t.Run("Consumer resubscribes after the connection drops", func(t *testing.T) {
+		b := newFakeBroker(t)
+		c := newTestClient(t, b)
+
+		msgs, err := c.Consume("q")
+		require.NoError(t, err)
+		assert.Equal(t, "q", waitConsume(t, b))
+
+		b.deliver("q", "before", 1)
+		assert.Equal(t, "before", string(receive(t, msgs).Body))
+
+		b.dropConnections()
+		assert.Equal(t, "q", waitConsume(t, b))
+
+		// Сообщения после переподключения приходят в тот же канал, что вернул Consume.
+		b.deliver("q", "after", 1)
+		assert.Equal(t, "after", string(receive(t, msgs).Body))
+	})
+}
+
+func waitConsume(t *testing.T, b *fakeBroker) string {
+	t.Helper()
+	select {
+	case queue := <-b.consumeCalls:
+		return queue
+	case <-time.After(time.Second):
+		t.Fatal("консьюмер не подписался")
+		return ""
+	}
+}
@@ -1,11 +1,38 @@
 package rabbitmq
 
-import "github.com/streadway/amqp"
+import (
+	"fmt"
+
+	"github.com/rendley/vegshare/backend/pkg/config"
+	"github.com/sirupsen/logrus"
+	"github.com/streadway/amqp"
+)
+
+// Драйверы шины сообщений, выбираются в конфиге (rabbitmq.driver).
+const (
+	DriverAMQP   = "amqp"
+	DriverMemory = "memory"
+)
 
 // ClientInterface defines the contract for a RabbitMQ client.
 // This allows for mocking in tests.
 type ClientInterface interface {
 	Publish(queueName, body string) error
 	Consume(queueName string) (<-chan amqp.Delivery, error)
+	DeclareTopology(t Topology) error
+	Retry(t Topology, d amqp.Delivery, cause error) error
+	DeadLetter(t Topology, d amqp.Delivery, cause error) error
 	Close()
 }
+
+// Connect создает клиент для драйвера из конфига.
+func Connect(cfg config.RabbitMQConfig, logger *logrus.Logger) (ClientInterface, error) {
+	switch cfg.Driver {
+	case "", DriverAMQP:
+		return New(cfg, logger)
+	case DriverMemory:
+		return NewMemoryClient(cfg.Prefetch), nil
+	default:
+		return nil, fmt.Errorf("unknown rabbitmq driver '%s'", cfg.Driver)
+	}
+}
@@ -0,0 +1,321 @@
+package rabbitmq
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+// MemoryClient - реализация ClientInterface внутри процесса, без брокера.
+// Предназначена для локального запуска API и воркера в одном бинарнике и для интеграционных тестов.
+//
+// Повторяет семантику RabbitMQ, на которую опирается код:
+//   - сообщение доставляется одному консьюмеру и ждет Ack;
+//   - Nack/Reject с requeue возвращает его в начало очереди с флагом Redelivered;
+//   - Nack/Reject без requeue и истечение x-message-ttl перекладывают сообщение
+//     в x-dead-letter-routing-key очереди (с заголовком x-death) или удаляют его;
+//   - консьюмеру выдается не больше prefetch неподтвержденных сообщений.
+//
+// Сообщения хранятся только в памяти и теряются при остановке процесса.
+type MemoryClient struct {
+	prefetch int
+
+	mu     sync.Mutex
+	cond   *sync.Cond
+	queues map[string]*memoryQueue
+	tag    uint64
+	closed bool
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+type memoryQueue struct {
+	name  string
+	ready []memoryMessage
+	// ttl и deadLetterTo берутся из аргументов очереди x-message-ttl и x-dead-letter-routing-key.
+	ttl          time.Duration
+	deadLetterTo string
+}
+
+type memoryMessage struct {
+	msg         amqp.Publishing
+	redelivered bool
+}
+
+// memoryConsumer - подписка на очередь; реализует amqp.Acknowledger для своих сообщений.
+type memoryConsumer struct {
+	client  *MemoryClient
+	queue   *memoryQueue
+	out     chan amqp.Delivery
+	unacked map[uint64]memoryMessage
+}
+
+var _ ClientInterface = (*MemoryClient)(nil)
+
+// NewMemoryClient создает шину в памяти. prefetch <= 0 заменяется значением по умолчанию.
+func NewMemoryClient(prefetch int) *MemoryClient {
+	if prefetch <= 0 {
+		prefetch = DefaultPrefetch
+	}
+	c := &MemoryClient{
+		prefetch: prefetch,
+		queues:   make(map[string]*memoryQueue),
+		done:     make(chan struct{}),
+	}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+// Publish publishes a message to a queue.
+func (c *MemoryClient) Publish(queueName, body string) error {
+	return c.publish(queueName, amqp.Publishing{
+		DeliveryMode: amqp.Persistent,
+		ContentType:  "text/plain",
+		Body:         []byte(body),
+	})
+}
+
+// DeclareTopology создает очереди топологии с теми же аргументами, что и в RabbitMQ.
+func (c *MemoryClient) DeclareTopology(t Topology) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return ErrClosed
+	}
+	for name, args := range t.queues() {
+		q := c.queue(name)
+		if ttl, ok := args["x-message-ttl"].(int64); ok {
+			q.ttl = time.Duration(ttl) * time.Millisecond
+		}
+		if target, ok := args["x-dead-letter-routing-key"].(string); ok {
+			q.deadLetterTo = target
+		}
+	}
+	// В RabbitMQ DLX рабочей очереди задает политика; здесь она применяется сразу.
+	if target, ok := t.deadLetterPolicy().Definition["dead-letter-routing-key"].(string); ok {
+		c.queue(t.Queue).deadLetterTo = target
+	}
+	return nil
+}
+
+// Retry переотправляет сообщение в очередь задержки для следующей попытки, а после
+// MaxAttempts - в DLQ. Исходное сообщение вызывающий должен подтвердить (Ack) сам.
+func (c *MemoryClient) Retry(t Topology, d amqp.Delivery, cause error) error {
+	return retry(c.publish, t, d, cause)
+}
+
+// DeadLetter отправляет сообщение в DLQ без дальнейших повторов.
+func (c *MemoryClient) DeadLetter(t Topology, d amqp.Delivery, cause error) error {
+	return deadLetter(c.publish, t, d, cause)
+}
+
+// Consume consumes messages from a queue.
+// Канал закрывается при вызове Close.
+func (c *MemoryClient) Consume(queueName string) (<-chan amqp.Delivery, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil, ErrClosed
+	}
+	cons := &memoryConsumer{
+		client:  c,
+		queue:   c.queue(queueName),
+		out:     make(chan amqp.Delivery),
+		unacked: make(map[uint64]memoryMessage),
+	}
+
+	c.wg.Add(1)
+	go cons.run()
+	return cons.out, nil
+}
+
+// Close останавливает доставку и закрывает каналы консьюмеров. Неподтвержденные сообщения теряются.
+func (c *MemoryClient) Close() {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return
+	}
+	c.closed = true
+	c.cond.Broadcast()
+	c.mu.Unlock()
+
+	close(c.done)
+	c.wg.Wait()
+}
+
+func (c *MemoryClient) publish(queueName string, msg amqp.Publishing) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return ErrClosed
+	}
+	c.enqueue(c.queue(queueName), memoryMessage{msg: msg})
+	return nil
+}
+
+// queue возвращает очередь по имени, создавая простую очередь при первом обращении.
+// Вызывается под c.mu.
+func (c *MemoryClient) queue(name string) *memoryQueue {
+	q, ok := c.queues[name]
+	if !ok {
+		q = &memoryQueue{name: name}
+		c.queues[name] = q
+	}
+	return q
+}
+
+// enqueue кладет сообщение в очередь. В очереди с TTL сообщение ждет его истечения
+// и уходит в dead-letter очередь - так работают очереди задержки повторов.
+// Вызывается под c.mu.
+func (c *MemoryClient) enqueue(q *memoryQueue, m memoryMessage) {
+	if q.ttl > 0 {
+		time.AfterFunc(q.ttl, func() {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			if !c.closed {
+				c.deadLetter(q, m, "expired")
+			}
+		})
+		return
+	}
+	q.ready = append(q.ready, m)
+	c.cond.Broadcast()
+}
+
+// deadLetter перекладывает сообщение в dead-letter очередь q, добавляя x-death, как это делает брокер.
+// Если у очереди нет dead-letter очереди, сообщение удаляется.
+// Вызывается под c.mu.
+func (c *MemoryClient) deadLetter(q *memoryQueue, m memoryMessage, reason string) {
+	if q.deadLetterTo == "" {
+		return
+	}
+
+	headers := amqp.Table{}
+	for k, v := range m.msg.Headers {
+		headers[k] = v
+	}
+	headers["x-death"] = []interface{}{amqp.Table{"queue": q.name, "reason": reason}}
+
+	msg := m.msg
+	msg.Headers = headers
+	c.enqueue(c.queue(q.deadLetterTo), memoryMessage{msg: msg})
+}
+
+// run выдает сообщения из очереди консьюмеру, соблюдая prefetch.
+func (cons *memoryConsumer) run() {
+	c := cons.client
+	defer c.wg.Done()
+	defer close(cons.out)
+
+	for {
+		c.mu.Lock()
+		for !c.closed && (len(cons.queue.ready) == 0 || len(cons.unacked) >= c.prefetch) {
+			c.cond.Wait()
+		}
+		if c.closed {
+			c.mu.Unlock()
+			return
+		}
+
+		m := cons.queue.ready[0]
+		cons.queue.ready = cons.queue.ready[1:]
+		c.tag++
+		tag := c.tag
+		cons.unacked[tag] = m
+		c.mu.Unlock()
+
+		d := amqp.Delivery{
+			Acknowledger: cons,
+			Headers:      m.msg.Headers,
+			ContentType:  m.msg.ContentType,
+			DeliveryMode: m.msg.DeliveryMode,
+			MessageId:    m.msg.MessageId,
+			Timestamp:    m.msg.Timestamp,
+			DeliveryTag:  tag,
+			Redelivered:  m.redelivered,
+			RoutingKey:   cons.queue.name,
+			Body:         m.msg.Body,
+		}
+
+		select {
+		case cons.out <- d:
+		case <-c.done:
+			return
+		}
+	}
+}
+
+func (cons *memoryConsumer) Ack(tag uint64, multiple bool) error {
+	c := cons.client
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, err := cons.take(tag, multiple); err != nil {
+		return err
+	}
+	c.cond.Broadcast()
+	return nil
+}
+
+func (cons *memoryConsumer) Nack(tag uint64, multiple bool, requeue bool) error {
+	c := cons.client
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	messages, err := cons.take(tag, multiple)
+	if err != nil {
+		return err
+	}
+	if requeue {
+		for i := range messages {
+			messages[i].redelivered = true
+		}
+		cons.queue.ready = append(messages, cons.queue.ready...)
+	} else {
+		for _, m := range messages {
+			c.deadLetter(cons.queue, m, "rejected")
+		}
+	}
+	c.cond.Broadcast()
+	return nil
+}
+
+func (cons *memoryConsumer) Reject(tag uint64, requeue bool) error {
+	return cons.Nack(tag, false, requeue)
+}
+
+// take удаляет из неподтвержденных сообщение tag (или все до tag включительно при multiple)
+// и возвращает их в порядке доставки. Вызывается под c.mu.
+func (cons *memoryConsumer) take(tag uint64, multiple bool) ([]memoryMessage, error) {
+	if !multiple {
+		m, ok := cons.unacked[tag]
+		if !ok {
+			return nil, fmt.Errorf("unknown delivery tag %d", tag)
+		}
+		delete(cons.unacked, tag)
+		return []memoryMessage{m}, nil
+	}
+
+	var tags []uint64
+	for t := range cons.unacked {
+		if t <= tag {
+			tags = append(tags, t)
+		}
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i] < tags[j] })
+
+	messages := make([]memoryMessage, 0, len(tags))
+	for _, t := range tags {
+		messages = append(messages, cons.unacked[t])
+		delete(cons.unacked, t)
+	}
+	return messages, nil
+}
@@ -0,0 +1,156 @@
+package rabbitmq
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rendley/vegshare/backend/pkg/config"
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func receive(t *testing.T, msgs <-chan amqp.Delivery) amqp.Delivery {
+	t.Helper()
+	select {
+	case d, ok := <-msgs:
+		require.True(t, ok, "канал консьюмера закрыт")
+		return d
+	case <-time.After(time.Second):
+		t.Fatal("сообщение не доставлено")
+		return amqp.Delivery{}
+	}
+}
+
+func assertEmpty(t *testing.T, msgs <-chan amqp.Delivery) {
+	t.Helper()
+	select {
+	case d := <-msgs:
+		t.Fatalf("неожиданное сообщение: %s", d.Body)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestMemoryClient(t *testing.T) {
+	t.Run("Ack removes the message", func(t *testing.T) {
+		c := NewMemoryClient(1)
+		defer c.Close()
+
+		msgs, err := c.Consume("q")
+		require.NoError(t, err)
+		require.NoError(t, c.Publish("q", "hello"))
+
+		d := receive(t, msgs)
+		assert.Equal(t, "hello", string(d.Body))
+		assert.False(t, d.Redelivered)
+		require.NoError(t, d.Ack(false))
+		assertEmpty(t, msgs)
+	})
+
+	t.Run("Nack with requeue redelivers the message first", func(t *testing.T) {
+		c := NewMemoryClient(1)
+		defer c.Close()
+
+		msgs, err := c.Consume("q")
+		require.NoError(t, err)
+		require.NoError(t, c.Publish("q", "first"))
+		require.NoError(t, c.Publish("q", "second"))
+
+		d := receive(t, msgs)
+		require.NoError(t, d.Nack(false, true))
+
+		d = receive(t, msgs)
+		assert.Equal(t, "first", string(d.Body))
+		assert.True(t, d.Redelivered)
+		require.NoError(t, d.Ack(false))
+		assert.Equal(t, "second", string(receive(t, msgs).Body))
+	})
+
+	t.Run("Prefetch limits unacknowledged deliveries", func(t *testing.T) {
+		c := NewMemoryClient(1)
+		defer c.Close()
+
+		msgs, err := c.Consume("q")
+		require.NoError(t, err)
+		require.NoError(t, c.Publish("q", "first"))
+		require.NoError(t, c.Publish("q", "second"))
+
+		d := receive(t, msgs)
+		assertEmpty(t, msgs)
+		require.NoError(t, d.Ack(false))
+		assert.Equal(t, "second", string(receive(t, msgs).Body))
+	})
+
+	t.Run("Unknown delivery tag is an error", func(t *testing.T) {
+		c := NewMemoryClient(1)
+		defer c.Close()
+
+		msgs, err := c.Consume("q")
+		require.NoError(t, err)
+		require.NoError(t, c.Publish("q", "hello"))
+
+		d := receive(t, msgs)
+		require.NoError(t, d.Ack(false))
+		assert.Error(t, d.Ack(false))
+	})
+
+	t.Run("Reject without requeue goes to DLQ via DLX", func(t *testing.T) {
+		c := NewMemoryClient(1)
+		defer c.Close()
+		topology := NewTopology("q", config.RetryConfig{})
+		require.NoError(t, c.DeclareTopology(topology))
+
+		msgs, err := c.Consume("q")
+		require.NoError(t, err)
+		dlq, err := c.Consume(topology.DeadLetterQueue())
+		require.NoError(t, err)
+		require.NoError(t, c.Publish("q", "poison"))
+
+		require.NoError(t, receive(t, msgs).Reject(false))
+
+		d := receive(t, dlq)
+		queue, _, lastError := DeadLetterInfo(d)
+		assert.Equal(t, "q", queue)
+		assert.Contains(t, lastError, "rejected")
+	})
+
+	t.Run("Retry waits for the delay queue TTL and then goes to DLQ", func(t *testing.T) {
+		c := NewMemoryClient(1)
+		defer c.Close()
+		topology := NewTopology("q", config.RetryConfig{MaxAttempts: 2, BaseDelay: 10 * time.Millisecond, MaxDelay: time.Second})
+		require.NoError(t, c.DeclareTopology(topology))
+
+		msgs, err := c.Consume("q")
+		require.NoError(t, err)
+		dlq, err := c.Consume(topology.DeadLetterQueue())
+		require.NoError(t, err)
+		require.NoError(t, c.Publish("q", "flaky"))
+
+		d := receive(t, msgs)
+		require.NoError(t, c.Retry(topology, d, errors.New("db down")))
+		require.NoError(t, d.Ack(false))
+
+		d = receive(t, msgs)
+		assert.Equal(t, 2, Attempt(d))
+		require.NoError(t, c.Retry(topology, d, errors.New("db still down")))
+		require.NoError(t, d.Ack(false))
+
+		queue, attempts, lastError := DeadLetterInfo(receive(t, dlq))
+		assert.Equal(t, "q", queue)
+		assert.Equal(t, 2, attempts)
+		assert.Equal(t, "db still down", lastError)
+	})
+
+	t.Run("Close closes consumer channels", func(t *testing.T) {
+		c := NewMemoryClient(1)
+		msgs, err := c.Consume("q")
+		require.NoError(t, err)
+
+		c.Close()
+
+		_, ok := <-msgs
+		assert.False(t, ok)
+		assert.ErrorIs(t, c.Publish("q", "late"), ErrClosed)
+	})
+}
@@ -0,0 +1,50 @@
+package rabbitmq
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rendley/vegshare/backend/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPutPolicy(t *testing.T) {
+	p := NewTopology("actions_queue", config.RetryConfig{}).deadLetterPolicy()
+
+	t.Run("Policy is put into the escaped vhost with credentials", func(t *testing.T) {
+		var got policy
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, pass, ok := r.BasicAuth()
+			assert.True(t, ok)
+			assert.Equal(t, "guest", user)
+			assert.Equal(t, "secret", pass)
+			assert.Equal(t, http.MethodPut, r.Method)
+			assert.Equal(t, "/api/policies/%2F/dlx.actions_queue", r.URL.EscapedPath())
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+			w.WriteHeader(http.StatusCreated)
+		}))
+		defer server.Close()
+
+		managementURL := "http://guest:secret@" + server.Listener.Addr().String()
+		err := putPolicy(context.Background(), server.Client(), managementURL, "/", p)
+
+		require.NoError(t, err)
+		assert.Equal(t, p.Pattern, got.Pattern)
+		assert.Equal(t, "actions_queue.dlq", got.Definition["dead-letter-routing-key"])
+	})
+
+	t.Run("Error status is reported", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		defer server.Close()
+
+		err := putPolicy(context.Background(), server.Client(), server.URL, "/", p)
+
+		assert.ErrorContains(t, err, "401")
+	})
+}
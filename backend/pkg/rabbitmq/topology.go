@@ -0,0 +1,189 @@
+package rabbitmq
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/rendley/vegshare/backend/pkg/config"
+	"github.com/streadway/amqp"
+)
+
+// Заголовки, которыми сопровождаются повторы и "мертвые" сообщения.
+const (
+	HeaderAttempt       = "x-attempt"
+	HeaderLastError     = "x-last-error"
+	HeaderOriginalQueue = "x-original-queue"
+)
+
+// Значения по умолчанию, если в конфиге секция retry не заполнена.
+const (
+	defaultMaxAttempts = 5
+	defaultBaseDelay   = 5 * time.Second
+	defaultMaxDelay    = 10 * time.Minute
+)
+
+// Topology описывает рабочую очередь с отложенными повторами и очередью "мертвых" сообщений (DLQ).
+//
+// Для очереди Queue объявляются:
+//   - Queue - рабочая очередь; отклоненные без requeue сообщения уходят через DLX в DLQ
+//     (DLX задается политикой, см. deadLetterPolicy);
+//   - Queue.retry.N - очереди задержки для N-й неудачной попытки; по истечении TTL
+//     сообщение возвращается в рабочую очередь;
+//   - Queue.dlq - сообщения, исчерпавшие MaxAttempts или заведомо некорректные.
+type Topology struct {
+	Queue       string
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// NewTopology создает топологию для очереди с настройками повторов из конфига.
+func NewTopology(queue string, cfg config.RetryConfig) Topology {
+	t := Topology{Queue: queue, MaxAttempts: cfg.MaxAttempts, BaseDelay: cfg.BaseDelay, MaxDelay: cfg.MaxDelay}
+	if t.MaxAttempts <= 0 {
+		t.MaxAttempts = defaultMaxAttempts
+	}
+	if t.BaseDelay <= 0 {
+		t.BaseDelay = defaultBaseDelay
+	}
+	if t.MaxDelay <= 0 {
+		t.MaxDelay = defaultMaxDelay
+	}
+	return t
+}
+
+// DeadLetterQueue возвращает имя DLQ.
+func (t Topology) DeadLetterQueue() string {
+	return t.Queue + ".dlq"
+}
+
+// RetryQueue возвращает имя очереди задержки после attempt-й неудачной попытки.
+func (t Topology) RetryQueue(attempt int) string {
+	return fmt.Sprintf("%s.retry.%d", t.Queue, attempt)
+}
+
+// Delay возвращает задержку после attempt-й неудачной попытки: BaseDelay * 2^(attempt-1), но не больше MaxDelay.
+func (t Topology) Delay(attempt int) time.Duration {
+	delay := t.BaseDelay
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= t.MaxDelay {
+			return t.MaxDelay
+		}
+	}
+	if delay > t.MaxDelay {
+		return t.MaxDelay
+	}
+	return delay
+}
+
+// nextQueue возвращает очередь, куда отправить сообщение после неудачной попытки attempt.
+func (t Topology) nextQueue(attempt int) (queue string, dead bool) {
+	if attempt >= t.MaxAttempts {
+		return t.DeadLetterQueue(), true
+	}
+	return t.RetryQueue(attempt), false
+}
+
+// republishing копирует сообщение с заголовками попытки, ошибки и исходной очереди.
+func republishing(d amqp.Delivery, attempt int, cause error, originalQueue string) amqp.Publishing {
+	headers := amqp.Table{}
+	for k, v := range d.Headers {
+		headers[k] = v
+	}
+	headers[HeaderAttempt] = int32(attempt)
+	headers[HeaderOriginalQueue] = originalQueue
+	if cause != nil {
+		headers[HeaderLastError] = cause.Error()
+	}
+
+	return amqp.Publishing{
+		Headers:      headers,
+		DeliveryMode: amqp.Persistent,
+		ContentType:  d.ContentType,
+		Body:         d.Body,
+	}
+}
+
+// publishFunc публикует готовое сообщение в очередь; у каждой реализации клиента своя.
+type publishFunc func(queueName string, msg amqp.Publishing) error
+
+// retry публикует копию сообщения в очередь задержки следующей попытки, а после MaxAttempts - в DLQ.
+func retry(publish publishFunc, t Topology, d amqp.Delivery, cause error) error {
+	attempt := Attempt(d)
+	queue, dead := t.nextQueue(attempt)
+	if dead {
+		return deadLetter(publish, t, d, cause)
+	}
+	return publish(queue, republishing(d, attempt+1, cause, t.Queue))
+}
+
+// deadLetter публикует копию сообщения в DLQ топологии.
+func deadLetter(publish publishFunc, t Topology, d amqp.Delivery, cause error) error {
+	return publish(t.DeadLetterQueue(), republishing(d, Attempt(d), cause, t.Queue))
+}
+
+// Attempt возвращает номер текущей попытки доставки (начиная с 1).
+func Attempt(d amqp.Delivery) int {
+	switch v := d.Headers[HeaderAttempt].(type) {
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case int:
+		return v
+	}
+	return 1
+}
+
+// DeadLetterInfo извлекает из сообщения DLQ исходную очередь, число попыток и последнюю ошибку.
+func DeadLetterInfo(d amqp.Delivery) (originalQueue string, attempts int, lastError string) {
+	originalQueue, _ = d.Headers[HeaderOriginalQueue].(string)
+	lastError, _ = d.Headers[HeaderLastError].(string)
+	if originalQueue == "" {
+		// Сообщение попало в DLQ через DLX (reject без requeue) - исходная очередь в x-death.
+		if deaths, ok := d.Headers["x-death"].([]interface{}); ok && len(deaths) > 0 {
+			if death, ok := deaths[0].(amqp.Table); ok {
+				originalQueue, _ = death["queue"].(string)
+				if lastError == "" {
+					reason, _ := death["reason"].(string)
+					lastError = "dead-lettered by broker: " + reason
+				}
+			}
+		}
+	}
+	return originalQueue, Attempt(d), lastError
+}
+
+// queues возвращает все очереди топологии с их аргументами.
+// Рабочая очередь объявляется без аргументов: она уже существует у развернутых брокеров,
+// а повторное объявление с другими аргументами RabbitMQ отклоняет (PRECONDITION_FAILED).
+func (t Topology) queues() map[string]amqp.Table {
+	queues := map[string]amqp.Table{
+		t.DeadLetterQueue(): nil,
+		t.Queue:             nil,
+	}
+	for attempt := 1; attempt < t.MaxAttempts; attempt++ {
+		queues[t.RetryQueue(attempt)] = amqp.Table{
+			"x-message-ttl":             int64(t.Delay(attempt) / time.Millisecond),
+			"x-dead-letter-exchange":    "",
+			"x-dead-letter-routing-key": t.Queue,
+		}
+	}
+	return queues
+}
+
+// deadLetterPolicy возвращает политику, которая направляет отклоненные без requeue
+// сообщения рабочей очереди в DLQ.
+func (t Topology) deadLetterPolicy() policy {
+	return policy{
+		Name:    "dlx." + t.Queue,
+		Pattern: "^" + regexp.QuoteMeta(t.Queue) + "$",
+		ApplyTo: "queues",
+		Definition: map[string]interface{}{
+			"dead-letter-exchange":    "",
+			"dead-letter-routing-key": t.DeadLetterQueue(),
+		},
+	}
+}
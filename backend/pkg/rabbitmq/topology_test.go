@@ -0,0 +1,93 @@
+package rabbitmq
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rendley/vegshare/backend/pkg/config"
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTopology(t *testing.T) {
+	topology := NewTopology("actions_queue", config.RetryConfig{MaxAttempts: 4, BaseDelay: time.Second, MaxDelay: 3 * time.Second})
+
+	t.Run("Defaults are applied to empty config", func(t *testing.T) {
+		d := NewTopology("q", config.RetryConfig{})
+
+		assert.Equal(t, defaultMaxAttempts, d.MaxAttempts)
+		assert.Equal(t, defaultBaseDelay, d.BaseDelay)
+		assert.Equal(t, defaultMaxDelay, d.MaxDelay)
+	})
+
+	t.Run("Delay grows exponentially and is capped", func(t *testing.T) {
+		assert.Equal(t, time.Second, topology.Delay(1))
+		assert.Equal(t, 2*time.Second, topology.Delay(2))
+		assert.Equal(t, 3*time.Second, topology.Delay(3))
+		assert.Equal(t, 3*time.Second, topology.Delay(10))
+	})
+
+	t.Run("Failed attempts go to retry queues, the last one to DLQ", func(t *testing.T) {
+		queue, dead := topology.nextQueue(1)
+		assert.Equal(t, "actions_queue.retry.1", queue)
+		assert.False(t, dead)
+
+		queue, dead = topology.nextQueue(3)
+		assert.Equal(t, "actions_queue.retry.3", queue)
+		assert.False(t, dead)
+
+		queue, dead = topology.nextQueue(4)
+		assert.Equal(t, "actions_queue.dlq", queue)
+		assert.True(t, dead)
+	})
+
+	t.Run("Queues are declared with DLX and TTL", func(t *testing.T) {
+		queues := topology.queues()
+
+		assert.Len(t, queues, 5)               // рабочая, DLQ и три очереди задержки
+		assert.Nil(t, queues["actions_queue"]) // существующая очередь объявляется без новых аргументов
+		assert.Equal(t, int64(2000), queues["actions_queue.retry.2"]["x-message-ttl"])
+		assert.Equal(t, "actions_queue", queues["actions_queue.retry.2"]["x-dead-letter-routing-key"])
+	})
+
+	t.Run("Work queue gets DLX from a policy", func(t *testing.T) {
+		p := NewTopology("actions.queue", config.RetryConfig{}).deadLetterPolicy()
+
+		assert.Equal(t, `^actions\.queue$`, p.Pattern)
+		assert.Equal(t, "queues", p.ApplyTo)
+		assert.Equal(t, "actions.queue.dlq", p.Definition["dead-letter-routing-key"])
+	})
+
+	t.Run("Republishing carries attempt, error and original queue", func(t *testing.T) {
+		d := amqp.Delivery{Body: []byte("payload"), Headers: amqp.Table{HeaderAttempt: int32(2)}}
+
+		msg := republishing(d, Attempt(d)+1, errors.New("boom"), "actions_queue")
+
+		assert.Equal(t, int32(3), msg.Headers[HeaderAttempt])
+		assert.Equal(t, "boom", msg.Headers[HeaderLastError])
+		assert.Equal(t, "payload", string(msg.Body))
+
+		queue, attempts, lastError := DeadLetterInfo(amqp.Delivery{Headers: msg.Headers})
+		assert.Equal(t, "actions_queue", queue)
+		assert.Equal(t, 3, attempts)
+		assert.Equal(t, "boom", lastError)
+	})
+
+	t.Run("Broker dead-lettered message is attributed via x-death", func(t *testing.T) {
+		d := amqp.Delivery{Headers: amqp.Table{"x-death": []interface{}{amqp.Table{"queue": "actions_queue", "reason": "rejected"}}}}
+
+		queue, attempts, lastError := DeadLetterInfo(d)
+
+		assert.Equal(t, "actions_queue", queue)
+		assert.Equal(t, 1, attempts)
+		assert.Equal(t, "dead-lettered by broker: rejected", lastError)
+	})
+}
+
+func TestReconnectDelay(t *testing.T) {
+	assert.Equal(t, time.Second, reconnectDelay(time.Second, 5*time.Second, 1))
+	assert.Equal(t, 4*time.Second, reconnectDelay(time.Second, 5*time.Second, 3))
+	assert.Equal(t, 5*time.Second, reconnectDelay(time.Second, 5*time.Second, 4))
+	assert.Equal(t, 5*time.Second, reconnectDelay(time.Second, 5*time.Second, 50))
+}
@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocal(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocal(t.TempDir())
+	require.NoError(t, err)
+
+	t.Run("Put then Get returns the same content", func(t *testing.T) {
+		require.NoError(t, store.Put(ctx, "tasks/1/photo.jpg", strings.NewReader("jpeg")))
+
+		r, err := store.Get(ctx, "tasks/1/photo.jpg")
+		require.NoError(t, err)
+		defer r.Close()
+		data, err := io.ReadAll(r)
+		require.NoError(t, err)
+		assert.Equal(t, "jpeg", string(data))
+	})
+
+	t.Run("Put replaces an existing object", func(t *testing.T) {
+		require.NoError(t, store.Put(ctx, "tasks/2/photo.jpg", strings.NewReader("old")))
+		require.NoError(t, store.Put(ctx, "tasks/2/photo.jpg", strings.NewReader("new")))
+
+		r, err := store.Get(ctx, "tasks/2/photo.jpg")
+		require.NoError(t, err)
+		defer r.Close()
+		data, _ := io.ReadAll(r)
+		assert.Equal(t, "new", string(data))
+	})
+
+	t.Run("Deleted object is not found", func(t *testing.T) {
+		require.NoError(t, store.Put(ctx, "tasks/3/photo.jpg", strings.NewReader("jpeg")))
+		require.NoError(t, store.Delete(ctx, "tasks/3/photo.jpg"))
+
+		_, err := store.Get(ctx, "tasks/3/photo.jpg")
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.NoError(t, store.Delete(ctx, "tasks/3/photo.jpg"))
+	})
+
+	t.Run("Keys outside the storage are rejected", func(t *testing.T) {
+		for _, key := range []string{"", "/etc/passwd", "../outside", "tasks/../../outside"} {
+			assert.ErrorIs(t, store.Put(ctx, key, strings.NewReader("x")), ErrInvalidKey, key)
+		}
+	})
+}
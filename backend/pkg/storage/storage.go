@@ -0,0 +1,42 @@
+// Пакет storage - хранилище файлов (фото выполнения задач и т.п.) с подключаемыми драйверами.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/rendley/vegshare/backend/pkg/config"
+)
+
+// Драйверы хранилища, выбираются в конфиге (storage.driver).
+const (
+	DriverLocal = "local"
+)
+
+// ErrNotFound возвращается, если объекта с таким ключом нет.
+var ErrNotFound = errors.New("объект не найден в хранилище")
+
+// ErrInvalidKey возвращается для пустого ключа или ключа, выходящего за пределы хранилища.
+var ErrInvalidKey = errors.New("некорректный ключ объекта")
+
+// Storage хранит двоичные объекты по ключу вида "tasks/<id>/<file>".
+type Storage interface {
+	// Put сохраняет объект целиком; существующий объект с тем же ключом заменяется.
+	Put(ctx context.Context, key string, r io.Reader) error
+	// Get открывает объект на чтение; вызывающий закрывает его.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete удаляет объект; отсутствие объекта ошибкой не считается.
+	Delete(ctx context.Context, key string) error
+}
+
+// New создает хранилище для драйвера из конфига.
+func New(cfg config.StorageConfig) (Storage, error) {
+	switch cfg.Driver {
+	case "", DriverLocal:
+		return NewLocal(cfg.LocalDir)
+	default:
+		return nil, fmt.Errorf("unknown storage driver '%s'", cfg.Driver)
+	}
+}
@@ -0,0 +1,195 @@
+// Package telegram - минимальный клиент Telegram Bot API: отправка сообщений с inline-кнопками,
+// ответы на нажатия кнопок и получение обновлений long polling'ом.
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultAPIURL - адрес Bot API, если в конфиге не задан другой (например, локальный сервер Bot API).
+const DefaultAPIURL = "https://api.telegram.org"
+
+// APIError - ошибка, которую вернул Bot API (ответ с ok=false).
+type APIError struct {
+	Code        int
+	Description string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("telegram: %d %s", e.Code, e.Description)
+}
+
+// Update - входящее обновление: сообщение боту или нажатие inline-кнопки.
+type Update struct {
+	UpdateID      int64          `json:"update_id"`
+	Message       *Message       `json:"message,omitempty"`
+	CallbackQuery *CallbackQuery `json:"callback_query,omitempty"`
+}
+
+type Message struct {
+	MessageID int64  `json:"message_id"`
+	Chat      Chat   `json:"chat"`
+	From      *User  `json:"from,omitempty"`
+	Text      string `json:"text,omitempty"`
+}
+
+// ChatTypePrivate - тип личного чата пользователя с ботом.
+const ChatTypePrivate = "private"
+
+type Chat struct {
+	ID   int64  `json:"id"`
+	Type string `json:"type"`
+}
+
+type User struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username,omitempty"`
+}
+
+// CallbackQuery - нажатие inline-кнопки. Data - значение callback_data кнопки.
+type CallbackQuery struct {
+	ID      string   `json:"id"`
+	From    User     `json:"from"`
+	Message *Message `json:"message,omitempty"`
+	Data    string   `json:"data,omitempty"`
+}
+
+type InlineKeyboardMarkup struct {
+	InlineKeyboard [][]InlineKeyboardButton `json:"inline_keyboard"`
+}
+
+type InlineKeyboardButton struct {
+	Text         string `json:"text"`
+	CallbackData string `json:"callback_data,omitempty"`
+}
+
+// Client вызывает методы Bot API от имени бота с токеном token.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewClient - конструктор для Client. Пустой apiURL означает DefaultAPIURL, nil httpClient - http.DefaultClient.
+func NewClient(apiURL, token string, httpClient *http.Client) *Client {
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{
+		baseURL:    strings.TrimRight(apiURL, "/") + "/bot" + token,
+		httpClient: httpClient,
+	}
+}
+
+// SendMessage отправляет текст в чат; markup может быть nil.
+func (c *Client) SendMessage(ctx context.Context, chatID int64, text string, markup *InlineKeyboardMarkup) (*Message, error) {
+	params := map[string]interface{}{
+		"chat_id": chatID,
+		"text":    text,
+	}
+	if markup != nil {
+		params["reply_markup"] = markup
+	}
+	var msg Message
+	if err := c.call(ctx, "sendMessage", params, &msg); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// EditMessageReplyMarkup заменяет кнопки под отправленным сообщением; nil markup убирает их.
+func (c *Client) EditMessageReplyMarkup(ctx context.Context, chatID, messageID int64, markup *InlineKeyboardMarkup) error {
+	if markup == nil {
+		markup = &InlineKeyboardMarkup{InlineKeyboard: [][]InlineKeyboardButton{}}
+	}
+	params := map[string]interface{}{
+		"chat_id":      chatID,
+		"message_id":   messageID,
+		"reply_markup": markup,
+	}
+	return c.call(ctx, "editMessageReplyMarkup", params, nil)
+}
+
+// AnswerCallbackQuery подтверждает нажатие кнопки; text показывается пользователю всплывающим уведомлением.
+func (c *Client) AnswerCallbackQuery(ctx context.Context, callbackQueryID, text string) error {
+	params := map[string]interface{}{
+		"callback_query_id": callbackQueryID,
+		"text":              text,
+	}
+	return c.call(ctx, "answerCallbackQuery", params, nil)
+}
+
+// GetUpdates ждет новые обновления не дольше timeout (long polling).
+// offset - update_id, с которого нужно продолжить: последний полученный плюс один.
+func (c *Client) GetUpdates(ctx context.Context, offset int64, timeout time.Duration) ([]Update, error) {
+	params := map[string]interface{}{
+		"offset":          offset,
+		"timeout":         int(timeout.Seconds()),
+		"allowed_updates": []string{"message", "callback_query"},
+	}
+	updates := []Update{}
+	if err := c.call(ctx, "getUpdates", params, &updates); err != nil {
+		return nil, err
+	}
+	return updates, nil
+}
+
+// response - конверт, в котором Bot API возвращает результат любого метода.
+type response struct {
+	OK          bool            `json:"ok"`
+	Result      json.RawMessage `json:"result"`
+	ErrorCode   int             `json:"error_code"`
+	Description string          `json:"description"`
+}
+
+func (c *Client) call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("telegram: не удалось сериализовать параметры %s: %w", method, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/"+method, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("telegram: не удалось создать запрос %s: %w", method, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		// Токен - часть URL, поэтому адрес из *url.Error в текст ошибки не попадает.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("telegram: запрос %s не выполнен: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	var r response
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return fmt.Errorf("telegram: некорректный ответ на %s (HTTP %d): %w", method, resp.StatusCode, err)
+	}
+	if !r.OK {
+		code := r.ErrorCode
+		if code == 0 {
+			code = resp.StatusCode
+		}
+		return &APIError{Code: code, Description: r.Description}
+	}
+	if result != nil {
+		if err := json.Unmarshal(r.Result, result); err != nil {
+			return fmt.Errorf("telegram: некорректный результат %s: %w", method, err)
+		}
+	}
+	return nil
+}
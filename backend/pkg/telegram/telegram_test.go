@@ -0,0 +1,119 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBotAPI - локальный сервер, отвечающий как Bot API. Запоминает последний вызов метода.
+type fakeBotAPI struct {
+	*httptest.Server
+	path    string
+	params  map[string]interface{}
+	replies map[string]string
+}
+
+func newFakeBotAPI(t *testing.T) *fakeBotAPI {
+	f := &fakeBotAPI{replies: map[string]string{}}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.path = r.URL.Path
+		f.params = map[string]interface{}{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&f.params))
+
+		method := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		reply, ok := f.replies[method]
+		if !ok {
+			reply = `{"ok":true,"result":true}`
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(reply))
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func TestClient(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("SendMessage posts text and inline keyboard to the bot's URL", func(t *testing.T) {
+		api := newFakeBotAPI(t)
+		api.replies["sendMessage"] = `{"ok":true,"result":{"message_id":42,"chat":{"id":100}}}`
+		client := NewClient(api.URL, "123:secret", api.Client())
+
+		markup := &InlineKeyboardMarkup{InlineKeyboard: [][]InlineKeyboardButton{{{Text: "Взять", CallbackData: "accept:1"}}}}
+		msg, err := client.SendMessage(ctx, 100, "Новая задача", markup)
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(42), msg.MessageID)
+		assert.Equal(t, int64(100), msg.Chat.ID)
+		assert.Equal(t, "/bot123:secret/sendMessage", api.path)
+		assert.Equal(t, float64(100), api.params["chat_id"])
+		assert.Equal(t, "Новая задача", api.params["text"])
+		keyboard := api.params["reply_markup"].(map[string]interface{})["inline_keyboard"].([]interface{})
+		button := keyboard[0].([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, "accept:1", button["callback_data"])
+	})
+
+	t.Run("EditMessageReplyMarkup with nil markup removes the buttons", func(t *testing.T) {
+		api := newFakeBotAPI(t)
+		client := NewClient(api.URL, "token", api.Client())
+
+		require.NoError(t, client.EditMessageReplyMarkup(ctx, 100, 42, nil))
+
+		assert.Equal(t, "/bottoken/editMessageReplyMarkup", api.path)
+		assert.Equal(t, float64(42), api.params["message_id"])
+		assert.Equal(t, map[string]interface{}{"inline_keyboard": []interface{}{}}, api.params["reply_markup"])
+	})
+
+	t.Run("GetUpdates decodes messages and button presses", func(t *testing.T) {
+		api := newFakeBotAPI(t)
+		api.replies["getUpdates"] = `{"ok":true,"result":[
+			{"update_id":7,"message":{"message_id":1,"chat":{"id":100},"text":"/start abc"}},
+			{"update_id":8,"callback_query":{"id":"cb1","from":{"id":100},"data":"accept:1","message":{"message_id":42,"chat":{"id":100}}}}
+		]}`
+		client := NewClient(api.URL, "token", api.Client())
+
+		updates, err := client.GetUpdates(ctx, 7, 30*time.Second)
+
+		require.NoError(t, err)
+		require.Len(t, updates, 2)
+		assert.Equal(t, "/start abc", updates[0].Message.Text)
+		assert.Equal(t, "accept:1", updates[1].CallbackQuery.Data)
+		assert.Equal(t, int64(42), updates[1].CallbackQuery.Message.MessageID)
+		assert.Equal(t, float64(7), api.params["offset"])
+		assert.Equal(t, float64(30), api.params["timeout"])
+	})
+
+	t.Run("ok=false is returned as APIError", func(t *testing.T) {
+		api := newFakeBotAPI(t)
+		api.replies["answerCallbackQuery"] = `{"ok":false,"error_code":400,"description":"Bad Request: query is too old"}`
+		client := NewClient(api.URL, "token", api.Client())
+
+		err := client.AnswerCallbackQuery(ctx, "cb1", "Готово")
+
+		var apiErr *APIError
+		require.True(t, errors.As(err, &apiErr))
+		assert.Equal(t, 400, apiErr.Code)
+		assert.Equal(t, "Bad Request: query is too old", apiErr.Description)
+	})
+
+	t.Run("Transport errors do not leak the token", func(t *testing.T) {
+		api := newFakeBotAPI(t)
+		api.Close()
+		client := NewClient(api.URL, "123:secret", api.Client())
+
+		_, err := client.SendMessage(ctx, 100, "text", nil)
+
+		require.Error(t, err)
+		assert.NotContains(t, err.Error(), "secret")
+	})
+}
@@ -63,6 +63,7 @@ export interface Lease {
   status: string;
   start_date: string;
   end_date: string;
+  tariff?: string;
   created_at: string;
   updated_at: string;
 }
@@ -100,6 +101,42 @@ export interface OperationLog {
     executed_at: string;
     created_at: string;
     updated_at: string;
+    schedule_id?: string;
+    task_id?: string;
+    task_status?: string;
+    completion_note?: string;
+    attachments?: TaskAttachment[];
+    retry_of?: string;
+    retried_by?: string;
+    failure_reason?: FailureReason;
+    failure_comment?: string;
+}
+
+export type FailureReason = 'weather' | 'out_of_stock' | 'plot_issue';
+
+export interface TaskAttachment {
+    id: string;
+    task_id: string;
+    file_name: string;
+    content_type: string;
+    size_bytes: number;
+    uploaded_by?: string;
+    created_at: string;
+}
+
+export interface ActionPage {
+    items: OperationLog[];
+    next_cursor?: string;
+}
+
+export interface QuotaUsage {
+    action_type: string;
+    limit: number;
+    period: 'day' | 'week' | 'month' | 'season';
+    used: number;
+    remaining: number;
+    period_start: string;
+    period_end: string;
 }
 
 export interface Task {
@@ -109,10 +146,104 @@ export interface Task {
     status: string;
     title: string;
     description?: string;
+    claimed_at?: string;
+    priority: 'low' | 'normal' | 'high' | 'urgent';
+    due_at?: string;
+    overdue_at?: string;
+    completion_note?: string;
+    failure_reason?: FailureReason;
+    failure_comment?: string;
     created_at: string;
     updated_at: string;
 }
 
+export interface TaskListItem extends Task {
+    action_type: string;
+    unit_id: string;
+    unit_type: string;
+    unit_name: string;
+    structure_id?: string;
+    land_parcel_id?: string;
+    region_id?: string;
+    structure_name?: string;
+    land_parcel_name?: string;
+    region_name?: string;
+    location_path: string;
+}
+
+export interface TaskPage {
+    items: TaskListItem[];
+    next_cursor?: string;
+}
+
+export interface WorkListStructure {
+    structure_id?: string;
+    name: string;
+    tasks: TaskListItem[];
+}
+
+export interface WorkListParcel {
+    land_parcel_id?: string;
+    name: string;
+    region_name: string;
+    structures: WorkListStructure[];
+}
+
+export interface WorkList {
+    assignee_id: string;
+    date: string;
+    total_tasks: number;
+    estimated_minutes: number;
+    parcels: WorkListParcel[];
+}
+
+export interface TaskFilter {
+    status?: string;
+    priority?: Task['priority'];
+    overdue?: boolean;
+    due_before?: string;
+    assignee_id?: string;
+    region_id?: string;
+    structure_id?: string;
+    action_type?: string;
+    from?: string;
+    to?: string;
+    sort?: 'created_at' | 'due_at' | 'priority';
+    cursor?: string;
+    limit?: number;
+}
+
+export interface TaskAssignment {
+    id: string;
+    task_id: string;
+    assignee_id?: string;
+    previous_assignee_id?: string;
+    changed_by?: string;
+    reason: 'accepted' | 'assigned' | 'unassigned' | 'timed_out';
+    created_at: string;
+}
+
+export interface TaskTransition {
+    id: string;
+    task_id: string;
+    actor_id?: string;
+    from_status?: Task['status'];
+    to_status: Task['status'];
+    comment?: string;
+    created_at: string;
+}
+
+export interface TelegramLinkStatus {
+    linked: boolean;
+    linked_at?: string;
+}
+
+export interface TelegramLinkCode {
+    code: string;
+    url?: string;
+    expires_at: string;
+}
+
 interface AuthRequest {
   email: string;
   password: string;
@@ -131,7 +262,7 @@ export const apiSlice = createApi({
       return headers;
     },
   }),
-  tagTypes: ['Region', 'LandParcel', 'Structure', 'Plot', 'Lease', 'OperationLog', 'Camera', 'CatalogItem', 'User', 'StructureType', 'Task'],
+  tagTypes: ['Region', 'LandParcel', 'Structure', 'Plot', 'Lease', 'OperationLog', 'Camera', 'CatalogItem', 'User', 'StructureType', 'Task', 'TelegramLink'],
   endpoints: (builder) => ({
     // QUERIES
     getRegions: builder.query<Region[], void>({
@@ -168,6 +299,12 @@ export const apiSlice = createApi({
     }),
     getActionsForUnit: builder.query<OperationLog[], string>({
         query: (unitId) => `operations/units/${unitId}/actions`,
+        transformResponse: (page: ActionPage) => page.items,
+        providesTags: (_result, _error, unitId) => [{ type: 'OperationLog', id: unitId }],
+    }),
+    getQuotasForUnit: builder.query<QuotaUsage[], string>({
+        query: (unitId) => `operations/units/${unitId}/quotas`,
+        // Остаток меняется после createAction, который инвалидирует этот тег.
         providesTags: (_result, _error, unitId) => [{ type: 'OperationLog', id: unitId }],
     }),
 
@@ -210,14 +347,49 @@ export const apiSlice = createApi({
         invalidatesTags: (_result, _error, _actionId) => [{ type: 'OperationLog', id: 'LIST' }], // This will refetch all actions for all units, which is not ideal. A more specific invalidation would be better.
     }),
 
+    retryAction: builder.mutation<OperationLog, string>({
+        query: (actionId) => ({
+            url: `operations/actions/${actionId}/retry`,
+            method: 'POST',
+        }),
+        invalidatesTags: [{ type: 'OperationLog', id: 'LIST' }],
+    }),
+
     // Admin Queries
     getUsers: builder.query<User[], void>({
       query: () => 'admin/users',
       providesTags: (result) => result ? [...result.map(({ id }) => ({ type: 'User' as const, id })), { type: 'User', id: 'LIST' }] : [{ type: 'User', id: 'LIST' }],
     }),
-    getTasks: builder.query<Task[], void>({
-      query: () => 'admin/tasks',
-      providesTags: (result) => result ? [...result.map(({ id }) => ({ type: 'Task' as const, id })), { type: 'Task', id: 'LIST' }] : [{ type: 'Task', id: 'LIST' }],
+    getTaskAssignments: builder.query<TaskAssignment[], string>({
+      query: (taskId) => `admin/tasks/${taskId}/assignments`,
+      providesTags: (_result, _error, taskId) => [{ type: 'Task', id: taskId }],
+    }),
+    getTaskTimeline: builder.query<TaskTransition[], string>({
+      query: (taskId) => `admin/tasks/${taskId}/timeline`,
+      providesTags: (_result, _error, taskId) => [{ type: 'Task', id: taskId }],
+    }),
+    getTasks: builder.query<TaskPage, TaskFilter | void>({
+      query: (filter) => ({ url: 'admin/tasks', params: filter ?? undefined }),
+      providesTags: (result) => result ? [...result.items.map(({ id }) => ({ type: 'Task' as const, id })), { type: 'Task', id: 'LIST' }] : [{ type: 'Task', id: 'LIST' }],
+    }),
+    getMyWorkList: builder.query<WorkList, string | void>({
+      query: (date) => ({ url: 'tasks/mine/worklist', params: date ? { date } : undefined }),
+      providesTags: [{ type: 'Task', id: 'LIST' }],
+    }),
+    getMyTasks: builder.query<TaskPage, TaskFilter | void>({
+      query: (filter) => ({ url: 'tasks/mine', params: filter ?? undefined }),
+      providesTags: (result) => result ? [...result.items.map(({ id }) => ({ type: 'Task' as const, id })), { type: 'Task', id: 'LIST' }] : [{ type: 'Task', id: 'LIST' }],
+    }),
+    getTelegramLink: builder.query<TelegramLinkStatus, void>({
+      query: () => 'notifications/telegram',
+      providesTags: ['TelegramLink'],
+    }),
+    createTelegramLinkCode: builder.mutation<TelegramLinkCode, void>({
+      query: () => ({ url: 'notifications/telegram/link-code', method: 'POST' }),
+    }),
+    deleteTelegramLink: builder.mutation<void, void>({
+      query: () => ({ url: 'notifications/telegram', method: 'DELETE' }),
+      invalidatesTags: ['TelegramLink'],
     }),
     getRegionsForAdmin: builder.query<Region[], void>({
       query: () => 'admin/farm/regions/all',
@@ -265,10 +437,26 @@ export const apiSlice = createApi({
       }),
       invalidatesTags: (_, __, taskId) => [{ type: 'Task', id: taskId }, { type: 'Task', id: 'LIST' }],
     }),
-    failTask: builder.mutation<Task, string>({
-      query: (taskId) => ({
+    failTask: builder.mutation<Task, { taskId: string; reason: FailureReason; comment?: string }>({
+      query: ({ taskId, reason, comment }) => ({
         url: `admin/tasks/${taskId}/fail`,
         method: 'POST',
+        body: { reason, comment },
+      }),
+      invalidatesTags: (_, __, { taskId }) => [{ type: 'Task', id: taskId }, { type: 'Task', id: 'LIST' }],
+    }),
+    assignTask: builder.mutation<Task, { taskId: string; assigneeId: string }>({
+      query: ({ taskId, assigneeId }) => ({
+        url: `admin/tasks/${taskId}/assign`,
+        method: 'POST',
+        body: { assignee_id: assigneeId },
+      }),
+      invalidatesTags: (_, __, { taskId }) => [{ type: 'Task', id: taskId }, { type: 'Task', id: 'LIST' }],
+    }),
+    unassignTask: builder.mutation<Task, string>({
+      query: (taskId) => ({
+        url: `admin/tasks/${taskId}/unassign`,
+        method: 'POST',
       }),
       invalidatesTags: (_, __, taskId) => [{ type: 'Task', id: taskId }, { type: 'Task', id: 'LIST' }],
     }),
@@ -433,17 +621,28 @@ export const {
   useGetMyLeasesQuery,
   useGetCatalogItemsQuery,
   useGetActionsForUnitQuery,
+  useGetQuotasForUnitQuery,
   useLoginMutation,
   useRegisterMutation,
   useLeasePlotMutation,
   useCreateActionMutation,
   useCancelActionMutation,
+  useRetryActionMutation,
   useGetUsersQuery,
   useUpdateUserRoleMutation,
   useGetTasksQuery,
+  useGetMyTasksQuery,
+  useGetMyWorkListQuery,
   useAcceptTaskMutation,
   useCompleteTaskMutation,
   useFailTaskMutation,
+  useAssignTaskMutation,
+  useUnassignTaskMutation,
+  useGetTaskAssignmentsQuery,
+  useGetTaskTimelineQuery,
+  useGetTelegramLinkQuery,
+  useCreateTelegramLinkCodeMutation,
+  useDeleteTelegramLinkMutation,
   useCreateRegionMutation,
   useCreateLandParcelMutation,
   useCreateStructureMutation,
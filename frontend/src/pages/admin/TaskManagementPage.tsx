@@ -1,6 +1,6 @@
-import { useMemo } from 'react';
+import { useMemo, useState } from 'react';
 import { useGetTasksQuery, useAcceptTaskMutation, useCompleteTaskMutation, useFailTaskMutation } from '../../features/api/apiSlice';
-import type { Task } from '../../features/api/apiSlice';
+import type { Task, FailureReason } from '../../features/api/apiSlice';
 import {
     Box,
     Typography,
@@ -13,14 +13,24 @@ import {
     TableRow,
     Paper,
     Button,
-    ButtonGroup
+    ButtonGroup,
+    Menu,
+    MenuItem
 } from '@mui/material';
 
+const failureReasons: { value: FailureReason; label: string }[] = [
+    { value: 'weather', label: 'Погода' },
+    { value: 'out_of_stock', label: 'Нет материалов' },
+    { value: 'plot_issue', label: 'Проблема с грядкой' },
+];
+
 const TaskManagementPage = () => {
-    const { data: tasks, isLoading, isError } = useGetTasksQuery();
+    const { data: page, isLoading, isError } = useGetTasksQuery();
+    const tasks = page?.items;
     const [acceptTask, { isLoading: isAccepting }] = useAcceptTaskMutation();
     const [completeTask, { isLoading: isCompleting }] = useCompleteTaskMutation();
     const [failTask, { isLoading: isFailing }] = useFailTaskMutation();
+    const [failMenu, setFailMenu] = useState<{ anchor: HTMLElement; taskId: string } | null>(null);
 
     const isMutating = isAccepting || isCompleting || isFailing;
 
@@ -65,7 +75,7 @@ const TaskManagementPage = () => {
                                         <Button color="success" onClick={() => completeTask(task.id)} disabled={task.status !== 'in_progress'}>
                                             Завершить
                                         </Button>
-                                        <Button color="error" onClick={() => failTask(task.id)} disabled={task.status !== 'in_progress'}>
+                                        <Button color="error" onClick={(e) => setFailMenu({ anchor: e.currentTarget, taskId: task.id })} disabled={task.status !== 'in_progress'}>
                                             Провалить
                                         </Button>
                                     </ButtonGroup>
@@ -75,6 +85,19 @@ const TaskManagementPage = () => {
                     </TableBody>
                 </Table>
             </TableContainer>
+            <Menu anchorEl={failMenu?.anchor} open={Boolean(failMenu)} onClose={() => setFailMenu(null)}>
+                {failureReasons.map(({ value, label }) => (
+                    <MenuItem
+                        key={value}
+                        onClick={() => {
+                            if (failMenu) failTask({ taskId: failMenu.taskId, reason: value });
+                            setFailMenu(null);
+                        }}
+                    >
+                        {label}
+                    </MenuItem>
+                ))}
+            </Menu>
         </Box>
     );
 };